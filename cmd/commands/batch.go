@@ -0,0 +1,561 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/pkg/casedb"
+	"github.com/google/container-explorer/pkg/render"
+	"github.com/google/container-explorer/pkg/timesketch"
+	"github.com/google/container-explorer/utils"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// Batch operation values.
+const (
+	batchList   = "list"
+	batchDrift  = "drift"
+	batchExport = "export"
+)
+
+var BatchCommand = cli.Command{
+	Name:        "batch",
+	Usage:       "run operations across many image roots",
+	Description: "run list, drift, and export operations across many mounted image roots with bounded parallelism. Each image root has its own runtime configuration and output directory, and all records are merged in batch.jsonl with the source root.",
+	ArgsUsage:   "[flag] OUTPUTDIR",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "roots, r",
+			Usage: "file with one image root per line, or a glob pattern of image roots",
+		},
+		cli.StringFlag{
+			Name:  "operations",
+			Usage: "comma separated operations list, drift, and export",
+			Value: "list,drift",
+		},
+		cli.IntFlag{
+			Name:  "jobs, j",
+			Usage: "number of image roots processed in parallel",
+			Value: 4,
+		},
+		cli.StringFlag{
+			Name:  "filter, f",
+			Usage: "comma separated label filter using key=value pair",
+		},
+		cli.BoolFlag{
+			Name:  "support-containers, s",
+			Usage: "include Kubernetes supporting containers in drift and export",
+		},
+		cli.BoolFlag{
+			Name:  "image, i",
+			Usage: "export containers as raw image",
+		},
+		cli.BoolFlag{
+			Name:  "archive, a",
+			Usage: "export containers as archive",
+		},
+		cli.BoolFlag{
+			Name:  "oci, o",
+			Usage: "export containers as OCI image archive with the container changes as the top layer",
+		},
+		cli.StringFlag{
+			Name:  "compression, c",
+			Usage: "archive compression gzip, zstd, or none",
+			Value: "gzip",
+		},
+		cli.StringFlag{
+			Name:  "operator",
+			Usage: "operator name recorded in the custody manifest. Default is the current user",
+		},
+	},
+	Action: func(clictx *cli.Context) error {
+		if clictx.NArg() < 1 {
+			return fmt.Errorf("output directory is required")
+		}
+		outputDir := clictx.Args().First()
+
+		roots, err := batchRoots(clictx.String("roots"))
+		if err != nil {
+			return err
+		}
+		operations, err := batchOperations(clictx.String("operations"))
+		if err != nil {
+			return err
+		}
+		if clictx.Int("jobs") < 1 {
+			return fmt.Errorf("jobs must be at least 1")
+		}
+
+		compression, err := explorers.ParseCompression(clictx.String("compression"))
+		if err != nil {
+			return err
+		}
+		exportOptions := explorers.ContainerExportOptions{
+			Image:       clictx.Bool("image"),
+			Archive:     clictx.Bool("archive"),
+			OCI:         clictx.Bool("oci"),
+			Compression: compression,
+		}
+		if !exportOptions.Image && !exportOptions.Archive && !exportOptions.OCI {
+			exportOptions.Image = true
+		}
+
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return fmt.Errorf("creating output directory: %w", err)
+		}
+		// With the sqlite and timesketch output formats, the records of all
+		// image roots are also added to the case database or the timeline of
+		// the output file, and the summary is written to stdout.
+		summaryOptions := renderOptions()
+		var output batchOutput
+		switch format := strings.ToLower(GlobalConfig.Output); format {
+		case render.FormatSQLite, render.FormatTimesketch:
+			if GlobalConfig.OutputFile == "" {
+				return fmt.Errorf("the %s output format requires an output file", format)
+			}
+			if format == render.FormatSQLite {
+				output, err = casedb.Open(GlobalConfig.OutputFile, summaryOptions.Command)
+			} else {
+				output, err = newTimelineWriter(GlobalConfig.OutputFile)
+			}
+			if err != nil {
+				return err
+			}
+			summaryOptions.Format, summaryOptions.OutputFile = render.FormatTable, ""
+		}
+		merged, err := newBatchWriter(filepath.Join(outputDir, "batch.jsonl"), output)
+		if err != nil {
+			if output != nil {
+				output.Close()
+			}
+			return err
+		}
+
+		job := batchJob{
+			operations:        operations,
+			filter:            clictx.String("filter"),
+			supportContainers: clictx.Bool("support-containers"),
+			exportOptions:     exportOptions,
+			operator:          clictx.String("operator"),
+			version:           clictx.App.Version,
+			merged:            merged,
+		}
+
+		results := make([]batchResult, len(roots))
+		var wg sync.WaitGroup
+		jobs := make(chan int)
+		for range min(clictx.Int("jobs"), len(roots)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					results[i] = job.run(roots[i], batchOutputDir(outputDir, i, roots[i]))
+				}
+			}()
+		}
+		for i := range roots {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+
+		if err := merged.close(); err != nil {
+			return err
+		}
+
+		sink, err := render.New(summaryOptions, []render.Column[batchResult]{
+			{Name: "ROOT", Value: func(r batchResult) any { return r.Root }},
+			{Name: "STATUS", Value: func(r batchResult) any { return r.status() }},
+			{Name: "CONTAINERS", Value: func(r batchResult) any { return r.Containers }},
+			{Name: "IMAGES", Value: func(r batchResult) any { return r.Images }},
+			{Name: "DRIFTS", Value: func(r batchResult) any { return r.Drifts }},
+			{Name: "OUTPUT", Value: func(r batchResult) any { return r.OutputDir }},
+		})
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
+		for _, r := range results {
+			if err := sink.Write(r); err != nil {
+				return err
+			}
+		}
+		return sink.Close()
+	},
+}
+
+// batchResult provides the outcome of the operations of an image root.
+type batchResult struct {
+	Root       string   `json:"root"`
+	OutputDir  string   `json:"output_dir"`
+	Containers int      `json:"containers"`
+	Images     int      `json:"images"`
+	Drifts     int      `json:"drifts"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// status returns OK, or ERROR if an operation of the image root failed.
+func (r batchResult) status() string {
+	if len(r.Errors) > 0 {
+		return "ERROR"
+	}
+	return "OK"
+}
+
+// batchRecord is a record of the merged JSONL file.
+type batchRecord struct {
+	SourceRoot string `json:"source_root"`
+	Operation  string `json:"operation"`
+	Record     any    `json:"record"`
+}
+
+// batchJob provides the operations and options run for each image root.
+type batchJob struct {
+	operations        map[string]bool
+	filter            string
+	supportContainers bool
+	exportOptions     explorers.ContainerExportOptions
+	operator          string
+	version           string
+	merged            *batchWriter
+}
+
+// run runs the operations of an image root with its own runtime
+// configuration and explorers, and writes the per-root output files.
+func (j batchJob) run(root string, outputDir string) batchResult {
+	result := batchResult{Root: root, OutputDir: outputDir}
+	fail := func(operation string, err error) {
+		log.WithFields(log.Fields{"root": root, "operation": operation}).Errorf("batch operation: %v", err)
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", operation, err))
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		fail("output", err)
+		return result
+	}
+
+	config := GlobalConfig
+	config.ImageRootDir = root
+	config.ContainerdRootDir = ""
+	config.DockerRootDir = ""
+	resolveRuntimeRoots(&config)
+
+	exps := getExplorersFor(config)
+	defer func() {
+		for _, xplr := range exps {
+			xplr.Close()
+		}
+	}()
+	if len(exps) == 0 {
+		fail("explorers", fmt.Errorf("no container explorers for image root"))
+		return result
+	}
+	ctx := config.Context
+
+	if j.operations[batchList] {
+		var containers []explorers.Container
+		var images []explorers.Image
+		for _, xplr := range exps {
+			c, err := xplr.ListContainers(ctx)
+			if err != nil {
+				fail(batchList, fmt.Errorf("listing %s containers: %w", xplr.Type(), err))
+			}
+			containers = append(containers, c...)
+
+			i, err := xplr.ListImages(ctx)
+			if err != nil {
+				fail(batchList, fmt.Errorf("listing %s images: %w", xplr.Type(), err))
+			}
+			images = append(images, i...)
+		}
+		result.Containers = len(containers)
+		result.Images = len(images)
+
+		if err := writeJSONFile(filepath.Join(outputDir, "containers.json"), containers); err != nil {
+			fail(batchList, err)
+		}
+		if err := writeJSONFile(filepath.Join(outputDir, "images.json"), images); err != nil {
+			fail(batchList, err)
+		}
+		for _, c := range containers {
+			j.merged.add(batchRecord{SourceRoot: root, Operation: "container", Record: c})
+		}
+		for _, i := range images {
+			j.merged.add(batchRecord{SourceRoot: root, Operation: "image", Record: i})
+		}
+	}
+
+	// Drift and export mount the containers, which is only supported on a
+	// Linux operating system.
+	if (j.operations[batchDrift] || j.operations[batchExport]) && runtime.GOOS != "linux" {
+		fail("mount", fmt.Errorf("drift and export are only supported on Linux"))
+		return result
+	}
+
+	if j.operations[batchDrift] {
+		// The drift of each container is written as it completes.
+		drifts, err := render.New[explorers.Drift](jsonFile(filepath.Join(outputDir, "drift.json")), nil)
+		if err != nil {
+			fail(batchDrift, err)
+		} else {
+			for _, xplr := range exps {
+				err := explorers.StreamDrift(ctx, xplr, j.filter, !j.supportContainers, "", func(d explorers.Drift) error {
+					result.Drifts++
+					j.merged.add(batchRecord{SourceRoot: root, Operation: batchDrift, Record: d})
+					return drifts.Write(d)
+				})
+				if err != nil {
+					fail(batchDrift, fmt.Errorf("retrieving %s container drift: %w", xplr.Type(), err))
+				}
+			}
+			if err := drifts.Close(); err != nil {
+				fail(batchDrift, err)
+			}
+		}
+	}
+
+	if j.operations[batchExport] {
+		exportDir := filepath.Join(outputDir, "export")
+		// The commands run by the exports are recorded to the custody
+		// record of the context.
+		custody := utils.NewCustody(j.operator, j.version, os.Args)
+		exportCtx := utils.WithCustody(ctx, custody)
+
+		var exportErrs []error
+		for _, xplr := range exps {
+			if err := xplr.ExportAllContainers(exportCtx, exportDir, j.exportOptions, getFilterMap(j.filter), j.supportContainers); err != nil {
+				exportErrs = append(exportErrs, fmt.Errorf("%s: %w", xplr.Type(), err))
+			}
+		}
+		custody.Complete(errors.Join(exportErrs...))
+		if err := errors.Join(exportErrs...); err != nil {
+			fail(batchExport, err)
+		}
+		if err := writeCustody(custody, exportDir); err != nil {
+			fail(batchExport, err)
+		}
+		for _, a := range custody.Artifacts {
+			j.merged.add(batchRecord{SourceRoot: root, Operation: batchExport, Record: a})
+		}
+	}
+
+	return result
+}
+
+// batchOutput is the output of the records of all image roots in the
+// sqlite or timesketch output format.
+type batchOutput interface {
+	Add(sourceRoot string, record any) error
+	Close() error
+}
+
+// batchWriter writes the records of all image roots to a JSONL file, and to
+// a batch output if set.
+type batchWriter struct {
+	mu     sync.Mutex
+	file   *os.File
+	w      *bufio.Writer
+	output batchOutput // case database or timeline of the records, or nil
+}
+
+// newBatchWriter returns a writer of a new JSONL file and of a batch output,
+// which can be nil.
+func newBatchWriter(path string, output batchOutput) (*batchWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating merged output file: %w", err)
+	}
+	return &batchWriter{file: file, w: bufio.NewWriter(file), output: output}, nil
+}
+
+// add writes a record as a JSON line.
+func (b *batchWriter) add(record batchRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		log.WithField("root", record.SourceRoot).Errorf("marshaling batch record: %v", err)
+		return
+	}
+
+	b.mu.Lock()
+	b.w.Write(data)
+	b.w.WriteByte('\n')
+	b.mu.Unlock()
+
+	// Export artifacts are neither case database records nor events.
+	if b.output != nil && casedb.Supported(record.Record) {
+		if err := b.output.Add(record.SourceRoot, record.Record); err != nil {
+			log.WithField("root", record.SourceRoot).Errorf("adding batch record: %v", err)
+		}
+	}
+}
+
+// close flushes and closes the JSONL file and the batch output.
+func (b *batchWriter) close() error {
+	var outputErr error
+	if b.output != nil {
+		outputErr = b.output.Close()
+	}
+	if err := b.w.Flush(); err != nil {
+		b.file.Close()
+		return errors.Join(fmt.Errorf("writing merged output file: %w", err), outputErr)
+	}
+	return errors.Join(b.file.Close(), outputErr)
+}
+
+// timelineWriter writes the Timesketch events of the records of all image
+// roots to a JSONL file.
+type timelineWriter struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+}
+
+// newTimelineWriter returns a writer of a new timeline file.
+func newTimelineWriter(path string) (*timelineWriter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("creating output file %s: %w", path, err)
+	}
+	return &timelineWriter{file: file, w: bufio.NewWriter(file)}, nil
+}
+
+// Add writes the events of a record of an image root.
+func (t *timelineWriter) Add(sourceRoot string, record any) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, event := range timesketch.Events(sourceRoot, record) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshaling to timesketch: %w", err)
+		}
+		t.w.Write(data)
+		t.w.WriteByte('\n')
+	}
+	return nil
+}
+
+// Close flushes and closes the timeline file.
+func (t *timelineWriter) Close() error {
+	if err := t.w.Flush(); err != nil {
+		t.file.Close()
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return t.file.Close()
+}
+
+// batchRoots returns the image roots of a roots file, or of a glob pattern
+// if the value is not a file. Empty lines and lines starting with # are
+// ignored in a roots file.
+func batchRoots(value string) ([]string, error) {
+	if value == "" {
+		return nil, fmt.Errorf("roots file or glob pattern is required")
+	}
+
+	var roots []string
+	if info, err := os.Stat(value); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("reading roots file: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			roots = append(roots, line)
+		}
+	} else {
+		matches, err := filepath.Glob(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid roots glob pattern: %w", err)
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				roots = append(roots, match)
+			}
+		}
+	}
+
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no image roots found for %s", value)
+	}
+	return roots, nil
+}
+
+// batchOperations returns the selected batch operations.
+func batchOperations(value string) (map[string]bool, error) {
+	operations := make(map[string]bool)
+	for _, op := range strings.Split(value, ",") {
+		op = strings.ToLower(strings.TrimSpace(op))
+		switch op {
+		case "":
+			continue
+		case batchList, batchDrift, batchExport:
+			operations[op] = true
+		default:
+			return nil, fmt.Errorf("unsupported batch operation %s", op)
+		}
+	}
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("at least one batch operation is required")
+	}
+	return operations, nil
+}
+
+// batchOutputDir returns the output directory of an image root. The index
+// keeps the directories of roots with the same base name apart.
+func batchOutputDir(outputDir string, index int, root string) string {
+	name := strings.NewReplacer("/", "_", ":", "_").Replace(strings.Trim(filepath.Clean(root), "/"))
+	if name == "" || name == "." {
+		name = "root"
+	}
+	return filepath.Join(outputDir, fmt.Sprintf("%03d-%s", index, name))
+}
+
+// jsonFile returns the output options of a JSON file.
+func jsonFile(path string) render.Options {
+	return render.Options{Format: render.FormatJSON, OutputFile: path}
+}
+
+// writeJSONFile writes records to a file as a JSON array.
+func writeJSONFile[T any](path string, records []T) error {
+	sink, err := render.New[T](jsonFile(path), nil)
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+
+	for _, record := range records {
+		if err := sink.Write(record); err != nil {
+			return err
+		}
+	}
+	return sink.Close()
+}
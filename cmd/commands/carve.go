@@ -0,0 +1,120 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/explorers/containerd"
+	"github.com/google/container-explorer/pkg/render"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var CarveCommand = cli.Command{
+	Name:        "carve",
+	Usage:       "recover deleted records from bolt database pages",
+	Description: "decode every page of containerd meta.db and snapshotter metadata.db files, including free pages, and report the container, image, and snapshot records found with their page offsets. The containerd root databases are carved if no file is specified.",
+	ArgsUsage:   "[flag] [FILE...]",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "include-live, a",
+			Usage: "include the records of live pages",
+		},
+	},
+	Action: func(clictx *cli.Context) error {
+
+		files := []string(clictx.Args())
+		if len(files) == 0 {
+			files = containerdBoltFiles(GlobalConfig.ContainerdRootDir)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no bolt database files found")
+		}
+
+		sink, err := newResultRenderer([]render.Column[explorers.CarvedRecord]{
+			{Name: "SOURCE", Value: func(r explorers.CarvedRecord) any { return filepath.Base(r.Source) }},
+			{Name: "PAGE STATE", Value: func(r explorers.CarvedRecord) any { return r.PageState }},
+			{Name: "PAGE", Value: func(r explorers.CarvedRecord) any { return r.Page }},
+			{Name: "OFFSET", Value: func(r explorers.CarvedRecord) any { return r.Offset }},
+			{Name: "KIND", Value: func(r explorers.CarvedRecord) any { return r.Kind }},
+			{Name: "NAMESPACE", Value: func(r explorers.CarvedRecord) any { return r.Namespace }},
+			{Name: "NAME", Value: func(r explorers.CarvedRecord) any { return r.Name }},
+			{Name: "LIVE", Value: func(r explorers.CarvedRecord) any { return r.Live }},
+			{Name: "DETAILS", Value: func(r explorers.CarvedRecord) any { return carvedDetails(r) }},
+		})
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
+		// The records of each file are written as the file is carved.
+		var itemErrs []explorers.ItemError
+		for _, file := range files {
+			carved, err := containerd.CarveBoltFile(file, clictx.Bool("include-live"))
+			if err != nil {
+				log.WithField("message", err).Errorf("carving %s", file)
+				itemErrs = append(itemErrs, *explorers.NewItemError("containerd", "carve", file, err))
+				continue
+			}
+			for _, r := range carved {
+				if err := sink.Write(r); err != nil {
+					return err
+				}
+			}
+		}
+
+		sink.AddErrors(itemErrs...)
+		if err := sink.Close(); err != nil {
+			return err
+		}
+		return partialError(itemErrs)
+	},
+}
+
+// containerdBoltFiles returns the meta.db file and the snapshotter
+// metadata.db files of a containerd root directory.
+func containerdBoltFiles(containerdRoot string) []string {
+	if containerdRoot == "" {
+		return nil
+	}
+
+	var files []string
+	metaFile := filepath.Join(containerdRoot, "io.containerd.metadata.v1.bolt", "meta.db")
+	if _, err := os.Stat(metaFile); err == nil {
+		files = append(files, metaFile)
+	}
+	snapshotFiles, _ := filepath.Glob(filepath.Join(containerdRoot, "io.containerd.snapshotter.v1.*", "metadata.db"))
+	return append(files, snapshotFiles...)
+}
+
+// carvedDetails returns the fields of a carved record as sorted key=value
+// pairs.
+func carvedDetails(r explorers.CarvedRecord) string {
+	var details []string
+	for k, v := range r.Fields {
+		details = append(details, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(details)
+	return strings.Join(details, ",")
+}
@@ -19,12 +19,17 @@ package commands
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"database/sql"
 	"encoding/binary"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -33,10 +38,14 @@ import (
 	"github.com/containerd/containerd/metadata"
 	"github.com/containerd/containerd/namespaces"
 	"github.com/gogo/protobuf/types"
+	"github.com/google/container-explorer/explorers"
 	"github.com/google/container-explorer/utils"
+	_ "github.com/mattn/go-sqlite3" // Required for sqlite3 driver
+	digest "github.com/opencontainers/go-digest"
 	oci "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/urfave/cli"
 	bolt "go.etcd.io/bbolt"
+	"gopkg.in/yaml.v3"
 )
 
 type mockCommandCall struct {
@@ -170,6 +179,42 @@ func setupMockDocker(t *testing.T, dockerRoot string, containerID string) {
 	}
 }
 
+// setupMockOverlay2Container creates a Docker overlay2 container with the
+// files of its writable layer on top of a base image layer containing
+// /etc/passwd and /etc/hosts.
+func setupMockOverlay2Container(t *testing.T, dockerRoot string, containerID string, files map[string]string) {
+	t.Helper()
+
+	setupMockDocker(t, dockerRoot, containerID)
+	configPath := filepath.Join(dockerRoot, "containers", containerID, "config.v2.json")
+	config, _ := os.ReadFile(configPath)
+	_ = os.WriteFile(configPath, []byte(strings.Replace(string(config), `"ID":`, `"Driver": "overlay2", "ID":`, 1)), 0600)
+
+	mountID := "mount-" + containerID
+	mountDir := filepath.Join(dockerRoot, "image", "overlay2", "layerdb", "mounts", containerID)
+	_ = os.MkdirAll(mountDir, 0755)
+	_ = os.WriteFile(filepath.Join(mountDir, "mount-id"), []byte(mountID), 0600)
+
+	overlayDir := filepath.Join(dockerRoot, "overlay2")
+	layerFiles := map[string]string{
+		"base/diff/etc/passwd": "root",
+		"base/diff/etc/hosts":  "localhost",
+		mountID + "/link":      "UPPER-" + containerID,
+		mountID + "/lower":     "l/BASE",
+	}
+	for name, content := range files {
+		layerFiles[filepath.Join(mountID, "diff", name)] = content
+	}
+	for name, content := range layerFiles {
+		_ = os.MkdirAll(filepath.Dir(filepath.Join(overlayDir, name)), 0755)
+		_ = os.WriteFile(filepath.Join(overlayDir, name), []byte(content), 0644)
+	}
+	_ = os.MkdirAll(filepath.Join(overlayDir, mountID, "diff"), 0755)
+	_ = os.MkdirAll(filepath.Join(overlayDir, "l"), 0755)
+	_ = os.Symlink("../"+mountID+"/diff", filepath.Join(overlayDir, "l", "UPPER-"+containerID))
+	_ = os.Symlink("../base/diff", filepath.Join(overlayDir, "l", "BASE"))
+}
+
 func createMetaSnapshot(tx *bolt.Tx, ns, snapshotter, key, name, parent string, created time.Time) error {
 	v1Bkt, err := tx.CreateBucketIfNotExists([]byte("v1"))
 	if err != nil {
@@ -239,18 +284,39 @@ func runApp(args []string) (string, error) {
 		cli.StringFlag{Name: "image-root, i"},
 		cli.StringFlag{Name: "docker-root, D"},
 		cli.StringFlag{Name: "output"},
+		cli.StringFlag{Name: "output-file, o"},
+		cli.StringFlag{Name: "format"},
+		cli.StringFlag{Name: "columns"},
+		cli.StringFlag{Name: "sort-by"},
+		cli.StringFlag{Name: "container-engine, e"},
+		cli.StringSliceFlag{Name: "engine-option"},
+		cli.StringFlag{Name: "hash-cache"},
+		cli.BoolFlag{Name: "no-hash-cache"},
 	}
 	app.Commands = []cli.Command{
 		ListCommand,
 		InfoCommand,
 		InspectCommand,
 		MountCommand,
+		UmountCommand,
+		MountsCommand,
 		DriftCommand,
 		ExportCommand,
+		ContentCommand,
+		FSCommand,
+		GrepCommand,
+		FindCommand,
+		CarveCommand,
+		CompareCommand,
+		BatchCommand,
 	}
 	app.Before = func(clictx *cli.Context) error {
 		return InitializeRuntime(clictx)
 	}
+	app.After = func(clictx *cli.Context) error {
+		CloseRuntime()
+		return nil
+	}
 
 	// Capture output
 	var stdoutBuf bytes.Buffer
@@ -429,7 +495,8 @@ func TestCLI_Export(t *testing.T) {
 			"losetup": {Stdout: "/dev/loop123\n", Err: nil},
 		},
 	}
-	utils.Runner = mockRunner
+	// The commands are recorded to the custody record of the context.
+	utils.Runner = utils.NewRecordingRunner(mockRunner)
 	defer func() { utils.Runner = origRunner }()
 
 	outputDir := filepath.Join(tmpDir, "output")
@@ -441,7 +508,6 @@ func TestCLI_Export(t *testing.T) {
 
 	hasMount := false
 	hasUmount := false
-	hasTar := false
 	for _, c := range mockRunner.Calls {
 		if c.Name == "mount" {
 			hasMount = true
@@ -449,9 +515,6 @@ func TestCLI_Export(t *testing.T) {
 		if c.Name == "umount" {
 			hasUmount = true
 		}
-		if c.Name == "tar" {
-			hasTar = true
-		}
 	}
 
 	if !hasMount {
@@ -460,72 +523,559 @@ func TestCLI_Export(t *testing.T) {
 	if !hasUmount {
 		t.Errorf("expected 'umount' command to be executed")
 	}
-	if !hasTar {
-		t.Errorf("expected 'tar' command to be executed")
+	if _, err := os.Stat(filepath.Join(outputDir, "container-cli-4.tar.gz")); err != nil {
+		t.Errorf("expected container archive: %v", err)
+	}
+
+	custodyFiles, _ := filepath.Glob(filepath.Join(outputDir, "custody-*.json"))
+	if len(custodyFiles) != 1 {
+		t.Fatalf("expected 1 custody manifest, got %v", custodyFiles)
+	}
+	data, err := os.ReadFile(custodyFiles[0])
+	if err != nil {
+		t.Fatalf("failed to read custody manifest: %v", err)
+	}
+	var custody utils.Custody
+	if err := json.Unmarshal(data, &custody); err != nil {
+		t.Fatalf("failed to decode custody manifest: %v", err)
+	}
+	if len(custody.Artifacts) == 0 || custody.Artifacts[0].SHA256 == "" {
+		t.Errorf("expected hashed artifacts, got %+v", custody.Artifacts)
+	}
+	if len(custody.Containers) != 1 || custody.Containers[0].ContainerID != "container-cli-4" {
+		t.Errorf("expected container-cli-4 custody record, got %+v", custody.Containers)
+	}
+	if len(custody.Commands) == 0 {
+		t.Errorf("expected recorded commands")
+	}
+}
+
+func TestCLI_Umount(t *testing.T) {
+	root := t.TempDir()
+	overlay := filepath.Join(root, "container-1")
+	image := filepath.Join(root, "container-2-img")
+	_ = utils.RegisterMount(utils.MountRecord{Mountpoint: overlay, Type: utils.MountTypeOverlay, ContainerID: "container-1"})
+	_ = utils.RegisterMount(utils.MountRecord{Mountpoint: image, Type: utils.MountTypeLoop, Device: "/dev/loop9", ContainerID: "container-2"})
+
+	output, err := runApp([]string{"container-explorer", "--output", "table", "mounts", root})
+	if err != nil {
+		t.Fatalf("runApp mounts failed: %v", err)
+	}
+	for _, expected := range []string{"MOUNTPOINT", overlay, image, "/dev/loop9"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected mounts output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	mockRunner := &mockCommandRunner{}
+	oldRunner := utils.Runner
+	utils.Runner = mockRunner
+	defer func() { utils.Runner = oldRunner }()
+
+	if _, err := runApp([]string{"container-explorer", "umount", "--all", root}); err != nil {
+		t.Fatalf("runApp umount failed: %v", err)
+	}
+
+	var calls []string
+	for _, call := range mockRunner.Calls {
+		calls = append(calls, call.Name+" "+strings.Join(call.Args, " "))
+	}
+	expected := []string{"umount " + overlay, "umount " + image, "losetup -d /dev/loop9"}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected calls %v, got %v", expected, calls)
+	}
+	if records, _ := utils.ReadMountRegistry(root); len(records) != 0 {
+		t.Errorf("expected no recorded mounts after umount, got %+v", records)
+	}
+}
+
+func TestCLI_MountImage(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	setupMockContainerd(t, containerdRoot, "ns-mount-image", "")
+
+	mockRunner := &mockCommandRunner{}
+	oldRunner := utils.Runner
+	utils.Runner = mockRunner
+	defer func() { utils.Runner = oldRunner }()
+
+	_, err := runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "mount", "image", "missing:latest", filepath.Join(tmpDir, "mnt")})
+	if err == nil || !strings.Contains(err.Error(), "no matching image") {
+		t.Errorf("expected no matching image error, got %v", err)
+	}
+	if len(mockRunner.Calls) != 0 {
+		t.Errorf("expected no commands, got %v", mockRunner.Calls)
+	}
+
+	if _, err := runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "mount", "image", "missing:latest"}); err == nil {
+		t.Errorf("expected error for missing mount point")
+	}
+}
+
+func TestCLI_Snapshot(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	setupMockContainerd(t, containerdRoot, "ns-snapshot", "")
+
+	dockerRoot := filepath.Join(tmpDir, "docker_root")
+	setupMockDocker(t, dockerRoot, "container-docker-1")
+
+	// A docker layer carrying a single file
+	chainID := digest.FromString("layer")
+	layerDir := filepath.Join(dockerRoot, "image", "overlay2", "layerdb", "sha256", chainID.Encoded())
+	if err := os.MkdirAll(layerDir, 0755); err != nil {
+		t.Fatalf("failed to create layer dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(layerDir, "cache-id"), []byte("cache-1"), 0644); err != nil {
+		t.Fatalf("failed to write cache-id: %v", err)
+	}
+	diffDir := filepath.Join(dockerRoot, "overlay2", "cache-1", "diff")
+	if err := os.MkdirAll(diffDir, 0755); err != nil {
+		t.Fatalf("failed to create diff dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(diffDir, "payload"), []byte("payload"), 0644); err != nil {
+		t.Fatalf("failed to write payload: %v", err)
+	}
+
+	mockRunner := &mockCommandRunner{}
+	oldRunner := utils.Runner
+	utils.Runner = mockRunner
+	defer func() { utils.Runner = oldRunner }()
+
+	outputDir := filepath.Join(tmpDir, "output")
+	args := []string{"container-explorer", "--containerd-root", containerdRoot, "--docker-root", dockerRoot, "export", "snapshot", "--compression", "none", chainID.String(), outputDir}
+	if _, err := runApp(args); err != nil {
+		t.Fatalf("runApp failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "snapshot-sha256_"+chainID.Encoded()+".tar")); err != nil {
+		t.Errorf("expected snapshot archive: %v", err)
+	}
+	if custodyFiles, _ := filepath.Glob(filepath.Join(outputDir, "custody-*.json")); len(custodyFiles) != 1 {
+		t.Errorf("expected custody manifest, got %v", custodyFiles)
+	}
+	if len(mockRunner.Calls) != 0 {
+		t.Errorf("expected no commands for a single layer, got %v", mockRunner.Calls)
+	}
+
+	_, err := runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "--docker-root", dockerRoot, "mount", "snapshot", "missing", filepath.Join(tmpDir, "mnt")})
+	if err == nil || !strings.Contains(err.Error(), "no matching snapshot") {
+		t.Errorf("expected no matching snapshot error, got %v", err)
+	}
+	_, err = runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "--docker-root", dockerRoot, "export", "snapshot", "missing", outputDir})
+	if err == nil || !strings.Contains(err.Error(), "no matching snapshot") {
+		t.Errorf("expected no matching snapshot error, got %v", err)
+	}
+
+	mountpoint := filepath.Join(tmpDir, "mnt")
+	args = []string{"container-explorer", "--containerd-root", containerdRoot, "--docker-root", dockerRoot, "mount", "snapshot", "cache-1", mountpoint}
+	if _, err := runApp(args); err != nil {
+		t.Fatalf("runApp failed: %v", err)
+	}
+	if len(mockRunner.Calls) != 1 || strings.Join(mockRunner.Calls[0].Args, " ") != "-o bind,ro "+diffDir+" "+mountpoint {
+		t.Errorf("expected bind mount of %s, got %v", diffDir, mockRunner.Calls)
+	}
+	records, _ := utils.ReadMountRegistry(tmpDir)
+	if len(records) != 1 || records[0].Snapshot != "cache-1" {
+		t.Errorf("expected snapshot mount record, got %+v", records)
+	}
+}
+
+func TestCLI_FS(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	setupMockContainerd(t, containerdRoot, "ns-fs", "")
+
+	// An overlay2 container with a file added on top of the image layer
+	dockerRoot := filepath.Join(tmpDir, "docker_root")
+	containerID := "container-fs-1"
+	setupMockOverlay2Container(t, dockerRoot, containerID, map[string]string{
+		"etc/passwd":  "root\nevil",
+		"tmp/evil.sh": "#!/bin/sh",
+	})
+	overlayDir := filepath.Join(dockerRoot, "overlay2")
+
+	run := func(args ...string) (string, error) {
+		return runApp(append([]string{"container-explorer", "--containerd-root", containerdRoot, "--docker-root", dockerRoot}, args...))
+	}
+
+	output, err := run("fs", "ls", containerID, "/etc")
+	if err != nil {
+		t.Fatalf("fs ls failed: %v", err)
+	}
+	for _, expected := range []string{"/etc/passwd", filepath.Join(overlayDir, "l", "UPPER-"+containerID), "/etc/hosts", filepath.Join(overlayDir, "l", "BASE")} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected fs ls output to contain %s, got:\n%s", expected, output)
+		}
+	}
+
+	output, err = run("fs", "cat", containerID, "/etc/passwd", "etc/hosts")
+	if err != nil {
+		t.Fatalf("fs cat failed: %v", err)
+	}
+	if output != "root\nevillocalhost" {
+		t.Errorf("expected merged file content, got %q", output)
+	}
+	if _, err := run("fs", "cat", containerID, "/etc"); err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("expected directory error, got %v", err)
+	}
+
+	output, err = run("--output", "json", "fs", "stat", containerID, "/tmp/evil.sh")
+	if err != nil {
+		t.Fatalf("fs stat failed: %v", err)
+	}
+	if !strings.Contains(output, fmt.Sprintf("%x", sha256.Sum256([]byte("#!/bin/sh")))) || !strings.Contains(output, `"type": "file"`) {
+		t.Errorf("expected file hash and type, got:\n%s", output)
+	}
+
+	findTests := []struct {
+		args     []string
+		expected []string
+		excluded []string
+	}{
+		{[]string{"--name", "*.sh"}, []string{"/tmp/evil.sh"}, []string{"/etc/passwd"}},
+		{[]string{"--size", "+5"}, []string{"/etc/passwd", "/etc/hosts", "/tmp/evil.sh"}, []string{"/tmp\t"}},
+		{[]string{"--size", "-5"}, nil, []string{"/etc/passwd", "/etc/hosts"}},
+		{[]string{"--newer", "2100-01-01"}, nil, []string{"/etc/passwd", "/tmp/evil.sh"}},
+	}
+	for _, tt := range findTests {
+		args := append(append([]string{"fs", "find"}, tt.args...), containerID)
+		output, err := run(args...)
+		if err != nil {
+			t.Fatalf("fs find %v failed: %v", tt.args, err)
+		}
+		for _, expected := range tt.expected {
+			if !strings.Contains(output, expected) {
+				t.Errorf("fs find %v: expected %s, got:\n%s", tt.args, expected, output)
+			}
+		}
+		for _, excluded := range tt.excluded {
+			if strings.Contains(output, excluded) {
+				t.Errorf("fs find %v: expected no %s, got:\n%s", tt.args, excluded, output)
+			}
+		}
+	}
+	if _, err := run("fs", "find", "--size", "big", containerID); err == nil {
+		t.Errorf("expected invalid size error")
+	}
+
+	if _, err := run("fs", "ls", "missing"); err == nil || !strings.Contains(err.Error(), "no matching container") {
+		t.Errorf("expected no matching container error, got %v", err)
+	}
+}
+
+func TestCLI_ListOrphans(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	setupMockContainerd(t, containerdRoot, "ns-orphans", "")
+
+	// The layer of a deleted container remains next to the base image layer
+	dockerRoot := filepath.Join(tmpDir, "docker_root")
+	setupMockOverlay2Container(t, dockerRoot, "container-orphans-1", nil)
+	layerDir := filepath.Join(dockerRoot, "image", "overlay2", "layerdb", "sha256", strings.Repeat("a", 64))
+	_ = os.MkdirAll(layerDir, 0755)
+	_ = os.WriteFile(filepath.Join(layerDir, "cache-id"), []byte("base"), 0600)
+
+	overlayDir := filepath.Join(dockerRoot, "overlay2")
+	_ = os.MkdirAll(filepath.Join(overlayDir, "deleted", "diff", "tmp"), 0755)
+	_ = os.WriteFile(filepath.Join(overlayDir, "deleted", "lower"), []byte("l/BASE"), 0600)
+	_ = os.WriteFile(filepath.Join(overlayDir, "deleted", "diff", "tmp", "kworker"), []byte("payload"), 0755)
+
+	output, err := runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "--docker-root", dockerRoot, "--output", "json_line", "list", "orphans"})
+	if err != nil {
+		t.Fatalf("list orphans failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 orphan, got:\n%s", output)
+	}
+	var orphan explorers.Orphan
+	if err := json.Unmarshal([]byte(lines[0]), &orphan); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", lines[0], err)
+	}
+	if orphan.Key != "deleted" || orphan.Reason != explorers.OrphanUnreferenced || orphan.Parent != "base" || orphan.Size != 7 || orphan.Files != 2 {
+		t.Errorf("unexpected orphan %+v", orphan)
+	}
+
+	output, err = runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "--docker-root", dockerRoot, "--output", "table", "list", "orphans"})
+	if err != nil {
+		t.Fatalf("list orphans failed: %v", err)
+	}
+	if !strings.Contains(output, "REASON") || !strings.Contains(output, filepath.Join(overlayDir, "deleted", "diff")) {
+		t.Errorf("expected orphan table, got:\n%s", output)
+	}
+}
+
+func TestCLI_Search(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	setupMockContainerd(t, containerdRoot, "ns-search", "")
+
+	dockerRoot := filepath.Join(tmpDir, "docker_root")
+	setupMockOverlay2Container(t, dockerRoot, "container-search-1", map[string]string{
+		"tmp/.x/kworker": "miner --pool stratum://evil",
+	})
+	setupMockOverlay2Container(t, dockerRoot, "container-search-2", map[string]string{
+		"app/config": "pool=none",
+		"app/blob":   "stratum\x00binary",
+	})
+
+	run := func(args ...string) ([]searchMatch, error) {
+		output, err := runApp(append([]string{"container-explorer", "--containerd-root", containerdRoot, "--docker-root", dockerRoot, "--output", "json_line"}, args...))
+		if err != nil {
+			return nil, err
+		}
+		var matches []searchMatch
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			if line == "" {
+				continue
+			}
+			var match searchMatch
+			if err := json.Unmarshal([]byte(line), &match); err != nil {
+				t.Fatalf("invalid JSON line %q: %v", line, err)
+			}
+			matches = append(matches, match)
+		}
+		return matches, nil
+	}
+
+	// A file path in every container
+	matches, err := run("find", "--path", "/tmp/.x/kworker")
+	if err != nil {
+		t.Fatalf("find failed: %v", err)
+	}
+	if len(matches) != 1 || matches[0].ContainerID != "container-search-1" || matches[0].Runtime != "docker" || matches[0].Path != "/tmp/.x/kworker" {
+		t.Errorf("expected kworker in container-search-1, got %+v", matches)
+	}
+	if !strings.HasSuffix(matches[0].Layer, "UPPER-container-search-1") {
+		t.Errorf("expected the writable layer, got %s", matches[0].Layer)
+	}
+
+	// The merged view includes the image layers unless only the upper
+	// directories are searched
+	matches, _ = run("find", "--name", "passwd")
+	if len(matches) != 2 {
+		t.Errorf("expected passwd in both containers, got %+v", matches)
+	}
+	matches, _ = run("find", "--upper-only", "--name", "passwd")
+	if len(matches) != 0 {
+		t.Errorf("expected no passwd in the writable layers, got %+v", matches)
+	}
+
+	// Content of text and binary files
+	matches, err = run("grep", "stratum")
+	if err != nil {
+		t.Fatalf("grep failed: %v", err)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Path < matches[j].Path })
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %+v", matches)
+	}
+	if matches[0].Path != "/app/blob" || !matches[0].Binary || matches[0].Text != "" {
+		t.Errorf("expected binary match of /app/blob, got %+v", matches[0])
+	}
+	if matches[1].Path != "/tmp/.x/kworker" || matches[1].Line != 1 || matches[1].Text != "miner --pool stratum://evil" {
+		t.Errorf("expected text match of /tmp/.x/kworker, got %+v", matches[1])
+	}
+
+	matches, _ = run("grep", "-F", "-i", "POOL=", "/app")
+	if len(matches) != 1 || matches[0].ContainerID != "container-search-2" {
+		t.Errorf("expected fixed string match in container-search-2, got %+v", matches)
+	}
+
+	// Label filters
+	if matches, _ = run("grep", "--filter", "app=test-docker", "localhost"); len(matches) != 2 {
+		t.Errorf("expected matches in the labeled containers, got %+v", matches)
+	}
+	if matches, _ = run("grep", "--filter", "app=other", "localhost"); len(matches) != 0 {
+		t.Errorf("expected no matches with a label filter, got %+v", matches)
+	}
+
+	if _, err := run("grep", "("); err == nil {
+		t.Errorf("expected invalid pattern error")
+	}
+
+	// Table output is sorted by container and path
+	output, err := runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "--docker-root", dockerRoot, "--output", "table", "find", "--name", "hosts"})
+	if err != nil {
+		t.Fatalf("find failed: %v", err)
+	}
+	if !strings.Contains(output, "CONTAINER ID") || strings.Index(output, "container-search-1") > strings.Index(output, "container-search-2") {
+		t.Errorf("expected sorted table, got:\n%s", output)
+	}
+}
+
+func TestPatternRoot(t *testing.T) {
+	tests := map[string]string{
+		"":                 "/",
+		"*.sh":             "/",
+		"/tmp/.x/kworker":  "/tmp/.x",
+		"/tmp/*/kworker":   "/tmp",
+		"/usr/lib/[ab]*/x": "/usr/lib",
+		"/kworker":         "/",
+	}
+	for pattern, expected := range tests {
+		if got := patternRoot(pattern); got != expected {
+			t.Errorf("patternRoot(%s) = %s, expected %s", pattern, got, expected)
+		}
+	}
+}
+
+func TestParseFSSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		size     int64
+		expected bool
+	}{
+		{"512", 512, true},
+		{"512", 513, false},
+		{"+1k", 1025, true},
+		{"+1k", 1024, false},
+		{"-2M", 2<<20 - 1, true},
+		{"-2M", 2 << 20, false},
+		{"1G", 1 << 30, true},
+	}
+	for _, tt := range tests {
+		match, err := parseFSSize(tt.value)
+		if err != nil {
+			t.Fatalf("parseFSSize(%s) failed: %v", tt.value, err)
+		}
+		if got := match(tt.size); got != tt.expected {
+			t.Errorf("parseFSSize(%s)(%d) = %t, expected %t", tt.value, tt.size, got, tt.expected)
+		}
+	}
+
+	for _, value := range []string{"", "+", "1T", "--1", "k"} {
+		if _, err := parseFSSize(value); err == nil {
+			t.Errorf("expected error for size %q", value)
+		}
+	}
+}
+
+func TestCLI_ExportImage(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	setupMockContainerd(t, containerdRoot, "ns-export-image", "")
+
+	args := []string{"container-explorer", "--containerd-root", containerdRoot, "export", "image", "ubuntu:latest", filepath.Join(tmpDir, "ubuntu.tar")}
+	_, err := runApp(args)
+	if err == nil || !strings.Contains(err.Error(), "no matching image") {
+		t.Errorf("expected 'no matching image' error, got %v", err)
+	}
+
+	args = []string{"container-explorer", "--containerd-root", containerdRoot, "export", "image", "ubuntu:latest"}
+	_, err = runApp(args)
+	if err == nil || !strings.Contains(err.Error(), "required") {
+		t.Errorf("expected missing argument error, got %v", err)
 	}
 }
 
-func TestGetDockerDataRoot(t *testing.T) {
-	// Case 1: Config does not exist -> default
+func TestCLI_Content(t *testing.T) {
 	tmpDir := t.TempDir()
-	path := getDockerDataRoot(tmpDir)
-	if path != defaultDockerRootDir {
-		t.Errorf("expected default docker data root %q, got %q", defaultDockerRootDir, path)
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	setupMockContainerd(t, containerdRoot, "ns-content", "")
+
+	blobDir := filepath.Join(containerdRoot, "io.containerd.content.v1.content", "blobs", "sha256")
+	if err := os.MkdirAll(blobDir, 0755); err != nil {
+		t.Fatalf("failed to create blob dir: %v", err)
+	}
+	data := []byte(`{"schemaVersion":2,"manifests":[]}`)
+	dgst := digest.FromBytes(data)
+	if err := os.WriteFile(filepath.Join(blobDir, dgst.Encoded()), data, 0644); err != nil {
+		t.Fatalf("failed to write blob: %v", err)
 	}
 
-	// Case 2: Config exists but is invalid JSON
-	dockerConfigDir := filepath.Join(tmpDir, "etc", "docker")
-	_ = os.MkdirAll(dockerConfigDir, 0755)
-	_ = os.WriteFile(filepath.Join(dockerConfigDir, "daemon.json"), []byte("{invalid-json}"), 0600)
-	path = getDockerDataRoot(tmpDir)
-	if path != defaultDockerRootDir {
-		t.Errorf("expected default docker data root on invalid JSON, got %q", path)
+	// An orphaned blob is not OK
+	args := []string{"container-explorer", "--containerd-root", containerdRoot, "content", "verify"}
+	output, err := runApp(args)
+	if ExitCode(err) != ExitPartial {
+		t.Fatalf("expected exit code %d, got %d: %v", ExitPartial, ExitCode(err), err)
+	}
+	if !strings.Contains(output, dgst.String()) || !strings.Contains(output, "ORPHANED") {
+		t.Errorf("expected output to report orphaned blob %s, got:\n%s", dgst, output)
 	}
 
-	// Case 3: Config exists, valid JSON, but missing data-root
-	_ = os.WriteFile(filepath.Join(dockerConfigDir, "daemon.json"), []byte(`{"debug": true}`), 0600)
-	path = getDockerDataRoot(tmpDir)
-	if path != defaultDockerRootDir {
-		t.Errorf("expected default docker data root on missing data-root, got %q", path)
+	args = []string{"container-explorer", "--containerd-root", containerdRoot, "content", "cat", dgst.Encoded()}
+	output, err = runApp(args)
+	if err != nil {
+		t.Fatalf("runApp failed: %v", err)
+	}
+	if !strings.Contains(output, "\n \"manifests\": []") {
+		t.Errorf("expected pretty-printed index, got:\n%s", output)
 	}
 
-	// Case 4: Config exists, valid JSON, custom data-root
-	_ = os.WriteFile(filepath.Join(dockerConfigDir, "daemon.json"), []byte(`{"data-root": "/custom/docker/root"}`), 0600)
-	path = getDockerDataRoot(tmpDir)
-	if path != "/custom/docker/root" {
-		t.Errorf("expected custom docker data root '/custom/docker/root', got %q", path)
+	args = []string{"container-explorer", "--containerd-root", containerdRoot, "content", "cat", "--raw", dgst.String()}
+	output, err = runApp(args)
+	if err != nil {
+		t.Fatalf("runApp failed: %v", err)
+	}
+	if output != string(data) {
+		t.Errorf("expected raw content %q, got %q", data, output)
 	}
 }
 
-func TestGetContainerdDataDir(t *testing.T) {
-	// Case 1: Config does not exist -> default
+func TestCLI_ContentVerifyCorrupted(t *testing.T) {
 	tmpDir := t.TempDir()
-	path := getContainerdDataDir(tmpDir)
-	if path != defaultContainerdRootDir {
-		t.Errorf("expected default containerd root %q, got %q", defaultContainerdRootDir, path)
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	setupMockContainerd(t, containerdRoot, "ns-verify", "")
+
+	// The blob recorded in the content metadata is replaced on disk
+	data := []byte(`{"schemaVersion":2,"manifests":[]}`)
+	dgst := digest.FromBytes(data)
+	db, err := bolt.Open(filepath.Join(containerdRoot, "io.containerd.metadata.v1.bolt", "meta.db"), 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open bolt db: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		blobBkt, err := tx.Bucket([]byte("v1")).Bucket([]byte("ns-verify")).CreateBucketIfNotExists([]byte("content"))
+		if err != nil {
+			return err
+		}
+		if blobBkt, err = blobBkt.CreateBucketIfNotExists([]byte("blob")); err != nil {
+			return err
+		}
+		digestBkt, err := blobBkt.CreateBucket([]byte(dgst.String()))
+		if err != nil {
+			return err
+		}
+		sizeBuf := make([]byte, binary.MaxVarintLen64)
+		n := binary.PutVarint(sizeBuf, int64(len(data)))
+		return digestBkt.Put([]byte("size"), sizeBuf[:n])
+	})
+	db.Close()
+	if err != nil {
+		t.Fatalf("failed to create content metadata: %v", err)
 	}
 
-	// Case 2: Config exists but parsing fails (invalid TOML)
-	containerdConfigDir := filepath.Join(tmpDir, "etc", "containerd")
-	_ = os.MkdirAll(containerdConfigDir, 0755)
-	_ = os.WriteFile(filepath.Join(containerdConfigDir, "config.toml"), []byte("invalid-toml"), 0600)
-	path = getContainerdDataDir(tmpDir)
-	if path != defaultContainerdRootDir {
-		t.Errorf("expected default containerd root on invalid TOML, got %q", path)
+	blobDir := filepath.Join(containerdRoot, "io.containerd.content.v1.content", "blobs", "sha256")
+	if err := os.MkdirAll(blobDir, 0755); err != nil {
+		t.Fatalf("failed to create blob dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(blobDir, dgst.Encoded()), bytes.ToUpper(data), 0644); err != nil {
+		t.Fatalf("failed to write blob: %v", err)
 	}
 
-	// Case 3: Config exists, valid TOML, but missing root
-	_ = os.WriteFile(filepath.Join(containerdConfigDir, "config.toml"), []byte(`version = 2`), 0600)
-	path = getContainerdDataDir(tmpDir)
-	if path != defaultContainerdRootDir {
-		t.Errorf("expected default containerd root on missing root key, got %q", path)
+	output, err := runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "--output", "json", "content", "verify"})
+	if ExitCode(err) != ExitPartial {
+		t.Fatalf("expected exit code %d, got %d: %v", ExitPartial, ExitCode(err), err)
+	}
+
+	var result explorers.Result[explorers.ContentVerification]
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, output)
+	}
+	if len(result.Items) != 1 || result.Items[0].Status != explorers.ContentStatusCorrupted {
+		t.Errorf("expected a corrupted blob, got %+v", result.Items)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Operation != "verify" || result.Errors[0].ID != dgst.String() {
+		t.Errorf("expected a verify error of %s, got %+v", dgst, result.Errors)
 	}
 
-	// Case 4: Config exists, valid TOML, custom root
-	_ = os.WriteFile(filepath.Join(containerdConfigDir, "config.toml"), []byte(`root = "/custom/containerd/root"`), 0600)
-	path = getContainerdDataDir(tmpDir)
-	if path != "/custom/containerd/root" {
-		t.Errorf("expected custom containerd root '/custom/containerd/root', got %q", path)
+	// The blob verifies once it is restored
+	if err := os.WriteFile(filepath.Join(blobDir, dgst.Encoded()), data, 0644); err != nil {
+		t.Fatalf("failed to write blob: %v", err)
+	}
+	if _, err := runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "content", "verify"}); err != nil {
+		t.Errorf("expected verified content, got %v", err)
 	}
 }
 
@@ -554,3 +1104,726 @@ func TestGetFilterMap(t *testing.T) {
 		t.Errorf("expected {'key2': 'val2', 'key3': ''}, got %v", m)
 	}
 }
+
+func TestCLI_Carve(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	setupMockContainerd(t, containerdRoot, "ns-carve", "container-carve-1")
+
+	dbPath := filepath.Join(containerdRoot, "io.containerd.metadata.v1.bolt", "meta.db")
+	db, err := bolt.Open(dbPath, 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open bolt db: %v", err)
+	}
+	ctx := namespaces.WithNamespace(context.Background(), "ns-carve")
+	if err := metadata.NewContainerStore(metadata.NewDB(db, nil, nil)).Delete(ctx, "container-carve-1"); err != nil {
+		db.Close()
+		t.Fatalf("failed to delete container: %v", err)
+	}
+	db.Close()
+
+	output, err := runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "--output", "json_line", "carve"})
+	if err != nil {
+		t.Fatalf("carve failed: %v", err)
+	}
+	var records []explorers.CarvedRecord
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+		var record explorers.CarvedRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+
+	found := false
+	for _, record := range records {
+		if record.Kind == explorers.CarvedContainer && record.Name == "container-carve-1" {
+			found = true
+			if record.Live || record.Namespace != "ns-carve" || record.Source != dbPath || record.Fields["args"] != "sleep 10" {
+				t.Errorf("unexpected carved container %+v", record)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected deleted container-carve-1, got %+v", records)
+	}
+
+	// Table output of a file argument
+	output, err = runApp([]string{"container-explorer", "--output", "table", "carve", dbPath})
+	if err != nil {
+		t.Fatalf("carve failed: %v", err)
+	}
+	if !strings.Contains(output, "PAGE STATE") || !strings.Contains(output, "container-carve-1") || !strings.Contains(output, "image=ubuntu:latest") {
+		t.Errorf("unexpected carve table output: %s", output)
+	}
+
+	// No bolt database files
+	if _, err := runApp([]string{"container-explorer", "--containerd-root", filepath.Join(tmpDir, "missing"), "carve"}); err == nil {
+		t.Errorf("expected error without bolt database files")
+	}
+}
+
+func TestCLI_ListDeletedContainers(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	setupMockContainerd(t, containerdRoot, "ns-deleted", "container-deleted-1")
+
+	db, err := bolt.Open(filepath.Join(containerdRoot, "io.containerd.metadata.v1.bolt", "meta.db"), 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open bolt db: %v", err)
+	}
+	ctx := namespaces.WithNamespace(context.Background(), "ns-deleted")
+	if err := metadata.NewContainerStore(metadata.NewDB(db, nil, nil)).Delete(ctx, "container-deleted-1"); err != nil {
+		db.Close()
+		t.Fatalf("failed to delete container: %v", err)
+	}
+	db.Close()
+
+	output, err := runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "--output", "table", "list", "containers"})
+	if err != nil {
+		t.Fatalf("list containers failed: %v", err)
+	}
+	if strings.Contains(output, "container-deleted-1") {
+		t.Errorf("expected deleted container to be hidden, got %s", output)
+	}
+
+	output, err = runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "--output", "table", "list", "containers", "--include-deleted"})
+	if err != nil {
+		t.Fatalf("list containers failed: %v", err)
+	}
+	if !strings.Contains(output, "RECOVERED FROM") || !strings.Contains(output, "container-deleted-1") || !strings.Contains(output, "DELETED") || !strings.Contains(output, "meta.db page ") {
+		t.Errorf("expected recovered deleted container, got %s", output)
+	}
+}
+
+func TestCLI_Compare(t *testing.T) {
+	tmpDir := t.TempDir()
+	dockerRootA := filepath.Join(tmpDir, "day1", "docker")
+	setupMockOverlay2Container(t, dockerRootA, "container-shared", map[string]string{
+		"app/config": "pool=none",
+		"app/same":   "unchanged",
+	})
+	setupMockOverlay2Container(t, dockerRootA, "container-removed", nil)
+
+	dockerRootB := filepath.Join(tmpDir, "day2", "docker")
+	setupMockOverlay2Container(t, dockerRootB, "container-shared", map[string]string{
+		"app/config":     "pool=stratum://evil",
+		"app/same":       "unchanged",
+		"tmp/.x/kworker": "miner",
+	})
+	setupMockOverlay2Container(t, dockerRootB, "container-added", nil)
+
+	// The unchanged file has the same modification time in both snapshots
+	modified := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, dockerRoot := range []string{dockerRootA, dockerRootB} {
+		_ = os.Chtimes(filepath.Join(dockerRoot, "overlay2", "mount-container-shared", "diff", "app", "same"), modified, modified)
+	}
+
+	output, err := runApp([]string{"container-explorer", "--docker-root", dockerRootA, "--output", "json_line", "compare", "--docker-root-b", dockerRootB})
+	if err != nil {
+		t.Fatalf("compare failed: %v", err)
+	}
+
+	changes := make(map[string]explorers.Change)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+		var change explorers.Change
+		if err := json.Unmarshal([]byte(line), &change); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		changes[change.Object+" "+change.ID] = change
+	}
+
+	expected := map[string]string{
+		"CONTAINER container-added":   explorers.ChangeAdded,
+		"CONTAINER container-removed": explorers.ChangeRemoved,
+		"FILE /tmp/.x/kworker":        explorers.ChangeAdded,
+		"FILE /app/config":            explorers.ChangeChanged,
+	}
+	for key, change := range expected {
+		if changes[key].Change != change {
+			t.Errorf("expected %s to be %s, got %+v", key, change, changes[key])
+		}
+	}
+	if c := changes["FILE /app/config"]; c.ContainerID != "container-shared" || len(c.Details) == 0 || !strings.HasPrefix(c.Details[0], "sha256: ") {
+		t.Errorf("unexpected file change %+v", c)
+	}
+	if _, ok := changes["FILE /app/same"]; ok {
+		t.Errorf("unexpected change of an unchanged file")
+	}
+	if _, ok := changes["CONTAINER container-shared"]; ok {
+		t.Errorf("unexpected change of an unchanged container")
+	}
+
+	// Table output without files
+	output, err = runApp([]string{"container-explorer", "--docker-root", dockerRootA, "--output", "table", "compare", "--no-files", "--docker-root-b", dockerRootB})
+	if err != nil {
+		t.Fatalf("compare failed: %v", err)
+	}
+	if !strings.Contains(output, "OBJECT") || !strings.Contains(output, "container-added") || strings.Contains(output, "kworker") {
+		t.Errorf("unexpected compare table output: %s", output)
+	}
+
+	// The second snapshot is required
+	if _, err := runApp([]string{"container-explorer", "--docker-root", dockerRootA, "compare"}); err == nil {
+		t.Errorf("expected error without the second snapshot")
+	}
+}
+
+func TestCLI_Batch(t *testing.T) {
+	tmpDir := t.TempDir()
+	rootA := filepath.Join(tmpDir, "images", "node-a")
+	rootB := filepath.Join(tmpDir, "images", "node-b")
+	setupMockOverlay2Container(t, filepath.Join(rootA, "var", "lib", "docker"), "container-node-a", map[string]string{"tmp/dropper": "payload"})
+	setupMockOverlay2Container(t, filepath.Join(rootB, "var", "lib", "docker"), "container-node-b", nil)
+
+	rootsFile := filepath.Join(tmpDir, "roots.txt")
+	_ = os.WriteFile(rootsFile, []byte("# node images\n"+rootA+"\n\n"+rootB+"\n"), 0600)
+
+	for _, roots := range []string{rootsFile, filepath.Join(tmpDir, "images", "node-*")} {
+		outputDir := filepath.Join(t.TempDir(), "batch")
+		output, err := runApp([]string{"container-explorer", "--output", "table", "batch", "--roots", roots, "--jobs", "2", outputDir})
+		if err != nil {
+			t.Fatalf("batch failed: %v", err)
+		}
+		if !strings.Contains(output, "CONTAINERS") || !strings.Contains(output, rootA) || !strings.Contains(output, rootB) {
+			t.Errorf("unexpected batch table output: %s", output)
+		}
+
+		data, err := os.ReadFile(filepath.Join(outputDir, "batch.jsonl"))
+		if err != nil {
+			t.Fatalf("reading batch.jsonl: %v", err)
+		}
+		containers := make(map[string]string)
+		var drifts int
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var record struct {
+				SourceRoot string          `json:"source_root"`
+				Operation  string          `json:"operation"`
+				Record     json.RawMessage `json:"record"`
+			}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("invalid JSON line %q: %v", line, err)
+			}
+			switch record.Operation {
+			case "container":
+				var c explorers.Container
+				_ = json.Unmarshal(record.Record, &c)
+				containers[c.ID] = record.SourceRoot
+			case "drift":
+				drifts++
+			}
+		}
+		if containers["container-node-a"] != rootA || containers["container-node-b"] != rootB {
+			t.Errorf("unexpected source roots of containers %v", containers)
+		}
+		if drifts == 0 {
+			t.Errorf("expected drift records in batch.jsonl")
+		}
+
+		for i := range []string{rootA, rootB} {
+			matches, _ := filepath.Glob(filepath.Join(outputDir, fmt.Sprintf("%03d-*", i), "containers.json"))
+			if len(matches) != 1 {
+				t.Errorf("expected containers.json of root %d, got %v", i, matches)
+			}
+		}
+	}
+
+	// Unsupported operations and missing roots are errors
+	if _, err := runApp([]string{"container-explorer", "batch", "--roots", rootsFile, "--operations", "audit", t.TempDir()}); err == nil {
+		t.Errorf("expected error for unsupported operation")
+	}
+	if _, err := runApp([]string{"container-explorer", "batch", "--roots", filepath.Join(tmpDir, "missing-*"), t.TempDir()}); err == nil {
+		t.Errorf("expected error without image roots")
+	}
+}
+
+func TestCLI_ContainerEngine(t *testing.T) {
+	dockerRoot := filepath.Join(t.TempDir(), "docker")
+	setupMockOverlay2Container(t, dockerRoot, "container-engine-1", nil)
+
+	output, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--container-engine", "docker", "--output", "table", "list", "containers"})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if !strings.Contains(output, "container-engine-1") {
+		t.Errorf("expected docker container, got:\n%s", output)
+	}
+
+	output, err = runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--container-engine", "podman", "--output", "table", "list", "containers"})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if strings.Contains(output, "container-engine-1") {
+		t.Errorf("unexpected docker container for podman engine, got:\n%s", output)
+	}
+
+	for _, args := range [][]string{
+		{"--container-engine", "cri-o"},
+		{"--engine-option", "containerd.unknown=x"},
+		{"--engine-option", "layer-cache"},
+	} {
+		args = append(append([]string{"container-explorer", "--docker-root", dockerRoot}, args...), "list", "containers")
+		if _, err := runApp(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+
+	// The engines of the global flag also select the containers searched
+	containerdRoot := filepath.Join(t.TempDir(), "containerd")
+	setupMockContainerd(t, containerdRoot, "ns-engine", "")
+	output, err = runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "--docker-root", dockerRoot, "-e", "containerd,docker", "--output", "table", "find", "--name", "passwd"})
+	if err != nil {
+		t.Fatalf("find failed: %v", err)
+	}
+	if !strings.Contains(output, "container-engine-1") {
+		t.Errorf("expected docker container, got:\n%s", output)
+	}
+	output, err = runApp([]string{"container-explorer", "--containerd-root", containerdRoot, "--docker-root", dockerRoot, "-e", "containerd", "--output", "table", "find", "--name", "passwd"})
+	if err != nil {
+		t.Fatalf("find failed: %v", err)
+	}
+	if strings.Contains(output, "container-engine-1") {
+		t.Errorf("unexpected docker container for containerd engine, got:\n%s", output)
+	}
+}
+
+func TestCLI_ListCapabilities(t *testing.T) {
+	dockerRoot := filepath.Join(t.TempDir(), "docker")
+	setupMockOverlay2Container(t, dockerRoot, "container-capability-1", nil)
+
+	output, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--output", "json_line", "list", "capabilities"})
+	if err != nil {
+		t.Fatalf("list capabilities failed: %v", err)
+	}
+
+	supported := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var c explorers.Capability
+		if err := json.Unmarshal([]byte(line), &c); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		if c.ContainerType == "docker" {
+			supported[c.Name] = c.Supported
+		}
+	}
+	if !supported[explorers.CapabilityNamespaces] || supported[explorers.CapabilitySnapshots] || supported[explorers.CapabilityImageExport] {
+		t.Errorf("unexpected docker capabilities %v", supported)
+	}
+}
+
+func TestCLI_PartialErrors(t *testing.T) {
+	dockerRoot := filepath.Join(t.TempDir(), "docker")
+	setupMockOverlay2Container(t, dockerRoot, "container-partial-1", map[string]string{"tmp/drift.sh": "echo"})
+	setupMockOverlay2Container(t, dockerRoot, "container-partial-2", nil)
+	_ = os.Remove(filepath.Join(dockerRoot, "image", "overlay2", "layerdb", "mounts", "container-partial-2", "mount-id"))
+
+	output, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--output", "json", "drift"})
+	if ExitCode(err) != ExitPartial {
+		t.Fatalf("expected partial failure exit code, got %v", err)
+	}
+
+	var result explorers.Result[explorers.Drift]
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", output, err)
+	}
+	if len(result.Items) != 1 || result.Items[0].ContainerID != "container-partial-1" {
+		t.Errorf("expected drift of container-partial-1, got %+v", result.Items)
+	}
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected 1 error, got %+v", result.Errors)
+	}
+	itemErr := result.Errors[0]
+	if itemErr.ContainerType != "docker" || itemErr.Operation != "drift" || itemErr.ID != "container-partial-2" || itemErr.Kind != explorers.ErrorKindNotFound {
+		t.Errorf("unexpected error %+v", itemErr)
+	}
+
+	// A file that fails to carve is reported in the errors
+	invalid := filepath.Join(t.TempDir(), "meta.db")
+	if err := os.WriteFile(invalid, []byte("not a bolt database"), 0644); err != nil {
+		t.Fatalf("failed to write invalid database: %v", err)
+	}
+	output, err = runApp([]string{"container-explorer", "--output", "json", "carve", invalid})
+	if ExitCode(err) != ExitPartial {
+		t.Fatalf("expected partial failure exit code for carve, got %v", err)
+	}
+	var carved explorers.Result[explorers.CarvedRecord]
+	if err := json.Unmarshal([]byte(output), &carved); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", output, err)
+	}
+	if len(carved.Errors) != 1 || carved.Errors[0].Operation != "carve" || carved.Errors[0].ID != invalid {
+		t.Errorf("unexpected carve errors %+v", carved.Errors)
+	}
+
+	if ExitCode(nil) != ExitOK || ExitCode(fmt.Errorf("failed")) != ExitFailure {
+		t.Errorf("unexpected exit codes")
+	}
+}
+
+func TestCLI_HashCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	dockerRoot := filepath.Join(tmpDir, "docker")
+	for i := range 2 {
+		setupMockOverlay2Container(t, dockerRoot, fmt.Sprintf("container-cache-%d", i), map[string]string{
+			"tmp/a.sh": "echo a",
+		})
+	}
+	cachePath := filepath.Join(tmpDir, "hashes.db")
+
+	// The hash cache is not used unless it is set.
+	if _, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--hash-cache", cachePath, "--no-hash-cache", "drift"}); err != nil {
+		t.Fatalf("drift failed: %v", err)
+	}
+	if _, err := os.Stat(cachePath); !os.IsNotExist(err) {
+		t.Fatalf("expected no hash cache with --no-hash-cache, got %v", err)
+	}
+
+	var outputs []string
+	for range 2 {
+		output, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--hash-cache", cachePath, "--output", "json", "drift"})
+		if err != nil {
+			t.Fatalf("drift failed: %v", err)
+		}
+		outputs = append(outputs, output)
+	}
+	if GlobalConfig.HashCache != nil {
+		t.Errorf("expected the hash cache to be closed after the command")
+	}
+
+	var firstResult, secondResult explorers.Result[explorers.Drift]
+	if err := json.Unmarshal([]byte(outputs[0]), &firstResult); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if err := json.Unmarshal([]byte(outputs[1]), &secondResult); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	first, second := firstResult.Items, secondResult.Items
+	if len(first) != 2 || len(second) != 2 {
+		t.Fatalf("expected 2 drifts, got %d and %d", len(first), len(second))
+	}
+	expected := fmt.Sprintf("%x", sha256.Sum256([]byte("echo a")))
+	for i := range first {
+		if hash := second[i].AddedOrModified[0].FileSHA256; hash != expected || first[i].AddedOrModified[0].FileSHA256 != expected {
+			t.Errorf("expected cached hash %s, got %s", expected, hash)
+		}
+	}
+
+	cache, err := explorers.OpenHashCache(cachePath)
+	if err != nil {
+		t.Fatalf("OpenHashCache failed: %v", err)
+	}
+	defer cache.Close()
+	ctx := explorers.WithHashCache(context.Background(), cache)
+	paths, _ := filepath.Glob(filepath.Join(dockerRoot, "overlay2", "*", "diff", "tmp", "a.sh"))
+	for _, path := range paths {
+		if _, err := explorers.FileSHA256SumContext(ctx, path); err != nil {
+			t.Fatalf("hashing %s failed: %v", path, err)
+		}
+	}
+	if stats := cache.Stats(); len(paths) != 2 || stats.Hits != 2 || stats.Misses != 0 {
+		t.Errorf("expected the 2 container files in the hash cache, got %+v for %v", stats, paths)
+	}
+}
+
+func TestCLI_DriftJobs(t *testing.T) {
+	dockerRoot := filepath.Join(t.TempDir(), "docker")
+	for i := range 6 {
+		setupMockOverlay2Container(t, dockerRoot, fmt.Sprintf("container-jobs-%d", i), map[string]string{
+			"tmp/a.sh": "echo a",
+			"tmp/b.sh": "echo b",
+		})
+	}
+
+	sequential, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--output", "json_line", "drift", "--jobs", "1"})
+	if err != nil {
+		t.Fatalf("drift failed: %v", err)
+	}
+	parallel, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--output", "json_line", "drift", "--jobs", "4", "--progress"})
+	if err != nil {
+		t.Fatalf("drift failed: %v", err)
+	}
+	// Hashing the files updates the access times, so the drifts are
+	// compared by container, path, and hash.
+	summary := func(output string) []string {
+		var lines []string
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			var drift explorers.Drift
+			if err := json.Unmarshal([]byte(line), &drift); err != nil {
+				t.Fatalf("invalid JSON line %q: %v", line, err)
+			}
+			for _, f := range drift.AddedOrModified {
+				lines = append(lines, drift.ContainerID+" "+f.FullPath+" "+f.FileSHA256)
+			}
+		}
+		return lines
+	}
+	expected := summary(sequential)
+	if len(expected) != 12 {
+		t.Errorf("expected 12 drifted files, got %v", expected)
+	}
+	if got := summary(parallel); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the same drifts with 4 jobs %v, got %v", expected, got)
+	}
+
+	if _, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "drift", "--jobs", "0"}); err == nil {
+		t.Errorf("expected error for 0 jobs")
+	}
+}
+
+func TestCLI_OutputFileFormats(t *testing.T) {
+	tmpDir := t.TempDir()
+	dockerRoot := filepath.Join(tmpDir, "docker")
+	for i := range 3 {
+		setupMockOverlay2Container(t, dockerRoot, fmt.Sprintf("container-sink-%d", i), map[string]string{
+			"tmp/a.sh": "echo a",
+		})
+	}
+
+	// JSON lines and tables are written to the output file.
+	outputFile := filepath.Join(tmpDir, "drift.jsonl")
+	output, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--output", "json_line", "--output-file", outputFile, "drift", "--jobs", "2"})
+	if err != nil {
+		t.Fatalf("drift failed: %v", err)
+	}
+	if output != "" {
+		t.Errorf("expected no stdout output, got %q", output)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var drift explorers.Drift
+		if err := json.Unmarshal([]byte(line), &drift); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		ids = append(ids, drift.ContainerID)
+	}
+	if !sort.StringsAreSorted(ids) || len(ids) != 3 {
+		t.Errorf("expected 3 drifts in container order, got %v", ids)
+	}
+
+	outputFile = filepath.Join(tmpDir, "containers.txt")
+	if _, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--output-file", outputFile, "list", "containers"}); err != nil {
+		t.Fatalf("list containers failed: %v", err)
+	}
+	data, err = os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "CONTAINER TYPE") || strings.Count(string(data), "container-sink-") != 3 {
+		t.Errorf("expected the container table in the output file, got %q", data)
+	}
+}
+
+func TestCLI_RenderOptions(t *testing.T) {
+	tmpDir := t.TempDir()
+	dockerRoot := filepath.Join(tmpDir, "docker")
+	for i := range 3 {
+		setupMockOverlay2Container(t, dockerRoot, fmt.Sprintf("container-render-%d", i), nil)
+	}
+
+	output, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--format", "{{.ID}}", "--sort-by", "-container-id", "list", "containers"})
+	if err != nil {
+		t.Fatalf("list containers failed: %v", err)
+	}
+	if expected := "container-render-2\ncontainer-render-1\ncontainer-render-0\n"; output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+
+	// Columns hidden by default can be selected.
+	outputFile := filepath.Join(tmpDir, "containers.csv")
+	args := []string{"container-explorer", "--docker-root", dockerRoot, "--output", "csv", "--columns", "container-id,updated-at", "--sort-by", "container-id", "--output-file", outputFile, "list", "containers"}
+	if _, err := runApp(args); err != nil {
+		t.Fatalf("list containers failed: %v", err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV %q: %v", data, err)
+	}
+	if len(rows) != 4 || !reflect.DeepEqual(rows[0], []string{"CONTAINER ID", "UPDATED AT"}) || rows[1][0] != "container-render-0" {
+		t.Errorf("unexpected CSV rows %v", rows)
+	}
+
+	output, err = runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--output", "yaml", "list", "containers"})
+	if err != nil {
+		t.Fatalf("list containers failed: %v", err)
+	}
+	var result struct {
+		Items []map[string]any `yaml:"Items"`
+	}
+	if err := yaml.Unmarshal([]byte(output), &result); err != nil || len(result.Items) != 3 {
+		t.Errorf("expected 3 YAML items, got %q (%v)", output, err)
+	}
+
+	if _, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--columns", "missing", "list", "containers"}); err == nil {
+		t.Errorf("expected error for an unknown column")
+	}
+	if _, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--output", "xml", "list", "containers"}); err == nil {
+		t.Errorf("expected error for an unsupported output format")
+	}
+}
+
+func TestCLI_SQLiteOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	dockerRoot := filepath.Join(tmpDir, "docker")
+	setupMockOverlay2Container(t, dockerRoot, "container-case-1", map[string]string{"tmp/a.sh": "echo a"})
+
+	// The runs of list and drift are added to the same case database.
+	caseDB := filepath.Join(tmpDir, "case.db")
+	for _, command := range []string{"list", "drift"} {
+		args := []string{"container-explorer", "--docker-root", dockerRoot, "--output", "sqlite", "--output-file", caseDB, command}
+		if command == "list" {
+			args = append(args, "containers")
+		}
+		output, err := runApp(args)
+		if err != nil {
+			t.Fatalf("%s failed: %v", command, err)
+		}
+		if output != "" {
+			t.Errorf("expected no stdout output, got %q", output)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", caseDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var runs, files int
+	if err := db.QueryRow("SELECT COUNT(*) FROM runs").Scan(&runs); err != nil || runs != 2 {
+		t.Errorf("expected 2 runs, got %d (%v)", runs, err)
+	}
+	err = db.QueryRow(`SELECT COUNT(*) FROM containers c JOIN drift_files d
+		ON c.source_root = d.source_root AND c.container_id = d.container_id
+		WHERE c.container_id = 'container-case-1' AND d.path LIKE '%a.sh' AND d.change = 'added_or_modified'`).Scan(&files)
+	if err != nil || files != 1 {
+		t.Errorf("expected 1 drift file joined with its container, got %d (%v)", files, err)
+	}
+
+	if _, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--output", "sqlite", "list", "containers"}); err == nil {
+		t.Errorf("expected error for the sqlite output without an output file")
+	}
+	if _, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--output", "sqlite", "--output-file", caseDB, "fs", "ls", "container-case-1", "/"}); err == nil {
+		t.Errorf("expected error for the sqlite output of fs ls")
+	}
+
+	// Batch adds the records of all image roots keyed by source root.
+	rootA := filepath.Join(tmpDir, "images", "node-a")
+	rootB := filepath.Join(tmpDir, "images", "node-b")
+	setupMockOverlay2Container(t, filepath.Join(rootA, "var", "lib", "docker"), "container-node-a", nil)
+	setupMockOverlay2Container(t, filepath.Join(rootB, "var", "lib", "docker"), "container-node-b", nil)
+	batchDB := filepath.Join(tmpDir, "batch.db")
+	output, err := runApp([]string{"container-explorer", "--output", "sqlite", "--output-file", batchDB, "batch", "--roots", filepath.Join(tmpDir, "images", "node-*"), "--operations", "list", filepath.Join(tmpDir, "batch")})
+	if err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	if !strings.Contains(output, "CONTAINERS") {
+		t.Errorf("expected the batch summary table, got %q", output)
+	}
+
+	batch, err := sql.Open("sqlite3", batchDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer batch.Close()
+	roots := make(map[string]string)
+	rows, err := batch.Query("SELECT source_root, container_id FROM containers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var root, id string
+		if err := rows.Scan(&root, &id); err != nil {
+			t.Fatal(err)
+		}
+		roots[id] = root
+	}
+	if roots["container-node-a"] != rootA || roots["container-node-b"] != rootB {
+		t.Errorf("unexpected source roots of containers %v", roots)
+	}
+}
+
+func TestCLI_TimesketchOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	dockerRoot := filepath.Join(tmpDir, "docker")
+	setupMockOverlay2Container(t, dockerRoot, "container-timeline-1", map[string]string{"tmp/a.sh": "echo a"})
+	configPath := filepath.Join(dockerRoot, "containers", "container-timeline-1", "config.v2.json")
+	config, _ := os.ReadFile(configPath)
+	state := `"Running": false, "OOMKilled": true, "StartedAt": "2026-06-12T01:00:00Z", "FinishedAt": "2026-06-12T02:00:00Z",`
+	_ = os.WriteFile(configPath, []byte(strings.Replace(string(config), `"Running": true,`, state, 1)), 0600)
+
+	events := func(args ...string) []map[string]any {
+		t.Helper()
+		output, err := runApp(append([]string{"container-explorer", "--docker-root", dockerRoot, "--output", "timesketch"}, args...))
+		if err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+		var events []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			var event map[string]any
+			if err := json.Unmarshal([]byte(line), &event); err != nil {
+				t.Fatalf("invalid JSON line %q: %v", line, err)
+			}
+			for _, field := range []string{"message", "datetime", "timestamp_desc"} {
+				if event[field] == nil || event[field] == "" {
+					t.Errorf("expected %s in event %v", field, event)
+				}
+			}
+			events = append(events, event)
+		}
+		return events
+	}
+
+	var descs []string
+	for _, event := range events("list", "containers") {
+		if event["container_id"] != "container-timeline-1" {
+			t.Errorf("expected the container ID in event %v", event)
+		}
+		descs = append(descs, event["timestamp_desc"].(string))
+	}
+	if expected := []string{"Creation Time", "Start Time", "OOM Kill Time"}; !reflect.DeepEqual(descs, expected) {
+		t.Errorf("expected container events %v, got %v", expected, descs)
+	}
+
+	var files int
+	for _, event := range events("drift") {
+		if event["data_type"] == "container:drift:file" && strings.HasSuffix(event["path"].(string), "a.sh") {
+			files++
+		}
+	}
+	if files == 0 {
+		t.Errorf("expected MACB events of the drifted file")
+	}
+
+	if _, err := runApp([]string{"container-explorer", "--docker-root", dockerRoot, "--output", "timesketch", "list", "tasks"}); err == nil {
+		t.Errorf("expected error for the timesketch output of list tasks")
+	}
+
+	// Batch writes the events of all image roots keyed by source root.
+	root := filepath.Join(tmpDir, "images", "node-a")
+	setupMockOverlay2Container(t, filepath.Join(root, "var", "lib", "docker"), "container-node-a", nil)
+	timeline := filepath.Join(tmpDir, "timeline.jsonl")
+	args := []string{"container-explorer", "--output", "timesketch", "--output-file", timeline, "batch", "--roots", filepath.Join(tmpDir, "images", "node-*"), "--operations", "list", filepath.Join(tmpDir, "batch")}
+	if _, err := runApp(args); err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	data, err := os.ReadFile(timeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"container_id":"container-node-a"`) || !strings.Contains(string(data), `"source_root":"`+root+`"`) {
+		t.Errorf("expected the batch container events in the timeline, got %s", data)
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/pkg/render"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var CompareCommand = cli.Command{
+	Name:        "compare",
+	Usage:       "compare two snapshots of the same host",
+	Description: "compare the container, image, and snapshot inventories of two snapshots of the same host, e.g. disk images collected on different days, and the upper directory files of the containers in both",
+	ArgsUsage:   "[flag]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "image-root, i",
+			Usage: "image root of the first snapshot (default is the global image root)",
+		},
+		cli.StringFlag{
+			Name:  "image-root-b",
+			Usage: "image root of the second snapshot",
+		},
+		cli.StringFlag{
+			Name:  "containerd-root-b",
+			Usage: "containerd root of the second snapshot",
+		},
+		cli.StringFlag{
+			Name:  "docker-root-b",
+			Usage: "docker root of the second snapshot",
+		},
+		cli.BoolFlag{
+			Name:  "no-files",
+			Usage: "skip comparing the upper directory files of containers",
+		},
+	},
+	Action: func(clictx *cli.Context) error {
+
+		configA := GlobalConfig
+		if clictx.String("image-root") != "" {
+			configA.ImageRootDir = clictx.String("image-root")
+			configA.ContainerdRootDir = ""
+			configA.DockerRootDir = ""
+			resolveRuntimeRoots(&configA)
+		}
+
+		configB := GlobalConfig
+		configB.ImageRootDir = clictx.String("image-root-b")
+		configB.ContainerdRootDir = clictx.String("containerd-root-b")
+		configB.DockerRootDir = clictx.String("docker-root-b")
+		if configB.ImageRootDir == "" && configB.ContainerdRootDir == "" && configB.DockerRootDir == "" {
+			return fmt.Errorf("image-root-b, containerd-root-b, or docker-root-b is required")
+		}
+		resolveRuntimeRoots(&configB)
+
+		ctx := GlobalConfig.Context
+		inventoryA := readInventory(ctx, getExplorersFor(configA))
+		inventoryB := readInventory(ctx, getExplorersFor(configB))
+
+		var changes []explorers.Change
+		changes = append(changes, explorers.CompareContainers(inventoryA.containers, inventoryB.containers)...)
+		changes = append(changes, explorers.CompareImages(inventoryA.images, inventoryB.images)...)
+		changes = append(changes, explorers.CompareSnapshots(inventoryA.snapshots, inventoryB.snapshots)...)
+		if !clictx.Bool("no-files") {
+			changes = append(changes, compareUpperDirs(ctx, inventoryA, inventoryB)...)
+		}
+
+		sink, err := newResultRenderer([]render.Column[explorers.Change]{
+			{Name: "OBJECT", Value: func(c explorers.Change) any { return c.Object }},
+			{Name: "CHANGE", Value: func(c explorers.Change) any { return c.Change }},
+			{Name: "CONTAINER TYPE", Value: func(c explorers.Change) any { return c.ContainerType }},
+			{Name: "NAMESPACE", Value: func(c explorers.Change) any { return c.Namespace }},
+			{Name: "ID", Value: func(c explorers.Change) any { return c.ID }},
+			{Name: "CONTAINER ID", Value: func(c explorers.Change) any { return c.ContainerID }},
+			{Name: "DETAILS", Value: func(c explorers.Change) any { return strings.Join(c.Details, "; ") }},
+		})
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
+		for _, c := range changes {
+			if err := sink.Write(c); err != nil {
+				return err
+			}
+		}
+
+		// The objects of an explorer that failed may be reported as added
+		// or removed.
+		itemErrs := slices.Concat(inventoryA.errors, inventoryB.errors)
+		sink.AddErrors(itemErrs...)
+		if err := sink.Close(); err != nil {
+			return err
+		}
+		return partialError(itemErrs)
+	},
+}
+
+// inventory holds the containers, images, and snapshots of a host snapshot
+// and the explorer of each container.
+type inventory struct {
+	containers []explorers.Container
+	images     []explorers.Image
+	snapshots  []explorers.SnapshotKeyInfo
+	explorers  map[string]explorers.ContainerExplorer // explorer by container type and ID
+	errors     []explorers.ItemError                  // errors of the explorers that failed
+}
+
+// readInventory returns the inventory of the explorers of a host snapshot.
+func readInventory(ctx context.Context, exps []explorers.ContainerExplorer) inventory {
+	inv := inventory{explorers: make(map[string]explorers.ContainerExplorer)}
+	for _, xplr := range exps {
+		engineName := xplr.Type()
+
+		containers, err := xplr.ListContainers(ctx)
+		if err != nil {
+			log.WithField("message", err).Errorf("listing %s containers", engineName)
+			inv.errors = append(inv.errors, explorers.ItemErrors(engineName, "list", err)...)
+		}
+		for _, c := range containers {
+			inv.explorers[c.ContainerType+"/"+c.ID] = xplr
+		}
+		inv.containers = append(inv.containers, containers...)
+
+		images, err := xplr.ListImages(ctx)
+		if err != nil {
+			log.WithField("message", err).Errorf("listing %s images", engineName)
+			inv.errors = append(inv.errors, explorers.ItemErrors(engineName, "list", err)...)
+		}
+		inv.images = append(inv.images, images...)
+
+		if snapshotLister, ok := xplr.(explorers.SnapshotLister); ok {
+			snapshots, err := snapshotLister.ListSnapshots(ctx)
+			if err != nil {
+				log.WithField("message", err).Errorf("listing %s snapshots", engineName)
+				inv.errors = append(inv.errors, explorers.ItemErrors(engineName, "list", err)...)
+			}
+			inv.snapshots = append(inv.snapshots, snapshots...)
+		}
+	}
+	return inv
+}
+
+// compareUpperDirs returns the file changes of the upper directories of the
+// containers in both inventories.
+func compareUpperDirs(ctx context.Context, a inventory, b inventory) []explorers.Change {
+	var changes []explorers.Change
+	for _, c := range a.containers {
+		key := c.ContainerType + "/" + c.ID
+		xplrB, ok := b.explorers[key]
+		if !ok {
+			continue
+		}
+
+		filesA, errA := upperDirFiles(ctx, a.explorers[key], c.ID)
+		filesB, errB := upperDirFiles(ctx, xplrB, c.ID)
+		if errA != nil || errB != nil {
+			log.WithFields(log.Fields{"containerID": c.ID, "first": errA, "second": errB}).Warn("reading container upper directory")
+			continue
+		}
+		changes = append(changes, explorers.CompareFiles(c, filesA, filesB)...)
+	}
+	return changes
+}
+
+// upperDirFiles returns the files of the upper directory of a container,
+// including the whiteout and inaccessible files.
+func upperDirFiles(ctx context.Context, xplr explorers.ContainerExplorer, containerID string) ([]explorers.FileInfo, error) {
+	layers, err := xplr.ContainerLayers(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	if layers.UpperDir == "" {
+		return nil, fmt.Errorf("container %s has no upper directory", containerID)
+	}
+
+	files, inaccessible, err := explorers.ScanDiffDirectory(layers.UpperDir)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, inaccessible...), nil
+}
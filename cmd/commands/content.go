@@ -0,0 +1,198 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/pkg/render"
+	digest "github.com/opencontainers/go-digest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var ContentCommand = cli.Command{
+	Name:        "content",
+	Usage:       "verify and read content store blobs",
+	Description: "verify and read content store blobs",
+	Subcommands: cli.Commands{
+		contentVerify,
+		contentCat,
+	},
+}
+
+var contentVerify = cli.Command{
+	Name:        "verify",
+	Usage:       "verify content blobs against their digests",
+	Description: "hash every content blob on disk and report missing, corrupted, and orphaned blobs",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "problems-only, p",
+			Usage: "only show blobs that failed verification",
+		},
+	},
+	Action: func(clictx *cli.Context) error {
+
+		sink, err := newResultRenderer([]render.Column[explorers.ContentVerification]{
+			{Name: "CONTAINER TYPE", Value: func(v explorers.ContentVerification) any { return v.ContainerType }},
+			{Name: "NAMESPACE", Value: func(v explorers.ContentVerification) any { return v.Namespace }},
+			{Name: "DIGEST", Value: func(v explorers.ContentVerification) any { return v.Digest }},
+			{Name: "STATUS", Value: func(v explorers.ContentVerification) any { return v.Status }},
+			{Name: "EXPECTED SIZE", Value: func(v explorers.ContentVerification) any { return v.ExpectedSize }},
+			{Name: "ACTUAL SIZE", Value: func(v explorers.ContentVerification) any { return v.ActualSize }},
+			{Name: "ACTUAL DIGEST", Value: func(v explorers.ContentVerification) any { return v.ActualDigest }},
+		})
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
+		var itemErrs []explorers.ItemError
+		exps := GetExplorers()
+		for _, xplr := range exps {
+			contentLister, ok := xplr.(explorers.ContentLister)
+			if !ok {
+				logUnsupported(xplr, explorers.CapabilityContent)
+				continue
+			}
+			engineVerifications, err := contentLister.VerifyContent(GlobalConfig.Context)
+			if err != nil {
+				engineName := xplr.Type()
+				log.WithField("message", err).Errorf("verifying %s content", engineName)
+				itemErrs = append(itemErrs, explorers.ItemErrors(engineName, "verify", err)...)
+				continue
+			}
+
+			// A blob that failed verification fails the command, so that
+			// an integrity check can be scripted.
+			for _, v := range engineVerifications {
+				if v.Status != explorers.ContentStatusOK {
+					itemErrs = append(itemErrs, *explorers.NewItemError(xplr.Type(), "verify", v.Digest.String(), fmt.Errorf("content %s", strings.ToLower(v.Status))))
+				} else if clictx.Bool("problems-only") {
+					continue
+				}
+				if err := sink.Write(v); err != nil {
+					return err
+				}
+			}
+		}
+
+		sink.AddErrors(itemErrs...)
+		if err := sink.Close(); err != nil {
+			return err
+		}
+		return partialError(itemErrs)
+	},
+}
+
+var contentCat = cli.Command{
+	Name:        "cat",
+	Usage:       "print a content blob",
+	Description: "print a content blob. Image index, manifest, and config blobs are pretty-printed",
+	ArgsUsage:   "DIGEST",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "raw, r",
+			Usage: "print the blob as stored without pretty-printing",
+		},
+	},
+	Action: func(clictx *cli.Context) error {
+		if clictx.NArg() < 1 {
+			return fmt.Errorf("digest is required")
+		}
+
+		dgst, err := parseDigest(clictx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		exps := GetExplorers()
+		for _, xplr := range exps {
+			contentLister, ok := xplr.(explorers.ContentLister)
+			if !ok {
+				continue
+			}
+			rc, err := contentLister.ReadContent(GlobalConfig.Context, dgst)
+			if err != nil {
+				log.Debugf("error reading content %s in %s explorer: %v", dgst, xplr.Type(), err)
+				continue
+			}
+			defer rc.Close()
+
+			return writeContent(os.Stdout, rc, !clictx.Bool("raw"))
+		}
+
+		return fmt.Errorf("content %s not found", dgst)
+	},
+}
+
+// parseDigest returns a digest from a user supplied value.
+//
+// A value without an algorithm prefix is treated as a sha256 digest.
+func parseDigest(value string) (digest.Digest, error) {
+	if !strings.Contains(value, ":") {
+		value = fmt.Sprintf("%s:%s", digest.SHA256, value)
+	}
+
+	dgst, err := digest.Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid digest %s: %w", value, err)
+	}
+	return dgst, nil
+}
+
+// writeContent copies a content blob to the writer.
+//
+// Image index, manifest, and config blobs are indented if pretty is true.
+// Other blobs, e.g. layers, are copied as is.
+func writeContent(w io.Writer, r io.Reader, pretty bool) error {
+	br := bufio.NewReader(r)
+
+	if pretty {
+		if b, err := br.Peek(1); err == nil && b[0] == '{' {
+			data, err := io.ReadAll(br)
+			if err != nil {
+				return fmt.Errorf("reading content: %w", err)
+			}
+
+			if mediaType := explorers.DetectContentMediaType(data); mediaType != "" {
+				log.WithField("mediaType", mediaType).Debug("pretty-printing content")
+
+				var buf bytes.Buffer
+				if err := json.Indent(&buf, data, "", " "); err == nil {
+					buf.WriteByte('\n')
+					_, err = buf.WriteTo(w)
+					return err
+				}
+			}
+
+			_, err = w.Write(data)
+			return err
+		}
+	}
+
+	_, err := io.Copy(w, br)
+	return err
+}
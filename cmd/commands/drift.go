@@ -18,12 +18,11 @@ package commands
 
 import (
 	"fmt"
-	"os"
 	"runtime"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/pkg/render"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -44,14 +43,14 @@ var DriftCommand = cli.Command{
 			Name:  "mount-support-containers, s",
 			Usage: "mount Kubernetes supporting containers",
 		},
+		jobsFlag,
+		progressFlag,
 	},
 	Action: func(clictx *cli.Context) error {
 		// Mounting a container is only supported on a Linux operating system.
 		if runtime.GOOS != "linux" {
 			return fmt.Errorf("feature is only supported on Linux")
 		}
-		output := GlobalConfig.Output
-		outputfile := GlobalConfig.OutputFile
 		filter := clictx.String("filter")
 
 		// Getting container ID positional arg
@@ -60,74 +59,55 @@ var DriftCommand = cli.Command{
 			containerID = clictx.Args().First()
 		}
 
-		var allDrifts []explorers.Drift
+		ctx, err := jobsContext(GlobalConfig.Context, clictx)
+		if err != nil {
+			return err
+		}
+
+		sink, err := newResultRenderer(driftColumns)
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
 
+		// The drift of each container is written as it completes.
 		exps := GetExplorers()
 		for _, xplr := range exps {
-			drifts, err := xplr.ContainerDrift(GlobalConfig.Context, filter, !clictx.Bool("mount-support-containers"), containerID)
+			err := explorers.StreamDrift(ctx, xplr, filter, !clictx.Bool("mount-support-containers"), containerID, sink.Write)
 			if err != nil {
 				engineName := xplr.Type()
 				log.WithField("message", err).Errorf("retrieving %s container drift", engineName)
-			} else if drifts != nil {
-				allDrifts = append(allDrifts, drifts...)
-			}
-		}
-
-		// Handle output formats
-		if strings.ToLower(output) == "json" {
-			if outputfile != "" {
-				writeOutputFile(allDrifts, outputfile)
-			} else {
-				printAsJSON(allDrifts)
+				sink.AddErrors(explorers.ItemErrors(engineName, "drift", err)...)
 			}
-			return nil
 		}
 
-		// Default to table output
-		tw := tabwriter.NewWriter(os.Stdout, 1, 8, 1, '\t', 0)
-		defer tw.Flush()
-
-		if output == "table" {
-			// Define the header
-			fmt.Fprintf(tw, "CONTAINER TYPE\tCONTAINER ID\tADDED/MODIFIED\tDELETED\n")
+		if err := sink.Close(); err != nil {
+			return err
 		}
+		return partialError(sink.Errors())
+	},
+}
 
-		for _, drift := range allDrifts {
-			switch strings.ToLower(output) {
-			case "json_line":
-				printAsJSONLine(drift)
-			default:
-				// Prepare the data for display
-				var addedOrModifiedFiles []string
-				var inaccessibleFiles []string
-
-				for _, fileinfo := range drift.AddedOrModified {
-					if fileinfo.FileType == "executable" {
-						addedOrModifiedFiles = append(addedOrModifiedFiles, fileinfo.FullPath+" (executable)")
-					} else {
-						addedOrModifiedFiles = append(addedOrModifiedFiles, fileinfo.FullPath)
-					}
-				}
-
-				for _, fileinfo := range drift.InaccessibleFiles {
-					inaccessibleFiles = append(inaccessibleFiles, fileinfo.FullPath)
-				}
-
-				displayAddedOrModifiedFiles := strings.Join(addedOrModifiedFiles, ", ")
-				displayInaccessibleFiles := strings.Join(inaccessibleFiles, ", ")
-
-				displayValues := fmt.Sprintf("%s\t%s\t%s\t%s",
-					drift.ContainerType,
-					drift.ContainerID,
-					displayAddedOrModifiedFiles,
-					displayInaccessibleFiles,
-				)
-
-				fmt.Fprintf(tw, "%v\n", displayValues)
+// driftColumns are the output columns of a container drift.
+var driftColumns = []render.Column[explorers.Drift]{
+	{Name: "CONTAINER TYPE", Value: func(d explorers.Drift) any { return d.ContainerType }},
+	{Name: "CONTAINER ID", Value: func(d explorers.Drift) any { return d.ContainerID }},
+	{Name: "ADDED/MODIFIED", Value: func(d explorers.Drift) any {
+		var files []string
+		for _, fileinfo := range d.AddedOrModified {
+			if fileinfo.FileType == "executable" {
+				files = append(files, fileinfo.FullPath+" (executable)")
+			} else {
+				files = append(files, fileinfo.FullPath)
 			}
 		}
-
-		// default
-		return nil
-	},
+		return strings.Join(files, ", ")
+	}},
+	{Name: "DELETED", Value: func(d explorers.Drift) any {
+		var files []string
+		for _, fileinfo := range d.InaccessibleFiles {
+			files = append(files, fileinfo.FullPath)
+		}
+		return strings.Join(files, ", ")
+	}},
 }
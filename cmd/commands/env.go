@@ -18,24 +18,20 @@ package commands
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
+	"maps"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/pkg/ce"
 	"github.com/urfave/cli"
 
-	containerdConfig "github.com/containerd/containerd/services/server/config"
-	dockerConfig "github.com/docker/docker/daemon/config"
 	log "github.com/sirupsen/logrus"
 )
 
-const (
-	defaultContainerdRootDir = "/var/lib/containerd"
-	defaultDockerRootDir     = "/var/lib/docker"
-)
-
 // RuntimeConfig holds the global configuration for container-explorer.
 type RuntimeConfig struct {
 	Context              context.Context
@@ -45,17 +41,30 @@ type RuntimeConfig struct {
 	PodmanRootDir        string
 	LayerCache           string
 	SupportContainerData *explorers.SupportContainer
+	Engines              []string                     // selected container engines, or detected if empty
+	EngineOptions        map[string]map[string]string // explorer specific options by container engine
 	Output               string
 	OutputFile           string
+	Format               string               // Go template of each output record, or empty
+	Columns              []string             // selected output columns, or the default columns if empty
+	SortBy               string               // output column sorting the records, or empty
+	HashCache            *explorers.HashCache // cache of the file hashes, or nil
 	Debug                bool
 }
 
 // GlobalConfig is the package-level configuration object.
 var GlobalConfig RuntimeConfig
 
+// stopInterrupt stops the interrupt handling of the global context.
+var stopInterrupt context.CancelFunc = func() {}
+
 // InitializeRuntime sets up the global configuration from the CLI context.
 func InitializeRuntime(clictx *cli.Context) error {
-	GlobalConfig.Context = context.Background()
+	// The first interrupt cancels the running operations, which return the
+	// containers completed so far. The next interrupt exits.
+	stopInterrupt()
+	GlobalConfig.Context, stopInterrupt = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(GlobalConfig.Context, stopInterrupt)
 	GlobalConfig.Debug = clictx.GlobalBool("debug")
 	GlobalConfig.ImageRootDir = clictx.GlobalString("image-root")
 	GlobalConfig.ContainerdRootDir = clictx.GlobalString("containerd-root")
@@ -63,6 +72,14 @@ func InitializeRuntime(clictx *cli.Context) error {
 	GlobalConfig.LayerCache = clictx.GlobalString("layer-cache")
 	GlobalConfig.Output = clictx.GlobalString("output")
 	GlobalConfig.OutputFile = clictx.GlobalString("output-file")
+	GlobalConfig.Format = clictx.GlobalString("format")
+	GlobalConfig.Columns = nil
+	for _, column := range strings.Split(clictx.GlobalString("columns"), ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			GlobalConfig.Columns = append(GlobalConfig.Columns, column)
+		}
+	}
+	GlobalConfig.SortBy = clictx.GlobalString("sort-by")
 
 	// Read support container data if provided.
 	supportContainerFile := clictx.GlobalString("support-container-data")
@@ -72,26 +89,31 @@ func InitializeRuntime(clictx *cli.Context) error {
 	}
 	GlobalConfig.SupportContainerData = sc
 
-	// Handle docker managed containers root.
-	if GlobalConfig.DockerRootDir == "" {
-		if GlobalConfig.ImageRootDir != "" {
-			dockerDataDir := getDockerDataRoot(GlobalConfig.ImageRootDir)
-			GlobalConfig.DockerRootDir = filepath.Join(GlobalConfig.ImageRootDir, strings.Replace(dockerDataDir, "/", "", 1))
-		} else if GlobalConfig.ContainerdRootDir != "" {
-			parentDir := filepath.Dir(strings.TrimSuffix(GlobalConfig.ContainerdRootDir, "/"))
-			GlobalConfig.DockerRootDir = filepath.Join(parentDir, "docker")
+	GlobalConfig.Engines = nil
+	if engines := clictx.GlobalString("container-engine"); engines != "" && engines != "all" {
+		for _, engine := range strings.Split(engines, ",") {
+			if err := validateContainerEngine(strings.TrimSpace(engine)); err != nil {
+				return err
+			}
+			GlobalConfig.Engines = append(GlobalConfig.Engines, strings.ToLower(strings.TrimSpace(engine)))
 		}
 	}
+	engineOptions, err := parseEngineOptions(clictx.GlobalStringSlice("engine-option"))
+	if err != nil {
+		return err
+	}
+	GlobalConfig.EngineOptions = engineOptions
 
-	// Handle containerd managed containers root.
-	if GlobalConfig.ContainerdRootDir == "" {
-		if GlobalConfig.ImageRootDir != "" {
-			containerdDataDir := getContainerdDataDir(GlobalConfig.ImageRootDir)
-			GlobalConfig.ContainerdRootDir = filepath.Join(GlobalConfig.ImageRootDir, strings.Replace(containerdDataDir, "/", "", 1))
-		} else if GlobalConfig.DockerRootDir != "" {
-			parentDir := filepath.Dir(strings.TrimSuffix(GlobalConfig.DockerRootDir, "/"))
-			GlobalConfig.ContainerdRootDir = filepath.Join(parentDir, "containerd")
+	resolveRuntimeRoots(&GlobalConfig)
+
+	closeHashCache()
+	if path := clictx.GlobalString("hash-cache"); path != "" && !clictx.GlobalBool("no-hash-cache") {
+		cache, err := explorers.OpenHashCache(path)
+		if err != nil {
+			return err
 		}
+		GlobalConfig.HashCache = cache
+		GlobalConfig.Context = explorers.WithHashCache(GlobalConfig.Context, cache)
 	}
 
 	if !clictx.GlobalBool("use-layer-cache") {
@@ -104,62 +126,104 @@ func InitializeRuntime(clictx *cli.Context) error {
 		"dockerRoot":           GlobalConfig.DockerRootDir,
 		"layercache":           GlobalConfig.LayerCache,
 		"supportContainerData": GlobalConfig.SupportContainerData,
+		"engines":              GlobalConfig.Engines,
+		"hashCache":            clictx.GlobalString("hash-cache"),
 		"debug":                GlobalConfig.Debug,
 	}).Debug("runtime configuration initialized")
 
 	return nil
 }
 
-// getDockerDataRoot returns Docker data-root directory.
-// Returns custom path if configured, otherwise returns the default path.
-func getDockerDataRoot(imageRootDir string) string {
-	configPath := filepath.Join(imageRootDir, "etc", "docker", "daemon.json")
+// CloseRuntime releases the resources of the global configuration, and
+// reports the hit rate of the hash cache.
+func CloseRuntime() {
+	closeHashCache()
+}
 
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		log.WithFields(log.Fields{"configPath": configPath, "error": err}).Debug("reading docker config")
-		return defaultDockerRootDir
+// closeHashCache closes the hash cache of the global configuration, if any.
+func closeHashCache() {
+	if GlobalConfig.HashCache == nil {
+		return
 	}
-
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		log.WithFields(log.Fields{"configPath": configPath, "error": err}).Debug("reading docker config file")
-		return defaultDockerRootDir
+	stats := GlobalConfig.HashCache.Stats()
+	log.WithFields(log.Fields{
+		"hits":    stats.Hits,
+		"misses":  stats.Misses,
+		"hitRate": fmt.Sprintf("%.1f%%", stats.HitRate()),
+	}).Info("hash cache")
+	if err := GlobalConfig.HashCache.Close(); err != nil {
+		log.WithField("error", err).Warn("closing hash cache")
 	}
+	GlobalConfig.HashCache = nil
+}
 
-	var cfg dockerConfig.Config
+// resolveRuntimeRoots sets the docker and containerd root directories that
+// are not specified, using the image root or the other root directory.
+func resolveRuntimeRoots(config *RuntimeConfig) {
+	options := ce.ResolveRoots(sessionOptions(*config))
+	config.ContainerdRootDir = options.ContainerdRoot
+	config.DockerRootDir = options.DockerRoot
+}
 
-	err = json.Unmarshal(data, &cfg)
-	if err != nil {
-		log.WithFields(log.Fields{"configPath": configPath, "error": err}).Debug("unmarshalling docker config")
-		return defaultDockerRootDir
+// sessionOptions returns the library session options of a runtime
+// configuration. The layer cache is the containerd layer-cache option.
+func sessionOptions(config RuntimeConfig) ce.Options {
+	engineOptions := make(map[string]map[string]string)
+	for engine, options := range config.EngineOptions {
+		engineOptions[engine] = maps.Clone(options)
 	}
-
-	if cfg.Root == "" {
-		return defaultDockerRootDir
+	if config.LayerCache != "" {
+		if engineOptions["containerd"] == nil {
+			engineOptions["containerd"] = make(map[string]string)
+		}
+		if _, ok := engineOptions["containerd"]["layer-cache"]; !ok {
+			engineOptions["containerd"]["layer-cache"] = config.LayerCache
+		}
 	}
 
-	return cfg.Root
+	return ce.Options{
+		ImageRoot:         config.ImageRootDir,
+		ContainerdRoot:    config.ContainerdRootDir,
+		DockerRoot:        config.DockerRootDir,
+		SupportContainers: config.SupportContainerData,
+		Engines:           config.Engines,
+		EngineOptions:     engineOptions,
+	}
 }
 
-// getContainerDataDir returns containerd root directory.
-// Returns custom path if configured, otherwise returns the default path.
-func getContainerdDataDir(imageRootDir string) string {
-	configPath := filepath.Join(imageRootDir, "etc", "containerd", "config.toml")
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		log.WithFields(log.Fields{"configPath": configPath, "error": err}).Debug("reading containerd config")
-		return defaultContainerdRootDir
+// parseEngineOptions returns the explorer specific options of engine.name=value
+// values, e.g. containerd.layer-cache=layers.
+func parseEngineOptions(values []string) (map[string]map[string]string, error) {
+	engineOptions := make(map[string]map[string]string)
+	for _, value := range values {
+		key, optionValue, ok := strings.Cut(value, "=")
+		engine, name, okName := strings.Cut(key, ".")
+		if !ok || !okName || engine == "" || name == "" {
+			return nil, fmt.Errorf("invalid engine option %q: use engine.name=value", value)
+		}
+		r, found := explorers.Lookup(engine)
+		if !found {
+			return nil, fmt.Errorf("invalid engine option %q: unsupported container engine %s", value, engine)
+		}
+		if _, err := r.ResolveOptions(map[string]string{name: optionValue}); err != nil {
+			return nil, fmt.Errorf("invalid engine option %q: %w", value, err)
+		}
+		if engineOptions[r.Name] == nil {
+			engineOptions[r.Name] = make(map[string]string)
+		}
+		engineOptions[r.Name][name] = optionValue
 	}
+	return engineOptions, nil
+}
 
-	var cfg containerdConfig.Config
-
-	if err := containerdConfig.LoadConfig(configPath, &cfg); err != nil {
-		log.WithFields(log.Fields{"configPath": configPath, "error": err}).Debug("parsing containerd config")
-		return defaultContainerdRootDir
+// validateContainerEngine returns an error if a container engine is neither
+// all nor a registered container engine.
+func validateContainerEngine(engine string) error {
+	if strings.ToLower(engine) == "all" {
+		return nil
 	}
-
-	if cfg.Root == "" {
-		return defaultContainerdRootDir
+	if _, ok := explorers.Lookup(engine); !ok {
+		return fmt.Errorf("unsupported container engine %s: use all, %s", engine, strings.Join(explorers.RegisteredNames(), ", "))
 	}
-
-	return cfg.Root
+	return nil
 }
@@ -0,0 +1,68 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/container-explorer/explorers"
+)
+
+// Exit codes of container-explorer.
+const (
+	ExitOK      = 0 // the command succeeded
+	ExitFailure = 1 // the command failed
+	ExitPartial = 2 // the command succeeded for some containers only
+)
+
+// PartialError is returned by a command that completed with the errors of
+// some containers or explorers. The output of the command includes the
+// items that succeeded.
+type PartialError struct {
+	Errors []explorers.ItemError
+}
+
+func (e *PartialError) Error() string {
+	var messages []string
+	for _, itemErr := range e.Errors {
+		messages = append(messages, itemErr.Error())
+	}
+	return fmt.Sprintf("%d errors: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// ExitCode returns the exit code of the error of a command.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var partialErr *PartialError
+	if errors.As(err, &partialErr) {
+		return ExitPartial
+	}
+	return ExitFailure
+}
+
+// partialError returns a PartialError of the item errors, or nil if there
+// are no item errors.
+func partialError(itemErrs []explorers.ItemError) error {
+	if len(itemErrs) == 0 {
+		return nil
+	}
+	return &PartialError{Errors: itemErrs}
+}
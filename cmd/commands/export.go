@@ -17,11 +17,14 @@ limitations under the License.
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"runtime"
 	"strings"
 
 	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/utils"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -32,6 +35,10 @@ var ExportCommand = cli.Command{
 	Usage:       "export a container or all containers as image or archive",
 	Description: "export a container or all containers as image or archive",
 	ArgsUsage:   "[flag] [ID] OUTPUTDIR",
+	Subcommands: cli.Commands{
+		exportImage,
+		exportSnapshot,
+	},
 	Flags: []cli.Flag{
 		cli.BoolFlag{
 			Name:  "image, i",
@@ -42,13 +49,21 @@ var ExportCommand = cli.Command{
 			Usage: "output container as archive",
 		},
 		cli.BoolFlag{
-			Name:  "all",
-			Usage: "export all containers",
+			Name:  "oci, o",
+			Usage: "output container as OCI image archive with the container changes as the top layer",
+		},
+		cli.StringFlag{
+			Name:  "compression, c",
+			Usage: "archive compression gzip, zstd, or none",
+			Value: "gzip",
 		},
 		cli.StringFlag{
-			Name:  "container-engine, e",
-			Usage: "supported container engine containerd, docker, and podman",
-			Value: "all",
+			Name:  "operator",
+			Usage: "operator name recorded in the custody manifest. Default is the current user",
+		},
+		cli.BoolFlag{
+			Name:  "all",
+			Usage: "export all containers",
 		},
 		cli.StringFlag{
 			Name:  "filter, f",
@@ -58,6 +73,8 @@ var ExportCommand = cli.Command{
 			Name:  "export-support-containers, s",
 			Usage: "export Kubernetes supporting containers",
 		},
+		jobsFlag,
+		progressFlag,
 	},
 	Action: func(clictx *cli.Context) error {
 
@@ -68,47 +85,72 @@ var ExportCommand = cli.Command{
 
 		exportAsImage := clictx.Bool("image")
 		exportAsArchive := clictx.Bool("archive")
+		exportAsOCI := clictx.Bool("oci")
 
 		// At least one options is required. If not provided by user
 		// export as image file.
-		if !exportAsArchive && !exportAsImage {
+		if !exportAsArchive && !exportAsImage && !exportAsOCI {
 			exportAsImage = true
 		}
 
-		exportOptions := make(map[string]bool)
-		exportOptions["image"] = exportAsImage
-		exportOptions["archive"] = exportAsArchive
+		compression, err := explorers.ParseCompression(clictx.String("compression"))
+		if err != nil {
+			return err
+		}
+
+		exportOptions := explorers.ContainerExportOptions{
+			Image:       exportAsImage,
+			Archive:     exportAsArchive,
+			OCI:         exportAsOCI,
+			Compression: compression,
+		}
+
+		// The custody record is carried in the context and collects the
+		// exported artifacts, their sources, and the commands run.
+		custody := utils.NewCustody(clictx.String("operator"), clictx.App.Version, os.Args)
+		ctx := utils.WithCustody(GlobalConfig.Context, custody)
 
 		if clictx.Bool("all") {
 			if clictx.NArg() < 1 {
 				return fmt.Errorf("output directory is required")
 			}
 			outputDir := clictx.Args().First()
-			containerEngine := clictx.String("container-engine")
-
 			filterString := clictx.String("filter")
 			filterMap := getFilterMap(filterString)
 
 			exportSupportContainers := clictx.Bool("export-support-containers")
 
 			log.WithFields(log.Fields{
-				"containerEngine":         containerEngine,
 				"exportAsImage":           exportAsImage,
 				"exportAsArchive":         exportAsArchive,
+				"exportAsOCI":             exportAsOCI,
+				"compression":             compression,
 				"filter":                  filterString,
 				"exportSupportContainers": exportSupportContainers,
 			}).Debug("exporting all containers")
 
+			ctx, err := jobsContext(ctx, clictx)
+			if err != nil {
+				return err
+			}
+
+			var exportErrs []error
+			var itemErrs []explorers.ItemError
 			exps := GetExplorers()
 			for _, xplr := range exps {
 				engineName := xplr.Type()
-				if containerEngine == "all" || strings.ToLower(containerEngine) == engineName {
-					if err := xplr.ExportAllContainers(GlobalConfig.Context, outputDir, exportOptions, filterMap, exportSupportContainers); err != nil {
-						log.Errorf("exporting all %s containers as image or archive: %v", engineName, err)
-					}
+				if err := xplr.ExportAllContainers(ctx, outputDir, exportOptions, filterMap, exportSupportContainers); err != nil {
+					log.Errorf("exporting all %s containers as image or archive: %v", engineName, err)
+					exportErrs = append(exportErrs, fmt.Errorf("%s: %w", engineName, err))
+					itemErrs = append(itemErrs, explorers.ItemErrors(engineName, "export", err)...)
 				}
 			}
-			return nil
+
+			custody.Complete(errors.Join(exportErrs...))
+			if err := writeCustody(custody, outputDir); err != nil {
+				return err
+			}
+			return partialError(itemErrs)
 		}
 
 		if clictx.NArg() < 2 {
@@ -123,15 +165,155 @@ var ExportCommand = cli.Command{
 			"outputDir":       outputDir,
 			"exportAsImage":   exportAsImage,
 			"exportAsArchive": exportAsArchive,
+			"exportAsOCI":     exportAsOCI,
+			"compression":     compression,
 		}).Debug("processing export request")
 
-		matched, err := ForMatchingContainer(GlobalConfig.Context, containerID, func(xplr explorers.ContainerExplorer) error {
-			return xplr.ExportContainer(GlobalConfig.Context, containerID, outputDir, exportOptions)
+		matched, err := ForMatchingContainer(ctx, containerID, func(xplr explorers.ContainerExplorer) error {
+			return xplr.ExportContainer(ctx, containerID, outputDir, exportOptions)
 		})
 
 		if !matched {
-			return fmt.Errorf("no matching container")
+			err = fmt.Errorf("no matching container")
+		}
+
+		custody.Complete(err)
+		if writeErr := writeCustody(custody, outputDir); writeErr != nil && err == nil {
+			return writeErr
 		}
 		return err
 	},
 }
+
+// writeCustody writes the custody manifest of an export to the output
+// directory.
+func writeCustody(custody *utils.Custody, outputDir string) error {
+	jsonPath, csvPath, err := custody.Write(outputDir)
+	if err != nil {
+		log.Errorf("writing custody manifest: %v", err)
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"json":      jsonPath,
+		"csv":       csvPath,
+		"artifacts": len(custody.Artifacts),
+	}).Info("custody manifest written")
+	return nil
+}
+
+var exportImage = cli.Command{
+	Name:        "image",
+	Usage:       "export an image from the content store",
+	Description: "export an image as a docker load compatible archive or an OCI image layout directory",
+	ArgsUsage:   "[flag] REF OUTPUT",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "layout, l",
+			Usage: "output image as OCI image layout directory instead of archive",
+		},
+		cli.StringFlag{
+			Name:  "platform, p",
+			Usage: "export only the specified platform e.g. linux/amd64",
+		},
+	},
+	Action: func(clictx *cli.Context) error {
+		if clictx.NArg() < 2 {
+			return fmt.Errorf("image reference and output path are required")
+		}
+
+		ref := clictx.Args().First()
+		outputPath := clictx.Args().Get(1)
+
+		options := explorers.ImageExportOptions{
+			Layout:   clictx.Bool("layout"),
+			Platform: clictx.String("platform"),
+		}
+
+		log.WithFields(log.Fields{
+			"ref":        ref,
+			"outputPath": outputPath,
+			"layout":     options.Layout,
+			"platform":   options.Platform,
+		}).Debug("processing image export request")
+
+		matched, err := ForMatchingImage(GlobalConfig.Context, ref, func(xplr explorers.ContainerExplorer) error {
+			imageExporter, ok := xplr.(explorers.ImageExporter)
+			if !ok {
+				return &explorers.UnsupportedError{ContainerType: xplr.Type(), Capability: explorers.CapabilityImageExport}
+			}
+			return imageExporter.ExportImage(GlobalConfig.Context, ref, outputPath, options)
+		})
+
+		if !matched {
+			return fmt.Errorf("no matching image")
+		}
+		return err
+	},
+}
+
+var exportSnapshot = cli.Command{
+	Name:        "snapshot",
+	Usage:       "export a snapshot or layer as archive",
+	Description: "export the directory of a containerd snapshot, docker layer, or podman layer as archive, alone or merged with its parent chain",
+	ArgsUsage:   "[flag] KEY OUTPUTDIR",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "parents, p",
+			Usage: "export the merged content of the snapshot and its parent chain",
+		},
+		cli.StringFlag{
+			Name:  "compression, c",
+			Usage: "archive compression gzip, zstd, or none",
+			Value: "gzip",
+		},
+		cli.StringFlag{
+			Name:  "operator",
+			Usage: "operator name recorded in the custody manifest. Default is the current user",
+		},
+	},
+	Action: func(clictx *cli.Context) error {
+		if clictx.NArg() < 2 {
+			return fmt.Errorf("snapshot key and output directory are required")
+		}
+
+		key := clictx.Args().First()
+		outputDir := clictx.Args().Get(1)
+		parents := clictx.Bool("parents")
+
+		compression, err := explorers.ParseCompression(clictx.String("compression"))
+		if err != nil {
+			return err
+		}
+
+		custody := utils.NewCustody(clictx.String("operator"), clictx.App.Version, os.Args)
+		ctx := utils.WithCustody(GlobalConfig.Context, custody)
+
+		log.WithFields(log.Fields{
+			"key":         key,
+			"outputDir":   outputDir,
+			"parents":     parents,
+			"compression": compression,
+		}).Debug("processing snapshot export request")
+
+		matched, err := ForMatchingSnapshot(ctx, key, parents, func(_ explorers.ContainerExplorer, layers explorers.LayerStack) error {
+			return utils.ExportLayersArchive(ctx, snapshotArchiveName(key), layers, outputDir, compression)
+		})
+
+		if !matched {
+			return fmt.Errorf("no matching snapshot")
+		}
+
+		custody.Complete(err)
+		if writeErr := writeCustody(custody, outputDir); writeErr != nil && err == nil {
+			return writeErr
+		}
+		return err
+	},
+}
+
+// snapshotArchiveName returns the archive name of a snapshot key. Snapshot
+// keys may contain path separators, e.g. containerd active snapshot keys.
+func snapshotArchiveName(key string) string {
+	return "snapshot-" + strings.NewReplacer("/", "_", ":", "_").Replace(key)
+}
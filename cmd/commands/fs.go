@@ -0,0 +1,488 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/pkg/render"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var FSCommand = cli.Command{
+	Name:        "fs",
+	Usage:       "browse and read container files without mounting",
+	Description: "resolve container files through the container layers, honoring overlay whiteouts and opaque directories, without mounting",
+	Subcommands: cli.Commands{
+		fsLs,
+		fsCat,
+		fsStat,
+		fsFind,
+	},
+}
+
+var fsLs = cli.Command{
+	Name:        "ls",
+	Usage:       "list a container directory",
+	Description: "list the merged entries of a container directory and the layer containing each entry",
+	ArgsUsage:   "ID [PATH]",
+	Action: func(clictx *cli.Context) error {
+		if clictx.NArg() < 1 {
+			return fmt.Errorf("container ID is required")
+		}
+
+		name := "/"
+		if clictx.NArg() > 1 {
+			name = clictx.Args().Get(1)
+		}
+
+		lfs, err := containerFS(GlobalConfig.Context, clictx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		f, err := lfs.Stat(name)
+		if err != nil {
+			return err
+		}
+
+		files := []explorers.LayeredFile{f}
+		if f.Info.IsDir() {
+			if files, err = lfs.ReadDir(name); err != nil {
+				return err
+			}
+		} else if files[0], err = lfs.Lstat(name); err != nil {
+			return err
+		}
+
+		sink, err := newRenderer(fsColumns)
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
+		for _, f := range files {
+			if err := sink.Write(newFSEntry(GlobalConfig.Context, f, false)); err != nil {
+				return err
+			}
+		}
+		return sink.Close()
+	},
+}
+
+var fsCat = cli.Command{
+	Name:        "cat",
+	Usage:       "print container files",
+	Description: "print the content of container files, following symbolic links within the container",
+	ArgsUsage:   "ID PATH [PATH...]",
+	Action: func(clictx *cli.Context) error {
+		if clictx.NArg() < 2 {
+			return fmt.Errorf("container ID and path are required")
+		}
+
+		lfs, err := containerFS(GlobalConfig.Context, clictx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		for _, name := range clictx.Args()[1:] {
+			if err := catLayeredFile(lfs, name, os.Stdout); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var fsStat = cli.Command{
+	Name:        "stat",
+	Usage:       "show container file information",
+	Description: "show the information, the layer, and the SHA256 of a container file",
+	ArgsUsage:   "[flag] ID PATH",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "dereference, L",
+			Usage: "follow symbolic links",
+		},
+	},
+	Action: func(clictx *cli.Context) error {
+		if clictx.NArg() < 2 {
+			return fmt.Errorf("container ID and path are required")
+		}
+
+		lfs, err := containerFS(GlobalConfig.Context, clictx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		name := clictx.Args().Get(1)
+		stat := lfs.Lstat
+		if clictx.Bool("dereference") {
+			stat = lfs.Stat
+		}
+		f, err := stat(name)
+		if err != nil {
+			return err
+		}
+		sink, err := newRecordRenderer(fsStatColumns)
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
+		if err := sink.Write(newFSEntry(GlobalConfig.Context, f, true)); err != nil {
+			return err
+		}
+		return sink.Close()
+	},
+}
+
+// fsStatColumns are the output columns of fs stat, a line per column in
+// the table format.
+var fsStatColumns = []render.Column[fsEntry]{
+	{Name: "Path", Value: func(e fsEntry) any { return e.Path }},
+	{Name: "Type", Value: func(e fsEntry) any { return e.Type }},
+	{Name: "Link", Value: func(e fsEntry) any { return e.Link }},
+	{Name: "Mode", Value: func(e fsEntry) any { return e.Mode }},
+	{Name: "UID", Value: func(e fsEntry) any { return e.UID }},
+	{Name: "GID", Value: func(e fsEntry) any { return e.GID }},
+	{Name: "Size", Value: func(e fsEntry) any { return e.Size }},
+	{Name: "Modified", Value: func(e fsEntry) any { return e.Modified.Format(time.RFC3339Nano) }},
+	{Name: "Accessed", Value: func(e fsEntry) any { return e.Accessed.Format(time.RFC3339Nano) }},
+	{Name: "Changed", Value: func(e fsEntry) any { return e.Changed.Format(time.RFC3339Nano) }},
+	{Name: "Layer", Value: func(e fsEntry) any { return e.Layer }},
+	{Name: "SHA256", Value: func(e fsEntry) any { return e.SHA256 }},
+}
+
+var fsFind = cli.Command{
+	Name:        "find",
+	Usage:       "find container files",
+	Description: "find container files by name, modification time, and size without following symbolic links",
+	ArgsUsage:   "[flag] ID [PATH]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "name",
+			Usage: "file name pattern e.g. '*.sh'",
+		},
+		cli.StringFlag{
+			Name:  "newer",
+			Usage: "files modified after a time e.g. 2026-01-02 or 2026-01-02T15:04:05Z",
+		},
+		cli.StringFlag{
+			Name:  "size",
+			Usage: "file size in bytes with an optional k, M, or G suffix; +N is larger and -N smaller than N",
+		},
+	},
+	Action: func(clictx *cli.Context) error {
+		if clictx.NArg() < 1 {
+			return fmt.Errorf("container ID is required")
+		}
+
+		root := "/"
+		if clictx.NArg() > 1 {
+			root = clictx.Args().Get(1)
+		}
+
+		match, err := newFSFilter(clictx.String("name"), "", clictx.String("newer"), clictx.String("size"))
+		if err != nil {
+			return err
+		}
+
+		lfs, err := containerFS(GlobalConfig.Context, clictx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		sink, err := newRenderer(fsColumns)
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
+		// The matching files are written as the directories are walked.
+		err = lfs.Walk(root, func(f explorers.LayeredFile, err error) error {
+			if err != nil {
+				if f.Info == nil {
+					return err
+				}
+				log.WithFields(log.Fields{"path": f.Path, "error": err}).Warn("reading container directory")
+				return nil
+			}
+			if match(f) {
+				return sink.Write(newFSEntry(GlobalConfig.Context, f, false))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return sink.Close()
+	},
+}
+
+// fsEntry is a container file in the output of the fs commands.
+type fsEntry struct {
+	Path     string    `json:"path"`
+	Type     string    `json:"type"`
+	Mode     string    `json:"mode"`
+	UID      uint32    `json:"uid"`
+	GID      uint32    `json:"gid"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Accessed time.Time `json:"accessed"`
+	Changed  time.Time `json:"changed"`
+	Link     string    `json:"link,omitempty"`
+	Layer    string    `json:"layer"`
+	SHA256   string    `json:"sha256,omitempty"`
+}
+
+// newFSEntry returns the output of a container file. The SHA256 of a
+// regular file is calculated with the hash cache of the context if hash is
+// set.
+func newFSEntry(ctx context.Context, f explorers.LayeredFile, hash bool) fsEntry {
+	entry := fsEntry{
+		Path:     f.Path,
+		Type:     fileType(f.Info.Mode()),
+		Mode:     f.Info.Mode().String(),
+		Size:     f.Info.Size(),
+		Modified: f.Info.ModTime().UTC(),
+		Layer:    f.Layer,
+	}
+
+	if stat, ok := f.Info.Sys().(*syscall.Stat_t); ok {
+		entry.UID = stat.Uid
+		entry.GID = stat.Gid
+		entry.Accessed = time.Unix(stat.Atim.Sec, stat.Atim.Nsec).UTC()
+		entry.Changed = time.Unix(stat.Ctim.Sec, stat.Ctim.Nsec).UTC()
+	}
+
+	if f.Info.Mode()&fs.ModeSymlink != 0 {
+		entry.Link, _ = os.Readlink(f.HostPath())
+	}
+
+	if hash && f.Info.Mode().IsRegular() {
+		sum, err := explorers.FileSHA256SumContext(ctx, f.HostPath())
+		if err != nil {
+			log.WithFields(log.Fields{"path": f.Path, "error": err}).Warn("hashing container file")
+		}
+		entry.SHA256 = sum
+	}
+	return entry
+}
+
+// fileType returns the type name of a file mode.
+func fileType(mode fs.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return "file"
+	case mode.IsDir():
+		return "directory"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	case mode&fs.ModeCharDevice != 0:
+		return "char_device"
+	case mode&fs.ModeDevice != 0:
+		return "block_device"
+	case mode&fs.ModeNamedPipe != 0:
+		return "fifo"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	default:
+		return "unknown"
+	}
+}
+
+// fsColumns are the output columns of the container files of fs ls and fs
+// find.
+var fsColumns = []render.Column[fsEntry]{
+	{Name: "MODE", Value: func(e fsEntry) any { return e.Mode }},
+	{Name: "UID", Value: func(e fsEntry) any { return e.UID }},
+	{Name: "GID", Value: func(e fsEntry) any { return e.GID }},
+	{Name: "SIZE", Value: func(e fsEntry) any { return e.Size }},
+	{Name: "MODIFIED", Value: func(e fsEntry) any { return e.Modified }},
+	{Name: "PATH", Value: func(e fsEntry) any {
+		if e.Link != "" {
+			return fmt.Sprintf("%s -> %s", e.Path, e.Link)
+		}
+		return e.Path
+	}},
+	{Name: "LAYER", Value: func(e fsEntry) any { return e.Layer }},
+}
+
+// containerFS returns the layered filesystem of a container.
+func containerFS(ctx context.Context, containerID string) (*explorers.LayeredFS, error) {
+	var layers explorers.LayerStack
+	matched, err := ForMatchingContainer(ctx, containerID, func(xplr explorers.ContainerExplorer) error {
+		var err error
+		if layers, err = xplr.ContainerLayers(ctx, containerID); err != nil {
+			return fmt.Errorf("getting %s container layers: %w", xplr.Type(), err)
+		}
+		return nil
+	})
+
+	if !matched {
+		return nil, fmt.Errorf("no matching container")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"containerID": containerID,
+		"upperdir":    layers.UpperDir,
+		"lowerdirs":   len(layers.LowerDirs),
+	}).Debug("container layered filesystem")
+
+	return explorers.NewLayeredFS(layers), nil
+}
+
+// catLayeredFile writes the content of a file of a layered filesystem.
+func catLayeredFile(lfs *explorers.LayeredFS, name string, w io.Writer) error {
+	f, err := lfs.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", name)
+	}
+
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+	return nil
+}
+
+// newFSFilter returns a function matching the files with the base name
+// pattern, the path pattern, modified after the time, and of the size of the
+// find flags. Empty flags match all files.
+func newFSFilter(name string, pathPattern string, newer string, size string) (func(explorers.LayeredFile) bool, error) {
+	for _, pattern := range []string{name, pathPattern} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+	}
+
+	var after time.Time
+	if newer != "" {
+		var err error
+		if after, err = parseFSTime(newer); err != nil {
+			return nil, err
+		}
+	}
+
+	matchSize := func(int64) bool { return true }
+	if size != "" {
+		var err error
+		if matchSize, err = parseFSSize(size); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(f explorers.LayeredFile) bool {
+		if name != "" {
+			if ok, _ := path.Match(name, path.Base(f.Path)); !ok {
+				return false
+			}
+		}
+		if pathPattern != "" {
+			if ok, _ := path.Match(pathPattern, f.Path); !ok {
+				return false
+			}
+		}
+		if !after.IsZero() && !f.Info.ModTime().After(after) {
+			return false
+		}
+		if size != "" && (!f.Info.Mode().IsRegular() || !matchSize(f.Info.Size())) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// parseFSTime parses an RFC 3339 time or a date in UTC.
+func parseFSTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %s: expected e.g. 2026-01-02 or 2026-01-02T15:04:05Z", value)
+}
+
+// parseFSSize parses a find size, e.g. +10M, -1k, or 512, and returns a
+// function matching a file size larger than, smaller than, or equal to it.
+func parseFSSize(value string) (func(int64) bool, error) {
+	s := value
+	compare := 0
+	if rest, ok := strings.CutPrefix(s, "+"); ok {
+		s, compare = rest, 1
+	} else if rest, ok := strings.CutPrefix(s, "-"); ok {
+		s, compare = rest, -1
+	}
+
+	n, err := parseByteSize(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size %s", value)
+	}
+
+	return func(size int64) bool {
+		switch compare {
+		case 1:
+			return size > n
+		case -1:
+			return size < n
+		default:
+			return size == n
+		}
+	}, nil
+}
+
+// parseByteSize parses a size in bytes with an optional k, M, or G suffix.
+func parseByteSize(value string) (int64, error) {
+	s := value
+	multiplier := int64(1)
+	for suffix, m := range map[string]int64{"k": 1 << 10, "M": 1 << 20, "G": 1 << 30} {
+		if rest, ok := strings.CutSuffix(s, suffix); ok {
+			s, multiplier = rest, m
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %s", value)
+	}
+	return n * multiplier, nil
+}
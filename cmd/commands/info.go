@@ -17,6 +17,7 @@ limitations under the License.
 package commands
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/google/container-explorer/explorers"
@@ -54,19 +55,25 @@ var infoContainer = cli.Command{
 		containerID := clictx.Args().First()
 		spec := clictx.Bool("spec")
 
+		// The container information is written as JSON in the table format.
+		sink, err := newRecordRenderer[any](nil)
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
 		matched, err := ForMatchingContainer(GlobalConfig.Context, containerID, func(xplr explorers.ContainerExplorer) error {
 			info, err := xplr.InfoContainer(GlobalConfig.Context, containerID, spec)
 			if err != nil {
 				return err
 			}
-			printAsJSON(info)
-			return nil
+			return sink.Write(info)
 		})
 
 		if !matched {
 			log.Errorf("container %s not found", containerID)
 		}
-		return err
+		return errors.Join(err, sink.Close())
 	},
 }
 
@@ -89,18 +96,24 @@ var InspectCommand = cli.Command{
 		containerID := clictx.Args().First()
 		spec := clictx.Bool("spec")
 
+		// The container information is written as JSON in the table format.
+		sink, err := newRecordRenderer[any](nil)
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
 		matched, err := ForMatchingContainer(GlobalConfig.Context, containerID, func(xplr explorers.ContainerExplorer) error {
 			info, err := xplr.InfoContainer(GlobalConfig.Context, containerID, spec)
 			if err != nil {
 				return err
 			}
-			printAsJSON(info)
-			return nil
+			return sink.Write(info)
 		})
 
 		if !matched {
 			log.Errorf("container %s not found", containerID)
 		}
-		return err
+		return errors.Join(err, sink.Close())
 	},
 }
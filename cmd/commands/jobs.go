@@ -0,0 +1,66 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/google/container-explorer/explorers"
+	"github.com/urfave/cli"
+)
+
+// jobsFlag sets the number of containers processed concurrently by the
+// commands that run on all containers.
+var jobsFlag = cli.IntFlag{
+	Name:  "jobs, j",
+	Usage: "number of containers processed concurrently, and of files hashed concurrently across the containers",
+	Value: runtime.NumCPU(),
+}
+
+// progressFlag reports the progress of the containers to stderr.
+var progressFlag = cli.BoolFlag{
+	Name:  "progress",
+	Usage: "report the progress of each container to stderr",
+}
+
+// jobsContext returns the context with the concurrent jobs and the
+// progress reporting of the command flags.
+func jobsContext(ctx context.Context, clictx *cli.Context) (context.Context, error) {
+	jobs := clictx.Int("jobs")
+	if jobs < 1 {
+		return nil, fmt.Errorf("jobs must be at least 1")
+	}
+	ctx = explorers.WithJobs(ctx, jobs)
+
+	if clictx.Bool("progress") {
+		ctx = explorers.WithProgress(ctx, printProgress)
+	}
+	return ctx, nil
+}
+
+// printProgress prints the progress of a container to stderr, so that it is
+// not mixed with the command output.
+func printProgress(p explorers.Progress) {
+	status := "done"
+	if p.Err != nil {
+		status = "failed"
+	}
+	fmt.Fprintf(os.Stderr, "%s %s %d/%d: %s %s\n", p.ContainerType, p.Operation, p.Done, p.Total, p.ID, status)
+}
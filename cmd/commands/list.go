@@ -18,12 +18,11 @@ package commands
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/pkg/render"
 
 	log "github.com/sirupsen/logrus"
 
@@ -42,7 +41,9 @@ var ListCommand = cli.Command{
 		listContents,
 		listImages,
 		listSnapshots,
+		listOrphans,
 		listTasks,
+		listCapabilities,
 	},
 }
 
@@ -52,25 +53,39 @@ var listNamespaces = cli.Command{
 	Usage:       "list all namespaces",
 	Description: "list all namespaces",
 	Action: func(_ *cli.Context) error {
+		sink, err := newRenderer([]render.Column[string]{
+			{Name: "NAMESPACE", Value: func(ns string) any { return ns }},
+		})
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
 		exps := GetExplorers()
-		fmt.Println("NAMESPACE")
 		for _, xplr := range exps {
 			// Currently namespaces are only relevant for containerd.
 			if xplr.Type() != "containerd" {
 				continue
 			}
 
-			nss, err := xplr.ListNamespaces(GlobalConfig.Context)
+			nsLister, ok := xplr.(explorers.NamespaceLister)
+			if !ok {
+				logUnsupported(xplr, explorers.CapabilityNamespaces)
+				continue
+			}
+			nss, err := nsLister.ListNamespaces(GlobalConfig.Context)
 			if err != nil {
 				log.Fatal(err)
 			}
 
 			for _, ns := range nss {
-				fmt.Println(ns)
+				if err := sink.Write(ns); err != nil {
+					return err
+				}
 			}
 		}
 
-		return nil
+		return sink.Close()
 	},
 }
 
@@ -104,13 +119,16 @@ var listContainers = cli.Command{
 			Name:  "running, r",
 			Usage: "show running docker managed containers",
 		},
+		cli.BoolFlag{
+			Name:  "include-deleted, d",
+			Usage: "include deleted containers and previous container versions recovered from the containerd and podman databases",
+		},
 	},
 	Action: func(clictx *cli.Context) error {
-		output := GlobalConfig.Output
-		outputfile := GlobalConfig.OutputFile
 		filters := clictx.String("filter")
 
 		containermap := make(map[string]explorers.Container)
+		var itemErrs []explorers.ItemError
 		exps := GetExplorers()
 
 		// First pass: Collect all containers
@@ -119,7 +137,7 @@ var listContainers = cli.Command{
 			if err != nil {
 				engineName := xplr.Type()
 				log.WithField("message", err).Errorf("listing %s containers", engineName)
-				continue
+				itemErrs = append(itemErrs, explorers.ItemErrors(engineName, "list", err)...)
 			}
 
 			for _, c := range engineContainers {
@@ -147,6 +165,25 @@ var listContainers = cli.Command{
 			containers = append(containers, container)
 		}
 
+		// Recovered containers are not merged, as each is a version of a
+		// container record.
+		if clictx.Bool("include-deleted") {
+			for _, xplr := range exps {
+				deletedLister, ok := xplr.(explorers.DeletedContainerLister)
+				if !ok {
+					logUnsupported(xplr, explorers.CapabilityDeletedContainers)
+					continue
+				}
+				deletedContainers, err := deletedLister.ListDeletedContainers(GlobalConfig.Context)
+				if err != nil {
+					engineName := xplr.Type()
+					log.WithField("message", err).Errorf("listing %s deleted containers", engineName)
+					itemErrs = append(itemErrs, explorers.ItemErrors(engineName, "list", err)...)
+				}
+				containers = append(containers, deletedContainers...)
+			}
+		}
+
 		// Filter containers
 		filteredContainers := containers[:0]
 		if filters != "" {
@@ -179,40 +216,32 @@ var listContainers = cli.Command{
 			containers = filteredContainers
 		}
 
-		// Handling JSON output
-		if strings.ToLower(output) == "json" {
-			if outputfile != "" {
-				writeOutputFile(containers, outputfile)
-			} else {
-				printAsJSON(containers)
-			}
-			return nil
-		}
-
-		// Handling table output
-		tw := tabwriter.NewWriter(os.Stdout, 1, 8, 1, '\t', 0)
-		defer tw.Flush()
-
-		if output == "table" {
-			displayFields := "CONTAINER TYPE\tNAMESPACE\tCONTAINER ID\tCONTAINER NAME\tIMAGE\tCREATED AT\tPID\tSTATUS"
-			// show updated timestamp
-			if clictx.Bool("updated") {
-				displayFields = fmt.Sprintf("%v\tUPDATED AT", displayFields)
-			}
-			// show exposed ports
-			if clictx.Bool("ports") {
-				displayFields = fmt.Sprintf("%v\tEXPOSED PORTS", displayFields)
-			}
-			// show labels
-			if !clictx.Bool("no-labels") {
-				displayFields = fmt.Sprintf("%v\tLABELS", displayFields)
-			}
-			fmt.Fprintf(tw, "%v\n", displayFields)
+		// The updated, ports, and include-deleted flags show their columns,
+		// which can also be selected with --columns.
+		sink, err := newResultRenderer([]render.Column[explorers.Container]{
+			{Name: "CONTAINER TYPE", Value: func(c explorers.Container) any { return c.ContainerType }},
+			{Name: "NAMESPACE", Value: func(c explorers.Container) any { return c.Namespace }},
+			{Name: "CONTAINER ID", Value: func(c explorers.Container) any { return c.ID }},
+			{Name: "CONTAINER NAME", Value: func(c explorers.Container) any { return c.Name }},
+			{Name: "IMAGE", Value: func(c explorers.Container) any { return c.Image }},
+			{Name: "CREATED AT", Value: func(c explorers.Container) any { return c.CreatedAt }},
+			{Name: "PID", Value: func(c explorers.Container) any { return c.ProcessID }},
+			{Name: "STATUS", Value: func(c explorers.Container) any { return c.Status }},
+			{Name: "UPDATED AT", Value: func(c explorers.Container) any { return c.UpdatedAt }, Hidden: !clictx.Bool("updated")},
+			{Name: "EXPOSED PORTS", Value: func(c explorers.Container) any { return arrayToString(c.ExposedPorts) }, Hidden: !clictx.Bool("ports")},
+			{Name: "RECOVERED", Value: func(c explorers.Container) any { return c.Recovered }, Hidden: !clictx.Bool("include-deleted")},
+			{Name: "RECOVERED FROM", Value: func(c explorers.Container) any { return c.RecoveredFrom }, Hidden: !clictx.Bool("include-deleted")},
+			{Name: "LABELS", Value: func(c explorers.Container) any { return labelString(c.Labels) }, Hidden: clictx.Bool("no-labels")},
+		})
+		if err != nil {
+			return err
 		}
+		defer sink.Close()
 
 		for _, container := range containers {
-			// Show Kubernetes support containers created
-			if !clictx.Bool("show-support-containers") && container.SupportContainer {
+			// Show Kubernetes support containers created. The JSON and YAML
+			// output includes the support containers with SupportContainer set.
+			if !structuredOutput() && !clictx.Bool("show-support-containers") && container.SupportContainer {
 				log.WithFields(log.Fields{
 					"namespace":        container.Namespace,
 					"containerID":      container.ID,
@@ -221,38 +250,16 @@ var listContainers = cli.Command{
 
 				continue
 			}
-
-			switch strings.ToLower(output) {
-			case "json_line":
-				printAsJSONLine(container)
-			default:
-				displayValues := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s",
-					container.ContainerType,
-					container.Namespace,
-					container.ID,
-					container.Name,
-					container.Image,
-					container.CreatedAt.Format(tsLayout),
-					container.ProcessID,
-					container.Status,
-				)
-				// show updated timestamp value
-				if clictx.Bool("updated") {
-					displayValues = fmt.Sprintf("%v\t%s", displayValues, container.UpdatedAt.Format(tsLayout))
-				}
-				// show exposed ports value
-				if clictx.Bool("ports") {
-					displayValues = fmt.Sprintf("%v\t%s", displayValues, arrayToString(container.ExposedPorts))
-				}
-				// show labels values
-				if !clictx.Bool("no-labels") {
-					displayValues = fmt.Sprintf("%v\t%v", displayValues, labelString(container.Labels))
-				}
-				fmt.Fprintf(tw, "%v\n", displayValues)
+			if err := sink.Write(container); err != nil {
+				return err
 			}
 		}
 
-		return nil
+		sink.AddErrors(itemErrs...)
+		if err := sink.Close(); err != nil {
+			return err
+		}
+		return partialError(itemErrs)
 	},
 }
 
@@ -276,10 +283,7 @@ var listImages = cli.Command{
 		},
 	},
 	Action: func(clictx *cli.Context) error {
-		output := GlobalConfig.Output
-		outputfile := GlobalConfig.OutputFile
-
-		var containerImages []explorers.Image
+		var result explorers.Result[explorers.Image]
 		exps := GetExplorers()
 
 		for _, xplr := range exps {
@@ -287,69 +291,45 @@ var listImages = cli.Command{
 			if err != nil {
 				engineName := xplr.Type()
 				log.WithField("message", err).Errorf("listing %s images", engineName)
-				continue
-			}
-			containerImages = append(containerImages, engineImages...)
-		}
-
-		// Handle JSON output
-		if strings.ToLower(output) == "json" {
-			if outputfile != "" {
-				writeOutputFile(containerImages, outputfile)
-			} else {
-				printAsJSON(containerImages)
 			}
-			return nil
+			result.Add(xplr.Type(), "list", engineImages, err)
 		}
-
-		// Handle table output
-		tw := tabwriter.NewWriter(os.Stdout, 1, 8, 1, '\t', 0)
-		defer tw.Flush()
-
-		// Setting table output
-		if strings.ToLower(output) == "table" {
-			displayFields := "CONTAINER TYPE\tNAMESPACE\tNAME\tCREATED AT\tDIGEST\tTYPE"
-			if clictx.Bool("updated") {
-				displayFields = fmt.Sprintf("%v\tUPDATED AT", displayFields)
-			}
-			if !clictx.Bool("no-labels") {
-				displayFields = fmt.Sprintf("%v\tLABELS", displayFields)
-			}
-
-			fmt.Fprintf(tw, "%v\n", displayFields)
+		containerImages := result.Items
+
+		sink, err := newResultRenderer([]render.Column[explorers.Image]{
+			{Name: "CONTAINER TYPE", Value: func(i explorers.Image) any { return i.ContainerType }},
+			{Name: "NAMESPACE", Value: func(i explorers.Image) any { return i.Namespace }},
+			{Name: "NAME", Value: func(i explorers.Image) any { return i.Name }},
+			{Name: "CREATED AT", Value: func(i explorers.Image) any { return i.CreatedAt }},
+			{Name: "DIGEST", Value: func(i explorers.Image) any { return string(i.Target.Digest) }},
+			{Name: "TYPE", Value: func(i explorers.Image) any { return i.Target.MediaType }},
+			{Name: "UPDATED AT", Value: func(i explorers.Image) any { return i.UpdatedAt }, Hidden: !clictx.Bool("updated")},
+			{Name: "LABELS", Value: func(i explorers.Image) any { return labelString(i.Labels) }, Hidden: clictx.Bool("no-labels")},
+		})
+		if err != nil {
+			return err
 		}
+		defer sink.Close()
 
 		for _, image := range containerImages {
-			if !clictx.Bool("show-support-containers") && image.SupportContainerImage {
+			// The JSON and YAML output includes the support container images.
+			if !structuredOutput() && !clictx.Bool("show-support-containers") && image.SupportContainerImage {
 				log.WithFields(log.Fields{
 					"namespace": image.Namespace,
 					"image":     image.Name,
 				}).Debug("skipping Kubernetes support container image")
 				continue
 			}
-
-			switch strings.ToLower(output) {
-			case "json_line":
-				printAsJSONLine(image)
-			default:
-				displayValues := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s",
-					image.ContainerType,
-					image.Namespace,
-					image.Name,
-					image.CreatedAt.Format(tsLayout),
-					string(image.Target.Digest),
-					image.Target.MediaType,
-				)
-				if clictx.Bool("updated") {
-					displayValues = fmt.Sprintf("%v\t%s", displayValues, image.UpdatedAt.Format(tsLayout))
-				}
-				if !clictx.Bool("no-labels") {
-					displayValues = fmt.Sprintf("%v\t%s", displayValues, labelString(image.Labels))
-				}
-				fmt.Fprintf(tw, "%v\n", displayValues)
+			if err := sink.Write(image); err != nil {
+				return err
 			}
 		}
-		return nil
+
+		sink.AddErrors(result.Errors...)
+		if err := sink.Close(); err != nil {
+			return err
+		}
+		return partialError(result.Errors)
 	},
 }
 
@@ -359,58 +339,51 @@ var listContents = cli.Command{
 	Usage:       "list content for all namespaces",
 	Description: "list content for all namespaces",
 	Action: func(_ *cli.Context) error {
-		output := GlobalConfig.Output
-		outputfile := GlobalConfig.OutputFile
 
 		var containerContents []explorers.Content
+		var itemErrs []explorers.ItemError
 		exps := GetExplorers()
 
 		for _, xplr := range exps {
-			engineContents, err := xplr.ListContent(GlobalConfig.Context)
+			contentLister, ok := xplr.(explorers.ContentLister)
+			if !ok {
+				logUnsupported(xplr, explorers.CapabilityContent)
+				continue
+			}
+			engineContents, err := contentLister.ListContent(GlobalConfig.Context)
 			if err != nil {
 				engineName := xplr.Type()
 				log.WithField("message", err).Errorf("listing %s content", engineName)
+				itemErrs = append(itemErrs, explorers.ItemErrors(engineName, "list", err)...)
 				continue
 			}
 			containerContents = append(containerContents, engineContents...)
 		}
 
-		// Handling JSON output
-		if strings.ToLower(output) == "json" {
-			if outputfile != "" {
-				writeOutputFile(containerContents, outputfile)
-			} else {
-				printAsJSON(containerContents)
-			}
-			return nil
-		}
-
-		// Handling table output
-		tw := tabwriter.NewWriter(os.Stdout, 1, 8, 1, '\t', 0)
-		defer tw.Flush()
-
-		if strings.ToLower(output) == "table" {
-			fmt.Fprintf(tw, "CONTAINER TYPE\tNAMESPACE\tDIGEST\tSIZE\tCREATED AT\tUPDATED AT\tLABELS\n")
+		sink, err := newResultRenderer([]render.Column[explorers.Content]{
+			{Name: "CONTAINER TYPE", Value: func(c explorers.Content) any { return c.ContainerType }},
+			{Name: "NAMESPACE", Value: func(c explorers.Content) any { return c.Namespace }},
+			{Name: "DIGEST", Value: func(c explorers.Content) any { return c.Digest }},
+			{Name: "SIZE", Value: func(c explorers.Content) any { return c.Size }},
+			{Name: "CREATED AT", Value: func(c explorers.Content) any { return c.CreatedAt }},
+			{Name: "UPDATED AT", Value: func(c explorers.Content) any { return c.UpdatedAt }},
+			{Name: "LABELS", Value: func(c explorers.Content) any { return labelString(c.Labels) }},
+		})
+		if err != nil {
+			return err
 		}
+		defer sink.Close()
 
 		for _, c := range containerContents {
-			switch strings.ToLower(output) {
-			case "json_line":
-				printAsJSONLine(c)
-			default:
-				fmt.Fprintf(tw, "%s\t%s\t%s\t%v\t%v\t%v\t%s\n",
-					c.ContainerType,
-					c.Namespace,
-					c.Digest,
-					c.Size,
-					c.CreatedAt.Format(tsLayout),
-					c.UpdatedAt.Format(tsLayout),
-					labelString(c.Labels),
-				)
+			if err := sink.Write(c); err != nil {
+				return err
 			}
 		}
-
-		return nil
+		sink.AddErrors(itemErrs...)
+		if err := sink.Close(); err != nil {
+			return err
+		}
+		return partialError(itemErrs)
 	},
 }
 
@@ -430,78 +403,118 @@ var listSnapshots = cli.Command{
 		},
 	},
 	Action: func(clictx *cli.Context) error {
-		output := GlobalConfig.Output
-		outputfile := GlobalConfig.OutputFile
 
 		var containerSnapshotKeyInfos []explorers.SnapshotKeyInfo
+		var itemErrs []explorers.ItemError
 		exps := GetExplorers()
 
 		for _, xplr := range exps {
-			engineSnapshots, err := xplr.ListSnapshots(GlobalConfig.Context)
+			snapshotLister, ok := xplr.(explorers.SnapshotLister)
+			if !ok {
+				logUnsupported(xplr, explorers.CapabilitySnapshots)
+				continue
+			}
+			engineSnapshots, err := snapshotLister.ListSnapshots(GlobalConfig.Context)
 			if err != nil {
 				engineName := xplr.Type()
 				log.WithField("message", err).Errorf("listing %s snapshots", engineName)
+				itemErrs = append(itemErrs, explorers.ItemErrors(engineName, "list", err)...)
 				continue
 			}
 
 			// Add full overlay path if requested
 			if clictx.Bool("full-overlay-path") {
 				for i := range engineSnapshots {
-					engineSnapshots[i].OverlayPath = filepath.Join(xplr.SnapshotRoot(engineSnapshots[i].Snapshotter), engineSnapshots[i].OverlayPath)
+					engineSnapshots[i].OverlayPath = filepath.Join(snapshotLister.SnapshotRoot(engineSnapshots[i].Snapshotter), engineSnapshots[i].OverlayPath)
 				}
 			}
 
 			containerSnapshotKeyInfos = append(containerSnapshotKeyInfos, engineSnapshots...)
 		}
 
-		// Handling JSON output
-		if strings.ToLower(output) == "json" {
-			if outputfile != "" {
-				writeOutputFile(containerSnapshotKeyInfos, outputfile)
-			} else {
-				printAsJSON(containerSnapshotKeyInfos)
+		sink, err := newResultRenderer([]render.Column[explorers.SnapshotKeyInfo]{
+			{Name: "CONTAINER TYPE", Value: func(s explorers.SnapshotKeyInfo) any { return s.ContainerType }},
+			{Name: "NAMESPACE", Value: func(s explorers.SnapshotKeyInfo) any { return s.Namespace }},
+			{Name: "SNAPSHOTTER", Value: func(s explorers.SnapshotKeyInfo) any { return s.Snapshotter }},
+			{Name: "CREATED AT", Value: func(s explorers.SnapshotKeyInfo) any { return s.CreatedAt }},
+			{Name: "UPDATED AT", Value: func(s explorers.SnapshotKeyInfo) any { return s.UpdatedAt }},
+			{Name: "KIND", Value: func(s explorers.SnapshotKeyInfo) any { return s.Kind }},
+			{Name: "NAME", Value: func(s explorers.SnapshotKeyInfo) any { return s.Key }},
+			{Name: "PARENT", Value: func(s explorers.SnapshotKeyInfo) any { return s.Parent }},
+			{Name: "LAYER PATH", Value: func(s explorers.SnapshotKeyInfo) any { return s.OverlayPath }},
+			{Name: "LABELS", Value: func(s explorers.SnapshotKeyInfo) any { return labelString(s.Labels) }, Hidden: clictx.Bool("no-labels")},
+		})
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
+		for _, s := range containerSnapshotKeyInfos {
+			if err := sink.Write(s); err != nil {
+				return err
 			}
-			return nil
 		}
+		sink.AddErrors(itemErrs...)
+		if err := sink.Close(); err != nil {
+			return err
+		}
+		return partialError(itemErrs)
+	},
+}
 
-		// Handling Table output
-		tw := tabwriter.NewWriter(os.Stdout, 1, 8, 1, '\t', 0)
-		defer tw.Flush()
+var listOrphans = cli.Command{
+	Name:        "orphans",
+	Aliases:     []string{"orphan"},
+	Usage:       "list orphaned snapshot and layer directories",
+	Description: "list the snapshot and layer directories left on disk without a container or image, e.g. after a container is deleted. Use the key with mount snapshot or export snapshot.",
+	Action: func(_ *cli.Context) error {
 
-		// Setting table output header
-		if strings.ToLower(output) == "table" {
-			displayFields := "CONTAINER TYPE\tNAMESPACE\tSNAPSHOTTER\tCREATED AT\tUPDATED AT\tKIND\tNAME\tPARENT\tLAYER PATH"
-			if !clictx.Bool("no-labels") {
-				displayFields = fmt.Sprintf("%s\tLABELS", displayFields)
+		var orphans []explorers.Orphan
+		var itemErrs []explorers.ItemError
+		exps := GetExplorers()
+
+		for _, xplr := range exps {
+			engineOrphans, err := xplr.ListOrphans(GlobalConfig.Context)
+			if err != nil {
+				engineName := xplr.Type()
+				log.WithField("message", err).Errorf("listing %s orphans", engineName)
+				itemErrs = append(itemErrs, explorers.ItemErrors(engineName, "list", err)...)
+				continue
 			}
-			fmt.Fprintf(tw, "%v\n", displayFields)
+			orphans = append(orphans, engineOrphans...)
 		}
 
-		for _, s := range containerSnapshotKeyInfos {
-			switch strings.ToLower(output) {
-			case "json_line":
-				printAsJSONLine(s)
-			default:
-				displayValue := fmt.Sprintf("%s\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v",
-					s.ContainerType,
-					s.Namespace,
-					s.Snapshotter,
-					s.CreatedAt.Format(tsLayout),
-					s.UpdatedAt.Format(tsLayout),
-					s.Kind,
-					s.Key,
-					s.Parent,
-					s.OverlayPath,
-				)
-
-				if !clictx.Bool("no-labels") {
-					displayValue = fmt.Sprintf("%v\t%v", displayValue, labelString(s.Labels))
+		sink, err := newResultRenderer([]render.Column[explorers.Orphan]{
+			{Name: "CONTAINER TYPE", Value: func(o explorers.Orphan) any { return o.ContainerType }},
+			{Name: "KEY", Value: func(o explorers.Orphan) any { return o.Key }},
+			{Name: "REASON", Value: func(o explorers.Orphan) any { return o.Reason }},
+			{Name: "SIZE", Value: func(o explorers.Orphan) any { return o.Size }},
+			{Name: "FILES", Value: func(o explorers.Orphan) any { return o.Files }},
+			{Name: "MODIFIED", Value: func(o explorers.Orphan) any { return o.ModifiedAt }},
+			{Name: "CHANGED", Value: func(o explorers.Orphan) any { return o.ChangedAt }},
+			{Name: "PARENT", Value: func(o explorers.Orphan) any {
+				if o.ParentGuessed {
+					return fmt.Sprintf("%s (guessed)", o.Parent)
 				}
-				fmt.Fprintf(tw, "%v\n", displayValue)
-			}
+				return o.Parent
+			}},
+			{Name: "PATH", Value: func(o explorers.Orphan) any { return o.Path }},
+		})
+		if err != nil {
+			return err
 		}
+		defer sink.Close()
 
-		return nil
+		for _, o := range orphans {
+			if err := sink.Write(o); err != nil {
+				return err
+			}
+		}
+		sink.AddErrors(itemErrs...)
+		if err := sink.Close(); err != nil {
+			return err
+		}
+		return partialError(itemErrs)
 	},
 }
 
@@ -511,10 +524,9 @@ var listTasks = cli.Command{
 	Usage:       "list tasks",
 	Description: "list container tasks",
 	Action: func(_ *cli.Context) error {
-		output := GlobalConfig.Output
-		outputfile := GlobalConfig.OutputFile
 
 		taskMap := make(map[string]explorers.Task)
+		var itemErrs []explorers.ItemError
 		exps := GetExplorers()
 
 		for _, xplr := range exps {
@@ -522,6 +534,7 @@ var listTasks = cli.Command{
 			if err != nil {
 				engineName := xplr.Type()
 				log.WithField("message", err).Errorf("listing %s tasks", engineName)
+				itemErrs = append(itemErrs, explorers.ItemErrors(engineName, "list", err)...)
 				continue
 			}
 
@@ -545,38 +558,58 @@ var listTasks = cli.Command{
 			containerTasks = append(containerTasks, task)
 		}
 
-		// Handling JSON output
-		if strings.ToLower(output) == "json" {
-			if outputfile != "" {
-				writeOutputFile(containerTasks, outputfile)
-			} else {
-				printAsJSON(containerTasks)
+		sink, err := newResultRenderer([]render.Column[explorers.Task]{
+			{Name: "CONTAINER TYPE", Value: func(t explorers.Task) any { return t.ContainerType }},
+			{Name: "NAMESPACE", Value: func(t explorers.Task) any { return t.Namespace }},
+			{Name: "CONTAINER ID", Value: func(t explorers.Task) any { return t.Name }},
+			{Name: "PID", Value: func(t explorers.Task) any { return t.PID }},
+			{Name: "STATUS", Value: func(t explorers.Task) any { return t.Status }},
+		})
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
+		for _, t := range containerTasks {
+			if err := sink.Write(t); err != nil {
+				return err
 			}
-			return nil
 		}
+		sink.AddErrors(itemErrs...)
+		if err := sink.Close(); err != nil {
+			return err
+		}
+		return partialError(itemErrs)
+	},
+}
 
-		// Handling table output
-		tw := tabwriter.NewWriter(os.Stdout, 1, 8, 1, '\t', 0)
-		defer tw.Flush()
+var listCapabilities = cli.Command{
+	Name:        "capabilities",
+	Aliases:     []string{"capability"},
+	Usage:       "list optional capabilities",
+	Description: "list the optional capabilities supported by each container engine explorer",
+	Action: func(_ *cli.Context) error {
 
-		displayFields := "CONTAINER TYPE\tNAMESPACE\tCONTAINER ID\tPID\tSTATUS"
-		fmt.Fprintf(tw, "%v\n", displayFields)
+		var capabilities []explorers.Capability
+		for _, xplr := range GetExplorers() {
+			capabilities = append(capabilities, explorers.Capabilities(xplr)...)
+		}
 
-		for _, t := range containerTasks {
-			switch strings.ToLower(output) {
-			case "json_line":
-				printAsJSONLine(t)
-			default:
-				displayValues := fmt.Sprintf("%v\t%v\t%v\t%v\t%v",
-					t.ContainerType,
-					t.Namespace,
-					t.Name,
-					t.PID,
-					t.Status,
-				)
-				fmt.Fprintf(tw, "%v\n", displayValues)
+		sink, err := newRenderer([]render.Column[explorers.Capability]{
+			{Name: "CONTAINER TYPE", Value: func(c explorers.Capability) any { return c.ContainerType }},
+			{Name: "CAPABILITY", Value: func(c explorers.Capability) any { return c.Name }},
+			{Name: "SUPPORTED", Value: func(c explorers.Capability) any { return c.Supported }},
+		})
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
+		for _, c := range capabilities {
+			if err := sink.Write(c); err != nil {
+				return err
 			}
 		}
-		return nil
+		return sink.Close()
 	},
 }
@@ -19,9 +19,9 @@ package commands
 import (
 	"fmt"
 	"runtime"
-	"strings"
 
 	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/utils"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -32,16 +32,15 @@ var MountCommand = cli.Command{
 	Usage:       "mount a container or all containers to a mount point",
 	Description: "mount a container or all containers to a mount point",
 	ArgsUsage:   "[flag] [ID] MOUNTPOINT",
+	Subcommands: cli.Commands{
+		mountImage,
+		mountSnapshot,
+	},
 	Flags: []cli.Flag{
 		cli.BoolFlag{
 			Name:  "all",
 			Usage: "mount all containers",
 		},
-		cli.StringFlag{
-			Name:  "container-engine, e",
-			Usage: "supported container engines are docker, containerd, and podman",
-			Value: "all",
-		},
 		cli.StringFlag{
 			Name:  "filter, f",
 			Usage: "comma separated label filter using key=value pair",
@@ -50,6 +49,8 @@ var MountCommand = cli.Command{
 			Name:  "mount-support-containers, s",
 			Usage: "mount Kubernetes supporting containers",
 		},
+		jobsFlag,
+		progressFlag,
 	},
 	Action: func(clictx *cli.Context) error {
 
@@ -64,26 +65,29 @@ var MountCommand = cli.Command{
 			}
 
 			mountpoint := clictx.Args().First()
-			containerEngine := clictx.String("container-engine")
 			filter := clictx.String("filter")
 			skipSupportContainer := !clictx.Bool("mount-support-containers")
 
 			log.WithFields(log.Fields{
-				"containerEngine":      containerEngine,
 				"filter":               filter,
 				"skipSupportContainer": skipSupportContainer,
 			}).Debug("mounting all containers")
 
+			ctx, err := jobsContext(GlobalConfig.Context, clictx)
+			if err != nil {
+				return err
+			}
+
+			var itemErrs []explorers.ItemError
 			exps := GetExplorers()
 			for _, xplr := range exps {
 				engineName := xplr.Type()
-				if containerEngine == "all" || strings.ToLower(containerEngine) == engineName {
-					if err := xplr.MountAllContainers(GlobalConfig.Context, mountpoint, filter, skipSupportContainer); err != nil {
-						log.Errorf("mounting %s containers: %v", engineName, err)
-					}
+				if err := xplr.MountAllContainers(ctx, mountpoint, filter, skipSupportContainer); err != nil {
+					log.Errorf("mounting %s containers: %v", engineName, err)
+					itemErrs = append(itemErrs, explorers.ItemErrors(engineName, "mount", err)...)
 				}
 			}
-			return nil
+			return partialError(itemErrs)
 		}
 
 		// Mount individual container
@@ -104,3 +108,86 @@ var MountCommand = cli.Command{
 		return err
 	},
 }
+
+var mountImage = cli.Command{
+	Name:        "image",
+	Usage:       "mount an image read-only to a mount point",
+	Description: "mount the unpacked layers of an image read-only to a mount point",
+	ArgsUsage:   "REF MOUNTPOINT",
+	Action: func(clictx *cli.Context) error {
+		// Mounting an image is only supported on a Linux operating system.
+		if runtime.GOOS != "linux" {
+			return fmt.Errorf("mounting an image is only supported on Linux")
+		}
+
+		if clictx.NArg() < 2 {
+			return fmt.Errorf("image reference and mount point are required")
+		}
+
+		ref := clictx.Args().First()
+		mountpoint := clictx.Args().Get(1)
+
+		matched, err := ForMatchingImage(GlobalConfig.Context, ref, func(xplr explorers.ContainerExplorer) error {
+			layers, err := xplr.ImageLayers(GlobalConfig.Context, ref)
+			if err != nil {
+				return fmt.Errorf("getting %s image layers: %w", xplr.Type(), err)
+			}
+
+			log.WithFields(log.Fields{
+				"ref":        ref,
+				"mountpoint": mountpoint,
+				"layers":     len(layers.LowerDirs),
+			}).Debug("mounting image")
+
+			return utils.MountLayers(GlobalConfig.Context, utils.MountRecord{Mountpoint: mountpoint, Image: ref}, layers)
+		})
+
+		if !matched {
+			return fmt.Errorf("no matching image")
+		}
+		return err
+	},
+}
+
+var mountSnapshot = cli.Command{
+	Name:        "snapshot",
+	Usage:       "mount a snapshot or layer read-only to a mount point",
+	Description: "mount the directory of a containerd snapshot, docker layer, or podman layer read-only to a mount point, alone or with its parent chain",
+	ArgsUsage:   "[flag] KEY MOUNTPOINT",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "parents, p",
+			Usage: "mount the snapshot with its parent chain",
+		},
+	},
+	Action: func(clictx *cli.Context) error {
+		// Mounting a snapshot is only supported on a Linux operating system.
+		if runtime.GOOS != "linux" {
+			return fmt.Errorf("mounting a snapshot is only supported on Linux")
+		}
+
+		if clictx.NArg() < 2 {
+			return fmt.Errorf("snapshot key and mount point are required")
+		}
+
+		key := clictx.Args().First()
+		mountpoint := clictx.Args().Get(1)
+		parents := clictx.Bool("parents")
+
+		matched, err := ForMatchingSnapshot(GlobalConfig.Context, key, parents, func(xplr explorers.ContainerExplorer, layers explorers.LayerStack) error {
+			log.WithFields(log.Fields{
+				"key":           key,
+				"containerType": xplr.Type(),
+				"mountpoint":    mountpoint,
+				"layers":        len(layers.LowerDirs),
+			}).Debug("mounting snapshot")
+
+			return utils.MountLayers(GlobalConfig.Context, utils.MountRecord{Mountpoint: mountpoint, Snapshot: key}, layers)
+		})
+
+		if !matched {
+			return fmt.Errorf("no matching snapshot")
+		}
+		return err
+	},
+}
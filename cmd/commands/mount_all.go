@@ -19,7 +19,8 @@ package commands
 import (
 	"fmt"
 	"runtime"
-	"strings"
+
+	"github.com/google/container-explorer/explorers"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -32,11 +33,6 @@ var MountAllCommand = cli.Command{
 	Description: "mount all containers to subdirectories with the specified mount point",
 	ArgsUsage:   "[flag] MOUNT_POINT",
 	Flags: []cli.Flag{
-		cli.StringFlag{
-			Name:  "container-engine, e",
-			Usage: "support container engines are docker, containerd, and podman",
-			Value: "all",
-		},
 		cli.StringFlag{
 			Name:  "filter, f",
 			Usage: "comma separated label filter using key=value pair",
@@ -45,6 +41,8 @@ var MountAllCommand = cli.Command{
 			Name:  "mount-support-containers, s",
 			Usage: "mount Kubernetes supporting containers",
 		},
+		jobsFlag,
+		progressFlag,
 	},
 	Action: func(clictx *cli.Context) error {
 		// Mounting a container is only supported on a Linux operating system.
@@ -57,26 +55,29 @@ var MountAllCommand = cli.Command{
 		}
 
 		mountpoint := clictx.Args().First()
-		containerEngine := clictx.String("container-engine")
 		filter := clictx.String("filter")
 		skipSupportContainer := !clictx.Bool("mount-support-containers")
 
 		log.WithFields(log.Fields{
-			"containerEngine":      containerEngine,
 			"filter":               filter,
 			"skipSupportContainer": skipSupportContainer,
 		}).Debug("mounting all containers")
 
+		ctx, err := jobsContext(GlobalConfig.Context, clictx)
+		if err != nil {
+			return err
+		}
+
+		var itemErrs []explorers.ItemError
 		exps := GetExplorers()
 		for _, xplr := range exps {
 			engineName := xplr.Type()
-			if containerEngine == "all" || strings.ToLower(containerEngine) == engineName {
-				if err := xplr.MountAllContainers(GlobalConfig.Context, mountpoint, filter, skipSupportContainer); err != nil {
-					log.Errorf("mounting %s containers: %v", engineName, err)
-				}
+			if err := xplr.MountAllContainers(ctx, mountpoint, filter, skipSupportContainer); err != nil {
+				log.Errorf("mounting %s containers: %v", engineName, err)
+				itemErrs = append(itemErrs, explorers.ItemErrors(engineName, "mount", err)...)
 			}
 		}
 
-		return nil // default
+		return partialError(itemErrs)
 	},
 }
@@ -0,0 +1,76 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"os"
+	"strings"
+
+	"github.com/google/container-explorer/pkg/render"
+)
+
+// renderOptions returns the output options of the command line.
+func renderOptions() render.Options {
+	return render.Options{
+		Format:     GlobalConfig.Output,
+		Template:   GlobalConfig.Format,
+		Columns:    GlobalConfig.Columns,
+		SortBy:     GlobalConfig.SortBy,
+		OutputFile: GlobalConfig.OutputFile,
+		SourceRoot: sourceRoot(GlobalConfig),
+		Command:    strings.Join(os.Args, " "),
+	}
+}
+
+// sourceRoot returns the image root of the records of a configuration, or
+// the first container engine root if the image root is not set.
+func sourceRoot(config RuntimeConfig) string {
+	for _, root := range []string{config.ImageRootDir, config.ContainerdRootDir, config.DockerRootDir, config.PodmanRootDir} {
+		if root != "" {
+			return root
+		}
+	}
+	return "/"
+}
+
+// newRenderer returns a renderer of an array of records in the output
+// options of the command line.
+func newRenderer[T any](columns []render.Column[T]) (*render.Renderer[T], error) {
+	return render.New(renderOptions(), columns)
+}
+
+// newResultRenderer returns a renderer of an explorers.Result of the records
+// and the item errors in the output options of the command line.
+func newResultRenderer[T any](columns []render.Column[T]) (*render.Renderer[T], error) {
+	return render.NewResult(renderOptions(), columns)
+}
+
+// newRecordRenderer returns a renderer of the record of a command that
+// outputs a single record in the output options of the command line.
+func newRecordRenderer[T any](columns []render.Column[T]) (*render.Renderer[T], error) {
+	return render.NewRecord(renderOptions(), columns)
+}
+
+// structuredOutput reports whether the output format writes the complete
+// records, which include the support containers.
+func structuredOutput() bool {
+	switch strings.ToLower(GlobalConfig.Output) {
+	case render.FormatJSON, render.FormatYAML:
+		return GlobalConfig.Format == ""
+	}
+	return false
+}
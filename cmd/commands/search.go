@@ -0,0 +1,462 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/pkg/render"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	// maxGrepLine is the longest line searched by grep.
+	maxGrepLine = 1 << 20
+
+	// maxGrepText is the length of the matching line text reported by grep.
+	maxGrepText = 256
+)
+
+// searchFlags select the containers and the layers searched by grep and
+// find.
+var searchFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "filter, f",
+		Usage: "comma separated label filter using key=value pair",
+	},
+	cli.BoolFlag{
+		Name:  "search-support-containers, s",
+		Usage: "search Kubernetes supporting containers",
+	},
+	cli.BoolFlag{
+		Name:  "upper-only, u",
+		Usage: "search only the container writable layer instead of the merged container filesystem",
+	},
+	jobsFlag,
+	progressFlag,
+}
+
+var GrepCommand = cli.Command{
+	Name:        "grep",
+	Usage:       "search container file content",
+	Description: "search the files of all containers for a regular expression or string in parallel",
+	ArgsUsage:   "[flag] PATTERN [PATH]",
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "fixed-strings, F",
+			Usage: "match the pattern as a string instead of a regular expression",
+		},
+		cli.BoolFlag{
+			Name:  "ignore-case, i",
+			Usage: "match the pattern ignoring case",
+		},
+		cli.BoolFlag{
+			Name:  "files-with-matches, l",
+			Usage: "report only the first match of a file",
+		},
+		cli.StringFlag{
+			Name:  "max-size",
+			Usage: "skip files larger than the size in bytes with an optional k, M, or G suffix",
+		},
+	}, searchFlags...),
+	Action: func(clictx *cli.Context) error {
+		if clictx.NArg() < 1 {
+			return fmt.Errorf("pattern is required")
+		}
+
+		pattern := clictx.Args().First()
+		if clictx.Bool("fixed-strings") {
+			pattern = regexp.QuoteMeta(pattern)
+		}
+		if clictx.Bool("ignore-case") {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+
+		var maxSize int64
+		if clictx.String("max-size") != "" {
+			if maxSize, err = parseByteSize(clictx.String("max-size")); err != nil {
+				return err
+			}
+		}
+
+		root := "/"
+		if clictx.NArg() > 1 {
+			root = clictx.Args().Get(1)
+		}
+
+		grep := &containerGrep{
+			re:        re,
+			maxSize:   maxSize,
+			firstOnly: clictx.Bool("files-with-matches"),
+		}
+		return searchContainers(clictx, root, true, grep.search)
+	},
+}
+
+var FindCommand = cli.Command{
+	Name:        "find",
+	Usage:       "find files in all containers",
+	Description: "find the files of all containers by path, name, modification time, and size in parallel without following symbolic links",
+	ArgsUsage:   "[flag] [PATH]",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "path",
+			Usage: "file path pattern e.g. '/tmp/*/kworker'",
+		},
+		cli.StringFlag{
+			Name:  "name",
+			Usage: "file name pattern e.g. '*.sh'",
+		},
+		cli.StringFlag{
+			Name:  "newer",
+			Usage: "files modified after a time e.g. 2026-01-02 or 2026-01-02T15:04:05Z",
+		},
+		cli.StringFlag{
+			Name:  "size",
+			Usage: "file size in bytes with an optional k, M, or G suffix; +N is larger and -N smaller than N",
+		},
+	}, searchFlags...),
+	Action: func(clictx *cli.Context) error {
+		root := patternRoot(clictx.String("path"))
+		if clictx.NArg() > 0 {
+			root = clictx.Args().First()
+		}
+
+		match, err := newFSFilter(clictx.String("name"), clictx.String("path"), clictx.String("newer"), clictx.String("size"))
+		if err != nil {
+			return err
+		}
+
+		return searchContainers(clictx, root, false, func(target searchTarget, f explorers.LayeredFile, report func(searchMatch)) {
+			if match(f) {
+				report(target.match(f))
+			}
+		})
+	},
+}
+
+// patternRoot returns the directory of a path pattern without pattern
+// characters, where the search of the pattern can start.
+func patternRoot(pattern string) string {
+	if !strings.HasPrefix(pattern, "/") {
+		return "/"
+	}
+
+	elems := strings.Split(pattern, "/")
+	root := "/"
+	for _, elem := range elems[:len(elems)-1] {
+		if strings.ContainsAny(elem, `*?[\`) {
+			break
+		}
+		root = path.Join(root, elem)
+	}
+	return root
+}
+
+// searchMatch is a file found by grep or find in a container.
+type searchMatch struct {
+	ContainerID string    `json:"container_id"`
+	Runtime     string    `json:"runtime"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Layer       string    `json:"layer"`
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	Modified    time.Time `json:"modified"`
+	Line        int       `json:"line,omitempty"`
+	Text        string    `json:"text,omitempty"`
+	Binary      bool      `json:"binary,omitempty"`
+}
+
+// searchTarget is a container searched by grep or find.
+type searchTarget struct {
+	container explorers.Container
+	lfs       *explorers.LayeredFS
+}
+
+// match returns the search match of a container file.
+func (t searchTarget) match(f explorers.LayeredFile) searchMatch {
+	return searchMatch{
+		ContainerID: t.container.ID,
+		Runtime:     t.container.ContainerType,
+		Namespace:   t.container.Namespace,
+		Layer:       f.Layer,
+		Path:        f.Path,
+		Size:        f.Info.Size(),
+		Modified:    f.Info.ModTime().UTC(),
+	}
+}
+
+// searchFunc reports the matches of a container file.
+type searchFunc func(target searchTarget, f explorers.LayeredFile, report func(searchMatch))
+
+// searchContainers walks the files of the selected containers on the
+// concurrent jobs of the command flags and writes the matches reported by
+// search.
+//
+// JSON lines are written as the matches are found. The matches of the other
+// formats are sorted by path and written as each container completes, in
+// the order of the container IDs.
+func searchContainers(clictx *cli.Context, root string, grep bool, search searchFunc) error {
+	ctx, err := jobsContext(GlobalConfig.Context, clictx)
+	if err != nil {
+		return err
+	}
+
+	targets, err := searchTargets(ctx, clictx)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].container.ID < targets[j].container.ID
+	})
+
+	sink, err := newSearchRenderer(grep)
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+
+	operation := "find"
+	if grep {
+		operation = "grep"
+	}
+	ids := make([]string, 0, len(targets))
+	for _, target := range targets {
+		ids = append(ids, target.container.ID)
+	}
+	stream := slices.Contains([]string{render.FormatJSONLine, "jsonl"}, strings.ToLower(GlobalConfig.Output))
+
+	err = explorers.StreamContainerJobs(ctx, "", operation, ids, func(i int) ([]searchMatch, error) {
+		var matches []searchMatch
+		walkSearchTarget(targets[i], root, search, func(match searchMatch) {
+			if !stream {
+				matches = append(matches, match)
+				return
+			}
+			if err := sink.Write(match); err != nil {
+				log.WithField("error", err).Error("writing search match")
+			}
+		})
+
+		sort.SliceStable(matches, func(i, j int) bool {
+			if matches[i].Path != matches[j].Path {
+				return matches[i].Path < matches[j].Path
+			}
+			return matches[i].Line < matches[j].Line
+		})
+		return matches, nil
+	}, func(matches []searchMatch) error {
+		for _, match := range matches {
+			if err := sink.Write(match); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return errors.Join(err, sink.Close())
+}
+
+// walkSearchTarget walks the files of a container from the root.
+func walkSearchTarget(target searchTarget, root string, search searchFunc, report func(searchMatch)) {
+	err := target.lfs.Walk(root, func(f explorers.LayeredFile, err error) error {
+		if err != nil {
+			if f.Info == nil {
+				return err
+			}
+			log.WithFields(log.Fields{
+				"containerID": target.container.ID,
+				"path":        f.Path,
+				"error":       err,
+			}).Warn("reading container directory")
+			return nil
+		}
+		search(target, f, report)
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.WithFields(log.Fields{"containerID": target.container.ID, "error": err}).Warn("searching container")
+	}
+}
+
+// searchTargets returns the containers selected by the label filter and
+// support container flags with their layered
+// filesystems.
+func searchTargets(ctx context.Context, clictx *cli.Context) ([]searchTarget, error) {
+	filter := getFilterMap(clictx.String("filter"))
+	skipSupportContainers := !clictx.Bool("search-support-containers")
+	upperOnly := clictx.Bool("upper-only")
+
+	var targets []searchTarget
+	for _, xplr := range GetExplorers() {
+		ctrs, err := xplr.ListContainers(ctx)
+		if err != nil {
+			log.Errorf("listing %s containers: %v", xplr.Type(), err)
+			continue
+		}
+
+		for _, ctr := range ctrs {
+			// Docker engine 29 containers are searched by the Docker explorer.
+			if xplr.Type() == "containerd" && ctr.Namespace == "moby" {
+				continue
+			}
+			if skipSupportContainers && ctr.SupportContainer {
+				log.WithFields(log.Fields{"containerID": ctr.ID}).Debug("skipping Kubernetes support container")
+				continue
+			}
+			if !matchLabels(ctr.Labels, filter) {
+				continue
+			}
+
+			layers, err := xplr.ContainerLayers(ctx, ctr.ID)
+			if err != nil {
+				log.WithFields(log.Fields{"containerID": ctr.ID, "error": err}).Warn("getting container layers")
+				continue
+			}
+			if upperOnly {
+				if layers.UpperDir == "" {
+					continue
+				}
+				layers = explorers.LayerStack{UpperDir: layers.UpperDir}
+			}
+
+			if ctr.ContainerType == "" {
+				ctr.ContainerType = xplr.Type()
+			}
+			targets = append(targets, searchTarget{
+				container: ctr,
+				lfs:       explorers.NewLayeredFS(layers),
+			})
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"containers": len(targets),
+		"upperOnly":  upperOnly,
+	}).Debug("searching containers")
+
+	return targets, nil
+}
+
+// matchLabels returns true if the labels contain every key-value pair of
+// the filter.
+func matchLabels(labels map[string]string, filter map[string]string) bool {
+	for key, value := range filter {
+		if labelValue, ok := labels[key]; !ok || labelValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// containerGrep searches the content of container files.
+type containerGrep struct {
+	re        *regexp.Regexp
+	maxSize   int64
+	firstOnly bool
+}
+
+// search reports the lines of a regular file matching the pattern. A binary
+// file, i.e. a file containing a NUL byte, is reported once without text.
+func (g *containerGrep) search(target searchTarget, f explorers.LayeredFile, report func(searchMatch)) {
+	if !f.Info.Mode().IsRegular() || (g.maxSize > 0 && f.Info.Size() > g.maxSize) {
+		return
+	}
+
+	//nolint:gosec // G304: Path is resolved within the container layers
+	file, err := os.Open(f.HostPath())
+	if err != nil {
+		log.WithFields(log.Fields{"path": f.HostPath(), "error": err}).Warn("opening container file")
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxGrepLine)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Bytes()
+		if !g.re.Match(text) {
+			continue
+		}
+
+		match := target.match(f)
+		if bytes.IndexByte(text, 0) >= 0 {
+			match.Binary = true
+			report(match)
+			return
+		}
+
+		match.Line = line
+		match.Text = string(text)
+		if len(match.Text) > maxGrepText {
+			match.Text = match.Text[:maxGrepText]
+		}
+		report(match)
+
+		if g.firstOnly {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.WithFields(log.Fields{"path": f.HostPath(), "error": err}).Warn("reading container file")
+	}
+}
+
+// newSearchRenderer returns the renderer of the matches of grep or find.
+func newSearchRenderer(grep bool) (*render.Renderer[searchMatch], error) {
+	if grep {
+		return newRenderer([]render.Column[searchMatch]{
+			{Name: "CONTAINER TYPE", Value: func(m searchMatch) any { return m.Runtime }},
+			{Name: "CONTAINER ID", Value: func(m searchMatch) any { return m.ContainerID }},
+			{Name: "PATH", Value: func(m searchMatch) any { return m.Path }},
+			{Name: "LINE", Value: func(m searchMatch) any { return m.Line }},
+			{Name: "TEXT", Value: func(m searchMatch) any {
+				if m.Binary {
+					return "(binary file matches)"
+				}
+				return strings.ReplaceAll(m.Text, "\t", " ")
+			}},
+			{Name: "LAYER", Value: func(m searchMatch) any { return m.Layer }},
+		})
+	}
+	return newRenderer([]render.Column[searchMatch]{
+		{Name: "CONTAINER TYPE", Value: func(m searchMatch) any { return m.Runtime }},
+		{Name: "CONTAINER ID", Value: func(m searchMatch) any { return m.ContainerID }},
+		{Name: "PATH", Value: func(m searchMatch) any { return m.Path }},
+		{Name: "SIZE", Value: func(m searchMatch) any { return m.Size }},
+		{Name: "MODIFIED", Value: func(m searchMatch) any { return m.Modified }},
+		{Name: "LAYER", Value: func(m searchMatch) any { return m.Layer }},
+	})
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/google/container-explorer/pkg/render"
+	"github.com/google/container-explorer/utils"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var UmountCommand = cli.Command{
+	Name:        "umount",
+	Aliases:     []string{"unmount"},
+	Usage:       "unmount a container mount point or all recorded mounts",
+	Description: "unmount mount points and detach loop devices recorded by container-explorer",
+	ArgsUsage:   "[flag] MOUNTPOINT | --all [MOUNTPOINT_ROOT...]",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "all",
+			Usage: "unmount all recorded mounts in the mount point roots or, if none are specified, all active recorded mounts",
+		},
+	},
+	Action: func(clictx *cli.Context) error {
+		// Unmounting is only supported on a Linux operating system.
+		if runtime.GOOS != "linux" {
+			return fmt.Errorf("unmounting is only supported on Linux")
+		}
+
+		if !clictx.Bool("all") {
+			if clictx.NArg() < 1 {
+				return fmt.Errorf("mount point is required")
+			}
+			return utils.Unmount(GlobalConfig.Context, clictx.Args().First())
+		}
+
+		records, err := utils.RegisteredMounts(clictx.Args()...)
+		if err != nil {
+			return err
+		}
+
+		var errs []error
+		for _, record := range records {
+			log.WithFields(log.Fields{
+				"mountpoint":  record.Mountpoint,
+				"type":        record.Type,
+				"containerID": record.ContainerID,
+			}).Debug("unmounting recorded mount")
+
+			if err := utils.Unmount(GlobalConfig.Context, record.Mountpoint); err != nil {
+				log.Errorf("unmounting %s: %v", record.Mountpoint, err)
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	},
+}
+
+var MountsCommand = cli.Command{
+	Name:        "mounts",
+	Usage:       "list mounts recorded by container-explorer",
+	Description: "list mounts and loop devices recorded in the mount point roots or, if none are specified, the roots of the active mounts",
+	ArgsUsage:   "[MOUNTPOINT_ROOT...]",
+	Action: func(clictx *cli.Context) error {
+		records, err := utils.RegisteredMounts(clictx.Args()...)
+		if err != nil {
+			return err
+		}
+
+		sink, err := newRenderer([]render.Column[utils.MountRecord]{
+			{Name: "MOUNTPOINT", Value: func(r utils.MountRecord) any { return r.Mountpoint }},
+			{Name: "TYPE", Value: func(r utils.MountRecord) any { return r.Type }},
+			{Name: "CONTAINER ID", Value: func(r utils.MountRecord) any { return r.ContainerID }},
+			{Name: "IMAGE", Value: func(r utils.MountRecord) any { return r.Image }},
+			{Name: "SNAPSHOT", Value: func(r utils.MountRecord) any { return r.Snapshot }},
+			{Name: "SOURCE", Value: func(r utils.MountRecord) any { return r.Source }},
+			{Name: "DEVICE", Value: func(r utils.MountRecord) any { return r.Device }},
+			{Name: "CREATED AT", Value: func(r utils.MountRecord) any { return r.CreatedAt }},
+			{Name: "PID", Value: func(r utils.MountRecord) any { return r.PID }},
+			{Name: "ACTIVE", Value: func(r utils.MountRecord) any { return r.Active }},
+		})
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+
+		for _, record := range records {
+			if err := sink.Write(record); err != nil {
+				return err
+			}
+		}
+		return sink.Close()
+	},
+}
@@ -18,48 +18,29 @@ package commands
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/google/container-explorer/explorers"
-	"github.com/google/container-explorer/explorers/containerd"
-	"github.com/google/container-explorer/explorers/docker"
-	"github.com/google/container-explorer/explorers/podman"
+	"github.com/google/container-explorer/pkg/ce"
 
 	log "github.com/sirupsen/logrus"
 )
 
 // GetExplorers returns a slice of all initialized container explorers.
 func GetExplorers() []explorers.ContainerExplorer {
-	var allExplorers []explorers.ContainerExplorer
-
-	// Docker
-	dkrxplr, err := docker.NewExplorer(GlobalConfig.ImageRootDir, GlobalConfig.ContainerdRootDir, GlobalConfig.DockerRootDir)
-	if err != nil {
-		log.Debugf("unable to get docker explorer: %v", err)
-	} else {
-		allExplorers = append(allExplorers, dkrxplr)
-	}
-
-	// Podman
-	pmxplr, err := podman.NewExplorer(GlobalConfig.ImageRootDir)
-	if err != nil {
-		log.Debugf("unable to get podman explorer: %v", err)
-	} else {
-		allExplorers = append(allExplorers, pmxplr)
-	}
+	return getExplorersFor(GlobalConfig)
+}
 
-	// Containerd
-	ctrxplr, err := containerd.NewExplorer(GlobalConfig.ImageRootDir, GlobalConfig.ContainerdRootDir, GlobalConfig.DockerRootDir, GlobalConfig.LayerCache, GlobalConfig.SupportContainerData)
+// getExplorersFor returns the container explorers of the root directories
+// of a runtime configuration.
+func getExplorersFor(config RuntimeConfig) []explorers.ContainerExplorer {
+	session, err := ce.New(sessionOptions(config))
 	if err != nil {
-		log.Debugf("unable to get containerd explorer: %v", err)
-	} else {
-		allExplorers = append(allExplorers, ctrxplr)
+		log.Debugf("unable to get container explorers: %v", err)
+		return nil
 	}
-
-	return allExplorers
+	return session.Explorers()
 }
 
 // ForMatchingContainer finds an explorer that has the given containerID and executes the provided function.
@@ -75,7 +56,46 @@ func ForMatchingContainer(ctx context.Context, containerID string, fn func(explo
 			return true, fn(exp)
 		}
 	}
-	return false, fmt.Errorf("container %s not found", containerID)
+	return false, fmt.Errorf("container %s %w", containerID, explorers.ErrNotFound)
+}
+
+// ForMatchingSnapshot finds an explorer that has a snapshot or layer matching the key and executes the provided function with its layers.
+func ForMatchingSnapshot(ctx context.Context, key string, parents bool, fn func(explorers.ContainerExplorer, explorers.LayerStack) error) (bool, error) {
+	exps := GetExplorers()
+	for _, exp := range exps {
+		layers, err := exp.SnapshotLayers(ctx, key, parents)
+		if err != nil {
+			log.Debugf("error checking snapshot %s in %s explorer: %v", key, exp.Type(), err)
+			continue
+		}
+		return true, fn(exp, layers)
+	}
+	return false, fmt.Errorf("snapshot %s %w", key, explorers.ErrNotFound)
+}
+
+// ForMatchingImage finds an explorer that has an image matching the reference and executes the provided function.
+func ForMatchingImage(ctx context.Context, ref string, fn func(explorers.ContainerExplorer) error) (bool, error) {
+	exps := GetExplorers()
+	for _, exp := range exps {
+		images, err := exp.ListImages(ctx)
+		if err != nil {
+			log.Debugf("error listing images in %s explorer: %v", exp.Type(), err)
+			continue
+		}
+		for _, image := range images {
+			if explorers.MatchImageReference(image.Name, image.Target.Digest, ref) {
+				return true, fn(exp)
+			}
+		}
+	}
+	return false, fmt.Errorf("image %s %w", ref, explorers.ErrNotFound)
+}
+
+// logUnsupported logs an optional capability that is not supported by an
+// explorer, so that unsupported is not mistaken for empty.
+func logUnsupported(xplr explorers.ContainerExplorer, capability string) {
+	err := &explorers.UnsupportedError{ContainerType: xplr.Type(), Capability: capability}
+	log.Warn(err.Error())
 }
 
 func getFilterMap(filter string) map[string]string {
@@ -94,25 +114,6 @@ func getFilterMap(filter string) map[string]string {
 	return filterMap
 }
 
-func printAsJSON(v any) {
-	b, err := json.MarshalIndent(v, "", " ")
-	if err != nil {
-		log.Error("error marshaling to JSON", err)
-		return
-	}
-
-	fmt.Println(string(b))
-}
-
-func printAsJSONLine(v any) {
-	b, err := json.Marshal(v)
-	if err != nil {
-		log.Error("error marshaling to json_line", err)
-		return
-	}
-	fmt.Println(string(b))
-}
-
 // labelString returns a string of comma separated key-value pairs.
 func labelString(labels map[string]string) string {
 	var lablestrings []string
@@ -137,9 +138,3 @@ func arrayToString(array []string) string {
 
 	return result
 }
-
-// writeOutputFile writes JSON data to specified file.
-func writeOutputFile(v any, outputfile string) {
-	data, _ := json.Marshal(v)
-	os.WriteFile(outputfile, data, 0600)
-}
@@ -70,19 +70,48 @@ func main() {
 			Name:  "docker-root, D",
 			Usage: "specify docker root directory",
 		},
+		cli.StringFlag{
+			Name:  "container-engine, e",
+			Usage: "comma separated container engines to explore, or all. Default is the engines detected in the root directories",
+		},
+		cli.StringSliceFlag{
+			Name:  "engine-option",
+			Usage: "container engine specific option as engine.name=value, e.g. containerd.layer-cache=layers",
+		},
+		cli.StringFlag{
+			Name:   "hash-cache",
+			Usage:  "file of the cache of the file hashes, created if it does not exist",
+			EnvVar: "CE_HASH_CACHE",
+		},
+		cli.BoolFlag{
+			Name:  "no-hash-cache",
+			Usage: "hash the files without the hash cache",
+		},
 		cli.StringFlag{
 			Name:  "support-container-data, s",
 			Usage: "a yaml file containing information about support containers",
 		},
 		cli.StringFlag{
 			Name:  "output",
-			Usage: "output format in json, table. Default is table",
+			Usage: "output format in table, json, json_line, csv, yaml, sqlite, or timesketch. Default is table",
 			Value: "table",
 		},
 		cli.StringFlag{
 			Name:  "output-file, o",
 			Usage: "output file to save the content",
 		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Go template of each output record e.g. '{{.ID}} {{.Image}}'",
+		},
+		cli.StringFlag{
+			Name:  "columns",
+			Usage: "comma separated table and csv columns e.g. container-id,image",
+		},
+		cli.StringFlag{
+			Name:  "sort-by",
+			Usage: "output column sorting the records, descending if prefixed with - e.g. -created-at",
+		},
 	}
 
 	app.Commands = []cli.Command{
@@ -90,8 +119,17 @@ func main() {
 		cecommands.InfoCommand,
 		cecommands.InspectCommand,
 		cecommands.MountCommand,
+		cecommands.UmountCommand,
+		cecommands.MountsCommand,
 		cecommands.DriftCommand,
 		cecommands.ExportCommand,
+		cecommands.ContentCommand,
+		cecommands.FSCommand,
+		cecommands.GrepCommand,
+		cecommands.FindCommand,
+		cecommands.CarveCommand,
+		cecommands.CompareCommand,
+		cecommands.BatchCommand,
 	}
 
 	app.Before = func(clictx *cli.Context) error {
@@ -100,9 +138,15 @@ func main() {
 		}
 		return cecommands.InitializeRuntime(clictx)
 	}
+	app.After = func(clictx *cli.Context) error {
+		cecommands.CloseRuntime()
+		return nil
+	}
 
+	// Exit with 2 if a command completed with the errors of some containers.
 	err := app.Run(os.Args)
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		os.Exit(cecommands.ExitCode(err))
 	}
 }
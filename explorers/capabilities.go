@@ -0,0 +1,72 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import "fmt"
+
+// Capability values of the optional explorer interfaces.
+const (
+	CapabilityNamespaces        = "namespaces"         // NamespaceLister
+	CapabilityDeletedContainers = "deleted-containers" // DeletedContainerLister
+	CapabilityContent           = "content"            // ContentLister
+	CapabilitySnapshots         = "snapshots"          // SnapshotLister
+	CapabilityImageExport       = "image-export"       // ImageExporter
+)
+
+// Capability provides whether an explorer supports an optional capability.
+type Capability struct {
+	ContainerType string // container type: containerd, docker, podman, etc.
+	Name          string // one of the capability values
+	Supported     bool   // the explorer implements the optional interface
+}
+
+// Capabilities returns the optional capabilities of an explorer.
+func Capabilities(xplr ContainerExplorer) []Capability {
+	supported := []struct {
+		name string
+		ok   bool
+	}{
+		{CapabilityNamespaces, implements[NamespaceLister](xplr)},
+		{CapabilityDeletedContainers, implements[DeletedContainerLister](xplr)},
+		{CapabilityContent, implements[ContentLister](xplr)},
+		{CapabilitySnapshots, implements[SnapshotLister](xplr)},
+		{CapabilityImageExport, implements[ImageExporter](xplr)},
+	}
+
+	capabilities := make([]Capability, 0, len(supported))
+	for _, s := range supported {
+		capabilities = append(capabilities, Capability{ContainerType: xplr.Type(), Name: s.name, Supported: s.ok})
+	}
+	return capabilities
+}
+
+// implements reports if an explorer implements an optional interface.
+func implements[T any](xplr ContainerExplorer) bool {
+	_, ok := xplr.(T)
+	return ok
+}
+
+// UnsupportedError is returned for an operation that requires an optional
+// capability that an explorer does not support.
+type UnsupportedError struct {
+	ContainerType string // container type of the explorer
+	Capability    string // one of the capability values
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s is unsupported by %s", e.Capability, e.ContainerType)
+}
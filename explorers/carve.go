@@ -0,0 +1,47 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+// Carved record kinds.
+const (
+	CarvedContainer = "CONTAINER" // container record
+	CarvedImage     = "IMAGE"     // image record
+	CarvedSnapshot  = "SNAPSHOT"  // snapshot record
+)
+
+// Carved page states.
+const (
+	PageLive        = "LIVE"        // page is in the live database tree
+	PageFree        = "FREE"        // page is in the freelist
+	PageUnreachable = "UNREACHABLE" // page is neither live nor in the freelist
+)
+
+// CarvedRecord provides a record recovered from the pages of a database
+// file, including the pages of deleted records.
+type CarvedRecord struct {
+	Source    string            // database file
+	Page      uint64            // ID of the page containing the record
+	Offset    int64             // file offset of the record data
+	PageState string            // one of the carved page state values
+	Kind      string            // one of the carved record kinds
+	Namespace string            // namespace, if the record bucket is known
+	Name      string            // container ID, image name, or snapshot key, if known
+	Bucket    string            // bucket path, if known
+	Fields    map[string]string // decoded record fields
+	Labels    map[string]string // record labels
+	Live      bool              // a record of the same kind and name is in the live database
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Compared object values.
+const (
+	ComparedContainer = "CONTAINER"
+	ComparedImage     = "IMAGE"
+	ComparedSnapshot  = "SNAPSHOT"
+	ComparedFile      = "FILE"
+)
+
+// Change values of a comparison.
+const (
+	ChangeAdded   = "ADDED"   // only in the second inventory
+	ChangeRemoved = "REMOVED" // only in the first inventory
+	ChangeChanged = "CHANGED" // in both inventories with different values
+)
+
+// Change provides a difference between two inventories of the same host,
+// e.g. collected on different days.
+type Change struct {
+	Object        string   // one of the compared object values
+	ContainerType string   // container type: containerd, docker, podman, etc.
+	Namespace     string   // namespace of the object, if any
+	ID            string   // container ID, image name, snapshot key, or file path
+	ContainerID   string   // container of a file change
+	Change        string   // one of the change values
+	Details       []string // changed values as name: first -> second
+}
+
+// CompareContainers returns the added, removed, and changed containers of
+// two container inventories. Containers are matched by container type,
+// namespace, and ID.
+func CompareContainers(a []Container, b []Container) []Change {
+	key := func(c Container) string {
+		return c.ContainerType + "/" + c.Namespace + "/" + c.ID
+	}
+	change := func(c Container) Change {
+		return Change{Object: ComparedContainer, ContainerType: c.ContainerType, Namespace: c.Namespace, ID: c.ID}
+	}
+	details := func(ca Container, cb Container) []string {
+		var details []string
+		details = appendDetail(details, "image", ca.Image, cb.Image)
+		details = appendDetail(details, "status", ca.Status, cb.Status)
+		details = appendDetail(details, "pid", fmt.Sprint(ca.ProcessID), fmt.Sprint(cb.ProcessID))
+		details = appendDetail(details, "running", fmt.Sprint(ca.Running), fmt.Sprint(cb.Running))
+		details = appendDetail(details, "snapshot_key", ca.SnapshotKey, cb.SnapshotKey)
+		details = appendDetail(details, "updated_at", formatCompareTime(ca.UpdatedAt), formatCompareTime(cb.UpdatedAt))
+		return appendLabelDetails(details, ca.Labels, cb.Labels)
+	}
+	return compareInventories(a, b, key, change, details)
+}
+
+// CompareImages returns the added, removed, and changed images of two
+// image inventories. Images are matched by container type, namespace, and
+// name, so that a tag moved to another image is a changed image.
+func CompareImages(a []Image, b []Image) []Change {
+	key := func(i Image) string {
+		return i.ContainerType + "/" + i.Namespace + "/" + i.Name
+	}
+	change := func(i Image) Change {
+		return Change{Object: ComparedImage, ContainerType: i.ContainerType, Namespace: i.Namespace, ID: i.Name}
+	}
+	details := func(ia Image, ib Image) []string {
+		var details []string
+		details = appendDetail(details, "target", ia.Target.Digest.String(), ib.Target.Digest.String())
+		details = appendDetail(details, "media_type", ia.Target.MediaType, ib.Target.MediaType)
+		return appendLabelDetails(details, ia.Labels, ib.Labels)
+	}
+	return compareInventories(a, b, key, change, details)
+}
+
+// CompareSnapshots returns the added, removed, and changed snapshots of two
+// snapshot inventories. Snapshots are matched by container type,
+// namespace, snapshotter, and key.
+func CompareSnapshots(a []SnapshotKeyInfo, b []SnapshotKeyInfo) []Change {
+	key := func(s SnapshotKeyInfo) string {
+		return s.ContainerType + "/" + s.Namespace + "/" + s.Snapshotter + "/" + s.Key
+	}
+	change := func(s SnapshotKeyInfo) Change {
+		return Change{Object: ComparedSnapshot, ContainerType: s.ContainerType, Namespace: s.Namespace, ID: s.Key}
+	}
+	details := func(sa SnapshotKeyInfo, sb SnapshotKeyInfo) []string {
+		var details []string
+		details = appendDetail(details, "kind", sa.Kind.String(), sb.Kind.String())
+		details = appendDetail(details, "parent", sa.Parent, sb.Parent)
+		details = appendDetail(details, "name", sa.Name, sb.Name)
+		details = appendDetail(details, "size", fmt.Sprint(sa.Size), fmt.Sprint(sb.Size))
+		return appendDetail(details, "updated_at", formatCompareTime(sa.UpdatedAt), formatCompareTime(sb.UpdatedAt))
+	}
+	return compareInventories(a, b, key, change, details)
+}
+
+// CompareFiles returns the added, removed, and changed files of the upper
+// directory of a container in two inventories. The files are the files
+// returned by ScanDiffDirectory, and are matched by path.
+func CompareFiles(container Container, a []FileInfo, b []FileInfo) []Change {
+	key := func(f FileInfo) string {
+		return f.FullPath
+	}
+	change := func(f FileInfo) Change {
+		return Change{
+			Object:        ComparedFile,
+			ContainerType: container.ContainerType,
+			Namespace:     container.Namespace,
+			ID:            f.FullPath,
+			ContainerID:   container.ID,
+		}
+	}
+	details := func(fa FileInfo, fb FileInfo) []string {
+		var details []string
+		details = appendDetail(details, "sha256", fa.FileSHA256, fb.FileSHA256)
+		details = appendDetail(details, "size", fmt.Sprint(fa.FileSize), fmt.Sprint(fb.FileSize))
+		details = appendDetail(details, "type", fa.FileType, fb.FileType)
+		return appendDetail(details, "modified", formatCompareTime(fa.FileModified), formatCompareTime(fb.FileModified))
+	}
+	return compareInventories(a, b, key, change, details)
+}
+
+// compareInventories returns the changes of two inventories sorted by key.
+// An object is changed if details returns differences.
+func compareInventories[T any](a []T, b []T, key func(T) string, change func(T) Change, details func(T, T) []string) []Change {
+	inA := make(map[string]T)
+	for _, item := range a {
+		inA[key(item)] = item
+	}
+	inB := make(map[string]T)
+	for _, item := range b {
+		inB[key(item)] = item
+	}
+
+	keys := make(map[string]bool)
+	for k := range inA {
+		keys[k] = true
+	}
+	for k := range inB {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var changes []Change
+	for _, k := range sorted {
+		itemA, okA := inA[k]
+		itemB, okB := inB[k]
+		switch {
+		case !okA:
+			c := change(itemB)
+			c.Change = ChangeAdded
+			changes = append(changes, c)
+		case !okB:
+			c := change(itemA)
+			c.Change = ChangeRemoved
+			changes = append(changes, c)
+		default:
+			if d := details(itemA, itemB); len(d) > 0 {
+				c := change(itemB)
+				c.Change = ChangeChanged
+				c.Details = d
+				changes = append(changes, c)
+			}
+		}
+	}
+	return changes
+}
+
+// appendDetail appends a changed value as name: first -> second.
+func appendDetail(details []string, name string, a string, b string) []string {
+	if a == b {
+		return details
+	}
+	return append(details, fmt.Sprintf("%s: %s -> %s", name, a, b))
+}
+
+// appendLabelDetails appends the changed labels sorted by label key.
+func appendLabelDetails(details []string, a map[string]string, b map[string]string) []string {
+	keys := make(map[string]bool)
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		details = appendDetail(details, "label "+k, a[k], b[k])
+	}
+	return details
+}
+
+// formatCompareTime returns a compared timestamp, or an empty string for a
+// zero timestamp.
+func formatCompareTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
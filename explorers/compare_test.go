@@ -0,0 +1,125 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/snapshots"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestCompareContainers(t *testing.T) {
+	container := func(id string, status string, labels map[string]string) Container {
+		return Container{
+			Namespace:     "default",
+			ContainerType: "containerd",
+			Status:        status,
+			Container:     containers.Container{ID: id, Image: "app:1.0", Labels: labels},
+		}
+	}
+	a := []Container{
+		container("removed", "RUNNING", nil),
+		container("changed", "RUNNING", map[string]string{"app": "web", "old": "x"}),
+		container("same", "STOPPED", nil),
+	}
+	b := []Container{
+		container("same", "STOPPED", nil),
+		container("changed", "STOPPED", map[string]string{"app": "miner", "old": "x"}),
+		container("added", "RUNNING", nil),
+	}
+
+	expected := []Change{
+		{Object: ComparedContainer, ContainerType: "containerd", Namespace: "default", ID: "added", Change: ChangeAdded},
+		{Object: ComparedContainer, ContainerType: "containerd", Namespace: "default", ID: "changed", Change: ChangeChanged,
+			Details: []string{"status: RUNNING -> STOPPED", "label app: web -> miner"}},
+		{Object: ComparedContainer, ContainerType: "containerd", Namespace: "default", ID: "removed", Change: ChangeRemoved},
+	}
+	if changes := CompareContainers(a, b); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %+v, got %+v", expected, changes)
+	}
+	if changes := CompareContainers(a, a); len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+}
+
+func TestCompareImages(t *testing.T) {
+	image := func(name string, dgst string) Image {
+		return Image{
+			Namespace:     "default",
+			ContainerType: "containerd",
+			Image:         images.Image{Name: name, Target: ocispec.Descriptor{Digest: digest.Digest("sha256:" + digestFor(dgst))}},
+		}
+	}
+	a := []Image{image("app:latest", "a"), image("old:1.0", "o")}
+	b := []Image{image("app:latest", "b"), image("new:1.0", "n")}
+
+	changes := CompareImages(a, b)
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", changes)
+	}
+	if changes[0].ID != "app:latest" || changes[0].Change != ChangeChanged ||
+		!reflect.DeepEqual(changes[0].Details, []string{"target: sha256:" + digestFor("a") + " -> sha256:" + digestFor("b")}) {
+		t.Errorf("expected moved tag, got %+v", changes[0])
+	}
+	if changes[1].ID != "new:1.0" || changes[1].Change != ChangeAdded || changes[2].ID != "old:1.0" || changes[2].Change != ChangeRemoved {
+		t.Errorf("expected added and removed images, got %+v", changes)
+	}
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	a := []SnapshotKeyInfo{{ContainerType: "containerd", Namespace: "default", Snapshotter: "overlayfs", Key: "c1", Kind: snapshots.KindActive, Parent: "l1"}}
+	b := []SnapshotKeyInfo{{ContainerType: "containerd", Namespace: "default", Snapshotter: "overlayfs", Key: "c1", Kind: snapshots.KindCommitted, Parent: "l1"}}
+
+	changes := CompareSnapshots(a, b)
+	if len(changes) != 1 || changes[0].Object != ComparedSnapshot || changes[0].Change != ChangeChanged ||
+		!reflect.DeepEqual(changes[0].Details, []string{"kind: Active -> Committed"}) {
+		t.Errorf("unexpected snapshot changes %+v", changes)
+	}
+}
+
+func TestCompareFiles(t *testing.T) {
+	container := Container{ContainerType: "docker", Container: containers.Container{ID: "c1"}}
+	a := []FileInfo{
+		{FullPath: "/etc/passwd", FileSize: 10, FileSHA256: "aa"},
+		{FullPath: "/tmp/old", FileSize: 1, FileSHA256: "bb"},
+	}
+	b := []FileInfo{
+		{FullPath: "/etc/passwd", FileSize: 12, FileSHA256: "cc"},
+		{FullPath: "/tmp/.x/kworker", FileSize: 5, FileSHA256: "dd", FileType: "executable"},
+	}
+
+	expected := []Change{
+		{Object: ComparedFile, ContainerType: "docker", ID: "/etc/passwd", ContainerID: "c1", Change: ChangeChanged,
+			Details: []string{"sha256: aa -> cc", "size: 10 -> 12"}},
+		{Object: ComparedFile, ContainerType: "docker", ID: "/tmp/.x/kworker", ContainerID: "c1", Change: ChangeAdded},
+		{Object: ComparedFile, ContainerType: "docker", ID: "/tmp/old", ContainerID: "c1", Change: ChangeRemoved},
+	}
+	if changes := CompareFiles(container, a, b); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %+v, got %+v", expected, changes)
+	}
+}
+
+// digestFor returns a fake hex digest repeating a character.
+func digestFor(c string) string {
+	return strings.Repeat(c, 64)
+}
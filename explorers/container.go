@@ -17,6 +17,8 @@ limitations under the License.
 package explorers
 
 import (
+	"time"
+
 	"github.com/containerd/containerd/containers"
 )
 
@@ -52,8 +54,23 @@ type Container struct {
 	// docker specific fields
 	Running      bool
 	ExposedPorts []string
+
+	// container state fields, docker and podman only
+	StartedAt  time.Time // last start time
+	FinishedAt time.Time // last exit time
+	OOMKilled  bool      // the container was killed when it ran out of memory
+
+	// recovered container fields
+	Recovered     string // one of the Recovered values for a container recovered from database pages
+	RecoveredFrom string // database file, page, and offset of the recovered record
 }
 
+// Recovered values of a container recovered from database pages.
+const (
+	RecoveredDeleted         = "DELETED"          // container is not in the database
+	RecoveredPreviousVersion = "PREVIOUS_VERSION" // previous version of a container in the database
+)
+
 // Drift provides information about container drift.
 type Drift struct {
 	ContainerID       string
@@ -21,15 +21,16 @@ import (
 )
 
 var (
-	bucketKeyVersion         = []byte("v1")
-	bucketKeyObjectSnapshots = []byte("snapshots") // stores snapshot references
-	bucketKeyObjectContent   = []byte("content")   // stores content references
-	bucketKeyObjectBlob      = []byte("blob")      // stores content links
-	bucketKeySize            = []byte("size")
-	bucketKeyName            = []byte("name")
-	bucketKeyParent          = []byte("parent")
-	bucketKeyKind            = []byte("kind")
-	bucketKeyID              = []byte("id")
+	bucketKeyVersion          = []byte("v1")
+	bucketKeyObjectSnapshots  = []byte("snapshots")  // stores snapshot references
+	bucketKeyObjectContainers = []byte("containers") // stores container objects
+	bucketKeyObjectContent    = []byte("content")    // stores content references
+	bucketKeyObjectBlob       = []byte("blob")       // stores content links
+	bucketKeySize             = []byte("size")
+	bucketKeyName             = []byte("name")
+	bucketKeyParent           = []byte("parent")
+	bucketKeyKind             = []byte("kind")
+	bucketKeyID               = []byte("id")
 )
 
 func getBucket(tx *bolt.Tx, keys ...[]byte) *bolt.Bucket {
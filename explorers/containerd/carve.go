@@ -0,0 +1,644 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package containerd
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/snapshots"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/google/container-explorer/explorers"
+	log "github.com/sirupsen/logrus"
+)
+
+// bbolt page layout.
+const (
+	boltMagic            = 0xED0CDAED
+	boltPageHeaderSize   = 16 // page ID, flags, count, and overflow
+	boltElementSize      = 16 // leaf and branch page element
+	boltBucketHeaderSize = 16 // bucket root page ID and sequence
+	boltMaxDepth         = 64 // deepest bucket nesting followed
+
+	boltBranchPage   = 0x01
+	boltLeafPage     = 0x02
+	boltFreelistPage = 0x10
+	boltBucketLeaf   = 0x01
+)
+
+// boltMeta is the meta page of a bbolt database.
+type boltMeta struct {
+	pageSize int
+	root     uint64 // root bucket page ID
+	freelist uint64 // freelist page ID
+	txid     uint64
+}
+
+// boltPage is the header of a bbolt page.
+type boltPage struct {
+	id       uint64
+	flags    uint16
+	count    uint16
+	overflow uint32
+}
+
+// boltLeaf is the decoded content of a leaf page or of an inline bucket.
+type boltLeaf struct {
+	page    uint64               // ID of the page containing the leaf
+	offset  int64                // file offset of the leaf
+	values  map[string][]byte    // key value pairs
+	buckets map[string]*boltLeaf // inline buckets
+	roots   map[string]uint64    // root page IDs of the other buckets
+}
+
+// boltRef is a reference to a page from a bucket element or branch page.
+type boltRef struct {
+	parent uint64 // ID of the referencing page
+	key    string // bucket name, or empty for the page of a branch
+}
+
+// boltCarver recovers the containerd records of the pages of a bbolt
+// database, including the pages freed by deleting or updating records.
+type boltCarver struct {
+	source    string
+	data      []byte
+	meta      boltMeta
+	states    map[uint64]string  // page state by page ID
+	liveRefs  map[uint64]boltRef // references of the live pages
+	staleRefs map[uint64]boltRef // references of the freed pages
+}
+
+// CarveBoltFile returns the container, image, and snapshot records found in
+// the pages of a containerd meta.db or snapshotter metadata.db file.
+//
+// Every page is decoded, not only the pages of the live database tree, so
+// that the records of deleted containers, images, and snapshots, and the
+// previous versions of updated records are recovered from freed pages.
+// Unless includeLive is set, the records of live pages are not returned.
+func CarveBoltFile(path string, includeLive bool) ([]explorers.CarvedRecord, error) {
+	//nolint:gosec // G304: Path is provided by the user
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading bolt file: %w", err)
+	}
+
+	meta, err := readBoltMeta(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	c := &boltCarver{
+		source:    path,
+		data:      data,
+		meta:      meta,
+		states:    make(map[uint64]string),
+		liveRefs:  make(map[uint64]boltRef),
+		staleRefs: make(map[uint64]boltRef),
+	}
+	c.markLive(meta.root, 0)
+	c.markFree()
+	c.readRefs()
+
+	records := c.carve()
+
+	// A record is live if a record of the same kind and name is on a live
+	// page, e.g. for the previous versions of updated records.
+	live := make(map[string]bool)
+	for _, record := range records {
+		if record.PageState == explorers.PageLive && record.Name != "" {
+			live[carvedRecordKey(record)] = true
+		}
+	}
+
+	var carved []explorers.CarvedRecord
+	for _, record := range records {
+		record.Live = record.Name != "" && live[carvedRecordKey(record)]
+		if record.PageState == explorers.PageLive && !includeLive {
+			continue
+		}
+		carved = append(carved, record)
+	}
+
+	log.WithFields(log.Fields{
+		"path":     path,
+		"pageSize": meta.pageSize,
+		"pages":    c.pageCount(),
+		"records":  len(records),
+	}).Debug("carved bolt file")
+
+	return carved, nil
+}
+
+// readBoltMeta returns the meta page of the latest transaction. The page
+// size is read from the first meta page, or found using the second meta
+// page if the first one is damaged.
+func readBoltMeta(data []byte) (boltMeta, error) {
+	var metas []boltMeta
+	if meta, ok := parseBoltMeta(data); ok {
+		metas = append(metas, meta)
+		if len(data) > meta.pageSize {
+			if meta1, ok := parseBoltMeta(data[meta.pageSize:]); ok && meta1.pageSize == meta.pageSize {
+				metas = append(metas, meta1)
+			}
+		}
+	} else {
+		for pageSize := 512; pageSize <= 65536 && pageSize < len(data); pageSize *= 2 {
+			if meta, ok := parseBoltMeta(data[pageSize:]); ok && meta.pageSize == pageSize {
+				metas = append(metas, meta)
+				break
+			}
+		}
+	}
+	if len(metas) == 0 {
+		return boltMeta{}, fmt.Errorf("not a bolt database")
+	}
+
+	latest := metas[0]
+	for _, meta := range metas[1:] {
+		if meta.txid > latest.txid {
+			latest = meta
+		}
+	}
+	return latest, nil
+}
+
+// parseBoltMeta decodes a meta page.
+func parseBoltMeta(data []byte) (boltMeta, bool) {
+	if len(data) < boltPageHeaderSize+56 {
+		return boltMeta{}, false
+	}
+	m := data[boltPageHeaderSize:]
+	if binary.LittleEndian.Uint32(m[0:4]) != boltMagic {
+		return boltMeta{}, false
+	}
+
+	pageSize := int(binary.LittleEndian.Uint32(m[8:12]))
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return boltMeta{}, false
+	}
+	return boltMeta{
+		pageSize: pageSize,
+		root:     binary.LittleEndian.Uint64(m[16:24]),
+		freelist: binary.LittleEndian.Uint64(m[32:40]),
+		txid:     binary.LittleEndian.Uint64(m[48:56]),
+	}, true
+}
+
+// parseBoltPage decodes a page header.
+func parseBoltPage(data []byte) (boltPage, bool) {
+	if len(data) < boltPageHeaderSize {
+		return boltPage{}, false
+	}
+	return boltPage{
+		id:       binary.LittleEndian.Uint64(data[0:8]),
+		flags:    binary.LittleEndian.Uint16(data[8:10]),
+		count:    binary.LittleEndian.Uint16(data[10:12]),
+		overflow: binary.LittleEndian.Uint32(data[12:16]),
+	}, true
+}
+
+// pageCount returns the number of pages of the file.
+func (c *boltCarver) pageCount() uint64 {
+	return uint64(len(c.data) / c.meta.pageSize)
+}
+
+// page returns the header and data of a page, including its overflow
+// pages. The data is truncated at the end of the file.
+func (c *boltCarver) page(id uint64) (boltPage, []byte, bool) {
+	if id >= c.pageCount() {
+		return boltPage{}, nil, false
+	}
+	start := id * uint64(c.meta.pageSize)
+	p, ok := parseBoltPage(c.data[start:])
+	if !ok || p.id != id {
+		return boltPage{}, nil, false
+	}
+
+	end := start + (uint64(p.overflow)+1)*uint64(c.meta.pageSize)
+	if end > uint64(len(c.data)) {
+		end = uint64(len(c.data))
+	}
+	return p, c.data[start:end], true
+}
+
+// markLive marks the pages of the bucket tree rooted at a page as live.
+func (c *boltCarver) markLive(id uint64, depth int) {
+	if depth > boltMaxDepth || c.states[id] != "" {
+		return
+	}
+	p, data, ok := c.page(id)
+	if !ok {
+		return
+	}
+	for i := uint64(0); i <= uint64(p.overflow); i++ {
+		c.states[id+i] = explorers.PageLive
+	}
+
+	switch {
+	case p.flags&boltBranchPage != 0:
+		for _, child := range boltBranchChildren(data, int(p.count)) {
+			c.markLive(child, depth+1)
+		}
+	case p.flags&boltLeafPage != 0:
+		for _, root := range decodeBoltLeaf(data, int(p.count), id, 0, 0).allRoots() {
+			c.markLive(root, depth+1)
+		}
+	}
+}
+
+// markFree marks the pages of the freelist that are not live as free.
+func (c *boltCarver) markFree() {
+	p, data, ok := c.page(c.meta.freelist)
+	if !ok || p.flags&boltFreelistPage == 0 {
+		return
+	}
+	for i := uint64(0); i <= uint64(p.overflow); i++ {
+		c.states[c.meta.freelist+i] = explorers.PageLive
+	}
+
+	count := uint64(p.count)
+	ids := data[boltPageHeaderSize:]
+	if count == 0xFFFF && len(ids) >= 8 {
+		count = binary.LittleEndian.Uint64(ids)
+		ids = ids[8:]
+	}
+	for i := uint64(0); i < count && (i+1)*8 <= uint64(len(ids)); i++ {
+		id := binary.LittleEndian.Uint64(ids[i*8:])
+		if c.states[id] == "" {
+			c.states[id] = explorers.PageFree
+		}
+	}
+}
+
+// state returns the state of a page.
+func (c *boltCarver) state(id uint64) string {
+	if state := c.states[id]; state != "" {
+		return state
+	}
+	return explorers.PageUnreachable
+}
+
+// readRefs records the pages referenced by the bucket elements and the
+// branch pages of every page. The references of live pages and of freed
+// pages are kept apart, as a freed page can be reused by a live bucket.
+func (c *boltCarver) readRefs() {
+	for id := uint64(2); id < c.pageCount(); id++ {
+		p, data, ok := c.page(id)
+		if !ok {
+			continue
+		}
+		refs := c.staleRefs
+		if c.state(id) == explorers.PageLive {
+			refs = c.liveRefs
+		}
+
+		switch {
+		case p.flags&boltBranchPage != 0:
+			for _, child := range boltBranchChildren(data, int(p.count)) {
+				if _, ok := refs[child]; !ok {
+					refs[child] = boltRef{parent: id}
+				}
+			}
+		case p.flags&boltLeafPage != 0:
+			for key, root := range decodeBoltLeaf(data, int(p.count), id, 0, 0).roots {
+				if _, ok := refs[root]; !ok {
+					refs[root] = boltRef{parent: id, key: key}
+				}
+			}
+		}
+	}
+}
+
+// bucketPath returns the names of the buckets containing a page, the top
+// bucket first. The path is partial if a page referencing the bucket was
+// overwritten.
+func (c *boltCarver) bucketPath(id uint64) []string {
+	refs := c.staleRefs
+	if c.state(id) == explorers.PageLive {
+		refs = c.liveRefs
+	}
+
+	var path []string
+	for depth := 0; depth < boltMaxDepth; depth++ {
+		ref, ok := refs[id]
+		if !ok {
+			break
+		}
+		if ref.key != "" {
+			path = append([]string{ref.key}, path...)
+		}
+		id = ref.parent
+	}
+	return path
+}
+
+// carve returns the records of every leaf page.
+func (c *boltCarver) carve() []explorers.CarvedRecord {
+	var records []explorers.CarvedRecord
+	for id := uint64(2); id < c.pageCount(); id++ {
+		p, data, ok := c.page(id)
+		if !ok || p.flags&boltLeafPage == 0 {
+			continue
+		}
+
+		leaf := decodeBoltLeaf(data, int(p.count), id, int64(id)*int64(c.meta.pageSize), 0)
+		records = append(records, c.leafRecords(leaf, c.bucketPath(id))...)
+	}
+	return records
+}
+
+// leafRecords returns the record of a leaf and of its inline buckets.
+func (c *boltCarver) leafRecords(leaf *boltLeaf, path []string) []explorers.CarvedRecord {
+	if record, ok := c.leafRecord(leaf, path); ok {
+		return []explorers.CarvedRecord{record}
+	}
+
+	var records []explorers.CarvedRecord
+	for key, bucket := range leaf.buckets {
+		records = append(records, c.leafRecords(bucket, append(append([]string{}, path...), key))...)
+	}
+	return records
+}
+
+// leafRecord returns the containerd record of a leaf, if any.
+func (c *boltCarver) leafRecord(leaf *boltLeaf, path []string) (explorers.CarvedRecord, bool) {
+	record := explorers.CarvedRecord{
+		Source:    c.source,
+		Page:      leaf.page,
+		Offset:    leaf.offset,
+		PageState: c.state(leaf.page),
+		Bucket:    strings.Join(path, "/"),
+		Fields:    make(map[string]string),
+	}
+	if len(path) > 0 {
+		record.Name = path[len(path)-1]
+	}
+	// meta.db buckets are v1/<namespace>/<object type>/...
+	if len(path) > 2 && path[0] == string(bucketKeyVersion) && path[1] != string(bucketKeyObjectSnapshots) {
+		record.Namespace = path[1]
+	}
+
+	// Sandboxes are stored like containers, in the sandboxes bucket.
+	var parent string
+	if len(path) > 1 {
+		parent = path[len(path)-2]
+	}
+
+	values := leaf.values
+	switch {
+	case (parent == "" || parent == string(bucketKeyObjectContainers)) &&
+		(values["snapshotKey"] != nil || values["spec"] != nil || (leaf.buckets["runtime"] != nil && values["createdat"] != nil)):
+		record.Kind = explorers.CarvedContainer
+		setField(record.Fields, "image", values["image"])
+		setField(record.Fields, "snapshotter", values["snapshotter"])
+		setField(record.Fields, "snapshot_key", values["snapshotKey"])
+		setField(record.Fields, "sandbox_id", values["sandboxid"])
+		if runtime := leaf.buckets["runtime"]; runtime != nil {
+			setField(record.Fields, "runtime", runtime.values["name"])
+		}
+		readCarvedSpec(record.Fields, values["spec"])
+	case leaf.buckets["target"] != nil:
+		record.Kind = explorers.CarvedImage
+		target := leaf.buckets["target"]
+		setField(record.Fields, "target_digest", target.values["digest"])
+		setField(record.Fields, "target_media_type", target.values["mediatype"])
+		if size, n := binary.Varint(target.values["size"]); n > 0 {
+			record.Fields["target_size"] = strconv.FormatInt(size, 10)
+		}
+	case values["id"] != nil && values["kind"] != nil:
+		// snapshotter metadata.db snapshot
+		record.Kind = explorers.CarvedSnapshot
+		if id, n := binary.Uvarint(values["id"]); n > 0 {
+			record.Fields["id"] = strconv.FormatUint(id, 10)
+		}
+		if kind := values["kind"]; len(kind) == 1 {
+			record.Fields["kind"] = snapshots.Kind(kind[0]).String()
+		}
+		setField(record.Fields, "parent", values["parent"])
+		if size, n := binary.Varint(values["size"]); n > 0 {
+			record.Fields["size"] = strconv.FormatInt(size, 10)
+		}
+	case values["name"] != nil && values["createdat"] != nil:
+		// meta.db snapshot
+		record.Kind = explorers.CarvedSnapshot
+		setField(record.Fields, "name", values["name"])
+		setField(record.Fields, "parent", values["parent"])
+	default:
+		return explorers.CarvedRecord{}, false
+	}
+
+	setTimeField(record.Fields, "created_at", values["createdat"])
+	setTimeField(record.Fields, "updated_at", values["updatedat"])
+	if labels := leaf.buckets["labels"]; labels != nil && len(labels.values) > 0 {
+		record.Labels = make(map[string]string)
+		for k, v := range labels.values {
+			record.Labels[k] = string(v)
+		}
+	}
+	return record, true
+}
+
+// allRoots returns the root page IDs of the buckets of a leaf and of its
+// inline buckets.
+func (l *boltLeaf) allRoots() []uint64 {
+	var roots []uint64
+	for _, root := range l.roots {
+		roots = append(roots, root)
+	}
+	for _, bucket := range l.buckets {
+		roots = append(roots, bucket.allRoots()...)
+	}
+	return roots
+}
+
+// decodeBoltLeaf decodes the elements of a leaf page or of an inline bucket
+// page. Elements out of the page bounds, e.g. of a partially overwritten
+// page, are skipped.
+func decodeBoltLeaf(data []byte, count int, page uint64, offset int64, depth int) *boltLeaf {
+	leaf := &boltLeaf{
+		page:    page,
+		offset:  offset,
+		values:  make(map[string][]byte),
+		buckets: make(map[string]*boltLeaf),
+		roots:   make(map[string]uint64),
+	}
+
+	for i := 0; i < count; i++ {
+		addr := boltPageHeaderSize + i*boltElementSize
+		if addr+boltElementSize > len(data) {
+			break
+		}
+		flags := binary.LittleEndian.Uint32(data[addr:])
+		pos := uint64(binary.LittleEndian.Uint32(data[addr+4:]))
+		ksize := uint64(binary.LittleEndian.Uint32(data[addr+8:]))
+		vsize := uint64(binary.LittleEndian.Uint32(data[addr+12:]))
+
+		start := uint64(addr) + pos
+		if ksize == 0 || start+ksize+vsize > uint64(len(data)) {
+			continue
+		}
+		key := string(data[start : start+ksize])
+		value := data[start+ksize : start+ksize+vsize]
+
+		if flags&boltBucketLeaf == 0 {
+			leaf.values[key] = value
+			continue
+		}
+		if len(value) < boltBucketHeaderSize {
+			continue
+		}
+
+		// A bucket with a zero root page ID is stored inline, after the
+		// bucket header.
+		root := binary.LittleEndian.Uint64(value)
+		if root != 0 {
+			leaf.roots[key] = root
+			continue
+		}
+		inline := value[boltBucketHeaderSize:]
+		if p, ok := parseBoltPage(inline); ok && p.flags&boltLeafPage != 0 && depth < boltMaxDepth {
+			inlineOffset := offset + int64(start+ksize) + boltBucketHeaderSize
+			leaf.buckets[key] = decodeBoltLeaf(inline, int(p.count), page, inlineOffset, depth+1)
+		}
+	}
+	return leaf
+}
+
+// boltBranchChildren returns the child page IDs of a branch page.
+func boltBranchChildren(data []byte, count int) []uint64 {
+	var children []uint64
+	for i := 0; i < count; i++ {
+		addr := boltPageHeaderSize + i*boltElementSize
+		if addr+boltElementSize > len(data) {
+			break
+		}
+		children = append(children, binary.LittleEndian.Uint64(data[addr+8:]))
+	}
+	return children
+}
+
+// readCarvedSpec adds the process and hostname of a container spec, a
+// protobuf Any containing the OCI runtime spec as JSON.
+func readCarvedSpec(fields map[string]string, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	var pbany types.Any
+	if err := proto.Unmarshal(data, &pbany); err != nil {
+		return
+	}
+	var spec struct {
+		Hostname string `json:"hostname"`
+		Process  *struct {
+			Args []string `json:"args"`
+			Cwd  string   `json:"cwd"`
+		} `json:"process"`
+	}
+	if err := json.Unmarshal(pbany.Value, &spec); err != nil {
+		return
+	}
+
+	if spec.Hostname != "" {
+		fields["hostname"] = spec.Hostname
+	}
+	if spec.Process != nil {
+		if len(spec.Process.Args) > 0 {
+			fields["args"] = strings.Join(spec.Process.Args, " ")
+		}
+		if spec.Process.Cwd != "" {
+			fields["cwd"] = spec.Process.Cwd
+		}
+	}
+}
+
+// setField adds a non-empty string field.
+func setField(fields map[string]string, name string, value []byte) {
+	if len(value) > 0 {
+		fields[name] = string(value)
+	}
+}
+
+// setTimeField adds a binary encoded timestamp field.
+func setTimeField(fields map[string]string, name string, value []byte) {
+	var t time.Time
+	if len(value) > 0 && t.UnmarshalBinary(value) == nil {
+		fields[name] = t.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// carvedRecordKey returns the key identifying the records of an object.
+// The namespace is not used, as the bucket path of a freed page can be
+// partial.
+func carvedRecordKey(record explorers.CarvedRecord) string {
+	return record.Kind + "/" + record.Name
+}
+
+// ListDeletedContainers returns the deleted containers and the previous
+// versions of updated containers carved from the pages of meta.db.
+func (e *explorer) ListDeletedContainers(_ context.Context) ([]explorers.Container, error) {
+	records, err := CarveBoltFile(e.manifestFile, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var recovered []explorers.Container
+	for _, record := range records {
+		if record.Kind != explorers.CarvedContainer || record.Name == "" {
+			continue
+		}
+
+		ceCtr := explorers.Container{
+			Namespace:     record.Namespace,
+			Hostname:      record.Fields["hostname"],
+			ContainerType: "containerd",
+			Container: containers.Container{
+				ID:          record.Name,
+				Labels:      record.Labels,
+				Image:       record.Fields["image"],
+				Runtime:     containers.RuntimeInfo{Name: record.Fields["runtime"]},
+				Snapshotter: record.Fields["snapshotter"],
+				SnapshotKey: record.Fields["snapshot_key"],
+				SandboxID:   record.Fields["sandbox_id"],
+			},
+			Recovered:     explorers.RecoveredDeleted,
+			RecoveredFrom: fmt.Sprintf("%s page %d offset %d", filepath.Base(record.Source), record.Page, record.Offset),
+		}
+		if record.Live {
+			ceCtr.Recovered = explorers.RecoveredPreviousVersion
+		}
+		ceCtr.CreatedAt, _ = time.Parse(time.RFC3339Nano, record.Fields["created_at"])
+		ceCtr.UpdatedAt, _ = time.Parse(time.RFC3339Nano, record.Fields["updated_at"])
+		if value, ok := record.Labels["io.kubernetes.pod.name"]; ok {
+			ceCtr.Hostname = value
+		}
+		ceCtr.ImageBase = imageBasename(ceCtr.Image)
+		ceCtr.SupportContainer = e.sc.IsSupportContainer(ceCtr)
+
+		recovered = append(recovered, ceCtr)
+	}
+	return recovered, nil
+}
@@ -50,6 +50,22 @@ type explorer struct {
 	sc             *explorers.SupportContainer // support container structure object
 }
 
+func init() {
+	explorers.Register(explorers.Registration{
+		Name:     "containerd",
+		Priority: 30,
+		New: func(config explorers.Config) (explorers.ContainerExplorer, error) {
+			return NewExplorer(config.ImageRoot, config.ContainerdRoot, config.DockerRoot, config.Options["layer-cache"], config.SupportContainers)
+		},
+		Detect: func(config explorers.Config) bool {
+			return config.ContainerdRoot != "" && utils.PathExistsV2(filepath.Join(config.ContainerdRoot, "io.containerd.metadata.v1.bolt", "meta.db"))
+		},
+		Options: []explorers.Option{
+			{Name: "layer-cache", Usage: "cached layer folder within the snapshot root, used where layers are symlinks"},
+		},
+	})
+}
+
 // NewExplorer returns a ContainerExplorer interface to explore containerd.
 func NewExplorer(imageRoot string, containerdRoot string, dockerRoot string, layercache string, sc *explorers.SupportContainer) (explorers.ContainerExplorer, error) {
 	opt := &bolt.Options{
@@ -604,6 +620,12 @@ func (e *explorer) resolveSnapshotter(ctx context.Context, container *containers
 
 // MountContainer mounts a container to the specified path
 func (e *explorer) MountContainer(ctx context.Context, containerID string, mountpoint string) error {
+	return e.mountContainer(ctx, containerID, mountpoint, e.snapshotFile)
+}
+
+// mountContainer mounts a container using the snapshot database file, or the
+// database of the container snapshotter if the file is empty.
+func (e *explorer) mountContainer(ctx context.Context, containerID string, mountpoint string, snapshotFile string) error {
 	container, ns, err := e.getContainerStoreInfo(ctx, containerID)
 	if err != nil {
 		return fmt.Errorf("failed getting container information %v", err)
@@ -620,10 +642,10 @@ func (e *explorer) MountContainer(ctx context.Context, containerID string, mount
 		ReadOnly: true,
 	}
 
-	if e.snapshotFile == "" {
+	if snapshotFile == "" {
 		snapshotterFolder := e.SnapshotRoot(container.Snapshotter)
 		if snapshotterFolder != "unknown" {
-			e.snapshotFile = filepath.Join(snapshotterFolder, "metadata.db")
+			snapshotFile = filepath.Join(snapshotterFolder, "metadata.db")
 		}
 	}
 
@@ -633,19 +655,21 @@ func (e *explorer) MountContainer(ctx context.Context, containerID string, mount
 		"snapshotter":     container.Snapshotter,
 		"snapshotKey":     container.SnapshotKey,
 		"image":           container.Image,
-		"snapshotterFile": e.snapshotFile,
+		"snapshotterFile": snapshotFile,
 	}).Debug("containerd container snapshotter")
 
-	ssDB, err := bolt.Open(e.snapshotFile, 0444, &opts)
+	ssDB, err := bolt.Open(snapshotFile, 0444, &opts)
 	if err != nil {
 		return fmt.Errorf("failed opening %s snapshot database %v", container.Snapshotter, err)
 	}
+	defer ssDB.Close()
 
 	// snapshot store
 	ssStore := newSnapshotStore(e.containerdRoot, e.layercache, e.mdb, ssDB)
 	var mountArgs []string
+	record := utils.MountRecord{Mountpoint: mountpoint, ContainerID: containerID}
 	hasWorkDir := false
-	snapshotRoot, _ := filepath.Split(e.snapshotFile)
+	snapshotRoot, _ := filepath.Split(snapshotFile)
 	matches, _ := filepath.Glob(filepath.Join(snapshotRoot, "snapshots/*/work"))
 	if len(matches) > 0 {
 		hasWorkDir = true
@@ -659,6 +683,8 @@ func (e *explorer) MountContainer(ctx context.Context, containerID string, mount
 			return fmt.Errorf("failed to get native path %v", err)
 		}
 		mountArgs = []string{"-t", "bind", upperdir, mountpoint, "-o", "rbind,ro"}
+		record.Type = utils.MountTypeBind
+		record.Source = upperdir
 	} else if hasWorkDir {
 		lowerdir, upperdir, workdir, err := ssStore.OverlayPath(ctx, container)
 		log.WithFields(log.Fields{
@@ -678,13 +704,15 @@ func (e *explorer) MountContainer(ctx context.Context, containerID string, mount
 		// a container
 		mountopts := fmt.Sprintf("ro,lowerdir=%s:%s", upperdir, lowerdir)
 		mountArgs = []string{"-t", "overlay", "overlay", "-o", mountopts, mountpoint}
+		record.Type = utils.MountTypeOverlay
+		record.Source = upperdir
 	} else {
 		log.Errorf("unsupported snapshotter: %s", container.Snapshotter)
 	}
 
 	log.Debug("container mount command ", mountArgs)
 
-	out, err := utils.Runner.RunWithoutContext("mount", mountArgs...)
+	out, err := utils.Mount(ctx, record, mountArgs...)
 	if err != nil {
 		log.Errorf("running mount command: %v", err)
 
@@ -710,6 +738,8 @@ func (e *explorer) MountAllContainers(ctx context.Context, mountpoint string, fi
 
 	filters := strings.Split(filter, ",")
 
+	var targets []explorers.Container
+	var ids []string
 	for _, ctr := range ctrs {
 		// Skip Docker-managed containers to avoid double mounting (as they will be mounted by the Docker explorer)
 		if ctr.Namespace == "moby" {
@@ -752,6 +782,13 @@ func (e *explorer) MountAllContainers(ctx context.Context, mountpoint string, fi
 			continue
 		}
 
+		targets = append(targets, ctr)
+		ids = append(ids, ctr.ID)
+	}
+
+	return explorers.RunContainerJobs(ctx, e.Type(), "mount", ids, func(i int) error {
+		ctr := targets[i]
+
 		// Create a subdirectory within the specified mountpoint
 		ctrmountpoint := filepath.Join(mountpoint, ctr.ID)
 		if err := os.MkdirAll(ctrmountpoint, 0755); err != nil {
@@ -762,31 +799,35 @@ func (e *explorer) MountAllContainers(ctx context.Context, mountpoint string, fi
 			}).Error("creating mount point for a container")
 
 			log.WithField("containerID", ctr.ID).Warn("skipping container mount")
-			continue
+			return err
 		}
 
-		// Clear snapshot database for each container
-		e.snapshotFile = ""
-		ctx = namespaces.WithNamespace(ctx, ctr.Namespace)
-		if err := e.MountContainer(ctx, ctr.ID, ctrmountpoint); err != nil {
+		// Use the snapshot database of each container snapshotter
+		if err := e.mountContainer(namespaces.WithNamespace(ctx, ctr.Namespace), ctr.ID, ctrmountpoint, ""); err != nil {
+			log.WithFields(log.Fields{"containerID": ctr.ID, "error": err}).Warn("skipping container mount")
 			return err
 		}
-	}
-
-	// default
-	return nil
+		return nil
+	})
 }
 
 // ContainerDrift finds drifted files from all the containers
 func (e *explorer) ContainerDrift(ctx context.Context, filter string, skipsupportcontainers bool, containerID string) ([]explorers.Drift, error) {
-	var drifts []explorers.Drift
+	return explorers.CollectDrift(ctx, e, filter, skipsupportcontainers, containerID)
+}
+
+// StreamContainerDrift finds drifted files from all the containers, and
+// calls fn with the drift of each container as it completes.
+func (e *explorer) StreamContainerDrift(ctx context.Context, filter string, skipsupportcontainers bool, containerID string, fn func(explorers.Drift) error) error {
 	ctrs, err := e.ListContainers(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	filters := strings.Split(filter, ",")
 
+	var targets []explorers.Container
+	var ids []string
 	for _, ctr := range ctrs {
 		// Temporary fix to remove duplicate errors for Docker engine 29 containers
 		// TODO: Build support for Docker engine 29.
@@ -835,109 +876,123 @@ func (e *explorer) ContainerDrift(ctx context.Context, filter string, skipsuppor
 			continue
 		}
 
-		e.snapshotFile = ""
-		ctx = namespaces.WithNamespace(ctx, ctr.Namespace)
-		store := metadata.NewContainerStore(metadata.NewDB(e.mdb, nil, nil))
+		targets = append(targets, ctr)
+		ids = append(ids, ctr.ID)
+	}
 
-		container, err := store.Get(ctx, ctr.ID)
-		if err != nil {
-			log.WithFields(log.Fields{"containerID": ctr.ID, "error": err}).Error("getting container information")
-			continue
-		}
-		if err := e.resolveSnapshotter(ctx, &container); err != nil {
-			return nil, fmt.Errorf("failed to resolve snapshotter: %w", err)
-		}
-		// Snapshot database metadata.db access
-		opts := bolt.Options{
-			ReadOnly: true,
-		}
-		if e.snapshotFile == "" {
-			snapshotterFolder := e.SnapshotRoot(container.Snapshotter)
-			if snapshotterFolder != "unknown" {
-				e.snapshotFile = filepath.Join(snapshotterFolder, "metadata.db")
-			}
+	err = explorers.StreamContainerJobs(ctx, e.Type(), "drift", ids, func(i int) (*explorers.Drift, error) {
+		return e.containerDrift(namespaces.WithNamespace(ctx, targets[i].Namespace), targets[i])
+	}, func(drift *explorers.Drift) error {
+		// Containers without drift detection have no drift.
+		if drift == nil {
+			return nil
 		}
+		return fn(*drift)
+	})
+	return err
+}
+
+// containerDrift finds the drifted files of a container.
+func (e *explorer) containerDrift(ctx context.Context, ctr explorers.Container) (*explorers.Drift, error) {
+	store := metadata.NewContainerStore(metadata.NewDB(e.mdb, nil, nil))
+
+	container, err := store.Get(ctx, ctr.ID)
+	if err != nil {
+		log.WithFields(log.Fields{"containerID": ctr.ID, "error": err}).Error("getting container information")
+		return nil, err
+	}
+	if err := e.resolveSnapshotter(ctx, &container); err != nil {
+		return nil, fmt.Errorf("failed to resolve snapshotter: %w", err)
+	}
+	// Snapshot database metadata.db access
+	opts := bolt.Options{
+		ReadOnly: true,
+	}
+	var snapshotFile string
+	snapshotterFolder := e.SnapshotRoot(container.Snapshotter)
+	if snapshotterFolder != "unknown" {
+		snapshotFile = filepath.Join(snapshotterFolder, "metadata.db")
+	}
+	log.WithFields(log.Fields{
+		"snapshotter":       container.Snapshotter,
+		"snapshotKey":       container.SnapshotKey,
+		"image":             container.Image,
+		"snapshotterFolder": snapshotFile,
+	}).Debug("container snapshotter")
+	ssdb, err := bolt.Open(snapshotFile, 0444, &opts)
+	if err != nil {
+		log.WithFields(log.Fields{"containerID": ctr.ID, "error": err}).Error("failed to open snapshot database")
+		return nil, err
+	}
+	defer ssdb.Close()
+
+	// snapshot store
+	ssstore := newSnapshotStore(e.containerdRoot, e.layercache, e.mdb, ssdb)
+	hasWorkDir := false
+	snapshotRoot, _ := filepath.Split(snapshotFile)
+	matches, _ := filepath.Glob(filepath.Join(snapshotRoot, "snapshots/*/work"))
+	if len(matches) > 0 {
+		hasWorkDir = true
+	}
+	if container.Snapshotter == "native" {
+		upperdir, err := ssstore.NativePath(ctx, container)
 		log.WithFields(log.Fields{
-			"snapshotter":       container.Snapshotter,
-			"snapshotKey":       container.SnapshotKey,
-			"image":             container.Image,
-			"snapshotterFolder": e.snapshotFile,
-		}).Debug("container snapshotter")
-		ssdb, err := bolt.Open(e.snapshotFile, 0444, &opts)
+			"upperdir": upperdir,
+		}).Debug("native directories")
 		if err != nil {
-			log.WithFields(log.Fields{"containerID": ctr.ID, "error": err}).Error("failed to open snapshot database")
-			continue
-		}
-		// snapshot store
-		ssstore := newSnapshotStore(e.containerdRoot, e.layercache, e.mdb, ssdb)
-		hasWorkDir := false
-		snapshotRoot, _ := filepath.Split(e.snapshotFile)
-		matches, _ := filepath.Glob(filepath.Join(snapshotRoot, "snapshots/*/work"))
-		if len(matches) > 0 {
-			hasWorkDir = true
+			log.WithFields(log.Fields{"containerID": ctr.ID, "error": err}).Error("failed to get native path")
+			return nil, err
 		}
-		if container.Snapshotter == "native" {
-			upperdir, err := ssstore.NativePath(ctx, container)
-			log.WithFields(log.Fields{
-				"upperdir": upperdir,
-			}).Debug("native directories")
-			if err != nil {
-				log.WithFields(log.Fields{"containerID": ctr.ID, "error": err}).Error("failed to get native path")
-				continue
-			}
-		} else if hasWorkDir {
-			lowerdir, upperdir, workdir, err := ssstore.OverlayPath(ctx, container)
-			log.WithFields(log.Fields{
-				"lowerdir": lowerdir,
-				"upperdir": upperdir,
-				"workdir":  workdir,
-			}).Debug("overlay directories")
-
-			log.WithFields(log.Fields{
-				"containerID": ctr.ID,
-			}).Debug("checking container drift")
-			if err != nil {
-				log.WithFields(log.Fields{"containerID": ctr.ID, "error": err}).Error("failed to get overlay path")
-				continue
-			}
-			if lowerdir == "" {
-				log.WithFields(log.Fields{"containerID": ctr.ID}).Error("lowerdir is empty")
-				continue
-			}
+		return nil, nil
+	}
+	if !hasWorkDir {
+		log.Error("unsupported snapshotter ", container.Snapshotter)
+		return nil, &explorers.UnsupportedError{ContainerType: e.Type(), Capability: container.Snapshotter + " snapshotter"}
+	}
 
-			// Scan upperdir
-			addedOrModified, inaccessibleFiles, err := explorers.ScanDiffDirectory(upperdir)
-			if err != nil {
-				log.WithFields(log.Fields{"containerID": ctr.ID, "error": err}).Error("failed to scan diff directory")
-				continue
-			}
+	lowerdir, upperdir, workdir, err := ssstore.OverlayPath(ctx, container)
+	log.WithFields(log.Fields{
+		"lowerdir": lowerdir,
+		"upperdir": upperdir,
+		"workdir":  workdir,
+	}).Debug("overlay directories")
 
-			drift := explorers.Drift{
-				ContainerID:       ctr.ID,
-				ContainerType:     ctr.ContainerType,
-				AddedOrModified:   addedOrModified,
-				InaccessibleFiles: inaccessibleFiles,
-			}
+	log.WithFields(log.Fields{
+		"containerID": ctr.ID,
+	}).Debug("checking container drift")
+	if err != nil {
+		log.WithFields(log.Fields{"containerID": ctr.ID, "error": err}).Error("failed to get overlay path")
+		return nil, err
+	}
+	if lowerdir == "" {
+		log.WithFields(log.Fields{"containerID": ctr.ID}).Error("lowerdir is empty")
+		return nil, fmt.Errorf("lowerdir is empty: %w", explorers.ErrCorruptMetadata)
+	}
 
-			drifts = append(drifts, drift)
+	// Scan upperdir
+	addedOrModified, inaccessibleFiles, err := explorers.ScanDiffDirectoryContext(ctx, upperdir)
+	if err != nil {
+		log.WithFields(log.Fields{"containerID": ctr.ID, "error": err}).Error("failed to scan diff directory")
+		return nil, err
+	}
 
-			for _, path := range addedOrModified {
-				log.WithFields(log.Fields{
-					"A ": path}).Debug("added or modified files")
-			}
-			if len(inaccessibleFiles) > 0 {
-				for _, path := range inaccessibleFiles {
-					log.WithFields(log.Fields{
-						"D ": path}).Debug("deleted files")
-				}
-			}
-		} else {
-			log.Error("unsupported snapshotter ", container.Snapshotter)
+	for _, path := range addedOrModified {
+		log.WithFields(log.Fields{
+			"A ": path}).Debug("added or modified files")
+	}
+	if len(inaccessibleFiles) > 0 {
+		for _, path := range inaccessibleFiles {
+			log.WithFields(log.Fields{
+				"D ": path}).Debug("deleted files")
 		}
 	}
 
-	// default
-	return drifts, nil
+	return &explorers.Drift{
+		ContainerID:       ctr.ID,
+		ContainerType:     ctr.ContainerType,
+		AddedOrModified:   addedOrModified,
+		InaccessibleFiles: inaccessibleFiles,
+	}, nil
 }
 
 // Close releases the internal resources
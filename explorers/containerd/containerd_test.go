@@ -17,12 +17,16 @@ limitations under the License.
 package containerd
 
 import (
+	"archive/tar"
 	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -30,9 +34,12 @@ import (
 	"github.com/containerd/containerd/images"
 	"github.com/containerd/containerd/metadata"
 	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/platforms"
 	"github.com/gogo/protobuf/types"
 	"github.com/google/container-explorer/explorers"
 	"github.com/google/container-explorer/utils"
+	digest "github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	oci "github.com/opencontainers/runtime-spec/specs-go"
 	bolt "go.etcd.io/bbolt"
@@ -116,7 +123,7 @@ func TestListNamespaces(t *testing.T) {
 	}
 	defer exp.Close()
 
-	nss, err := exp.ListNamespaces(context.Background())
+	nss, err := exp.(explorers.NamespaceLister).ListNamespaces(context.Background())
 	if err != nil {
 		t.Fatalf("ListNamespaces failed: %v", err)
 	}
@@ -348,7 +355,7 @@ func TestListContent(t *testing.T) {
 	}
 	defer exp.Close()
 
-	contents, err := exp.ListContent(context.Background())
+	contents, err := exp.(explorers.ContentLister).ListContent(context.Background())
 	if err != nil {
 		t.Fatalf("ListContent failed: %v", err)
 	}
@@ -495,7 +502,7 @@ func TestListSnapshots(t *testing.T) {
 	}
 	defer exp.Close()
 
-	snaps, err := exp.ListSnapshots(context.Background())
+	snaps, err := exp.(explorers.SnapshotLister).ListSnapshots(context.Background())
 	if err != nil {
 		t.Fatalf("ListSnapshots failed: %v", err)
 	}
@@ -654,7 +661,7 @@ func TestContainerd_DatabaseClosedErrorCases(t *testing.T) {
 
 	ctx := context.Background()
 
-	if _, err := exp.ListNamespaces(ctx); err == nil {
+	if _, err := exp.(explorers.NamespaceLister).ListNamespaces(ctx); err == nil {
 		t.Errorf("ListNamespaces expected error when DB is closed, got nil")
 	}
 
@@ -666,11 +673,11 @@ func TestContainerd_DatabaseClosedErrorCases(t *testing.T) {
 		t.Errorf("ListImages expected error when DB is closed, got nil")
 	}
 
-	if _, err := exp.ListContent(ctx); err == nil {
+	if _, err := exp.(explorers.ContentLister).ListContent(ctx); err == nil {
 		t.Errorf("ListContent expected error when DB is closed, got nil")
 	}
 
-	if _, err := exp.ListSnapshots(ctx); err == nil {
+	if _, err := exp.(explorers.SnapshotLister).ListSnapshots(ctx); err == nil {
 		t.Errorf("ListSnapshots expected error when DB is closed, got nil")
 	}
 }
@@ -764,7 +771,7 @@ func TestListSnapshots_InvalidKind(t *testing.T) {
 	}
 	defer exp.Close()
 
-	snaps, err := exp.ListSnapshots(context.Background())
+	snaps, err := exp.(explorers.SnapshotLister).ListSnapshots(context.Background())
 	if err == nil {
 		t.Errorf("ListSnapshots expected error when snapshot Kind is invalid (>255) in metadata.db, got nil. Snaps: %+v", snaps)
 	}
@@ -913,8 +920,8 @@ func TestExportContainer(t *testing.T) {
 	defer func() { utils.Runner = origRunner }()
 
 	outputDir := filepath.Join(tmpDir, "output")
-	exportOptions := map[string]bool{
-		"archive": true,
+	exportOptions := explorers.ContainerExportOptions{
+		Archive: true,
 	}
 
 	err = exp.ExportContainer(context.Background(), "container-1", outputDir, exportOptions)
@@ -924,7 +931,6 @@ func TestExportContainer(t *testing.T) {
 
 	hasMount := false
 	hasUmount := false
-	hasTar := false
 	for _, c := range mockRunner.Calls {
 		if c.Name == "mount" {
 			hasMount = true
@@ -932,9 +938,6 @@ func TestExportContainer(t *testing.T) {
 		if c.Name == "umount" {
 			hasUmount = true
 		}
-		if c.Name == "tar" {
-			hasTar = true
-		}
 	}
 
 	if !hasMount {
@@ -943,8 +946,8 @@ func TestExportContainer(t *testing.T) {
 	if !hasUmount {
 		t.Errorf("expected 'umount' to be executed")
 	}
-	if !hasTar {
-		t.Errorf("expected 'tar' to be executed")
+	if _, err := os.Stat(filepath.Join(outputDir, "container-1.tar.gz")); err != nil {
+		t.Errorf("expected container archive: %v", err)
 	}
 }
 
@@ -1042,8 +1045,8 @@ func TestExportAllContainers(t *testing.T) {
 	defer func() { utils.Runner = origRunner }()
 
 	outputDir := filepath.Join(tmpDir, "output")
-	exportOptions := map[string]bool{
-		"archive": true,
+	exportOptions := explorers.ContainerExportOptions{
+		Archive: true,
 	}
 
 	err = exp.ExportAllContainers(namespaces.WithNamespace(context.Background(), "ns1"), outputDir, exportOptions, nil, false)
@@ -1052,23 +1055,11 @@ func TestExportAllContainers(t *testing.T) {
 	}
 
 	exportedArchive := filepath.Join(outputDir, "container-1.tar.gz")
-	hasTar := false
-	for _, c := range mockRunner.Calls {
-		if c.Name == "tar" {
-			hasTar = true
-			foundOut := false
-			for _, arg := range c.Args {
-				if arg == exportedArchive {
-					foundOut = true
-				}
-			}
-			if !foundOut {
-				t.Errorf("expected tar command to export to '%s', args were: %v", exportedArchive, c.Args)
-			}
-		}
+	if _, err := os.Stat(exportedArchive); err != nil {
+		t.Errorf("expected archive %s: %v", exportedArchive, err)
 	}
-	if !hasTar {
-		t.Errorf("expected 'tar' to be executed")
+	if _, err := os.Stat(exportedArchive + ".manifest.json"); err != nil {
+		t.Errorf("expected archive manifest: %v", err)
 	}
 }
 
@@ -1619,3 +1610,941 @@ func TestGetContainerState(t *testing.T) {
 		t.Errorf("expected rootless to be true, got false")
 	}
 }
+
+func TestVerifyContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	metaDir := filepath.Join(containerdRoot, "io.containerd.metadata.v1.bolt")
+	blobDir := filepath.Join(containerdRoot, "io.containerd.content.v1.content", "blobs", "sha256")
+	if err := os.MkdirAll(metaDir, 0755); err != nil {
+		t.Fatalf("failed to create meta dir: %v", err)
+	}
+	if err := os.MkdirAll(blobDir, 0755); err != nil {
+		t.Fatalf("failed to create blob dir: %v", err)
+	}
+
+	okData := []byte(`{"schemaVersion":2}`)
+	okDigest := digest.FromBytes(okData)
+	corruptedDigest := digest.FromBytes([]byte("original"))
+	missingDigest := digest.FromBytes([]byte("missing"))
+	orphanData := []byte("orphan")
+	orphanDigest := digest.FromBytes(orphanData)
+
+	blobs := map[digest.Digest][]byte{
+		okDigest:        okData,
+		corruptedDigest: []byte("tampered"),
+		orphanDigest:    orphanData,
+	}
+	for dgst, data := range blobs {
+		if err := os.WriteFile(filepath.Join(blobDir, dgst.Encoded()), data, 0644); err != nil {
+			t.Fatalf("failed to write blob: %v", err)
+		}
+	}
+
+	db, err := bolt.Open(filepath.Join(metaDir, "meta.db"), 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open bolt db: %v", err)
+	}
+
+	now := time.Now().UTC()
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, ns := range []string{"ns1", "ns2"} {
+			if err := metadata.NewNamespaceStore(tx).Create(context.Background(), ns, nil); err != nil {
+				return err
+			}
+			if err := createBlob(tx, ns, okDigest.String(), int64(len(okData)), now, nil); err != nil {
+				return err
+			}
+		}
+		if err := createBlob(tx, "ns1", corruptedDigest.String(), 8, now, nil); err != nil {
+			return err
+		}
+		return createBlob(tx, "ns1", missingDigest.String(), 7, now, nil)
+	})
+	if err != nil {
+		db.Close()
+		t.Fatalf("failed to populate blobs: %v", err)
+	}
+	db.Close()
+
+	sc, _ := explorers.NewSupportContainer("")
+	exp, err := NewExplorer("", containerdRoot, "", "", sc)
+	if err != nil {
+		t.Fatalf("failed to create explorer: %v", err)
+	}
+	defer exp.Close()
+
+	verifications, err := exp.(explorers.ContentLister).VerifyContent(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyContent failed: %v", err)
+	}
+
+	if len(verifications) != 5 {
+		t.Fatalf("expected 5 verifications, got %d: %+v", len(verifications), verifications)
+	}
+
+	statuses := make(map[string]string)
+	for _, v := range verifications {
+		statuses[v.Namespace+"/"+v.Digest.String()] = v.Status
+
+		if v.Digest == orphanDigest && v.ActualDigest != orphanDigest {
+			t.Errorf("expected orphaned blob ActualDigest %s, got %s", orphanDigest, v.ActualDigest)
+		}
+		if v.Digest == corruptedDigest && v.ActualSize != 8 {
+			t.Errorf("expected corrupted blob ActualSize 8, got %d", v.ActualSize)
+		}
+	}
+
+	expected := map[string]string{
+		"ns1/" + okDigest.String():        explorers.ContentStatusOK,
+		"ns2/" + okDigest.String():        explorers.ContentStatusOK,
+		"ns1/" + corruptedDigest.String(): explorers.ContentStatusCorrupted,
+		"ns1/" + missingDigest.String():   explorers.ContentStatusMissing,
+		"/" + orphanDigest.String():       explorers.ContentStatusOrphaned,
+	}
+	for key, status := range expected {
+		if statuses[key] != status {
+			t.Errorf("expected %s status %s, got %q", key, status, statuses[key])
+		}
+	}
+}
+
+func TestReadContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	metaDir := filepath.Join(containerdRoot, "io.containerd.metadata.v1.bolt")
+	blobDir := filepath.Join(containerdRoot, "io.containerd.content.v1.content", "blobs", "sha256")
+	if err := os.MkdirAll(metaDir, 0755); err != nil {
+		t.Fatalf("failed to create meta dir: %v", err)
+	}
+	if err := os.MkdirAll(blobDir, 0755); err != nil {
+		t.Fatalf("failed to create blob dir: %v", err)
+	}
+
+	data := []byte("layer data")
+	dgst := digest.FromBytes(data)
+	if err := os.WriteFile(filepath.Join(blobDir, dgst.Encoded()), data, 0644); err != nil {
+		t.Fatalf("failed to write blob: %v", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(metaDir, "meta.db"), 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open bolt db: %v", err)
+	}
+	db.Close()
+
+	sc, _ := explorers.NewSupportContainer("")
+	exp, err := NewExplorer("", containerdRoot, "", "", sc)
+	if err != nil {
+		t.Fatalf("failed to create explorer: %v", err)
+	}
+	defer exp.Close()
+
+	rc, err := exp.(explorers.ContentLister).ReadContent(context.Background(), dgst)
+	if err != nil {
+		t.Fatalf("ReadContent failed: %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatalf("failed to read content: %v", err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("expected content %q, got %q", data, got)
+	}
+
+	if _, err := exp.(explorers.ContentLister).ReadContent(context.Background(), digest.FromBytes([]byte("absent"))); err == nil {
+		t.Error("expected error reading missing content, got nil")
+	}
+	if _, err := exp.(explorers.ContentLister).ReadContent(context.Background(), digest.Digest("sha256:../../etc/passwd")); err == nil {
+		t.Error("expected error reading invalid digest, got nil")
+	}
+}
+
+// writeContentBlob writes a blob to the containerd content store and returns
+// its descriptor.
+func writeContentBlob(t *testing.T, containerdRoot string, mediaType string, data []byte) ocispec.Descriptor {
+	t.Helper()
+
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+
+	blobDir := filepath.Join(containerdRoot, "io.containerd.content.v1.content", "blobs", "sha256")
+	if err := os.MkdirAll(blobDir, 0755); err != nil {
+		t.Fatalf("failed to create blob dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(blobDir, desc.Digest.Encoded()), data, 0644); err != nil {
+		t.Fatalf("failed to write blob: %v", err)
+	}
+	return desc
+}
+
+func TestExportImage(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	metaDir := filepath.Join(containerdRoot, "io.containerd.metadata.v1.bolt")
+	if err := os.MkdirAll(metaDir, 0755); err != nil {
+		t.Fatalf("failed to create meta dir: %v", err)
+	}
+
+	// Image with an amd64 manifest in the content store and an arm64 manifest
+	// that was not pulled.
+	config := writeContentBlob(t, containerdRoot, ocispec.MediaTypeImageConfig, []byte(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers"}}`))
+	layer := writeContentBlob(t, containerdRoot, ocispec.MediaTypeImageLayerGzip, []byte("layer data"))
+
+	manifestData, _ := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	manifest := writeContentBlob(t, containerdRoot, ocispec.MediaTypeImageManifest, manifestData)
+	manifest.Platform = &ocispec.Platform{OS: "linux", Architecture: "amd64"}
+
+	missingManifest := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("arm64 manifest"),
+		Size:      14,
+		Platform:  &ocispec.Platform{OS: "linux", Architecture: "arm64"},
+	}
+
+	indexData, _ := json.Marshal(ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{manifest, missingManifest},
+	})
+	index := writeContentBlob(t, containerdRoot, ocispec.MediaTypeImageIndex, indexData)
+
+	db, err := bolt.Open(filepath.Join(metaDir, "meta.db"), 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open bolt db: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return metadata.NewNamespaceStore(tx).Create(context.Background(), "ns1", nil)
+	})
+	if err != nil {
+		db.Close()
+		t.Fatalf("failed to populate namespace: %v", err)
+	}
+
+	ctx := namespaces.WithNamespace(context.Background(), "ns1")
+	imgStore := metadata.NewImageStore(metadata.NewDB(db, nil, nil))
+	for _, img := range []images.Image{
+		{Name: "docker.io/library/app:1.0", Target: index},
+		{Name: "docker.io/library/single:latest", Target: manifest},
+	} {
+		if _, err := imgStore.Create(ctx, img); err != nil {
+			db.Close()
+			t.Fatalf("failed to populate image: %v", err)
+		}
+	}
+	db.Close()
+
+	sc, _ := explorers.NewSupportContainer("")
+	exp, err := NewExplorer("", containerdRoot, "", "", sc)
+	if err != nil {
+		t.Fatalf("failed to create explorer: %v", err)
+	}
+	defer exp.Close()
+
+	readIndex := func(t *testing.T, layoutDir string) ocispec.Index {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(layoutDir, "index.json"))
+		if err != nil {
+			t.Fatalf("failed to read index.json: %v", err)
+		}
+		var idx ocispec.Index
+		if err := json.Unmarshal(data, &idx); err != nil {
+			t.Fatalf("failed to unmarshal index.json: %v", err)
+		}
+		return idx
+	}
+
+	t.Run("Partial index", func(t *testing.T) {
+		outputPath := filepath.Join(t.TempDir(), "layout")
+		err := exp.(explorers.ImageExporter).ExportImage(context.Background(), "app:1.0", outputPath, explorers.ImageExportOptions{Layout: true})
+		if err != nil {
+			t.Fatalf("ExportImage failed: %v", err)
+		}
+
+		// index.json references the exported manifest as the index is incomplete
+		idx := readIndex(t, outputPath)
+		if len(idx.Manifests) != 1 || idx.Manifests[0].Digest != manifest.Digest {
+			t.Fatalf("expected index.json to reference manifest %s, got %+v", manifest.Digest, idx.Manifests)
+		}
+		if idx.Manifests[0].Annotations[ocispec.AnnotationRefName] != "1.0" {
+			t.Errorf("expected ref name annotation '1.0', got %q", idx.Manifests[0].Annotations[ocispec.AnnotationRefName])
+		}
+
+		for _, desc := range []ocispec.Descriptor{config, layer, manifest} {
+			if _, err := os.Stat(filepath.Join(outputPath, "blobs", "sha256", desc.Digest.Encoded())); err != nil {
+				t.Errorf("expected blob %s: %v", desc.Digest, err)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(outputPath, "blobs", "sha256", index.Digest.Encoded())); err == nil {
+			t.Error("expected incomplete index blob not to be exported")
+		}
+	})
+
+	t.Run("Platform without manifest", func(t *testing.T) {
+		outputPath := filepath.Join(t.TempDir(), "layout")
+		err := exp.(explorers.ImageExporter).ExportImage(context.Background(), "app:1.0", outputPath, explorers.ImageExportOptions{Layout: true, Platform: "linux/arm64"})
+		if err == nil {
+			t.Fatal("expected error exporting missing platform, got nil")
+		}
+		if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+			t.Errorf("expected output to be removed, got %v", err)
+		}
+	})
+
+	t.Run("Archive", func(t *testing.T) {
+		outputPath := filepath.Join(t.TempDir(), "single.tar")
+		err := exp.(explorers.ImageExporter).ExportImage(context.Background(), "single", outputPath, explorers.ImageExportOptions{})
+		if err != nil {
+			t.Fatalf("ExportImage failed: %v", err)
+		}
+		if _, err := os.Stat(outputPath); err != nil {
+			t.Errorf("expected archive %s: %v", outputPath, err)
+		}
+	})
+
+	t.Run("Corrupted layer", func(t *testing.T) {
+		layerPath := filepath.Join(containerdRoot, "io.containerd.content.v1.content", "blobs", "sha256", layer.Digest.Encoded())
+		if err := os.WriteFile(layerPath, []byte("tampered!!"), 0644); err != nil {
+			t.Fatalf("failed to tamper layer: %v", err)
+		}
+
+		outputPath := filepath.Join(t.TempDir(), "single.tar")
+		err := exp.(explorers.ImageExporter).ExportImage(context.Background(), "single", outputPath, explorers.ImageExportOptions{})
+		if err == nil {
+			t.Fatal("expected error exporting corrupted layer, got nil")
+		}
+		if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+			t.Errorf("expected archive to be removed, got %v", err)
+		}
+	})
+
+	t.Run("Unknown image", func(t *testing.T) {
+		err := exp.(explorers.ImageExporter).ExportImage(context.Background(), "unknown", filepath.Join(t.TempDir(), "out.tar"), explorers.ImageExportOptions{})
+		if err == nil {
+			t.Fatal("expected error exporting unknown image, got nil")
+		}
+	})
+}
+
+func TestExportImage_MultiPlatformTags(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	metaDir := filepath.Join(containerdRoot, "io.containerd.metadata.v1.bolt")
+	if err := os.MkdirAll(metaDir, 0755); err != nil {
+		t.Fatalf("failed to create meta dir: %v", err)
+	}
+
+	// Image with the manifests of another platform and the host platform in
+	// the content store
+	layer := writeContentBlob(t, containerdRoot, ocispec.MediaTypeImageLayerGzip, []byte("layer data"))
+	var manifests []ocispec.Descriptor
+	var configs []ocispec.Descriptor
+	for _, platform := range []ocispec.Platform{{OS: "windows", Architecture: "amd64"}, platforms.DefaultSpec()} {
+		configData, _ := json.Marshal(ocispec.Image{Platform: platform, RootFS: ocispec.RootFS{Type: "layers"}})
+		config := writeContentBlob(t, containerdRoot, ocispec.MediaTypeImageConfig, configData)
+		manifestData, _ := json.Marshal(ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    []ocispec.Descriptor{layer},
+		})
+		manifest := writeContentBlob(t, containerdRoot, ocispec.MediaTypeImageManifest, manifestData)
+		manifest.Platform = &platform
+		manifests = append(manifests, manifest)
+		configs = append(configs, config)
+	}
+	indexData, _ := json.Marshal(ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: manifests,
+	})
+	index := writeContentBlob(t, containerdRoot, ocispec.MediaTypeImageIndex, indexData)
+
+	db, err := bolt.Open(filepath.Join(metaDir, "meta.db"), 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open bolt db: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return metadata.NewNamespaceStore(tx).Create(context.Background(), "ns1", nil)
+	})
+	if err == nil {
+		ctx := namespaces.WithNamespace(context.Background(), "ns1")
+		_, err = metadata.NewImageStore(metadata.NewDB(db, nil, nil)).Create(ctx, images.Image{Name: "docker.io/library/app:1.0", Target: index})
+	}
+	db.Close()
+	if err != nil {
+		t.Fatalf("failed to populate image: %v", err)
+	}
+
+	sc, _ := explorers.NewSupportContainer("")
+	exp, err := NewExplorer("", containerdRoot, "", "", sc)
+	if err != nil {
+		t.Fatalf("failed to create explorer: %v", err)
+	}
+	defer exp.Close()
+
+	// repoTags returns the RepoTags of the manifest.json entries by config.
+	repoTags := func(t *testing.T, options explorers.ImageExportOptions) map[string][]string {
+		t.Helper()
+		outputPath := filepath.Join(t.TempDir(), "app.tar")
+		if err := exp.(explorers.ImageExporter).ExportImage(context.Background(), "app:1.0", outputPath, options); err != nil {
+			t.Fatalf("ExportImage failed: %v", err)
+		}
+		f, err := os.Open(outputPath)
+		if err != nil {
+			t.Fatalf("failed to open archive: %v", err)
+		}
+		defer f.Close()
+
+		var dockerManifests []utils.DockerManifest
+		tr := tar.NewReader(f)
+		for {
+			hdr, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("failed to read archive: %v", err)
+			}
+			if hdr.Name == "manifest.json" {
+				_ = json.NewDecoder(tr).Decode(&dockerManifests)
+			}
+		}
+		tags := make(map[string][]string)
+		for _, m := range dockerManifests {
+			tags[m.Config] = m.RepoTags
+		}
+		return tags
+	}
+
+	// Only the host platform manifest is tagged
+	tags := repoTags(t, explorers.ImageExportOptions{})
+	if len(tags) != 2 {
+		t.Fatalf("expected 2 manifests, got %v", tags)
+	}
+	if hostTags := tags[utils.BlobPath(configs[1].Digest)]; len(hostTags) != 1 || hostTags[0] != "app:1.0" {
+		t.Errorf("expected the host platform manifest to be tagged app:1.0, got %v", hostTags)
+	}
+	if otherTags := tags[utils.BlobPath(configs[0].Digest)]; len(otherTags) != 0 {
+		t.Errorf("expected the windows manifest to be untagged, got %v", otherTags)
+	}
+
+	// The manifest of the selected platform is tagged
+	tags = repoTags(t, explorers.ImageExportOptions{Platform: "windows/amd64"})
+	if otherTags := tags[utils.BlobPath(configs[0].Digest)]; len(tags) != 1 || len(otherTags) != 1 {
+		t.Errorf("expected the tagged windows manifest only, got %v", tags)
+	}
+}
+
+func TestContainerLayers(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	metaDir := filepath.Join(containerdRoot, "io.containerd.metadata.v1.bolt")
+	if err := os.MkdirAll(metaDir, 0755); err != nil {
+		t.Fatalf("failed to create meta dir: %v", err)
+	}
+
+	// The parent snapshot key of the container is the chain ID of the image
+	// layers, i.e. the diff ID of the single layer.
+	layer := writeContentBlob(t, containerdRoot, ocispec.MediaTypeImageLayerGzip, []byte("layer data"))
+	diffID := digest.FromString("layer diff")
+	configData, _ := json.Marshal(ocispec.Image{
+		Platform: ocispec.Platform{OS: "linux", Architecture: "amd64"},
+		RootFS:   ocispec.RootFS{Type: "layers", DiffIDs: []digest.Digest{diffID}},
+	})
+	config := writeContentBlob(t, containerdRoot, ocispec.MediaTypeImageConfig, configData)
+	manifestData, _ := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	manifest := writeContentBlob(t, containerdRoot, ocispec.MediaTypeImageManifest, manifestData)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	db, err := bolt.Open(filepath.Join(metaDir, "meta.db"), 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open meta.db: %v", err)
+	}
+	_ = db.Update(func(tx *bolt.Tx) error {
+		return metadata.NewNamespaceStore(tx).Create(context.Background(), "ns1", nil)
+	})
+
+	specJSON, _ := json.Marshal(oci.Spec{
+		Linux:   &oci.Linux{CgroupsPath: "/default/container-1"},
+		Process: &oci.Process{Args: []string{"sleep", "10"}},
+	})
+
+	ctx := namespaces.WithNamespace(context.Background(), "ns1")
+	dbStore := metadata.NewDB(db, nil, nil)
+	if _, err := metadata.NewImageStore(dbStore).Create(ctx, images.Image{Name: "docker.io/library/app:1.0", Target: manifest}); err != nil {
+		db.Close()
+		t.Fatalf("failed to create image: %v", err)
+	}
+	c := containers.Container{
+		ID:          "container-1",
+		Image:       "docker.io/library/app:1.0",
+		Snapshotter: "overlayfs",
+		SnapshotKey: "snap1",
+		Runtime:     containers.RuntimeInfo{Name: "io.containerd.runc.v2"},
+		Spec:        &types.Any{TypeUrl: "types.containerd.io/opencontainers/runtime-spec/1/Spec", Value: specJSON},
+	}
+	if _, err := metadata.NewContainerStore(dbStore).Create(ctx, c); err != nil {
+		db.Close()
+		t.Fatalf("failed to create container: %v", err)
+	}
+	_ = db.Update(func(tx *bolt.Tx) error {
+		_ = createMetaSnapshot(tx, "ns1", "overlayfs", "snap1", "snapshot-name-1", diffID.String(), now)
+		return createMetaSnapshot(tx, "ns1", "overlayfs", diffID.String(), "snapshot-name-parent", "", now)
+	})
+	db.Close()
+
+	snapshotterDir := filepath.Join(containerdRoot, "io.containerd.snapshotter.v1.overlayfs")
+	_ = os.MkdirAll(snapshotterDir, 0755)
+	ssDB, err := bolt.Open(filepath.Join(snapshotterDir, "metadata.db"), 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open snapshotter metadata.db: %v", err)
+	}
+	_ = ssDB.Update(func(tx *bolt.Tx) error {
+		_ = createOverlaySnapshot(tx, "snapshot-name-1", 42, 2, "snapshot-name-parent", 10240, now)
+		return createOverlaySnapshot(tx, "snapshot-name-parent", 41, 2, "", 10240, now)
+	})
+	ssDB.Close()
+
+	upperDir := filepath.Join(snapshotterDir, "snapshots", "42", "fs")
+	lowerDir := filepath.Join(snapshotterDir, "snapshots", "41", "fs")
+	_ = os.MkdirAll(filepath.Join(snapshotterDir, "snapshots", "42", "work"), 0755)
+	_ = os.MkdirAll(upperDir, 0755)
+	_ = os.MkdirAll(lowerDir, 0755)
+	_ = os.WriteFile(filepath.Join(upperDir, "changed"), []byte("changed"), 0644)
+
+	sc, _ := explorers.NewSupportContainer("")
+	exp, err := NewExplorer("", containerdRoot, "", "", sc)
+	if err != nil {
+		t.Fatalf("failed to create explorer: %v", err)
+	}
+	defer exp.Close()
+
+	layers, err := exp.ContainerLayers(context.Background(), "container-1")
+	if err != nil {
+		t.Fatalf("ContainerLayers failed: %v", err)
+	}
+	if layers.UpperDir != upperDir {
+		t.Errorf("expected upper dir %s, got %s", upperDir, layers.UpperDir)
+	}
+	if len(layers.LowerDirs) != 1 || layers.LowerDirs[0] != lowerDir {
+		t.Errorf("expected lower dirs [%s], got %v", lowerDir, layers.LowerDirs)
+	}
+
+	if _, err := exp.ContainerLayers(context.Background(), "missing"); err == nil {
+		t.Errorf("expected error for unknown container")
+	}
+
+	// The image layers are the committed snapshots of the image chain ID
+	imageLayers, err := exp.ImageLayers(context.Background(), "app:1.0")
+	if err != nil {
+		t.Fatalf("ImageLayers failed: %v", err)
+	}
+	if imageLayers.UpperDir != "" || len(imageLayers.LowerDirs) != 1 || imageLayers.LowerDirs[0] != lowerDir {
+		t.Errorf("expected image lower dirs [%s], got %+v", lowerDir, imageLayers)
+	}
+	if _, err := exp.ImageLayers(context.Background(), "missing:latest"); err == nil {
+		t.Errorf("expected error for unknown image")
+	}
+
+	// A snapshot is the first lower directory, followed by its parents
+	snapshotTests := []struct {
+		key      string
+		parents  bool
+		expected []string
+	}{
+		{"snap1", false, []string{upperDir}},
+		{"snap1", true, []string{upperDir, lowerDir}},
+		{diffID.String(), false, []string{lowerDir}},
+		{diffID.String(), true, []string{lowerDir}},
+	}
+	for _, tt := range snapshotTests {
+		snapshotLayers, err := exp.SnapshotLayers(context.Background(), tt.key, tt.parents)
+		if err != nil {
+			t.Fatalf("SnapshotLayers(%s, %t) failed: %v", tt.key, tt.parents, err)
+		}
+		if snapshotLayers.UpperDir != "" || strings.Join(snapshotLayers.LowerDirs, ":") != strings.Join(tt.expected, ":") {
+			t.Errorf("SnapshotLayers(%s, %t): expected lower dirs %v, got %+v", tt.key, tt.parents, tt.expected, snapshotLayers)
+		}
+	}
+	if _, err := exp.SnapshotLayers(context.Background(), "missing", false); err == nil {
+		t.Errorf("expected error for unknown snapshot")
+	}
+
+	// The image layer blob is copied and the container changes are added
+	outputDir := filepath.Join(tmpDir, "output")
+	if err := exp.ExportContainer(context.Background(), "container-1", outputDir, explorers.ContainerExportOptions{OCI: true}); err != nil {
+		t.Fatalf("ExportContainer failed: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(outputDir, "container-1.oci.tar"))
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer f.Close()
+
+	var dockerManifests []utils.DockerManifest
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read archive: %v", err)
+		}
+		if hdr.Name == "manifest.json" {
+			_ = json.NewDecoder(tr).Decode(&dockerManifests)
+		}
+	}
+	if len(dockerManifests) != 1 || len(dockerManifests[0].Layers) != 2 {
+		t.Fatalf("expected 1 manifest with 2 layers, got %+v", dockerManifests)
+	}
+	if dockerManifests[0].Layers[0] != utils.BlobPath(layer.Digest) {
+		t.Errorf("expected image layer %s, got %s", utils.BlobPath(layer.Digest), dockerManifests[0].Layers[0])
+	}
+}
+
+func TestListOrphans(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	metaDir := filepath.Join(containerdRoot, "io.containerd.metadata.v1.bolt")
+	if err := os.MkdirAll(metaDir, 0755); err != nil {
+		t.Fatalf("failed to create meta dir: %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	db, err := bolt.Open(filepath.Join(metaDir, "meta.db"), 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open meta.db: %v", err)
+	}
+	_ = db.Update(func(tx *bolt.Tx) error {
+		return metadata.NewNamespaceStore(tx).Create(context.Background(), "ns1", nil)
+	})
+	ctx := namespaces.WithNamespace(context.Background(), "ns1")
+	c := containers.Container{
+		ID:          "container-1",
+		Snapshotter: "overlayfs",
+		SnapshotKey: "container-1",
+		Runtime:     containers.RuntimeInfo{Name: "io.containerd.runc.v2"},
+		Spec:        &types.Any{TypeUrl: "types.containerd.io/opencontainers/runtime-spec/1/Spec", Value: []byte("{}")},
+	}
+	if _, err := metadata.NewContainerStore(metadata.NewDB(db, nil, nil)).Create(ctx, c); err != nil {
+		db.Close()
+		t.Fatalf("failed to create container: %v", err)
+	}
+
+	// The snapshots of a container, of a removed container, and of the image
+	// layer. The snapshot of the deleted container is still in meta.db.
+	_ = db.Update(func(tx *bolt.Tx) error {
+		_ = createMetaSnapshot(tx, "ns1", "overlayfs", "container-1", "ns1/2/container-1", "layer", now)
+		_ = createMetaSnapshot(tx, "ns1", "overlayfs", "container-2", "ns1/3/container-2", "layer", now)
+		return createMetaSnapshot(tx, "ns1", "overlayfs", "layer", "ns1/1/layer", "", now)
+	})
+	db.Close()
+
+	snapshotterDir := filepath.Join(containerdRoot, "io.containerd.snapshotter.v1.overlayfs")
+	_ = os.MkdirAll(snapshotterDir, 0755)
+	ssDB, err := bolt.Open(filepath.Join(snapshotterDir, "metadata.db"), 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open snapshotter metadata.db: %v", err)
+	}
+	_ = ssDB.Update(func(tx *bolt.Tx) error {
+		_ = createOverlaySnapshot(tx, "ns1/1/layer", 1, uint8(snapshots.KindCommitted), "", 0, now)
+		_ = createOverlaySnapshot(tx, "ns1/2/container-1", 2, uint8(snapshots.KindActive), "ns1/1/layer", 0, now)
+		_ = createOverlaySnapshot(tx, "ns1/3/container-2", 3, uint8(snapshots.KindActive), "ns1/1/layer", 0, now)
+		return createOverlaySnapshot(tx, "ns1/4/container-3", 4, uint8(snapshots.KindActive), "ns1/1/layer", 0, now)
+	})
+	ssDB.Close()
+
+	// Snapshot 4 is only in metadata.db and snapshot 5 is only on disk
+	for id := 1; id <= 5; id++ {
+		_ = os.MkdirAll(filepath.Join(snapshotterDir, "snapshots", fmt.Sprint(id), "fs"), 0755)
+	}
+	_ = os.WriteFile(filepath.Join(snapshotterDir, "snapshots", "5", "fs", "kworker"), []byte("payload"), 0755)
+
+	sc, _ := explorers.NewSupportContainer("")
+	exp, err := NewExplorer("", containerdRoot, "", "", sc)
+	if err != nil {
+		t.Fatalf("failed to create explorer: %v", err)
+	}
+	defer exp.Close()
+
+	orphans, err := exp.ListOrphans(context.Background())
+	if err != nil {
+		t.Fatalf("ListOrphans failed: %v", err)
+	}
+	if len(orphans) != 3 {
+		t.Fatalf("expected 3 orphans, got %+v", orphans)
+	}
+
+	snapshotDir := func(id string) string {
+		return filepath.Join(snapshotterDir, "snapshots", id, "fs")
+	}
+	expected := []explorers.Orphan{
+		{Key: "container-2", Reason: explorers.OrphanNoContainer, Parent: "layer", Path: snapshotDir("3")},
+		{Key: "ns1/4/container-3", Reason: explorers.OrphanNoMetadata, Parent: "ns1/1/layer", Path: snapshotDir("4")},
+		{Key: "5", Reason: explorers.OrphanUnreferenced, Parent: "ns1/1/layer", ParentGuessed: true, Path: snapshotDir("5")},
+	}
+	for i, o := range orphans {
+		if o.ContainerType != "containerd" || o.Key != expected[i].Key || o.Reason != expected[i].Reason || o.Parent != expected[i].Parent ||
+			o.ParentGuessed != expected[i].ParentGuessed || o.Path != expected[i].Path {
+			t.Errorf("expected orphan %+v, got %+v", expected[i], o)
+		}
+	}
+	if orphans[2].Size != 7 || orphans[2].Files != 1 {
+		t.Errorf("expected 7 bytes in 1 file, got %+v", orphans[2])
+	}
+
+	// The orphans are mounted using their keys
+	snapshotTests := []struct {
+		key      string
+		parents  bool
+		expected []string
+	}{
+		{"container-2", true, []string{snapshotDir("3"), snapshotDir("1")}},
+		{"ns1/4/container-3", false, []string{snapshotDir("4")}},
+		{"ns1/4/container-3", true, []string{snapshotDir("4"), snapshotDir("1")}},
+		{"5", false, []string{snapshotDir("5")}},
+		{"5", true, []string{snapshotDir("5"), snapshotDir("1")}},
+	}
+	for _, tt := range snapshotTests {
+		layers, err := exp.SnapshotLayers(context.Background(), tt.key, tt.parents)
+		if err != nil {
+			t.Fatalf("SnapshotLayers(%s, %t) failed: %v", tt.key, tt.parents, err)
+		}
+		if layers.UpperDir != "" || strings.Join(layers.LowerDirs, ":") != strings.Join(tt.expected, ":") {
+			t.Errorf("SnapshotLayers(%s, %t): expected lower dirs %v, got %+v", tt.key, tt.parents, tt.expected, layers)
+		}
+	}
+	if _, err := exp.SnapshotLayers(context.Background(), "6", false); err == nil {
+		t.Errorf("expected error for unknown snapshot directory")
+	}
+}
+
+func TestCarveBoltFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbFile := filepath.Join(tmpDir, "meta.db")
+	db, err := bolt.Open(dbFile, 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open meta.db: %v", err)
+	}
+	_ = db.Update(func(tx *bolt.Tx) error {
+		return metadata.NewNamespaceStore(tx).Create(context.Background(), "ns1", nil)
+	})
+
+	ctx := namespaces.WithNamespace(context.Background(), "ns1")
+	dbStore := metadata.NewDB(db, nil, nil)
+	specJSON, _ := json.Marshal(oci.Spec{
+		Hostname: "miner",
+		Process:  &oci.Process{Args: []string{"/tmp/.x/kworker", "--pool", "evil"}, Cwd: "/tmp"},
+	})
+	for _, id := range []string{"container-1", "container-2"} {
+		c := containers.Container{
+			ID:          id,
+			Image:       "docker.io/library/app:1.0",
+			Labels:      map[string]string{"app": id},
+			Snapshotter: "overlayfs",
+			SnapshotKey: id,
+			Runtime:     containers.RuntimeInfo{Name: "io.containerd.runc.v2"},
+			Spec:        &types.Any{TypeUrl: "types.containerd.io/opencontainers/runtime-spec/1/Spec", Value: specJSON},
+		}
+		if _, err := metadata.NewContainerStore(dbStore).Create(ctx, c); err != nil {
+			db.Close()
+			t.Fatalf("failed to create container: %v", err)
+		}
+	}
+	image := images.Image{
+		Name:   "docker.io/library/tool:1.0",
+		Target: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("manifest"), Size: 42},
+	}
+	if _, err := metadata.NewImageStore(dbStore).Create(ctx, image); err != nil {
+		db.Close()
+		t.Fatalf("failed to create image: %v", err)
+	}
+
+	// The deleted container remains in the page freed by the delete
+	if err := metadata.NewContainerStore(dbStore).Delete(ctx, "container-2"); err != nil {
+		db.Close()
+		t.Fatalf("failed to delete container: %v", err)
+	}
+	db.Close()
+
+	records, err := CarveBoltFile(dbFile, false)
+	if err != nil {
+		t.Fatalf("CarveBoltFile failed: %v", err)
+	}
+
+	var deletedContainer *explorers.CarvedRecord
+	for i, record := range records {
+		if record.PageState == explorers.PageLive {
+			t.Errorf("unexpected live record %+v", record)
+		}
+		if record.Offset < int64(record.Page)*int64(os.Getpagesize()) {
+			t.Errorf("record offset %d before page %d", record.Offset, record.Page)
+		}
+		if record.Kind == explorers.CarvedContainer && record.Name == "container-2" {
+			deletedContainer = &records[i]
+		}
+	}
+
+	if deletedContainer == nil {
+		t.Fatalf("expected deleted container record, got %+v", records)
+	}
+	if deletedContainer.Live || deletedContainer.Namespace != "ns1" || deletedContainer.Bucket != "v1/ns1/containers/container-2" {
+		t.Errorf("unexpected deleted container record %+v", deletedContainer)
+	}
+	expectedFields := map[string]string{
+		"image":        "docker.io/library/app:1.0",
+		"snapshotter":  "overlayfs",
+		"snapshot_key": "container-2",
+		"runtime":      "io.containerd.runc.v2",
+		"hostname":     "miner",
+		"args":         "/tmp/.x/kworker --pool evil",
+		"cwd":          "/tmp",
+	}
+	for name, expected := range expectedFields {
+		if deletedContainer.Fields[name] != expected {
+			t.Errorf("expected container field %s=%s, got %s", name, expected, deletedContainer.Fields[name])
+		}
+	}
+	if deletedContainer.Fields["created_at"] == "" || deletedContainer.Labels["app"] != "container-2" {
+		t.Errorf("expected timestamps and labels, got %+v", deletedContainer)
+	}
+
+	// The live records are included on request
+	records, err = CarveBoltFile(dbFile, true)
+	if err != nil {
+		t.Fatalf("CarveBoltFile failed: %v", err)
+	}
+	liveContainer, liveImage := false, false
+	for _, record := range records {
+		if record.PageState != explorers.PageLive {
+			continue
+		}
+		if record.Kind == explorers.CarvedContainer && record.Name == "container-1" {
+			liveContainer = record.Live
+		}
+		if record.Kind == explorers.CarvedImage && record.Name == image.Name {
+			liveImage = record.Live && record.Fields["target_digest"] == image.Target.Digest.String() && record.Fields["target_size"] == "42"
+		}
+		if record.Name == "container-2" && record.Live {
+			t.Errorf("expected deleted container not to be live, got %+v", record)
+		}
+	}
+	if !liveContainer || !liveImage {
+		t.Errorf("expected live container and image records, got %+v", records)
+	}
+
+	if _, err := CarveBoltFile(filepath.Join(tmpDir, "missing.db"), false); err == nil {
+		t.Errorf("expected error for a missing file")
+	}
+	_ = os.WriteFile(filepath.Join(tmpDir, "invalid.db"), make([]byte, 8192), 0644)
+	if _, err := CarveBoltFile(filepath.Join(tmpDir, "invalid.db"), false); err == nil {
+		t.Errorf("expected error for a file that is not a bolt database")
+	}
+}
+
+func TestCarveBoltFile_Snapshotter(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "metadata.db")
+	db, err := bolt.Open(dbFile, 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open metadata.db: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	_ = db.Update(func(tx *bolt.Tx) error {
+		_ = createOverlaySnapshot(tx, "ns1/1/layer", 1, uint8(snapshots.KindCommitted), "", 0, now)
+		return createOverlaySnapshot(tx, "ns1/2/container-1", 2, uint8(snapshots.KindActive), "ns1/1/layer", 0, now)
+	})
+	_ = db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("v1")).Bucket([]byte("snapshots")).DeleteBucket([]byte("ns1/2/container-1"))
+	})
+	db.Close()
+
+	records, err := CarveBoltFile(dbFile, false)
+	if err != nil {
+		t.Fatalf("CarveBoltFile failed: %v", err)
+	}
+
+	found := false
+	for _, record := range records {
+		if record.Kind == explorers.CarvedSnapshot && record.Name == "ns1/2/container-1" && !record.Live {
+			found = true
+			if record.Fields["id"] != "2" || record.Fields["kind"] != "Active" || record.Fields["parent"] != "ns1/1/layer" || record.Namespace != "" {
+				t.Errorf("unexpected snapshot record %+v", record)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected deleted snapshot record, got %+v", records)
+	}
+}
+
+func TestListDeletedContainers(t *testing.T) {
+	tmpDir := t.TempDir()
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	metaDir := filepath.Join(containerdRoot, "io.containerd.metadata.v1.bolt")
+	if err := os.MkdirAll(metaDir, 0755); err != nil {
+		t.Fatalf("failed to create meta dir: %v", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(metaDir, "meta.db"), 0644, nil)
+	if err != nil {
+		t.Fatalf("failed to open meta.db: %v", err)
+	}
+	_ = db.Update(func(tx *bolt.Tx) error {
+		return metadata.NewNamespaceStore(tx).Create(context.Background(), "ns1", nil)
+	})
+	ctx := namespaces.WithNamespace(context.Background(), "ns1")
+	store := metadata.NewContainerStore(metadata.NewDB(db, nil, nil))
+	c := containers.Container{
+		ID:          "container-1",
+		Image:       "docker.io/library/app:1.0",
+		Labels:      map[string]string{"app": "deleted"},
+		Snapshotter: "overlayfs",
+		SnapshotKey: "container-1",
+		Runtime:     containers.RuntimeInfo{Name: "io.containerd.runc.v2"},
+		Spec:        &types.Any{TypeUrl: "types.containerd.io/opencontainers/runtime-spec/1/Spec", Value: []byte(`{"hostname":"app-host"}`)},
+	}
+	if _, err := store.Create(ctx, c); err != nil {
+		db.Close()
+		t.Fatalf("failed to create container: %v", err)
+	}
+	if err := store.Delete(ctx, "container-1"); err != nil {
+		db.Close()
+		t.Fatalf("failed to delete container: %v", err)
+	}
+	db.Close()
+
+	exp, err := NewExplorer("", containerdRoot, "", "", nil)
+	if err != nil {
+		t.Fatalf("NewExplorer failed: %v", err)
+	}
+	defer exp.Close()
+
+	deleted, err := exp.(explorers.DeletedContainerLister).ListDeletedContainers(context.Background())
+	if err != nil {
+		t.Fatalf("ListDeletedContainers failed: %v", err)
+	}
+	if len(deleted) != 1 {
+		t.Fatalf("expected 1 deleted container, got %+v", deleted)
+	}
+	ctr := deleted[0]
+	if ctr.ID != "container-1" || ctr.Namespace != "ns1" || ctr.ContainerType != "containerd" || ctr.Image != c.Image ||
+		ctr.ImageBase != "docker.io/library/app" || ctr.Hostname != "app-host" || ctr.Labels["app"] != "deleted" || ctr.SnapshotKey != "container-1" ||
+		ctr.Runtime.Name != "io.containerd.runc.v2" || ctr.CreatedAt.IsZero() {
+		t.Errorf("unexpected deleted container %+v", ctr)
+	}
+	if ctr.Recovered != explorers.RecoveredDeleted || !strings.HasPrefix(ctr.RecoveredFrom, "meta.db page ") {
+		t.Errorf("unexpected recovered values %q, %q", ctr.Recovered, ctr.RecoveredFrom)
+	}
+}
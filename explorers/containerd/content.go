@@ -19,14 +19,24 @@ package containerd
 import (
 	"context"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
 
 	"github.com/containerd/containerd/content"
 	"github.com/containerd/containerd/metadata/boltutil"
 	"github.com/containerd/containerd/namespaces"
+	"github.com/google/container-explorer/explorers"
 	"github.com/opencontainers/go-digest"
+	log "github.com/sirupsen/logrus"
 	bolt "go.etcd.io/bbolt"
 )
 
+const contentDirName = "io.containerd.content.v1.content"
+
 type blobStore struct {
 	db *bolt.DB
 }
@@ -95,3 +105,156 @@ func readBlob(info *content.Info, bkt *bolt.Bucket) error {
 
 	return nil
 }
+
+// VerifyContent hashes the blobs in the content store and compares them with
+// the content information in meta.db.
+//
+// In containerd, the content blobs are shared between the namespaces and
+// stored in io.containerd.content.v1.content/blobs/<algorithm>/<encoded digest>.
+// Blobs on disk without a content entry in any namespace are reported as
+// orphaned.
+func (e *explorer) VerifyContent(ctx context.Context) ([]explorers.ContentVerification, error) {
+	contents, err := e.ListContent(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing content: %w", err)
+	}
+
+	contentRoot := filepath.Join(e.containerdRoot, contentDirName)
+
+	var verifications []explorers.ContentVerification
+
+	// A blob may be referenced by multiple namespaces. Hash each blob once.
+	hashed := make(map[digest.Digest]blobDigest)
+	known := make(map[digest.Digest]bool)
+
+	for _, c := range contents {
+		known[c.Digest] = true
+
+		v := explorers.ContentVerification{
+			Namespace:     c.Namespace,
+			ContainerType: "containerd",
+			Digest:        c.Digest,
+			ExpectedSize:  c.Size,
+		}
+
+		path, err := blobPath(contentRoot, c.Digest)
+		if err != nil {
+			log.WithFields(log.Fields{"digest": c.Digest, "error": err}).Warn("invalid content digest")
+			v.Status = explorers.ContentStatusCorrupted
+			verifications = append(verifications, v)
+			continue
+		}
+		v.Path = path
+
+		bd, ok := hashed[c.Digest]
+		if !ok {
+			bd = digestBlob(path, c.Digest.Algorithm())
+			hashed[c.Digest] = bd
+		}
+		v.ActualDigest = bd.digest
+		v.ActualSize = bd.size
+
+		switch {
+		case errors.Is(bd.err, fs.ErrNotExist):
+			v.Status = explorers.ContentStatusMissing
+		case bd.err != nil:
+			log.WithFields(log.Fields{"path": path, "error": bd.err}).Warn("reading content blob")
+			v.Status = explorers.ContentStatusUnreadable
+		case bd.digest != c.Digest || bd.size != c.Size:
+			v.Status = explorers.ContentStatusCorrupted
+		default:
+			v.Status = explorers.ContentStatusOK
+		}
+
+		verifications = append(verifications, v)
+	}
+
+	// Blobs on disk without content information in meta.db
+	blobFiles, err := filepath.Glob(filepath.Join(contentRoot, "blobs", "*", "*"))
+	if err != nil {
+		return nil, fmt.Errorf("listing content blobs: %w", err)
+	}
+
+	for _, blobFile := range blobFiles {
+		algorithm := digest.Algorithm(filepath.Base(filepath.Dir(blobFile)))
+		dgst := digest.NewDigestFromEncoded(algorithm, filepath.Base(blobFile))
+		if known[dgst] {
+			continue
+		}
+
+		v := explorers.ContentVerification{
+			ContainerType: "containerd",
+			Digest:        dgst,
+			Status:        explorers.ContentStatusOrphaned,
+			Path:          blobFile,
+		}
+
+		bd := digestBlob(blobFile, algorithm)
+		if bd.err != nil {
+			log.WithFields(log.Fields{"path": blobFile, "error": bd.err}).Warn("reading orphaned content blob")
+		}
+		v.ActualDigest = bd.digest
+		v.ActualSize = bd.size
+
+		verifications = append(verifications, v)
+	}
+
+	return verifications, nil
+}
+
+// ReadContent returns a reader for the content blob matching the digest.
+//
+// The blob is read as stored on disk and is not verified.
+func (e *explorer) ReadContent(_ context.Context, dgst digest.Digest) (io.ReadCloser, error) {
+	path, err := blobPath(filepath.Join(e.containerdRoot, contentDirName), dgst)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest %s: %w", dgst, err)
+	}
+
+	//nolint:gosec // G304: Path is constructed from a validated digest
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening content blob: %w", err)
+	}
+	return f, nil
+}
+
+// blobPath returns the path of a content blob.
+//
+// i.e. /var/lib/containerd/io.containerd.content.v1.content/blobs/sha256/<encoded digest>
+func blobPath(contentRoot string, dgst digest.Digest) (string, error) {
+	if err := dgst.Validate(); err != nil {
+		return "", err
+	}
+	return filepath.Join(contentRoot, "blobs", dgst.Algorithm().String(), dgst.Encoded()), nil
+}
+
+// blobDigest holds the computed digest and size of a blob on disk.
+type blobDigest struct {
+	digest digest.Digest
+	size   int64
+	err    error
+}
+
+// digestBlob computes the digest and size of a blob using the specified
+// algorithm.
+func digestBlob(path string, algorithm digest.Algorithm) blobDigest {
+	if !algorithm.Available() {
+		return blobDigest{err: fmt.Errorf("unsupported digest algorithm %q", algorithm)}
+	}
+
+	//nolint:gosec // G304: Path is within the content store
+	f, err := os.Open(path)
+	if err != nil {
+		return blobDigest{err: err}
+	}
+	defer f.Close()
+
+	digester := algorithm.Digester()
+	size, err := io.Copy(digester.Hash(), f)
+	if err != nil {
+		return blobDigest{size: size, err: err}
+	}
+
+	return blobDigest{digest: digester.Digest(), size: size}
+}
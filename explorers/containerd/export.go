@@ -17,18 +17,28 @@ limitations under the License.
 package containerd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 
+	"github.com/containerd/containerd/images"
 	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
 	"github.com/google/container-explorer/explorers"
 	"github.com/google/container-explorer/utils"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	log "github.com/sirupsen/logrus"
 )
 
 // ExportContainer exports a container either as a raw image or an archive.
-func (e *explorer) ExportContainer(ctx context.Context, containerID string, outputDir string, exportOptions map[string]bool) error {
+func (e *explorer) ExportContainer(ctx context.Context, containerID string, outputDir string, options explorers.ContainerExportOptions) error {
 	// Check if the specified containerID exists.
 	containerExists := false
 
@@ -77,6 +87,26 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 			"containerType": targetContainer.ContainerType,
 		}).Info("container found")
 
+		e.recordCustody(ctx, targetContainer.ID)
+
+		if options.OCI {
+			log.Infof("exporting container %s as an OCI image to %s", targetContainer.ID, outputDir)
+			container, _, err := e.getContainerStoreInfo(ctx, targetContainer.ID)
+			if err != nil {
+				return fmt.Errorf("failed getting container information %v", err)
+			}
+			if err := e.exportContainerOCI(ctx, container, outputDir); err != nil {
+				return fmt.Errorf("failed to export container %s as OCI image: %w", targetContainer.ID, err)
+			}
+			log.Infof("successfully exported container %s as an OCI image", targetContainer.ID)
+
+			// The OCI image is created from the overlay directories and does
+			// not require mounting the container.
+			if !options.Image && !options.Archive {
+				return nil
+			}
+		}
+
 		// Ensure outputDir exists
 		if err := os.MkdirAll(outputDir, 0755); err != nil {
 			return fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
@@ -107,11 +137,10 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 		// Defer unmount and cleanup of the mountpoint
 		defer func() {
 			log.Infof("cleaning up mountpoint %s for container %s", mountpoint, targetContainer.ID)
-			unmountCmdOutput, unmountErr := utils.Runner.RunWithoutContext("umount", mountpoint)
-			if unmountErr != nil {
-				log.Warnf("failed to unmount %s: %v; output: %s", mountpoint, unmountErr, string(unmountCmdOutput))
+			if unmountErr := utils.Unmount(ctx, mountpoint); unmountErr != nil {
+				log.Warnf("failed to unmount %s: %v", mountpoint, unmountErr)
 			} else {
-				log.Infof("successfully unmounted %s; output: %s", mountpoint, string(unmountCmdOutput))
+				log.Infof("successfully unmounted %s", mountpoint)
 			}
 
 			if rmErr := os.Remove(mountpoint); rmErr != nil {
@@ -121,7 +150,7 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 			}
 		}()
 
-		if exportOptions["image"] {
+		if options.Image {
 			log.Infof("exporting container %s as a raw image to %s", targetContainer.ID, outputDir)
 			if err := utils.ExportContainerImage(ctx, targetContainer.ID, mountpoint, outputDir); err != nil {
 				return fmt.Errorf("failed to export container %s as raw image: %w", targetContainer.ID, err)
@@ -129,9 +158,9 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 			log.Infof("successfully exported container %s as a raw image", targetContainer.ID)
 		}
 
-		if exportOptions["archive"] {
+		if options.Archive {
 			log.Infof("exporting container %s as an archive to %s", targetContainer.ID, outputDir)
-			if err := utils.ExportContainerArchive(ctx, targetContainer.ID, mountpoint, outputDir); err != nil {
+			if err := utils.ExportContainerArchive(ctx, targetContainer.ID, mountpoint, outputDir, options.Compression); err != nil {
 				return fmt.Errorf("failed to export container %s as archive: %w", targetContainer.ID, err)
 			}
 			log.Infof("successfully exported container %s as an archive", targetContainer.ID)
@@ -145,70 +174,407 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 	return nil
 }
 
+// recordCustody records the container and the metadata databases it is read
+// from to the custody record of the context.
+func (e *explorer) recordCustody(ctx context.Context, containerID string) {
+	custody := utils.CustodyFromContext(ctx)
+	if custody == nil {
+		return
+	}
+
+	container, ns, err := e.getContainerStoreInfo(ctx, containerID)
+	if err != nil {
+		log.WithFields(log.Fields{"containerID": containerID, "error": err}).Warn("recording container custody")
+		return
+	}
+	ctx = namespaces.WithNamespace(ctx, ns)
+
+	custody.AddSource("containerd metadata", e.mdb.Path())
+
+	record := utils.CustodyContainer{
+		ContainerID: container.ID,
+		Runtime:     e.Type(),
+		Namespace:   ns,
+		Image:       container.Image,
+		SnapshotKey: container.SnapshotKey,
+	}
+
+	layers, _, err := e.containerLayers(ctx, &container)
+	if err != nil {
+		log.WithFields(log.Fields{"containerID": containerID, "error": err}).Debug("container layers for custody")
+	} else {
+		record.Snapshots = append([]string{layers.UpperDir}, layers.LowerDirs...)
+	}
+
+	// The snapshotter is resolved by containerLayers.
+	record.Snapshotter = container.Snapshotter
+	if snapshotFile, err := e.snapshotDBPath(container.Snapshotter); err == nil {
+		custody.AddSource(container.Snapshotter+" snapshot metadata", snapshotFile)
+	}
+
+	custody.AddContainer(record)
+}
+
 // ExportAllContainers exports all containerd containers to specified output directory.
-func (e *explorer) ExportAllContainers(ctx context.Context, outputDir string, exportOptions map[string]bool, filter map[string]string, exportSupportContainers bool) error {
-	containerNamespaces, err := e.ListNamespaces(ctx)
+func (e *explorer) ExportAllContainers(ctx context.Context, outputDir string, options explorers.ContainerExportOptions, filter map[string]string, exportSupportContainers bool) error {
+	containers, err := e.ListContainers(ctx)
 	if err != nil {
-		return fmt.Errorf("listing namespaces: %w", err)
+		return fmt.Errorf("listing containers: %w", err)
 	}
 
-	for _, containerNamespace := range containerNamespaces {
-		ctx = namespaces.WithNamespace(ctx, containerNamespace)
+	log.WithFields(log.Fields{
+		"container_count": len(containers),
+	}).Debug("containerd containers")
 
-		containers, err := e.ListContainers(ctx)
-		if err != nil {
+	var targets []explorers.Container
+	var ids []string
+	for _, container := range containers {
+		log.WithFields(log.Fields{
+			"containerID":   container.ID,
+			"name":          container.Runtime.Name,
+			"namespace":     container.Namespace,
+			"containerType": container.ContainerType,
+		}).Debug("processing containerd container for export")
+
+		if !exportSupportContainers && container.SupportContainer {
 			log.WithFields(log.Fields{
-				"namespace": containerNamespace,
-				"error":     err,
-			}).Warnf("error listing containers in namespace")
+				"containerID":   container.ID,
+				"name":          container.Runtime.Name,
+				"namespace":     container.Namespace,
+				"containerType": container.ContainerType,
+			}).Debug("skipping Kubernetes support containers")
 			continue
 		}
 
-		log.WithFields(log.Fields{
-			"namespace":       containerNamespace,
-			"container_count": len(containers),
-		}).Debug("containerd containers in namespace")
+		if utils.IncludeContainer(container, filter) {
+			targets = append(targets, container)
+			ids = append(ids, container.ID)
+		}
+	}
 
-		for _, container := range containers {
+	return explorers.RunContainerJobs(ctx, e.Type(), "export", ids, func(i int) error {
+		container := targets[i]
+		err := e.ExportContainer(namespaces.WithNamespace(ctx, container.Namespace), container.ID, outputDir, options)
+		if err != nil {
 			log.WithFields(log.Fields{
 				"containerID":   container.ID,
 				"name":          container.Runtime.Name,
 				"namespace":     container.Namespace,
 				"containerType": container.ContainerType,
-			}).Debug("processing containerd container for export")
+				"error":         err,
+			}).Error("error exporting containerd container")
+		}
+		return err
+	})
+}
 
-			if !exportSupportContainers && container.SupportContainer {
-				log.WithFields(log.Fields{
-					"containerID":   container.ID,
-					"name":          container.Runtime.Name,
-					"namespace":     container.Namespace,
-					"containerType": container.ContainerType,
-				}).Debug("skipping Kubernetes support containers")
+// ExportImage exports an image from the content store.
+//
+// The image target is followed through the index, manifests, config, and
+// layer blobs in io.containerd.content.v1.content. The image is written
+// as an OCI image layout directory or as a tar archive that can be loaded
+// using docker load.
+//
+// Manifests of a multi-platform image that are not in the content store,
+// i.e. platforms that were not pulled, are skipped. In that case, the index
+// is not exported and index.json references the exported manifests.
+//
+// Only the manifest of the selected platform, or of the host platform, is
+// tagged in the manifest.json of docker load. The manifests of the other
+// platforms are loaded untagged.
+func (e *explorer) ExportImage(ctx context.Context, ref string, outputPath string, options explorers.ImageExportOptions) error {
+	ceImages, err := e.ListImages(ctx)
+	if err != nil {
+		return fmt.Errorf("listing images: %w", err)
+	}
+
+	var image *explorers.Image
+	for i := range ceImages {
+		if explorers.MatchImageReference(ceImages[i].Name, ceImages[i].Target.Digest, ref) {
+			image = &ceImages[i]
+			break
+		}
+	}
+	if image == nil {
+		return fmt.Errorf("image %s not found", ref)
+	}
+
+	log.WithFields(log.Fields{
+		"name":      image.Name,
+		"namespace": image.Namespace,
+		"target":    image.Target.Digest,
+		"mediaType": image.Target.MediaType,
+	}).Info("image found")
+
+	var matcher platforms.MatchComparer
+	if options.Platform != "" {
+		p, err := platforms.Parse(options.Platform)
+		if err != nil {
+			return fmt.Errorf("parsing platform %s: %w", options.Platform, err)
+		}
+		matcher = platforms.Only(p)
+	}
+
+	w, err := utils.NewImageWriter(outputPath, !options.Layout)
+	if err != nil {
+		return err
+	}
+
+	exporter := &imageExporter{
+		e:        e,
+		w:        w,
+		repoTags: imageRepoTags(image.Name),
+		platform: matcher,
+	}
+
+	index, err := exporter.export(ctx, image.Target, image.Name)
+	if err != nil {
+		w.Abort()
+		return fmt.Errorf("exporting image %s: %w", image.Name, err)
+	}
+
+	if err := w.Finish(index, exporter.manifests); err != nil {
+		w.Abort()
+		return fmt.Errorf("exporting image %s: %w", image.Name, err)
+	}
+
+	log.Infof("successfully exported image %s to %s", image.Name, outputPath)
+	return nil
+}
+
+// imageExporter copies the blobs of an image from the content store.
+type imageExporter struct {
+	e         *explorer
+	w         *utils.ImageWriter
+	repoTags  []string
+	platform  platforms.MatchComparer
+	manifests []utils.DockerManifest
+}
+
+// export writes the blobs referenced by the image target and returns the
+// index for index.json.
+func (x *imageExporter) export(ctx context.Context, target ocispec.Descriptor, name string) (ocispec.Index, error) {
+	index := ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+	}
+
+	switch {
+	case images.IsManifestType(target.MediaType):
+		manifest, err := x.exportManifest(ctx, target)
+		if err != nil {
+			return index, err
+		}
+		manifest.RepoTags = x.repoTags
+		x.manifests = append(x.manifests, manifest)
+		index.Manifests = []ocispec.Descriptor{annotateImageName(target, name)}
+
+	case images.IsIndexType(target.MediaType):
+		data, err := x.readBlob(ctx, target)
+		if err != nil {
+			return index, err
+		}
+
+		var idx ocispec.Index
+		if err := json.Unmarshal(data, &idx); err != nil {
+			return index, fmt.Errorf("unmarshaling index %s: %w", target.Digest, err)
+		}
+
+		complete := true
+		var exported []ocispec.Descriptor
+		var manifests []utils.DockerManifest
+
+		for _, desc := range idx.Manifests {
+			if x.platform != nil && (desc.Platform == nil || !x.platform.Match(*desc.Platform)) {
+				complete = false
 				continue
 			}
 
-			if utils.IncludeContainer(container, filter) {
+			if !images.IsManifestType(desc.MediaType) {
 				log.WithFields(log.Fields{
-					"containerID":   container.ID,
-					"name":          container.Runtime.Name,
-					"namespace":     container.Namespace,
-					"containerType": container.ContainerType,
-				}).Debug("ignoring containerd container for export")
-
-				err := e.ExportContainer(ctx, container.ID, outputDir, exportOptions)
-				if err != nil {
-					log.WithFields(log.Fields{
-						"containerID":   container.ID,
-						"name":          container.Runtime.Name,
-						"namespace":     container.Namespace,
-						"containerType": container.ContainerType,
-						"error":         err,
-					}).Error("error exporting containerd container")
+					"digest":    desc.Digest,
+					"mediaType": desc.MediaType,
+				}).Warn("skipping unsupported index entry")
+				complete = false
+				continue
+			}
+
+			manifest, err := x.exportManifest(ctx, desc)
+			if err != nil {
+				if !errors.Is(err, fs.ErrNotExist) {
+					return index, err
 				}
+				log.WithFields(log.Fields{
+					"digest":   desc.Digest,
+					"platform": platformString(desc.Platform),
+				}).Info("skipping manifest not in the content store")
+				complete = false
+				continue
 			}
+			exported = append(exported, desc)
+			manifests = append(manifests, manifest)
+		}
+
+		if len(exported) == 0 {
+			return index, fmt.Errorf("no manifests of index %s in the content store", target.Digest)
 		}
+
+		// docker load tags the image of every manifest.json entry with its
+		// RepoTags, so that the last platform loaded would win. Only the
+		// manifest of the selected or host platform is tagged.
+		tagged := taggedManifest(exported, x.platform)
+		manifests[tagged].RepoTags = x.repoTags
+		x.manifests = append(x.manifests, manifests...)
+		log.WithFields(log.Fields{
+			"digest":   exported[tagged].Digest,
+			"platform": platformString(exported[tagged].Platform),
+		}).Debug("tagging image manifest")
+
+		if complete {
+			if err := x.w.WriteBlob(target, bytes.NewReader(data)); err != nil {
+				return index, err
+			}
+			index.Manifests = []ocispec.Descriptor{annotateImageName(target, name)}
+		} else {
+			for _, desc := range exported {
+				index.Manifests = append(index.Manifests, annotateImageName(desc, name))
+			}
+		}
+
+	default:
+		return index, fmt.Errorf("unsupported image media type %s", target.MediaType)
+	}
+
+	return index, nil
+}
+
+// exportManifest writes the config, layer, and manifest blobs and returns
+// the untagged manifest.json entry of the manifest.
+func (x *imageExporter) exportManifest(ctx context.Context, desc ocispec.Descriptor) (utils.DockerManifest, error) {
+	data, err := x.readBlob(ctx, desc)
+	if err != nil {
+		return utils.DockerManifest{}, err
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return utils.DockerManifest{}, fmt.Errorf("unmarshaling manifest %s: %w", desc.Digest, err)
+	}
+
+	dockerManifest := utils.DockerManifest{
+		Config: utils.BlobPath(manifest.Config.Digest),
+	}
+
+	blobs := append([]ocispec.Descriptor{manifest.Config}, manifest.Layers...)
+	for _, blob := range blobs {
+		if err := x.copyBlob(ctx, blob); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// The manifest is in the content store but its blobs are not.
+				// i.e. the layers were discarded after unpacking.
+				return dockerManifest, fmt.Errorf("blob %s of manifest %s is not in the content store: %v", blob.Digest, desc.Digest, err)
+			}
+			return dockerManifest, err
+		}
+		if images.IsLayerType(blob.MediaType) {
+			dockerManifest.Layers = append(dockerManifest.Layers, utils.BlobPath(blob.Digest))
+		}
+	}
+
+	if err := x.w.WriteBlob(desc, bytes.NewReader(data)); err != nil {
+		return dockerManifest, err
+	}
+	return dockerManifest, nil
+}
+
+// readBlob reads a blob into memory and verifies its digest.
+//
+// readBlob is used for index and manifest blobs that are small.
+func (x *imageExporter) readBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, error) {
+	rc, err := x.e.ReadContent(ctx, desc.Digest)
+	if err != nil {
+		return nil, err
 	}
+	defer rc.Close()
 
-	// Default
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("reading blob %s: %w", desc.Digest, err)
+	}
+
+	if actual := desc.Digest.Algorithm().FromBytes(data); actual != desc.Digest {
+		return nil, fmt.Errorf("blob digest mismatch: expected %s, got %s", desc.Digest, actual)
+	}
+	return data, nil
+}
+
+// copyBlob copies a blob from the content store to the image writer.
+func (x *imageExporter) copyBlob(ctx context.Context, desc ocispec.Descriptor) error {
+	rc, err := x.e.ReadContent(ctx, desc.Digest)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return x.w.WriteBlob(desc, rc)
+}
+
+// imageRepoTags returns the repository tags used by docker load.
+//
+// An image referenced only by digest does not have a tag.
+func imageRepoTags(name string) []string {
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return nil
+	}
+	if tagged, ok := named.(reference.NamedTagged); ok {
+		return []string{reference.FamiliarString(tagged)}
+	}
 	return nil
 }
+
+// annotateImageName returns a copy of the descriptor with image name
+// annotations.
+func annotateImageName(desc ocispec.Descriptor, name string) ocispec.Descriptor {
+	annotations := map[string]string{
+		images.AnnotationImageName: name,
+	}
+	if named, err := reference.ParseNormalizedNamed(name); err == nil {
+		if tagged, ok := named.(reference.NamedTagged); ok {
+			annotations[ocispec.AnnotationRefName] = tagged.Tag()
+		}
+	}
+
+	desc.Annotations = annotations
+	return desc
+}
+
+// platformString returns the platform as os/arch[/variant].
+func platformString(p *ocispec.Platform) string {
+	if p == nil {
+		return ""
+	}
+	return platforms.Format(*p)
+}
+
+// taggedManifest returns the index of the manifest that best matches the
+// platform, or the host platform if no platform is selected. The first
+// manifest is returned if no manifest matches.
+func taggedManifest(descs []ocispec.Descriptor, platform platforms.MatchComparer) int {
+	if platform == nil {
+		platform = platforms.Default()
+	}
+
+	best := -1
+	for i, desc := range descs {
+		if desc.Platform == nil || !platform.Match(*desc.Platform) {
+			continue
+		}
+		if best < 0 || platform.Less(*desc.Platform, *descs[best].Platform) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return 0
+	}
+	return best
+}
@@ -0,0 +1,370 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package containerd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/metadata"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/utils"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/identity"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ContainerLayers returns the overlay directories of a container.
+func (e *explorer) ContainerLayers(ctx context.Context, containerID string) (explorers.LayerStack, error) {
+	container, ns, err := e.getContainerStoreInfo(ctx, containerID)
+	if err != nil {
+		return explorers.LayerStack{}, fmt.Errorf("failed getting container information %v", err)
+	}
+	ctx = namespaces.WithNamespace(ctx, ns)
+
+	layers, _, err := e.containerLayers(ctx, &container)
+	return layers, err
+}
+
+// containerLayers returns the overlay directories and the parent snapshot
+// key of a container.
+//
+// The parent snapshot key of a container created from an image is the
+// chain ID of the image layers.
+func (e *explorer) containerLayers(ctx context.Context, container *containers.Container) (explorers.LayerStack, string, error) {
+	if err := e.resolveSnapshotter(ctx, container); err != nil {
+		return explorers.LayerStack{}, "", fmt.Errorf("failed resolving snapshotter: %w", err)
+	}
+
+	if container.Snapshotter == "native" {
+		return explorers.LayerStack{}, "", fmt.Errorf("native snapshotter does not use layers")
+	}
+
+	snapshotFile, err := e.snapshotDBPath(container.Snapshotter)
+	if err != nil {
+		return explorers.LayerStack{}, "", err
+	}
+
+	ssDB, err := bolt.Open(snapshotFile, 0444, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return explorers.LayerStack{}, "", fmt.Errorf("failed opening %s snapshot database %v", container.Snapshotter, err)
+	}
+	defer ssDB.Close()
+
+	ssStore := newSnapshotStore(e.containerdRoot, e.layercache, e.mdb, ssDB)
+	lowerdir, upperdir, _, err := ssStore.OverlayPath(ctx, *container)
+	if err != nil {
+		return explorers.LayerStack{}, "", fmt.Errorf("failed to get overlay path %v", err)
+	}
+
+	layers := explorers.LayerStack{
+		UpperDir: upperdir,
+	}
+	if lowerdir != "" {
+		layers.LowerDirs = strings.Split(lowerdir, ":")
+	}
+
+	namespace, _ := namespaces.Namespace(ctx)
+
+	var parent string
+	if err := e.mdb.View(func(tx *bolt.Tx) error {
+		bkt := getsnapshotKeyBucket(tx, namespace, container.Snapshotter, container.SnapshotKey)
+		if bkt != nil {
+			parent = string(bkt.Get(bucketKeyParent))
+		}
+		return nil
+	}); err != nil {
+		return explorers.LayerStack{}, "", err
+	}
+
+	log.WithFields(log.Fields{
+		"containerID": container.ID,
+		"upperdir":    layers.UpperDir,
+		"lowerdirs":   len(layers.LowerDirs),
+		"parent":      parent,
+	}).Debug("container layers")
+
+	return layers, parent, nil
+}
+
+// ImageLayers returns the overlay directories of the committed snapshots of
+// an image.
+//
+// The top snapshot key of an unpacked image is the chain ID of its layers.
+// For a multi-platform image, the first manifest unpacked on the host is
+// used.
+func (e *explorer) ImageLayers(ctx context.Context, ref string) (explorers.LayerStack, error) {
+	nss, err := e.ListNamespaces(ctx)
+	if err != nil {
+		return explorers.LayerStack{}, err
+	}
+
+	store := metadata.NewImageStore(metadata.NewDB(e.mdb, nil, nil))
+	for _, ns := range nss {
+		nsctx := namespaces.WithNamespace(ctx, ns)
+		imgs, err := store.List(nsctx)
+		if err != nil {
+			log.WithFields(log.Fields{"namespace": ns, "error": err}).Debug("listing images")
+			continue
+		}
+
+		for _, image := range imgs {
+			if explorers.MatchImageReference(image.Name, image.Target.Digest, ref) {
+				return e.imageLayers(nsctx, image)
+			}
+		}
+	}
+	return explorers.LayerStack{}, fmt.Errorf("image %s not found", ref)
+}
+
+// imageLayers returns the overlay directories of an image found in the
+// namespace of the context.
+func (e *explorer) imageLayers(ctx context.Context, image images.Image) (explorers.LayerStack, error) {
+	manifests := []ocispec.Descriptor{image.Target}
+	if images.IsIndexType(image.Target.MediaType) {
+		var idx ocispec.Index
+		if err := e.readContentJSON(ctx, image.Target.Digest, &idx); err != nil {
+			return explorers.LayerStack{}, fmt.Errorf("reading image index: %w", err)
+		}
+		manifests = idx.Manifests
+	}
+
+	namespace, _ := namespaces.Namespace(ctx)
+
+	for _, desc := range manifests {
+		if !images.IsManifestType(desc.MediaType) {
+			continue
+		}
+
+		var manifest ocispec.Manifest
+		if err := e.readContentJSON(ctx, desc.Digest, &manifest); err != nil {
+			log.WithFields(log.Fields{"image": image.Name, "manifest": desc.Digest, "error": err}).Debug("reading image manifest")
+			continue
+		}
+
+		var config ocispec.Image
+		if err := e.readContentJSON(ctx, manifest.Config.Digest, &config); err != nil {
+			log.WithFields(log.Fields{"image": image.Name, "config": manifest.Config.Digest, "error": err}).Debug("reading image config")
+			continue
+		}
+		if len(config.RootFS.DiffIDs) == 0 {
+			continue
+		}
+
+		chainID := identity.ChainID(config.RootFS.DiffIDs).String()
+		snapshotter := e.chainSnapshotter(namespace, chainID)
+		if snapshotter == "" {
+			log.WithFields(log.Fields{"image": image.Name, "manifest": desc.Digest, "chainID": chainID}).Debug("image manifest is not unpacked")
+			continue
+		}
+
+		snapshot := containers.Container{
+			ID:          image.Name,
+			Snapshotter: snapshotter,
+			SnapshotKey: chainID,
+		}
+		layers, _, err := e.containerLayers(ctx, &snapshot)
+		if err != nil {
+			return explorers.LayerStack{}, err
+		}
+
+		// An image has no writable layer.
+		return explorers.LayerStack{
+			LowerDirs: append([]string{layers.UpperDir}, layers.LowerDirs...),
+		}, nil
+	}
+	return explorers.LayerStack{}, fmt.Errorf("image %s is not unpacked to a snapshotter", image.Name)
+}
+
+// SnapshotLayers returns the overlay directories of a snapshot found by its
+// meta.db key in any namespace, its metadata.db name, or its snapshot
+// directory ID.
+func (e *explorer) SnapshotLayers(ctx context.Context, key string, parents bool) (explorers.LayerStack, error) {
+	nss, err := e.ListNamespaces(ctx)
+	if err != nil {
+		return explorers.LayerStack{}, err
+	}
+
+	for _, ns := range nss {
+		snapshotter := e.chainSnapshotter(ns, key)
+		if snapshotter == "" {
+			continue
+		}
+
+		snapshot := containers.Container{
+			ID:          key,
+			Snapshotter: snapshotter,
+			SnapshotKey: key,
+		}
+		layers, _, err := e.containerLayers(namespaces.WithNamespace(ctx, ns), &snapshot)
+		if err != nil {
+			return explorers.LayerStack{}, err
+		}
+
+		// A snapshot is exposed read-only like an image layer.
+		stack := explorers.LayerStack{LowerDirs: []string{layers.UpperDir}}
+		if parents {
+			stack.LowerDirs = append(stack.LowerDirs, layers.LowerDirs...)
+		}
+		return stack, nil
+	}
+
+	// The snapshots of removed containers can remain in the snapshotter
+	// database or only on disk.
+	return e.orphanLayers(key, parents)
+}
+
+// chainSnapshotter returns the snapshotter containing the snapshot key, or an
+// empty string. The overlayfs snapshotter is preferred.
+func (e *explorer) chainSnapshotter(namespace string, key string) string {
+	var snapshotter string
+	_ = e.mdb.View(func(tx *bolt.Tx) error {
+		bkt := getSnapshottersBucket(tx, namespace)
+		if bkt == nil {
+			return nil
+		}
+		if getsnapshotKeyBucket(tx, namespace, "overlayfs", key) != nil {
+			snapshotter = "overlayfs"
+			return nil
+		}
+		return bkt.ForEach(func(k, _ []byte) error {
+			if snapshotter == "" && getsnapshotKeyBucket(tx, namespace, string(k), key) != nil {
+				snapshotter = string(k)
+			}
+			return nil
+		})
+	})
+	return snapshotter
+}
+
+// snapshotDBPath returns the path of the snapshotter metadata database.
+func (e *explorer) snapshotDBPath(snapshotter string) (string, error) {
+	if e.snapshotFile != "" {
+		return e.snapshotFile, nil
+	}
+
+	snapshotterFolder := e.SnapshotRoot(snapshotter)
+	if snapshotterFolder == "unknown" {
+		return "", fmt.Errorf("snapshot root for %s snapshotter not found", snapshotter)
+	}
+	return filepath.Join(snapshotterFolder, "metadata.db"), nil
+}
+
+// exportContainerOCI exports a container as an OCI image archive.
+func (e *explorer) exportContainerOCI(ctx context.Context, container containers.Container, outputDir string) error {
+	layers, parent, err := e.containerLayers(ctx, &container)
+	if err != nil {
+		return err
+	}
+
+	base := e.containerBaseImage(ctx, container, parent)
+	return utils.ExportContainerOCI(ctx, container.ID, layers, base, outputDir)
+}
+
+// containerBaseImage returns the image of a container from the content store.
+//
+// The manifest of a multi-platform image is selected by comparing the chain
+// ID of its layers with the parent snapshot key of the container. If none
+// matches, only the image config is returned.
+func (e *explorer) containerBaseImage(ctx context.Context, container containers.Container, parent string) *utils.BaseImage {
+	if container.Image == "" {
+		return nil
+	}
+
+	image, err := metadata.NewImageStore(metadata.NewDB(e.mdb, nil, nil)).Get(ctx, container.Image)
+	if err != nil {
+		log.WithFields(log.Fields{"image": container.Image, "error": err}).Info("container image not found")
+		return nil
+	}
+
+	manifests := []ocispec.Descriptor{image.Target}
+	if images.IsIndexType(image.Target.MediaType) {
+		var idx ocispec.Index
+		if err := e.readContentJSON(ctx, image.Target.Digest, &idx); err != nil {
+			log.WithFields(log.Fields{"image": image.Name, "error": err}).Info("reading image index")
+			return nil
+		}
+		manifests = idx.Manifests
+	}
+
+	var base *utils.BaseImage
+	for _, desc := range manifests {
+		if !images.IsManifestType(desc.MediaType) {
+			continue
+		}
+
+		var manifest ocispec.Manifest
+		if err := e.readContentJSON(ctx, desc.Digest, &manifest); err != nil {
+			continue
+		}
+
+		var config ocispec.Image
+		if err := e.readContentJSON(ctx, manifest.Config.Digest, &config); err != nil {
+			continue
+		}
+
+		candidate := &utils.BaseImage{
+			Name:   image.Name,
+			Config: &config,
+			Layers: manifest.Layers,
+			ReadBlob: func(dgst digest.Digest) (io.ReadCloser, error) {
+				return e.ReadContent(ctx, dgst)
+			},
+		}
+
+		if len(config.RootFS.DiffIDs) > 0 && identity.ChainID(config.RootFS.DiffIDs).String() == parent {
+			return candidate
+		}
+
+		if base == nil {
+			base = candidate
+		}
+	}
+
+	if base != nil {
+		log.WithFields(log.Fields{
+			"image":  image.Name,
+			"parent": parent,
+		}).Info("image layers do not match the container snapshots")
+
+		base.Layers = nil
+	}
+	return base
+}
+
+// readContentJSON reads a JSON content blob into v.
+func (e *explorer) readContentJSON(ctx context.Context, dgst digest.Digest, v any) error {
+	rc, err := e.ReadContent(ctx, dgst)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s: %w", dgst, err)
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package containerd
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd/metadata"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/snapshots"
+	"github.com/google/container-explorer/explorers"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// snapshotterPrefix is the directory name prefix of the snapshotter roots
+// in the containerd root directory.
+const snapshotterPrefix = "io.containerd.snapshotter.v1."
+
+// snapshotRecord is a snapshot of a snapshotter database i.e. metadata.db.
+type snapshotRecord struct {
+	name   string // snapshot name i.e. the meta.db snapshot name
+	id     uint64 // snapshot directory ID
+	parent string // parent snapshot name
+	kind   snapshots.Kind
+}
+
+// metaSnapshotRef is a meta.db snapshot key referencing a snapshot name.
+type metaSnapshotRef struct {
+	namespace string
+	key       string
+	parent    string
+}
+
+// ListOrphans returns the snapshot directories of the snapshotters that are
+// not used by a container or an image.
+//
+// A snapshot directory is an orphan if it is missing from the snapshotter
+// database metadata.db, if its metadata.db snapshot is not referenced in
+// meta.db, or if it is an active snapshot without a container.
+func (e *explorer) ListOrphans(ctx context.Context) ([]explorers.Orphan, error) {
+	refs, used, err := e.metaSnapshotRefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []explorers.Orphan
+	for _, snapshotter := range e.snapshotters() {
+		root := filepath.Join(e.containerdRoot, snapshotterPrefix+snapshotter)
+		records, err := readSnapshotRecords(filepath.Join(root, "metadata.db"))
+		if err != nil {
+			log.WithFields(log.Fields{"snapshotter": snapshotter, "error": err}).Warn("reading snapshot database")
+			continue
+		}
+		byID := make(map[uint64]snapshotRecord)
+		for _, record := range records {
+			byID[record.id] = record
+		}
+
+		entries, err := os.ReadDir(filepath.Join(root, "snapshots"))
+		if err != nil {
+			log.WithFields(log.Fields{"snapshotter": snapshotter, "error": err}).Debug("reading snapshots directory")
+			continue
+		}
+		for _, entry := range entries {
+			id, err := strconv.ParseUint(entry.Name(), 10, 64)
+			if err != nil || !entry.IsDir() {
+				continue
+			}
+			dir := snapshotDir(root, id)
+
+			record, ok := byID[id]
+			if !ok {
+				orphan := explorers.NewOrphan("containerd", entry.Name(), dir, explorers.OrphanUnreferenced)
+				if parent, ok := guessParent(records, id); ok {
+					orphan.Parent = parent.name
+					orphan.ParentGuessed = true
+				}
+				orphans = append(orphans, orphan)
+				continue
+			}
+
+			ref, ok := refs[snapshotter+"/"+record.name]
+			if !ok {
+				orphan := explorers.NewOrphan("containerd", record.name, dir, explorers.OrphanNoMetadata)
+				orphan.Parent = record.parent
+				orphans = append(orphans, orphan)
+				continue
+			}
+
+			// Docker containers using the containerd image store are not
+			// recorded in meta.db.
+			if record.kind == snapshots.KindActive && ref.namespace != "moby" && !used[ref.namespace+"/"+snapshotter+"/"+ref.key] {
+				orphan := explorers.NewOrphan("containerd", ref.key, dir, explorers.OrphanNoContainer)
+				orphan.Parent = ref.parent
+				orphans = append(orphans, orphan)
+			}
+		}
+	}
+	return orphans, nil
+}
+
+// metaSnapshotRefs returns the meta.db snapshot keys by snapshotter and
+// snapshot name, and the snapshot keys used by containers by namespace,
+// snapshotter, and key.
+func (e *explorer) metaSnapshotRefs(ctx context.Context) (map[string]metaSnapshotRef, map[string]bool, error) {
+	nss, err := e.ListNamespaces(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refs := make(map[string]metaSnapshotRef)
+	used := make(map[string]bool)
+	store := metadata.NewContainerStore(metadata.NewDB(e.mdb, nil, nil))
+	for _, ns := range nss {
+		if err := e.mdb.View(func(tx *bolt.Tx) error {
+			bkt := getSnapshottersBucket(tx, ns)
+			if bkt == nil {
+				return nil
+			}
+			return bkt.ForEach(func(snapshotter, _ []byte) error {
+				ssbkt := bkt.Bucket(snapshotter)
+				if ssbkt == nil {
+					return nil
+				}
+				return ssbkt.ForEach(func(key, _ []byte) error {
+					kbkt := ssbkt.Bucket(key)
+					if kbkt == nil {
+						return nil
+					}
+					refs[string(snapshotter)+"/"+string(kbkt.Get(bucketKeyName))] = metaSnapshotRef{
+						namespace: ns,
+						key:       string(key),
+						parent:    string(kbkt.Get(bucketKeyParent)),
+					}
+					return nil
+				})
+			})
+		}); err != nil {
+			return nil, nil, err
+		}
+
+		ctrs, err := store.List(namespaces.WithNamespace(ctx, ns))
+		if err != nil {
+			return nil, nil, fmt.Errorf("listing containers in namespace %s: %w", ns, err)
+		}
+		for _, ctr := range ctrs {
+			used[ns+"/"+ctr.Snapshotter+"/"+ctr.SnapshotKey] = true
+		}
+	}
+	return refs, used, nil
+}
+
+// orphanLayers returns the overlay directories of a snapshot found by its
+// metadata.db name or by its snapshot directory ID. The parent of a
+// snapshot directory missing from metadata.db is guessed.
+func (e *explorer) orphanLayers(key string, parents bool) (explorers.LayerStack, error) {
+	for _, snapshotter := range e.snapshotters() {
+		root := filepath.Join(e.containerdRoot, snapshotterPrefix+snapshotter)
+		records, err := readSnapshotRecords(filepath.Join(root, "metadata.db"))
+		if err != nil {
+			continue
+		}
+		byName := make(map[string]snapshotRecord)
+		for _, record := range records {
+			byName[record.name] = record
+		}
+
+		var stack explorers.LayerStack
+		name := key
+		if _, ok := byName[key]; !ok {
+			id, err := strconv.ParseUint(key, 10, 64)
+			if err != nil {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(root, "snapshots", key)); err != nil {
+				continue
+			}
+			stack.LowerDirs = append(stack.LowerDirs, snapshotDir(root, id))
+
+			parent, _ := guessParent(records, id)
+			name = parent.name
+		}
+
+		for name != "" && (parents || len(stack.LowerDirs) == 0) {
+			record, ok := byName[name]
+			if !ok {
+				return explorers.LayerStack{}, fmt.Errorf("parent snapshot %s not found", name)
+			}
+			stack.LowerDirs = append(stack.LowerDirs, snapshotDir(root, record.id))
+			name = record.parent
+		}
+
+		log.WithFields(log.Fields{
+			"key":         key,
+			"snapshotter": snapshotter,
+			"lowerdirs":   len(stack.LowerDirs),
+		}).Debug("orphan snapshot layers")
+
+		return stack, nil
+	}
+	return explorers.LayerStack{}, fmt.Errorf("snapshot %s not found", key)
+}
+
+// snapshotters returns the snapshotters with a snapshot database in the
+// containerd root directory, the overlayfs snapshotter first.
+func (e *explorer) snapshotters() []string {
+	dbFiles, _ := filepath.Glob(filepath.Join(e.containerdRoot, snapshotterPrefix+"*", "metadata.db"))
+
+	var snapshotters []string
+	for _, dbFile := range dbFiles {
+		snapshotters = append(snapshotters, strings.TrimPrefix(filepath.Base(filepath.Dir(dbFile)), snapshotterPrefix))
+	}
+	sort.SliceStable(snapshotters, func(i, j int) bool {
+		return snapshotters[i] == "overlayfs" && snapshotters[j] != "overlayfs"
+	})
+	return snapshotters
+}
+
+// snapshotDir returns the directory containing the files of a snapshot. The
+// files of an overlayfs snapshot are in the fs directory, and the files of
+// a native snapshot are in the snapshot directory.
+func snapshotDir(root string, id uint64) string {
+	dir := filepath.Join(root, "snapshots", strconv.FormatUint(id, 10))
+	if _, err := os.Stat(filepath.Join(dir, "fs")); err == nil {
+		return filepath.Join(dir, "fs")
+	}
+	return dir
+}
+
+// guessParent returns the committed snapshot with the highest ID lower than
+// a snapshot ID. Snapshot IDs are sequential, and the snapshot of a container
+// is prepared after the image layers it uses are committed.
+func guessParent(records []snapshotRecord, id uint64) (snapshotRecord, bool) {
+	var parent snapshotRecord
+	found := false
+	for _, record := range records {
+		if record.kind == snapshots.KindCommitted && record.id < id && (!found || record.id > parent.id) {
+			parent = record
+			found = true
+		}
+	}
+	return parent, found
+}
+
+// readSnapshotRecords returns the snapshots of a snapshotter database.
+func readSnapshotRecords(dbFile string) ([]snapshotRecord, error) {
+	if _, err := os.Stat(dbFile); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(dbFile, 0444, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", dbFile, err)
+	}
+	defer db.Close()
+
+	var records []snapshotRecord
+	err = db.View(func(tx *bolt.Tx) error {
+		bkt := getBucket(tx, bucketKeyVersion, bucketKeyObjectSnapshots)
+		if bkt == nil {
+			return nil
+		}
+		return bkt.ForEach(func(k, _ []byte) error {
+			sbkt := bkt.Bucket(k)
+			if sbkt == nil {
+				return nil
+			}
+			id, _ := binary.Uvarint(sbkt.Get(bucketKeyID))
+			kind, _ := binary.Uvarint(sbkt.Get(bucketKeyKind))
+			records = append(records, snapshotRecord{
+				name:   string(k),
+				id:     id,
+				parent: string(sbkt.Get(bucketKeyParent)),
+				kind:   snapshots.Kind(uint8(kind)),
+			})
+			return nil
+		})
+	})
+	return records, err
+}
@@ -16,7 +16,22 @@ limitations under the License.
 
 package explorers
 
-import "github.com/containerd/containerd/content"
+import (
+	"encoding/json"
+
+	"github.com/containerd/containerd/content"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Content verification status values.
+const (
+	ContentStatusOK         = "OK"         // blob exists and matches its digest and size
+	ContentStatusMissing    = "MISSING"    // blob is in the metadata but not on disk
+	ContentStatusCorrupted  = "CORRUPTED"  // blob on disk does not match its digest or size
+	ContentStatusOrphaned   = "ORPHANED"   // blob is on disk but not in the metadata
+	ContentStatusUnreadable = "UNREADABLE" // blob exists but could not be read
+)
 
 // Content provides information about containers' content
 type Content struct {
@@ -24,3 +39,46 @@ type Content struct {
 	ContainerType string // Indicate if the container is containerd, docker, podman
 	content.Info
 }
+
+// ContentVerification provides the result of verifying a content blob on disk
+// against the content metadata.
+type ContentVerification struct {
+	Namespace     string        // namespace referencing the blob. Empty for orphaned blobs
+	ContainerType string        // container type: containerd, docker, podman, etc.
+	Digest        digest.Digest // digest recorded in the metadata or blob file name
+	Status        string        // one of the ContentStatus values
+	ExpectedSize  int64         // size recorded in the metadata
+	ActualSize    int64         // size of the blob on disk
+	ActualDigest  digest.Digest // digest computed from the blob on disk
+	Path          string        // blob path on disk
+}
+
+// DetectContentMediaType returns the media type of an image index, manifest,
+// or config blob.
+//
+// An empty string is returned if the data is not a recognized image metadata
+// blob, e.g. a layer.
+func DetectContentMediaType(data []byte) string {
+	var v struct {
+		MediaType string            `json:"mediaType"`
+		Manifests []json.RawMessage `json:"manifests"`
+		Layers    []json.RawMessage `json:"layers"`
+		Config    *json.RawMessage  `json:"config"`
+		RootFS    *json.RawMessage  `json:"rootfs"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return ""
+	}
+
+	switch {
+	case v.MediaType != "":
+		return v.MediaType
+	case v.Manifests != nil:
+		return ocispec.MediaTypeImageIndex
+	case v.Layers != nil && v.Config != nil:
+		return ocispec.MediaTypeImageManifest
+	case v.RootFS != nil:
+		return ocispec.MediaTypeImageConfig
+	}
+	return ""
+}
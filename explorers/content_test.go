@@ -0,0 +1,74 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestDetectContentMediaType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "Explicit media type",
+			data: `{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json"}`,
+			want: "application/vnd.docker.distribution.manifest.v2+json",
+		},
+		{
+			name: "Index without media type",
+			data: `{"schemaVersion":2,"manifests":[]}`,
+			want: ocispec.MediaTypeImageIndex,
+		},
+		{
+			name: "Manifest without media type",
+			data: `{"schemaVersion":2,"config":{"digest":"sha256:abcd"},"layers":[]}`,
+			want: ocispec.MediaTypeImageManifest,
+		},
+		{
+			name: "Image config",
+			data: `{"architecture":"amd64","config":{"Env":["PATH=/bin"]},"rootfs":{"type":"layers"}}`,
+			want: ocispec.MediaTypeImageConfig,
+		},
+		{
+			name: "Unrelated JSON",
+			data: `{"foo":"bar"}`,
+			want: "",
+		},
+		{
+			name: "Binary layer",
+			data: "\x1f\x8b\x08\x00",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := DetectContentMediaType([]byte(tt.data)); got != tt.want {
+				t.Errorf("DetectContentMediaType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
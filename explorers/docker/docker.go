@@ -21,10 +21,12 @@ import (
 	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/containerd/containerd/containers"
@@ -36,6 +38,7 @@ import (
 
 	"github.com/containerd/containerd/v2/core/mount"
 	digest "github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/identity"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	log "github.com/sirupsen/logrus"
 	bolt "go.etcd.io/bbolt"
@@ -50,8 +53,6 @@ const (
 	storageOverlay2      = "overlay2"
 )
 
-var imagerepo map[string]string
-
 type ImageName map[string]string
 
 type ImageRepository struct {
@@ -67,6 +68,22 @@ type explorer struct {
 	snapshotPath   string                      // Docker29+ overlayfs snapshotter database (containerd's overlayfs snapshotter)
 	snapshotDB     *bolt.DB                    // Containerd overlayfs snapshotter database handle
 	sc             *explorers.SupportContainer // support container object
+
+	imageReposOnce sync.Once         // reads the image repositories once
+	imageRepos     map[string]string // image friendly name by image ID
+}
+
+func init() {
+	explorers.Register(explorers.Registration{
+		Name:     "docker",
+		Priority: 10,
+		New: func(config explorers.Config) (explorers.ContainerExplorer, error) {
+			return NewExplorer(config.ImageRoot, config.ContainerdRoot, config.DockerRoot)
+		},
+		Detect: func(config explorers.Config) bool {
+			return config.DockerRoot != "" && utils.PathExistsV2(config.DockerRoot)
+		},
+	})
 }
 
 // NewExplorer returns a ContainerExplorer interface to explorer docker managed
@@ -134,15 +151,6 @@ func NewExplorer(imageRoot string, containerdRoot string, dockerRoot string) (ex
 	}, nil
 }
 
-// SnapshotRoot returns the snapshot root directory for docker managed
-// containers.
-//
-//nolint:revive // function is not yet implemented
-func (e *explorer) SnapshotRoot(snapshotter string) string {
-	// TODO(rmaskey): implement the function
-	return ""
-}
-
 // ListNamespaces returns namespaces for docker managed containers.
 func (e *explorer) ListNamespaces(ctx context.Context) ([]string, error) {
 	var nss []string
@@ -344,22 +352,6 @@ func (e *explorer) ListImages(_ context.Context) ([]explorers.Image, error) {
 	return ceimages, nil
 }
 
-// ListContent returns content information.
-func (e *explorer) ListContent(_ context.Context) ([]explorers.Content, error) {
-	// TODO(rmaskey): implement the function
-	log.Info("listing docker content not implemented")
-
-	return nil, nil
-}
-
-// ListSnapshots returns snapshot information.
-func (e *explorer) ListSnapshots(_ context.Context) ([]explorers.SnapshotKeyInfo, error) {
-	// TODO(rmaskey): implement the function
-	log.Info("listing docker snapshots is not implemented")
-
-	return nil, nil
-}
-
 // ListTasks returns container task status
 func (e *explorer) ListTasks(_ context.Context) ([]explorers.Task, error) {
 	var tasks []explorers.Task
@@ -435,13 +427,51 @@ func (e *explorer) MountContainer(ctx context.Context, containerID string, mount
 }
 
 // mountDockerV2Container mounts a container to the specified path
-func (e *explorer) mountDockerV2Container(_ context.Context, container ConfigFile, containerID string, mountpoint string) error {
+func (e *explorer) mountDockerV2Container(ctx context.Context, container ConfigFile, containerID string, mountpoint string) error {
+	layers, err := e.overlay2Layers(container, containerID)
+	if err != nil {
+		return err
+	}
+	upperDir := layers.UpperDir
+	lowerDir := strings.Join(layers.LowerDirs, ":")
+
+	// mounting container
+	mountopts := fmt.Sprintf("ro,lowerdir=%s:%s", upperDir, lowerDir)
+	mountargs := []string{"-t", "overlay", "overlay", "-o", mountopts, mountpoint}
+
+	record := utils.MountRecord{Mountpoint: mountpoint, Type: utils.MountTypeOverlay, Source: upperDir, ContainerID: containerID}
+	out, err := utils.Mount(ctx, record, mountargs...)
+	if err != nil {
+		log.Errorf("running mount command: %v", mountargs)
+
+		if strings.Contains(err.Error(), " 32") {
+			if string(out) != "" {
+				return fmt.Errorf("invalid lowerdir path %v; output: %s", err, strings.TrimSpace(string(out)))
+			}
+			return fmt.Errorf("invalid lowerdir path %v: use --debug to view lowerdir path", err)
+		}
+		if string(out) != "" {
+			return fmt.Errorf("executing mount command %v; output: %s", err, strings.TrimSpace(string(out)))
+		}
+		return fmt.Errorf("executing mount command %v", err)
+	}
+
+	if string(out) != "" {
+		log.WithField("mount command", string(out)).Debug("container mount command")
+	}
+
+	return nil
+}
+
+// overlay2Layers returns the overlay directories of a container using the
+// overlay2 storage driver.
+func (e *explorer) overlay2Layers(container ConfigFile, containerID string) (explorers.LayerStack, error) {
 	containerMountIDPath := filepath.Join(e.dockerRoot, imageDirName, container.Driver, "layerdb", "mounts", containerID, "mount-id")
 	log.WithField("containerMountIDPath", containerMountIDPath).Debug("container mount-id path")
 
 	mountIDByte, err := os.ReadFile(containerMountIDPath)
 	if err != nil {
-		return fmt.Errorf("reading container mount-id")
+		return explorers.LayerStack{}, fmt.Errorf("reading container mount-id")
 	}
 	mountID := strings.TrimSpace(string(mountIDByte))
 	log.WithField("mount-id", mountID).Debug("container mount-id")
@@ -452,7 +482,7 @@ func (e *explorer) mountDockerV2Container(_ context.Context, container ConfigFil
 	//nolint:gosec // G703: Path is constructed from trusted docker root and config
 	data, err := os.ReadFile(lowerdirpath)
 	if err != nil {
-		return fmt.Errorf("reading lower file %v", err)
+		return explorers.LayerStack{}, fmt.Errorf("reading lower file %v", err)
 	}
 
 	// Computing lowerdir for mounting
@@ -460,46 +490,227 @@ func (e *explorer) mountDockerV2Container(_ context.Context, container ConfigFil
 	for _, ldir := range strings.Split(strings.TrimSpace(string(data)), ":") {
 		lowerDirs = append(lowerDirs, filepath.Join(e.dockerRoot, container.Driver, ldir))
 	}
-	lowerDir := strings.Join(lowerDirs, ":")
 
 	// Getting upperdir for mounting
 	//nolint:gosec // G703: Path is constructed from trusted docker root and config
 	upperData, err := os.ReadFile(filepath.Join(e.dockerRoot, container.Driver, mountID, "link"))
 	if err != nil {
-		return fmt.Errorf("reading link file %v", err)
+		return explorers.LayerStack{}, fmt.Errorf("reading link file %v", err)
 	}
 	upperDir := filepath.Join(e.dockerRoot, container.Driver, "l", strings.TrimSpace(string(upperData)))
 
 	log.WithFields(log.Fields{
-		"lowerdir": lowerDir,
+		"lowerdir": strings.Join(lowerDirs, ":"),
 		"upperdir": upperDir,
 	}).Debug("container overlay directories")
 
-	// mounting container
-	mountopts := fmt.Sprintf("ro,lowerdir=%s:%s", upperDir, lowerDir)
-	mountargs := []string{"-t", "overlay", "overlay", "-o", mountopts, mountpoint}
+	return explorers.LayerStack{
+		UpperDir:  upperDir,
+		LowerDirs: lowerDirs,
+	}, nil
+}
 
-	out, err := utils.Runner.RunWithoutContext("mount", mountargs...)
+// ContainerLayers returns the overlay directories of a container.
+func (e *explorer) ContainerLayers(ctx context.Context, containerID string) (explorers.LayerStack, error) {
+	container, err := e.ReadContainerConfig(ctx, containerID)
 	if err != nil {
-		log.Errorf("running mount command: %v", mountargs)
+		return explorers.LayerStack{}, fmt.Errorf("reading container config: %w", err)
+	}
 
-		if strings.Contains(err.Error(), " 32") {
-			if string(out) != "" {
-				return fmt.Errorf("invalid lowerdir path %v; output: %s", err, strings.TrimSpace(string(out)))
-			}
-			return fmt.Errorf("invalid lowerdir path %v: use --debug to view lowerdir path", err)
+	switch container.Driver {
+	case "overlay2":
+		return e.overlay2Layers(container, containerID)
+	case "overlayfs":
+		upperdir, lowerPaths, err := e.GetOverlayfsLayers("moby", containerID)
+		if err != nil {
+			return explorers.LayerStack{}, fmt.Errorf("getting overlay layers: %w", err)
 		}
-		if string(out) != "" {
-			return fmt.Errorf("executing mount command %v; output: %s", err, strings.TrimSpace(string(out)))
+		return explorers.LayerStack{UpperDir: upperdir, LowerDirs: lowerPaths}, nil
+	default:
+		return explorers.LayerStack{}, fmt.Errorf("unsupported storage driver: %s", container.Driver)
+	}
+}
+
+// ImageLayers returns the overlay2 directories of the layers of an image.
+//
+// The image layers are found using the layer chain IDs in layerdb, where
+// cache-id names the layer directory in the overlay2 storage.
+func (e *explorer) ImageLayers(ctx context.Context, ref string) (explorers.LayerStack, error) {
+	storageDir := filepath.Join(e.dockerRoot, imageDirName, storageOverlay2)
+
+	imageID, err := e.findImageID(ctx, storageDir, ref)
+	if err != nil {
+		return explorers.LayerStack{}, err
+	}
+
+	imageContent, err := readImageContent(storageOverlay2, storageDir, imageID)
+	if err != nil {
+		return explorers.LayerStack{}, fmt.Errorf("reading image config %s: %w", imageID, err)
+	}
+	if len(imageContent.Rootfs.DiffIDs) == 0 {
+		return explorers.LayerStack{}, fmt.Errorf("image %s has no layers", ref)
+	}
+
+	diffIDs := make([]digest.Digest, len(imageContent.Rootfs.DiffIDs))
+	for i, diffID := range imageContent.Rootfs.DiffIDs {
+		diffIDs[i] = digest.Digest(diffID)
+	}
+	chainIDs := identity.ChainIDs(diffIDs)
+
+	// The top layer is the first lower directory.
+	var lowerDirs []string
+	for i := len(chainIDs) - 1; i >= 0; i-- {
+		cacheIDPath := filepath.Join(storageDir, "layerdb", chainIDs[i].Algorithm().String(), chainIDs[i].Encoded(), "cache-id")
+		//nolint:gosec // G304: Path is constructed from trusted docker root and image config
+		cacheID, err := os.ReadFile(cacheIDPath)
+		if err != nil {
+			return explorers.LayerStack{}, fmt.Errorf("reading layer cache-id: %w", err)
 		}
-		return fmt.Errorf("executing mount command %v", err)
+		lowerDirs = append(lowerDirs, e.overlay2LayerDir(strings.TrimSpace(string(cacheID))))
 	}
 
-	if string(out) != "" {
-		log.WithField("mount command", string(out)).Debug("container mount command")
+	log.WithFields(log.Fields{
+		"image":     ref,
+		"imageID":   imageID,
+		"lowerdirs": len(lowerDirs),
+	}).Debug("image layers")
+
+	return explorers.LayerStack{LowerDirs: lowerDirs}, nil
+}
+
+// findImageID returns the ID of an image matching the reference in
+// repositories.json or, for untagged images, the image ID prefix.
+func (e *explorer) findImageID(ctx context.Context, storageDir string, ref string) (digest.Digest, error) {
+	ceimages, err := e.ListImages(ctx)
+	if err != nil {
+		log.WithField("error", err).Debug("listing docker images")
+	}
+	for _, ceimage := range ceimages {
+		if explorers.MatchImageReference(ceimage.Name, ceimage.Target.Digest, ref) {
+			return ceimage.Target.Digest, nil
+		}
 	}
 
-	return nil
+	encoded := strings.TrimPrefix(ref, digest.SHA256.String()+":")
+	if len(encoded) >= 12 {
+		matches, _ := filepath.Glob(filepath.Join(storageDir, "imagedb", "content", digest.SHA256.String(), encoded+"*"))
+		if len(matches) == 1 {
+			return digest.NewDigestFromEncoded(digest.SHA256, filepath.Base(matches[0])), nil
+		}
+	}
+	return "", fmt.Errorf("image %s not found", ref)
+}
+
+// SnapshotLayers returns the overlay directories of a layer found by its
+// layerdb chain ID, its cache ID, the mount ID of a container layer, or its
+// overlay2 directory name. The parent chain is read from the layerdb parent
+// files.
+func (e *explorer) SnapshotLayers(_ context.Context, key string, parents bool) (explorers.LayerStack, error) {
+	storageDir := filepath.Join(e.dockerRoot, imageDirName, storageOverlay2)
+
+	layerDir, err := findLayerDBDir(storageDir, key)
+	if err != nil {
+		// The layer directories of removed containers and images can remain
+		// without a layerdb entry.
+		if layers, orphanErr := e.overlay2OrphanLayers(key, parents); orphanErr == nil {
+			return layers, nil
+		}
+		return explorers.LayerStack{}, err
+	}
+
+	var lowerDirs []string
+	for layerDir != "" {
+		cacheID, parent, err := readLayerDBDir(layerDir)
+		if err != nil {
+			return explorers.LayerStack{}, err
+		}
+		lowerDirs = append(lowerDirs, e.overlay2LayerDir(cacheID))
+
+		if !parents || parent == "" {
+			break
+		}
+		layerDir = filepath.Join(storageDir, "layerdb", parent.Algorithm().String(), parent.Encoded())
+	}
+
+	log.WithFields(log.Fields{
+		"key":       key,
+		"parents":   parents,
+		"lowerdirs": len(lowerDirs),
+	}).Debug("snapshot layers")
+
+	return explorers.LayerStack{LowerDirs: lowerDirs}, nil
+}
+
+// findLayerDBDir returns the layerdb directory of a layer chain ID or chain
+// ID prefix, a layer cache ID, or a container mount ID.
+func findLayerDBDir(storageDir string, key string) (string, error) {
+	layerDBDir := filepath.Join(storageDir, "layerdb")
+
+	encoded := strings.TrimPrefix(key, digest.SHA256.String()+":")
+	if len(encoded) >= 12 {
+		matches, _ := filepath.Glob(filepath.Join(layerDBDir, digest.SHA256.String(), encoded+"*"))
+		if len(matches) == 1 {
+			return matches[0], nil
+		}
+	}
+
+	for _, idFile := range []string{
+		filepath.Join(layerDBDir, digest.SHA256.String(), "*", "cache-id"),
+		filepath.Join(layerDBDir, "mounts", "*", "mount-id"),
+	} {
+		idPaths, _ := filepath.Glob(idFile)
+		for _, idPath := range idPaths {
+			//nolint:gosec // G304: Path is constructed from trusted docker root
+			id, err := os.ReadFile(idPath)
+			if err == nil && strings.TrimSpace(string(id)) == key {
+				return filepath.Dir(idPath), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("layer %s not found", key)
+}
+
+// readLayerDBDir returns the overlay2 directory name and the parent chain ID
+// of a layerdb layer or container mount directory.
+func readLayerDBDir(layerDir string) (string, digest.Digest, error) {
+	idFile := "cache-id"
+	if filepath.Base(filepath.Dir(layerDir)) == "mounts" {
+		idFile = "mount-id"
+	}
+
+	//nolint:gosec // G304: Path is constructed from trusted docker root
+	id, err := os.ReadFile(filepath.Join(layerDir, idFile))
+	if err != nil {
+		return "", "", fmt.Errorf("reading layer %s: %w", idFile, err)
+	}
+
+	//nolint:gosec // G304: Path is constructed from trusted docker root
+	parent, err := os.ReadFile(filepath.Join(layerDir, "parent"))
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", fmt.Errorf("reading layer parent: %w", err)
+	}
+
+	parentID := digest.Digest(strings.TrimSpace(string(parent)))
+	if parentID != "" {
+		if err := parentID.Validate(); err != nil {
+			return "", "", fmt.Errorf("invalid layer parent %s: %w", parentID, err)
+		}
+	}
+	return strings.TrimSpace(string(id)), parentID, nil
+}
+
+// overlay2LayerDir returns the short link of an overlay2 layer directory,
+// keeping the mount options short, or its diff directory.
+func (e *explorer) overlay2LayerDir(cacheID string) string {
+	layerDir := filepath.Join(e.dockerRoot, storageOverlay2, cacheID)
+	//nolint:gosec // G304: Path is constructed from trusted docker root and layerdb
+	if link, err := os.ReadFile(filepath.Join(layerDir, "link")); err == nil {
+		linkDir := filepath.Join(e.dockerRoot, storageOverlay2, "l", strings.TrimSpace(string(link)))
+		if _, err := os.Stat(linkDir); err == nil {
+			return linkDir
+		}
+	}
+	return filepath.Join(layerDir, "diff")
 }
 
 func (e *explorer) GetOverlayfsLayers(namespace string, containerID string) (string, []string, error) {
@@ -646,6 +857,10 @@ func (e *explorer) mountDockerV29Container(_ context.Context, _ ConfigFile, cont
 		return fmt.Errorf("read-only overlay mount failed: %w", err)
 	}
 
+	record := utils.MountRecord{Mountpoint: absMountPoint, Type: utils.MountTypeOverlay, Source: upperdir, ContainerID: containerID}
+	if err := utils.RegisterMount(record); err != nil {
+		log.WithFields(log.Fields{"mountpoint": absMountPoint, "error": err}).Warn("recording mount")
+	}
 	return nil
 }
 
@@ -664,11 +879,15 @@ func (e *explorer) MountAllContainers(ctx context.Context, mountpoint string, fi
 
 	filters := strings.Split(filter, ",")
 
+	var errs []error
+	var targets []explorers.Container
+	var ids []string
 	for _, containerID := range containerIDs {
 		cecontainer, err := e.GetCEContainer(ctx, containerID)
 		if err != nil {
 			log.WithField("containerID", containerID).Error("getting container details")
 			log.WithField("containerID", containerID).Warn("skipping container mount")
+			errs = append(errs, explorers.NewItemError(e.Type(), "mount", containerID, err))
 			continue
 		}
 
@@ -706,6 +925,13 @@ func (e *explorer) MountAllContainers(ctx context.Context, mountpoint string, fi
 			continue
 		}
 
+		targets = append(targets, cecontainer)
+		ids = append(ids, containerID)
+	}
+
+	err = explorers.RunContainerJobs(ctx, e.Type(), "mount", ids, func(i int) error {
+		cecontainer := targets[i]
+
 		// Create mountpoint for each container
 		ctrmountpoint := filepath.Join(mountpoint, cecontainer.ID)
 		if err := os.MkdirAll(ctrmountpoint, 0755); err != nil {
@@ -714,38 +940,47 @@ func (e *explorer) MountAllContainers(ctx context.Context, mountpoint string, fi
 				"containerID": cecontainer.ID,
 				"mountpoint":  ctrmountpoint,
 			}).Error("creating mountpoint for container")
-			log.WithField("containerID", containerID).Warn("skippoing container mount")
-			continue
+			log.WithField("containerID", ids[i]).Warn("skippoing container mount")
+			return err
 		}
 
-		if err := e.MountContainer(ctx, containerID, ctrmountpoint); err != nil {
+		if err := e.MountContainer(ctx, ids[i], ctrmountpoint); err != nil {
 			log.WithFields(log.Fields{
-				"containerID": containerID,
+				"containerID": ids[i],
 				"message":     err.Error(),
 			}).Error("mounting container")
+			return err
 		}
-	}
+		return nil
+	})
 
-	// default
-	return nil
+	return errors.Join(append(errs, err)...)
 }
 
 // ContainerDrift finds drifted files from all the containers
 func (e *explorer) ContainerDrift(ctx context.Context, filter string, skipsupportcontainers bool, containerID string) ([]explorers.Drift, error) {
-	var drifts []explorers.Drift
+	return explorers.CollectDrift(ctx, e, filter, skipsupportcontainers, containerID)
+}
+
+// StreamContainerDrift finds drifted files from all the containers, and
+// calls fn with the drift of each container as it completes.
+func (e *explorer) StreamContainerDrift(ctx context.Context, filter string, skipsupportcontainers bool, containerID string, fn func(explorers.Drift) error) error {
+	var errs []error
 	containerDir := filepath.Join(e.dockerRoot, containerDirName)
 	log.WithField("containerDir", containerDir).Debug("docker containers directory")
 
 	containerIDs, err := e.GetContainerIDs(ctx, containerDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed listing container IDs %v", err)
+		return fmt.Errorf("failed listing container IDs %v", err)
 	}
 	if containerIDs == nil {
-		return nil, fmt.Errorf("no container IDs returned")
+		return fmt.Errorf("no container IDs returned")
 	}
 
 	filters := strings.Split(filter, ",")
 
+	var targets []explorers.Container
+	var ids []string
 	for _, id := range containerIDs {
 		cecontainer, err := e.GetCEContainer(ctx, id)
 		if err != nil {
@@ -753,6 +988,9 @@ func (e *explorer) ContainerDrift(ctx context.Context, filter string, skipsuppor
 				"containerID": id,
 				"message":     err.Error(),
 			}).Warn("unable to get container details. Skipping container mount")
+			if containerID == "" || id == containerID {
+				errs = append(errs, explorers.NewItemError(e.Type(), "drift", id, err))
+			}
 			continue
 		}
 
@@ -795,90 +1033,106 @@ func (e *explorer) ContainerDrift(ctx context.Context, filter string, skipsuppor
 			continue
 		}
 
-		container, err := e.ReadContainerConfig(ctx, cecontainer.ID)
-		if err != nil {
-			log.WithFields(log.Fields{"containerID": cecontainer.ID, "error": err}).Error("getting container")
-			continue
-		}
+		targets = append(targets, cecontainer)
+		ids = append(ids, cecontainer.ID)
+	}
 
-		// Container upper directory for drift scanning
-		var upperDir string
+	err = explorers.StreamContainerJobs(ctx, e.Type(), "drift", ids, func(i int) (*explorers.Drift, error) {
+		return e.containerDrift(ctx, targets[i])
+	}, func(drift *explorers.Drift) error {
+		// Containers without drift detection have no drift.
+		if drift == nil {
+			return nil
+		}
+		return fn(*drift)
+	})
+	return errors.Join(append(errs, err)...)
+}
 
-		switch container.Driver {
-		case "overlay2":
-			containerMountIDPath := filepath.Join(e.dockerRoot, imageDirName, container.Driver, "layerdb", "mounts", container.ID, "mount-id")
+// containerDrift finds the drifted files of a container.
+func (e *explorer) containerDrift(ctx context.Context, cecontainer explorers.Container) (*explorers.Drift, error) {
+	container, err := e.ReadContainerConfig(ctx, cecontainer.ID)
+	if err != nil {
+		log.WithFields(log.Fields{"containerID": cecontainer.ID, "error": err}).Error("getting container")
+		return nil, err
+	}
 
-			mountIDByte, err := os.ReadFile(containerMountIDPath)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"containerID": container.ID,
-					"message":     err,
-				}).Info("reading container mount-id")
-				continue
-			}
-			mountID := strings.TrimSpace(string(mountIDByte))
+	// Container upper directory for drift scanning
+	var upperDir string
 
-			upperDirLinkFile := filepath.Join(e.dockerRoot, container.Driver, mountID, "link")
+	switch container.Driver {
+	case "overlay2":
+		containerMountIDPath := filepath.Join(e.dockerRoot, imageDirName, container.Driver, "layerdb", "mounts", container.ID, "mount-id")
 
-			//nolint:gosec // G703: Path is constructed from trusted docker root and config
-			linkData, err := os.ReadFile(upperDirLinkFile)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"containerID": container.ID,
-					"message":     err,
-				}).Info("reading upperdir link file")
-				continue
-			}
-			upperDir = filepath.Join(e.dockerRoot, container.Driver, "l", strings.TrimSpace(string(linkData)))
+		mountIDByte, err := os.ReadFile(containerMountIDPath)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"containerID": container.ID,
+				"message":     err,
+			}).Info("reading container mount-id")
+			return nil, err
+		}
+		mountID := strings.TrimSpace(string(mountIDByte))
 
-		case "overlayfs":
-			upperDir, _, err = e.GetOverlayfsLayers("moby", container.ID)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"containerID": container.ID,
-					"error":       err,
-				}).Info("getting upperdir snapshot")
-				continue
-			}
+		upperDirLinkFile := filepath.Join(e.dockerRoot, container.Driver, mountID, "link")
 
-		default:
-			log.WithField("containerID", container.ID).Warn("unable to find upperdir")
+		//nolint:gosec // G703: Path is constructed from trusted docker root and config
+		linkData, err := os.ReadFile(upperDirLinkFile)
+		if err != nil {
 			log.WithFields(log.Fields{
-				"containerType": e.Type(),
-				"containerID":   container.ID,
-				"driver":        container.Driver,
-			}).Info("unsupported driver")
-			upperDir = ""
-			continue
+				"containerID": container.ID,
+				"message":     err,
+			}).Info("reading upperdir link file")
+			return nil, err
 		}
+		upperDir = filepath.Join(e.dockerRoot, container.Driver, "l", strings.TrimSpace(string(linkData)))
 
-		// ScanDiff
-		addedOrModified, inaccessibleFiles, err := explorers.ScanDiffDirectory(upperDir)
+	case "overlayfs":
+		upperDir, _, err = e.GetOverlayfsLayers("moby", container.ID)
 		if err != nil {
-			log.WithFields(log.Fields{"containerID": container.ID, "error": err}).Error("failed to scan diff directory")
-			continue
-		}
-		drift := explorers.Drift{
-			ContainerID:       cecontainer.ID,
-			ContainerType:     cecontainer.ContainerType,
-			AddedOrModified:   addedOrModified,
-			InaccessibleFiles: inaccessibleFiles,
+			log.WithFields(log.Fields{
+				"containerID": container.ID,
+				"error":       err,
+			}).Info("getting upperdir snapshot")
+			return nil, err
 		}
 
-		drifts = append(drifts, drift)
-		for _, path := range addedOrModified {
+	default:
+		log.WithField("containerID", container.ID).Warn("unable to find upperdir")
+		log.WithFields(log.Fields{
+			"containerType": e.Type(),
+			"containerID":   container.ID,
+			"driver":        container.Driver,
+		}).Info("unsupported driver")
+		upperDir = ""
+		return nil, &explorers.UnsupportedError{ContainerType: e.Type(), Capability: container.Driver + " driver"}
+	}
+
+	// ScanDiff
+	addedOrModified, inaccessibleFiles, err := explorers.ScanDiffDirectoryContext(ctx, upperDir)
+	if err != nil {
+		log.WithFields(log.Fields{"containerID": container.ID, "error": err}).Error("failed to scan diff directory")
+		return nil, err
+	}
+	drift := explorers.Drift{
+		ContainerID:       cecontainer.ID,
+		ContainerType:     cecontainer.ContainerType,
+		AddedOrModified:   addedOrModified,
+		InaccessibleFiles: inaccessibleFiles,
+	}
+
+	for _, path := range addedOrModified {
+		log.WithFields(log.Fields{
+			"A ": path}).Debug("added or modified files")
+	}
+	if len(inaccessibleFiles) > 0 {
+		for _, path := range inaccessibleFiles {
 			log.WithFields(log.Fields{
-				"A ": path}).Debug("added or modified files")
-		}
-		if len(inaccessibleFiles) > 0 {
-			for _, path := range inaccessibleFiles {
-				log.WithFields(log.Fields{
-					"D ": path}).Debug("deleted files")
-			}
+				"D ": path}).Debug("deleted files")
 		}
 	}
-	// default
-	return drifts, nil
+
+	return &drift, nil
 }
 
 // Close releases internal resources.
@@ -932,9 +1186,9 @@ func (e *explorer) ReadContainerConfig(_ context.Context, containerID string) (C
 
 // GetCEContainer returns ContainerExplorer container
 func (e *explorer) GetCEContainer(ctx context.Context, containerID string) (explorers.Container, error) {
-	if imagerepo == nil {
-		imagerepo, _ = e.GetRepositories(ctx)
-	}
+	e.imageReposOnce.Do(func() {
+		e.imageRepos, _ = e.GetRepositories(ctx)
+	})
 
 	// Get docker container configuration based on container ID
 	config, err := e.ReadContainerConfig(ctx, containerID)
@@ -945,8 +1199,8 @@ func (e *explorer) GetCEContainer(ctx context.Context, containerID string) (expl
 	cectr := convertToContainerExplorerContainer(config)
 
 	// Use image friendly name if exits
-	if imagerepo != nil {
-		if val, found := imagerepo[cectr.Image]; found {
+	if e.imageRepos != nil {
+		if val, found := e.imageRepos[cectr.Image]; found {
 			cectr.Image = val
 		}
 	}
@@ -1068,6 +1322,7 @@ func convertToContainerExplorerContainer(config ConfigFile) explorers.Container
 			CreatedAt:   config.Created,
 			Image:       config.Image,
 			Snapshotter: config.Driver,
+			Labels:      config.Config.Labels,
 			Runtime: containers.RuntimeInfo{
 				Name: config.Name,
 			},
@@ -1075,6 +1330,9 @@ func convertToContainerExplorerContainer(config ConfigFile) explorers.Container
 		Running:      config.State.Running,
 		ExposedPorts: exposedPorts,
 		Status:       status,
+		StartedAt:    config.State.StartedAt,
+		FinishedAt:   config.State.FinishedAt,
+		OOMKilled:    config.State.OOMKilled,
 	}
 }
 
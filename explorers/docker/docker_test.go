@@ -22,11 +22,15 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/containerd/containerd/metadata"
+	"github.com/google/container-explorer/explorers"
 	"github.com/google/container-explorer/utils"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/identity"
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -294,9 +298,15 @@ func TestGetCEContainer(t *testing.T) {
 	if ceCtr.Status != "RUNNING" {
 		t.Errorf("expected Status 'RUNNING', got '%s'", ceCtr.Status)
 	}
+	if !ceCtr.StartedAt.Equal(now) || !ceCtr.FinishedAt.IsZero() || ceCtr.OOMKilled {
+		t.Errorf("expected StartedAt %v, got %v %v %t", now, ceCtr.StartedAt, ceCtr.FinishedAt, ceCtr.OOMKilled)
+	}
 	if ceCtr.Image != "sha256:imagehash12345" {
 		t.Errorf("expected Image 'sha256:imagehash12345', got '%s'", ceCtr.Image)
 	}
+	if ceCtr.Labels["app"] != "test" {
+		t.Errorf("expected label app=test, got %v", ceCtr.Labels)
+	}
 }
 
 func TestListContainers(t *testing.T) {
@@ -597,7 +607,7 @@ func TestListNamespaces(t *testing.T) {
 	}
 	defer exp.Close()
 
-	nss, err := exp.ListNamespaces(context.Background())
+	nss, err := exp.(explorers.NamespaceLister).ListNamespaces(context.Background())
 	if err != nil {
 		t.Fatalf("ListNamespaces failed: %v", err)
 	}
@@ -951,9 +961,9 @@ func TestExportContainer(t *testing.T) {
 	defer func() { utils.Runner = origRunner }()
 
 	outputDir := filepath.Join(tmpDir, "output")
-	exportOptions := map[string]bool{
-		"archive": true,
-		"image":   true,
+	exportOptions := explorers.ContainerExportOptions{
+		Archive: true,
+		Image:   true,
 	}
 	err = exp.ExportContainer(context.Background(), cID, outputDir, exportOptions)
 	if err != nil {
@@ -969,7 +979,6 @@ func TestExportContainer(t *testing.T) {
 
 	hasMount := false
 	hasUmount := false
-	hasTar := false
 	for _, name := range callNames {
 		if name == "mount" {
 			hasMount = true
@@ -977,9 +986,6 @@ func TestExportContainer(t *testing.T) {
 		if name == "umount" {
 			hasUmount = true
 		}
-		if name == "tar" {
-			hasTar = true
-		}
 	}
 
 	if !hasMount {
@@ -988,8 +994,8 @@ func TestExportContainer(t *testing.T) {
 	if !hasUmount {
 		t.Errorf("expected 'umount' to be executed")
 	}
-	if !hasTar {
-		t.Errorf("expected 'tar' to be executed")
+	if _, err := os.Stat(filepath.Join(outputDir, cID+".tar.gz")); err != nil {
+		t.Errorf("expected container archive: %v", err)
 	}
 }
 
@@ -1052,8 +1058,8 @@ func TestExportAllContainers(t *testing.T) {
 	defer func() { utils.Runner = origRunner }()
 
 	outputDir := filepath.Join(tmpDir, "output")
-	exportOptions := map[string]bool{
-		"archive": true,
+	exportOptions := explorers.ContainerExportOptions{
+		Archive: true,
 	}
 
 	err = exp.ExportAllContainers(context.Background(), outputDir, exportOptions, nil, false)
@@ -1062,23 +1068,11 @@ func TestExportAllContainers(t *testing.T) {
 	}
 
 	exportedArchive := filepath.Join(outputDir, cID+".tar.gz")
-	hasTar := false
-	for _, c := range mockRunner.Calls {
-		if c.Name == "tar" {
-			hasTar = true
-			foundOut := false
-			for _, arg := range c.Args {
-				if arg == exportedArchive {
-					foundOut = true
-				}
-			}
-			if !foundOut {
-				t.Errorf("expected tar command to export to '%s', args were: %v", exportedArchive, c.Args)
-			}
-		}
+	if _, err := os.Stat(exportedArchive); err != nil {
+		t.Errorf("expected archive %s: %v", exportedArchive, err)
 	}
-	if !hasTar {
-		t.Errorf("expected 'tar' to be executed")
+	if _, err := os.Stat(exportedArchive + ".manifest.json"); err != nil {
+		t.Errorf("expected archive manifest: %v", err)
 	}
 }
 
@@ -1151,6 +1145,174 @@ func TestContainerDrift(t *testing.T) {
 	}
 }
 
+func TestContainerLayers(t *testing.T) {
+	tmpDir := t.TempDir()
+	dockerRoot := filepath.Join(tmpDir, "docker_root")
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	_ = os.Mkdir(dockerRoot, 0755)
+	_ = os.Mkdir(containerdRoot, 0755)
+
+	exp, err := NewExplorer("", containerdRoot, dockerRoot)
+	if err != nil {
+		t.Fatalf("failed to create explorer: %v", err)
+	}
+
+	cID := "container-1"
+	cDir := filepath.Join(dockerRoot, "containers", cID)
+	_ = os.MkdirAll(cDir, 0755)
+	data, _ := json.Marshal(ConfigFile{ID: cID, Driver: "overlay2"})
+	_ = os.WriteFile(filepath.Join(cDir, "config.v2.json"), data, 0600)
+
+	mountIDDir := filepath.Join(dockerRoot, "image", "overlay2", "layerdb", "mounts", cID)
+	_ = os.MkdirAll(mountIDDir, 0755)
+	_ = os.WriteFile(filepath.Join(mountIDDir, "mount-id"), []byte("mount-id-123"), 0600)
+
+	mountDir := filepath.Join(dockerRoot, "overlay2", "mount-id-123")
+	_ = os.MkdirAll(mountDir, 0755)
+	_ = os.WriteFile(filepath.Join(mountDir, "link"), []byte("link-xyz"), 0600)
+	_ = os.WriteFile(filepath.Join(mountDir, "lower"), []byte("l/lower-2:l/lower-1"), 0600)
+
+	layers, err := exp.ContainerLayers(context.Background(), cID)
+	if err != nil {
+		t.Fatalf("ContainerLayers failed: %v", err)
+	}
+
+	expectedUpper := filepath.Join(dockerRoot, "overlay2", "l", "link-xyz")
+	if layers.UpperDir != expectedUpper {
+		t.Errorf("expected upper dir %s, got %s", expectedUpper, layers.UpperDir)
+	}
+	expectedLower := []string{
+		filepath.Join(dockerRoot, "overlay2", "l", "lower-2"),
+		filepath.Join(dockerRoot, "overlay2", "l", "lower-1"),
+	}
+	if len(layers.LowerDirs) != 2 || layers.LowerDirs[0] != expectedLower[0] || layers.LowerDirs[1] != expectedLower[1] {
+		t.Errorf("expected lower dirs %v, got %v", expectedLower, layers.LowerDirs)
+	}
+
+	// Unsupported storage driver
+	data, _ = json.Marshal(ConfigFile{ID: cID, Driver: "vfs"})
+	_ = os.WriteFile(filepath.Join(cDir, "config.v2.json"), data, 0600)
+	if _, err := exp.ContainerLayers(context.Background(), cID); err == nil {
+		t.Errorf("expected error for unsupported storage driver")
+	}
+}
+
+func TestImageLayers(t *testing.T) {
+	tmpDir := t.TempDir()
+	dockerRoot := filepath.Join(tmpDir, "docker_root")
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	_ = os.Mkdir(containerdRoot, 0755)
+
+	// Two layers, the top layer with a short link
+	diffIDs := []digest.Digest{digest.FromString("base"), digest.FromString("top")}
+	chainIDs := identity.ChainIDs(append([]digest.Digest(nil), diffIDs...))
+
+	storageDir := filepath.Join(dockerRoot, "image", "overlay2")
+	configData, _ := json.Marshal(map[string]any{
+		"rootfs": map[string]any{"type": "layers", "diff_ids": diffIDs},
+	})
+	imageID := digest.FromBytes(configData)
+	_ = os.MkdirAll(filepath.Join(storageDir, "imagedb", "content", "sha256"), 0755)
+	_ = os.WriteFile(filepath.Join(storageDir, "imagedb", "content", "sha256", imageID.Encoded()), configData, 0600)
+	repositories, _ := json.Marshal(ImageRepository{Repositories: map[string]ImageName{
+		"nginx": {"nginx:latest": imageID.String()},
+	}})
+	_ = os.WriteFile(filepath.Join(storageDir, "repositories.json"), repositories, 0600)
+
+	for i, cacheID := range []string{"cache-base", "cache-top"} {
+		layerDir := filepath.Join(storageDir, "layerdb", "sha256", chainIDs[i].Encoded())
+		_ = os.MkdirAll(layerDir, 0755)
+		_ = os.WriteFile(filepath.Join(layerDir, "cache-id"), []byte(cacheID), 0600)
+		_ = os.MkdirAll(filepath.Join(dockerRoot, "overlay2", cacheID, "diff"), 0755)
+	}
+	_ = os.WriteFile(filepath.Join(dockerRoot, "overlay2", "cache-top", "link"), []byte("TOPLINK"), 0600)
+	_ = os.MkdirAll(filepath.Join(dockerRoot, "overlay2", "l"), 0755)
+	_ = os.Symlink("../cache-top/diff", filepath.Join(dockerRoot, "overlay2", "l", "TOPLINK"))
+
+	exp, err := NewExplorer("", containerdRoot, dockerRoot)
+	if err != nil {
+		t.Fatalf("failed to create explorer: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(dockerRoot, "overlay2", "l", "TOPLINK"),
+		filepath.Join(dockerRoot, "overlay2", "cache-base", "diff"),
+	}
+	for _, ref := range []string{"nginx", "nginx:latest", imageID.Encoded()[:12]} {
+		layers, err := exp.ImageLayers(context.Background(), ref)
+		if err != nil {
+			t.Fatalf("ImageLayers(%s) failed: %v", ref, err)
+		}
+		if layers.UpperDir != "" || strings.Join(layers.LowerDirs, ":") != strings.Join(expected, ":") {
+			t.Errorf("ImageLayers(%s): expected lower dirs %v, got %+v", ref, expected, layers)
+		}
+	}
+
+	if _, err := exp.ImageLayers(context.Background(), "missing:latest"); err == nil {
+		t.Errorf("expected error for unknown image")
+	}
+}
+
+func TestSnapshotLayers(t *testing.T) {
+	tmpDir := t.TempDir()
+	dockerRoot := filepath.Join(tmpDir, "docker_root")
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	_ = os.Mkdir(containerdRoot, 0755)
+
+	// Two image layers and a container layer on top
+	chainIDs := identity.ChainIDs([]digest.Digest{digest.FromString("base"), digest.FromString("top")})
+	storageDir := filepath.Join(dockerRoot, "image", "overlay2")
+	for i, cacheID := range []string{"cache-base", "cache-top"} {
+		layerDir := filepath.Join(storageDir, "layerdb", "sha256", chainIDs[i].Encoded())
+		_ = os.MkdirAll(layerDir, 0755)
+		_ = os.WriteFile(filepath.Join(layerDir, "cache-id"), []byte(cacheID+"\n"), 0600)
+		if i > 0 {
+			_ = os.WriteFile(filepath.Join(layerDir, "parent"), []byte(chainIDs[i-1].String()), 0600)
+		}
+		_ = os.MkdirAll(filepath.Join(dockerRoot, "overlay2", cacheID, "diff"), 0755)
+	}
+	mountDir := filepath.Join(storageDir, "layerdb", "mounts", "container-1")
+	_ = os.MkdirAll(mountDir, 0755)
+	_ = os.WriteFile(filepath.Join(mountDir, "mount-id"), []byte("mount-1"), 0600)
+	_ = os.WriteFile(filepath.Join(mountDir, "parent"), []byte(chainIDs[1].String()), 0600)
+	_ = os.MkdirAll(filepath.Join(dockerRoot, "overlay2", "mount-1", "diff"), 0755)
+
+	exp, err := NewExplorer("", containerdRoot, dockerRoot)
+	if err != nil {
+		t.Fatalf("failed to create explorer: %v", err)
+	}
+
+	base := filepath.Join(dockerRoot, "overlay2", "cache-base", "diff")
+	top := filepath.Join(dockerRoot, "overlay2", "cache-top", "diff")
+	container := filepath.Join(dockerRoot, "overlay2", "mount-1", "diff")
+
+	tests := []struct {
+		key      string
+		parents  bool
+		expected []string
+	}{
+		{chainIDs[1].String(), false, []string{top}},
+		{chainIDs[1].Encoded()[:12], true, []string{top, base}},
+		{"cache-base", true, []string{base}},
+		{"cache-top", true, []string{top, base}},
+		{"mount-1", false, []string{container}},
+		{"mount-1", true, []string{container, top, base}},
+	}
+	for _, tt := range tests {
+		layers, err := exp.SnapshotLayers(context.Background(), tt.key, tt.parents)
+		if err != nil {
+			t.Fatalf("SnapshotLayers(%s, %t) failed: %v", tt.key, tt.parents, err)
+		}
+		if layers.UpperDir != "" || strings.Join(layers.LowerDirs, ":") != strings.Join(tt.expected, ":") {
+			t.Errorf("SnapshotLayers(%s, %t): expected lower dirs %v, got %+v", tt.key, tt.parents, tt.expected, layers)
+		}
+	}
+
+	if _, err := exp.SnapshotLayers(context.Background(), "missing", false); err == nil {
+		t.Errorf("expected error for unknown layer")
+	}
+}
+
 func TestGetRepositories(t *testing.T) {
 	// Case 1: Missing image repository directory entirely
 	tmpDir := t.TempDir()
@@ -1214,3 +1376,81 @@ func TestGetRepositories(t *testing.T) {
 		t.Errorf("expected mapping %s -> 'nginx:latest', got '%s'", expectedDigest, repos[expectedDigest])
 	}
 }
+
+func TestListOrphans(t *testing.T) {
+	tmpDir := t.TempDir()
+	dockerRoot := filepath.Join(tmpDir, "docker_root")
+	containerdRoot := filepath.Join(tmpDir, "containerd_root")
+	_ = os.Mkdir(containerdRoot, 0755)
+
+	// An image layer, the layers of a container and of a removed container
+	// still in layerdb, and a layer of a removed container only on disk
+	overlayDir := filepath.Join(dockerRoot, "overlay2")
+	layerDBDir := filepath.Join(dockerRoot, "image", "overlay2", "layerdb")
+	chainID := digest.FromString("base")
+	_ = os.MkdirAll(filepath.Join(layerDBDir, "sha256", chainID.Encoded()), 0755)
+	_ = os.WriteFile(filepath.Join(layerDBDir, "sha256", chainID.Encoded(), "cache-id"), []byte("cache-base"), 0600)
+	for _, containerID := range []string{"container-1", "container-2"} {
+		mountDir := filepath.Join(layerDBDir, "mounts", containerID)
+		_ = os.MkdirAll(mountDir, 0755)
+		_ = os.WriteFile(filepath.Join(mountDir, "mount-id"), []byte("mount-"+containerID), 0600)
+		_ = os.WriteFile(filepath.Join(mountDir, "init-id"), []byte("mount-"+containerID+"-init"), 0600)
+		_ = os.WriteFile(filepath.Join(mountDir, "parent"), []byte(chainID.String()), 0600)
+	}
+	_ = os.MkdirAll(filepath.Join(dockerRoot, "containers", "container-1"), 0755)
+
+	_ = os.MkdirAll(filepath.Join(overlayDir, "l"), 0755)
+	for _, id := range []string{"cache-base", "mount-container-1", "mount-container-1-init", "mount-container-2", "mount-container-2-init", "deleted", "deleted-init"} {
+		_ = os.MkdirAll(filepath.Join(overlayDir, id, "diff"), 0755)
+		_ = os.WriteFile(filepath.Join(overlayDir, id, "link"), []byte("L-"+id), 0600)
+		_ = os.Symlink("../"+id+"/diff", filepath.Join(overlayDir, "l", "L-"+id))
+		if strings.HasSuffix(id, "-init") {
+			_ = os.WriteFile(filepath.Join(overlayDir, id, "lower"), []byte("l/L-cache-base"), 0600)
+		} else if id != "cache-base" {
+			_ = os.WriteFile(filepath.Join(overlayDir, id, "lower"), []byte("l/L-"+id+"-init:l/L-cache-base"), 0600)
+		}
+	}
+	_ = os.WriteFile(filepath.Join(overlayDir, "deleted", "diff", "kworker"), []byte("payload"), 0755)
+
+	exp, err := NewExplorer("", containerdRoot, dockerRoot)
+	if err != nil {
+		t.Fatalf("failed to create explorer: %v", err)
+	}
+
+	orphans, err := exp.ListOrphans(context.Background())
+	if err != nil {
+		t.Fatalf("ListOrphans failed: %v", err)
+	}
+	if len(orphans) != 2 {
+		t.Fatalf("expected 2 orphans, got %+v", orphans)
+	}
+	if orphans[0].Key != "mount-container-2" || orphans[0].Reason != explorers.OrphanNoContainer || orphans[0].Parent != "mount-container-2-init" {
+		t.Errorf("expected the layer of the removed container, got %+v", orphans[0])
+	}
+	if orphans[1].Key != "deleted" || orphans[1].Reason != explorers.OrphanUnreferenced || orphans[1].Parent != "deleted-init" || orphans[1].ParentGuessed {
+		t.Errorf("expected the unreferenced layer, got %+v", orphans[1])
+	}
+	if orphans[1].ContainerType != "docker" || orphans[1].Path != filepath.Join(overlayDir, "deleted", "diff") || orphans[1].Size != 7 {
+		t.Errorf("unexpected unreferenced layer %+v", orphans[1])
+	}
+
+	// The unreferenced layer is mounted using its directory name
+	layers, err := exp.SnapshotLayers(context.Background(), "deleted", true)
+	if err != nil {
+		t.Fatalf("SnapshotLayers failed: %v", err)
+	}
+	expected := []string{
+		filepath.Join(overlayDir, "l", "L-deleted"),
+		filepath.Join(overlayDir, "l", "L-deleted-init"),
+		filepath.Join(overlayDir, "l", "L-cache-base"),
+	}
+	if strings.Join(layers.LowerDirs, ":") != strings.Join(expected, ":") {
+		t.Errorf("expected lower dirs %v, got %v", expected, layers.LowerDirs)
+	}
+	if layers, err := exp.SnapshotLayers(context.Background(), "deleted", false); err != nil || len(layers.LowerDirs) != 1 {
+		t.Errorf("expected a single layer, got %+v, %v", layers, err)
+	}
+	if _, err := exp.SnapshotLayers(context.Background(), "l", false); err == nil {
+		t.Errorf("expected error for the short link directory")
+	}
+}
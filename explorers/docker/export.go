@@ -18,17 +18,22 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/containerd/containerd/namespaces"
 	"github.com/google/container-explorer/explorers"
 	"github.com/google/container-explorer/utils"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	log "github.com/sirupsen/logrus"
 )
 
 // ExportContainer exports a container either as a raw image or an archive.
-func (e *explorer) ExportContainer(ctx context.Context, containerID string, outputDir string, exportOptions map[string]bool) error {
+func (e *explorer) ExportContainer(ctx context.Context, containerID string, outputDir string, options explorers.ContainerExportOptions) error {
 	// Check if the specified containerID exists.
 	containerExists := false
 
@@ -77,6 +82,22 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 			"containerType": targetContainer.ContainerType,
 		}).Info("container found")
 
+		e.recordCustody(ctx, targetContainer.ID)
+
+		if options.OCI {
+			log.Infof("exporting container %s as an OCI image to %s", targetContainer.ID, outputDir)
+			if err := e.exportContainerOCI(ctx, targetContainer.ID, outputDir); err != nil {
+				return fmt.Errorf("failed to export container %s as OCI image: %w", targetContainer.ID, err)
+			}
+			log.Infof("successfully exported container %s as an OCI image", targetContainer.ID)
+
+			// The OCI image is created from the overlay directories and does
+			// not require mounting the container.
+			if !options.Image && !options.Archive {
+				return nil
+			}
+		}
+
 		// Ensure outputDir exists
 		if err := os.MkdirAll(outputDir, 0755); err != nil {
 			return fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
@@ -107,11 +128,10 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 		// Defer unmount and cleanup of the mountpoint
 		defer func() {
 			log.Infof("cleaning up mountpoint %s for container %s", mountpoint, targetContainer.ID)
-			unmountCmdOutput, unmountErr := utils.Runner.RunWithoutContext("umount", mountpoint)
-			if unmountErr != nil {
-				log.Warnf("failed to unmount %s: %v; output: %s", mountpoint, unmountErr, string(unmountCmdOutput))
+			if unmountErr := utils.Unmount(ctx, mountpoint); unmountErr != nil {
+				log.Warnf("failed to unmount %s: %v", mountpoint, unmountErr)
 			} else {
-				log.Infof("successfully unmounted %s; output: %s", mountpoint, string(unmountCmdOutput))
+				log.Infof("successfully unmounted %s", mountpoint)
 			}
 
 			if rmErr := os.Remove(mountpoint); rmErr != nil {
@@ -121,7 +141,7 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 			}
 		}()
 
-		if exportOptions["image"] {
+		if options.Image {
 			log.Infof("exporting container %s as a raw image to %s", targetContainer.ID, outputDir)
 			if err := utils.ExportContainerImage(ctx, targetContainer.ID, mountpoint, outputDir); err != nil {
 				return fmt.Errorf("failed to export container %s as raw image: %w", targetContainer.ID, err)
@@ -129,9 +149,9 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 			log.Infof("successfully exported container %s as a raw image", targetContainer.ID)
 		}
 
-		if exportOptions["archive"] {
+		if options.Archive {
 			log.Infof("exporting container %s as an archive to %s", targetContainer.ID, outputDir)
-			if err := utils.ExportContainerArchive(ctx, targetContainer.ID, mountpoint, outputDir); err != nil {
+			if err := utils.ExportContainerArchive(ctx, targetContainer.ID, mountpoint, outputDir, options.Compression); err != nil {
 				return fmt.Errorf("failed to export container %s as archive: %w", targetContainer.ID, err)
 			}
 			log.Infof("successfully exported container %s as an archive", targetContainer.ID)
@@ -145,70 +165,132 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 	return nil
 }
 
+// recordCustody records the container and the configuration files it is read
+// from to the custody record of the context.
+func (e *explorer) recordCustody(ctx context.Context, containerID string) {
+	custody := utils.CustodyFromContext(ctx)
+	if custody == nil {
+		return
+	}
+
+	container, err := e.ReadContainerConfig(ctx, containerID)
+	if err != nil {
+		log.WithFields(log.Fields{"containerID": containerID, "error": err}).Warn("recording container custody")
+		return
+	}
+
+	custody.AddSource("docker container config", filepath.Join(e.dockerRoot, containerDirName, containerID, configV2Filename))
+
+	record := utils.CustodyContainer{
+		ContainerID: containerID,
+		Runtime:     e.Type(),
+		Image:       container.Config.Image,
+		Snapshotter: container.Driver,
+	}
+
+	if container.Driver == "overlay2" {
+		mountIDPath := filepath.Join(e.dockerRoot, imageDirName, container.Driver, "layerdb", "mounts", containerID, "mount-id")
+		custody.AddSource("docker layer mount-id", mountIDPath)
+		//nolint:gosec // G304: Path is constructed from trusted docker root
+		if data, err := os.ReadFile(mountIDPath); err == nil {
+			record.SnapshotKey = strings.TrimSpace(string(data))
+		}
+	}
+
+	if layers, err := e.ContainerLayers(ctx, containerID); err == nil {
+		record.Snapshots = append([]string{layers.UpperDir}, layers.LowerDirs...)
+	}
+
+	custody.AddContainer(record)
+}
+
 // ExportAllContainers exports all Docker containers to specified output directory.
-func (e *explorer) ExportAllContainers(ctx context.Context, outputDir string, exportOptions map[string]bool, filter map[string]string, exportSupportContainers bool) error {
-	containerNamespaces, err := e.ListNamespaces(ctx)
+func (e *explorer) ExportAllContainers(ctx context.Context, outputDir string, options explorers.ContainerExportOptions, filter map[string]string, exportSupportContainers bool) error {
+	containers, err := e.ListContainers(ctx)
 	if err != nil {
-		return fmt.Errorf("listing namespaces: %w", err)
+		return fmt.Errorf("listing containers: %w", err)
 	}
+	log.WithFields(log.Fields{
+		"containerCount": len(containers),
+	}).Debug("Docker containers")
 
-	for _, containerNamespace := range containerNamespaces {
-		ctx = namespaces.WithNamespace(ctx, containerNamespace)
+	var targets []explorers.Container
+	var ids []string
+	for _, container := range containers {
+		log.WithFields(log.Fields{
+			"containerID":   container.ID,
+			"name":          container.Runtime.Name,
+			"namespace":     container.Namespace,
+			"containerType": container.ContainerType,
+		}).Debug("processing Docker container for export")
 
-		containers, err := e.ListContainers(ctx)
-		if err != nil {
+		if !exportSupportContainers && container.SupportContainer {
 			log.WithFields(log.Fields{
-				"namespace": containerNamespace,
-				"error":     err,
-			}).Warnf("error listing containers in namespace")
-
+				"containerID":   container.ID,
+				"name":          container.Runtime.Name,
+				"namespace":     container.Namespace,
+				"containerType": container.ContainerType,
+			}).Debug("skipping Kubernetes support containers")
 			continue
 		}
-		log.WithFields(log.Fields{
-			"namespace":      containerNamespace,
-			"containerCount": len(containers),
-		}).Debug("Docker containers in namespace")
 
-		for _, container := range containers {
+		if utils.IncludeContainer(container, filter) {
+			targets = append(targets, container)
+			ids = append(ids, container.ID)
+		}
+	}
+
+	return explorers.RunContainerJobs(ctx, e.Type(), "export", ids, func(i int) error {
+		container := targets[i]
+		err := e.ExportContainer(ctx, container.ID, outputDir, options)
+		if err != nil {
 			log.WithFields(log.Fields{
 				"containerID":   container.ID,
 				"name":          container.Runtime.Name,
 				"namespace":     container.Namespace,
 				"containerType": container.ContainerType,
-			}).Debug("processing Docker container for export")
-
-			if !exportSupportContainers && container.SupportContainer {
-				log.WithFields(log.Fields{
-					"containerID":   container.ID,
-					"name":          container.Runtime.Name,
-					"namespace":     container.Namespace,
-					"containerType": container.ContainerType,
-				}).Debug("skipping Kubernetes support containers")
-				continue
-			}
+				"error":         err,
+			}).Error("error exporting Docker container")
+		}
+		return err
+	})
+}
 
-			if utils.IncludeContainer(container, filter) {
-				log.WithFields(log.Fields{
-					"containerID":   container.ID,
-					"name":          container.Runtime.Name,
-					"namespace":     container.Namespace,
-					"containerType": container.ContainerType,
-				}).Debug("ignoring Docker container for export")
-
-				err := e.ExportContainer(ctx, container.ID, outputDir, exportOptions)
-				if err != nil {
-					log.WithFields(log.Fields{
-						"containerID":   container.ID,
-						"name":          container.Runtime.Name,
-						"namespace":     container.Namespace,
-						"containerType": container.ContainerType,
-						"error":         err,
-					}).Error("error exporting Docker container")
+// exportContainerOCI exports a container as an OCI image archive.
+//
+// Docker does not keep the image layer blobs. The image layers are created
+// from the lower directories and the image config is read from the docker
+// image database.
+func (e *explorer) exportContainerOCI(ctx context.Context, containerID string, outputDir string) error {
+	container, err := e.ReadContainerConfig(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("reading container config: %w", err)
+	}
+
+	layers, err := e.ContainerLayers(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	var base *utils.BaseImage
+	imageID := digest.Digest(container.Image)
+	if imageID.Validate() == nil {
+		configFile := filepath.Join(e.dockerRoot, imageDirName, container.Driver, "imagedb", "content", imageID.Algorithm().String(), imageID.Encoded())
+		//nolint:gosec // G304: Path is constructed from a validated image digest
+		data, err := os.ReadFile(configFile)
+		if err == nil {
+			var config ocispec.Image
+			if err := json.Unmarshal(data, &config); err == nil {
+				base = &utils.BaseImage{
+					Name:   container.Config.Image,
+					Config: &config,
 				}
 			}
 		}
+		if base == nil {
+			log.WithFields(log.Fields{"image": container.Image, "error": err}).Info("docker image config not found")
+		}
 	}
 
-	// Default
-	return nil
+	return utils.ExportContainerOCI(ctx, containerID, layers, base, outputDir)
 }
@@ -0,0 +1,141 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/container-explorer/explorers"
+	digest "github.com/opencontainers/go-digest"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListOrphans returns the overlay2 layer directories that are not used by
+// an image or a container.
+//
+// A layer directory is an orphan if no layerdb layer or container mount
+// references it, or if it is the writable layer of a container mount
+// whose container directory was removed.
+func (e *explorer) ListOrphans(_ context.Context) ([]explorers.Orphan, error) {
+	overlayDir := filepath.Join(e.dockerRoot, storageOverlay2)
+	entries, err := os.ReadDir(overlayDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading overlay2 directory: %w", err)
+	}
+
+	layerDBDir := filepath.Join(e.dockerRoot, imageDirName, storageOverlay2, "layerdb")
+	referenced := make(map[string]bool)
+
+	cacheIDFiles, _ := filepath.Glob(filepath.Join(layerDBDir, digest.SHA256.String(), "*", "cache-id"))
+	for _, idFile := range cacheIDFiles {
+		if id, err := readIDFile(idFile); err == nil {
+			referenced[id] = true
+		}
+	}
+
+	var orphans []explorers.Orphan
+	mountIDFiles, _ := filepath.Glob(filepath.Join(layerDBDir, "mounts", "*", "mount-id"))
+	for _, idFile := range mountIDFiles {
+		id, err := readIDFile(idFile)
+		if err != nil {
+			continue
+		}
+		referenced[id] = true
+		if initID, err := readIDFile(filepath.Join(filepath.Dir(idFile), "init-id")); err == nil {
+			referenced[initID] = true
+		}
+
+		containerID := filepath.Base(filepath.Dir(idFile))
+		if fileExists(filepath.Join(e.dockerRoot, containerDirName, containerID)) {
+			continue
+		}
+		orphans = append(orphans, newOverlay2Orphan(overlayDir, id, explorers.OrphanNoContainer))
+	}
+
+	for _, entry := range entries {
+		id := entry.Name()
+		if !entry.IsDir() || id == "l" || referenced[id] {
+			continue
+		}
+		// The init layer of a container is the parent of the container
+		// layer.
+		if strings.HasSuffix(id, "-init") && fileExists(filepath.Join(overlayDir, strings.TrimSuffix(id, "-init"))) {
+			continue
+		}
+		orphans = append(orphans, newOverlay2Orphan(overlayDir, id, explorers.OrphanUnreferenced))
+	}
+	return orphans, nil
+}
+
+// newOverlay2Orphan returns an orphan overlay2 layer directory. The parent
+// is the top layer of the lower file.
+func newOverlay2Orphan(overlayDir string, id string, reason string) explorers.Orphan {
+	orphan := explorers.NewOrphan("docker", id, filepath.Join(overlayDir, id, "diff"), reason)
+
+	lowerIDs, err := explorers.ReadOverlayLower(overlayDir, id)
+	if err != nil {
+		log.WithFields(log.Fields{"id": id, "error": err}).Debug("reading overlay2 lower file")
+	}
+	if len(lowerIDs) > 0 {
+		orphan.Parent = lowerIDs[0]
+	}
+	return orphan
+}
+
+// overlay2OrphanLayers returns the overlay directories of an overlay2 layer
+// directory found by its name. The parent chain is read from the lower file
+// of the layer.
+func (e *explorer) overlay2OrphanLayers(key string, parents bool) (explorers.LayerStack, error) {
+	overlayDir := filepath.Join(e.dockerRoot, storageOverlay2)
+	if key != filepath.Base(key) || key == "l" {
+		return explorers.LayerStack{}, fmt.Errorf("layer %s not found", key)
+	}
+	if _, err := os.Stat(filepath.Join(overlayDir, key, "diff")); err != nil {
+		return explorers.LayerStack{}, fmt.Errorf("layer %s not found", key)
+	}
+
+	stack := explorers.LayerStack{LowerDirs: []string{e.overlay2LayerDir(key)}}
+	if !parents {
+		return stack, nil
+	}
+
+	lowerIDs, err := explorers.ReadOverlayLower(overlayDir, key)
+	if err != nil {
+		return explorers.LayerStack{}, fmt.Errorf("reading lower file: %w", err)
+	}
+	for _, id := range lowerIDs {
+		stack.LowerDirs = append(stack.LowerDirs, e.overlay2LayerDir(id))
+	}
+	return stack, nil
+}
+
+// readIDFile returns the trimmed content of a layerdb ID file.
+func readIDFile(path string) (string, error) {
+	//nolint:gosec // G304: Path is constructed from trusted docker root
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+)
+
+// Error kinds of an item error.
+const (
+	ErrorKindNotFound    = "NOT_FOUND"
+	ErrorKindUnsupported = "UNSUPPORTED"
+	ErrorKindPermission  = "PERMISSION"
+	ErrorKindCorrupt     = "CORRUPT_METADATA"
+	ErrorKindOther       = "ERROR"
+)
+
+var (
+	// ErrNotFound is wrapped by the errors of a container, image, or
+	// snapshot that is not found.
+	ErrNotFound = errors.New("not found")
+
+	// ErrCorruptMetadata is wrapped by the errors of metadata that cannot
+	// be decoded.
+	ErrCorruptMetadata = errors.New("corrupt metadata")
+)
+
+// ItemError provides the error of an operation on a container or another
+// item of an explorer. Item errors are collected, so that an operation on
+// all containers continues after a container fails.
+type ItemError struct {
+	ContainerType string // container type: containerd, docker, podman, etc.
+	Operation     string // operation, e.g. list, drift, mount, or export
+	ID            string // container ID or another item, empty for the explorer
+	Kind          string // one of the error kinds
+	Message       string // error message
+
+	err error
+}
+
+// NewItemError returns the item error of an operation error.
+func NewItemError(containerType string, operation string, id string, err error) *ItemError {
+	return &ItemError{
+		ContainerType: containerType,
+		Operation:     operation,
+		ID:            id,
+		Kind:          ErrorKind(err),
+		Message:       err.Error(),
+		err:           err,
+	}
+}
+
+func (e *ItemError) Error() string {
+	if e.ID == "" {
+		return e.ContainerType + " " + e.Operation + ": " + e.Message
+	}
+	return e.ContainerType + " " + e.Operation + " " + e.ID + ": " + e.Message
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.err
+}
+
+// ErrorKind returns the error kind of an error.
+func ErrorKind(err error) string {
+	var unsupported *UnsupportedError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &unsupported), errors.Is(err, errors.ErrUnsupported):
+		return ErrorKindUnsupported
+	case errors.Is(err, fs.ErrPermission):
+		return ErrorKindPermission
+	case errors.Is(err, ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return ErrorKindNotFound
+	case errors.Is(err, ErrCorruptMetadata), errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ErrorKindCorrupt
+	default:
+		return ErrorKindOther
+	}
+}
+
+// ItemErrors returns the item errors of an error. The item errors joined in
+// the error are returned as they are, and other errors are returned as
+// errors of the explorer operation.
+func ItemErrors(containerType string, operation string, err error) []ItemError {
+	if err == nil {
+		return nil
+	}
+
+	var itemErr *ItemError
+	if errors.As(err, &itemErr) && itemErr == err {
+		return []ItemError{*itemErr}
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var itemErrs []ItemError
+		for _, e := range joined.Unwrap() {
+			itemErrs = append(itemErrs, ItemErrors(containerType, operation, e)...)
+		}
+		return itemErrs
+	}
+	return []ItemError{*NewItemError(containerType, operation, "", err)}
+}
+
+// Result provides the items of an operation on all containers and the
+// errors of the containers and explorers that failed.
+type Result[T any] struct {
+	Items  []T
+	Errors []ItemError
+}
+
+// Add adds the items and the item errors of an explorer operation. The
+// items are added even if the operation returned an error, as an error can
+// be the errors of some containers only.
+func (r *Result[T]) Add(containerType string, operation string, items []T, err error) {
+	r.Items = append(r.Items, items...)
+	r.Errors = append(r.Errors, ItemErrors(containerType, operation, err)...)
+}
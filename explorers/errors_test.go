@@ -0,0 +1,86 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind string
+	}{
+		{&UnsupportedError{ContainerType: "docker", Capability: CapabilityContent}, ErrorKindUnsupported},
+		{fmt.Errorf("opening meta.db: %w", fs.ErrPermission), ErrorKindPermission},
+		{fmt.Errorf("container abc %w", ErrNotFound), ErrorKindNotFound},
+		{&fs.PathError{Op: "open", Path: "link", Err: fs.ErrNotExist}, ErrorKindNotFound},
+		{fmt.Errorf("reading config: %w", &json.SyntaxError{}), ErrorKindCorrupt},
+		{errors.New("mount failed"), ErrorKindOther},
+	}
+	for _, tt := range tests {
+		if kind := ErrorKind(tt.err); kind != tt.kind {
+			t.Errorf("ErrorKind(%v) = %s, expected %s", tt.err, kind, tt.kind)
+		}
+	}
+}
+
+func TestItemErrors(t *testing.T) {
+	if itemErrs := ItemErrors("docker", "drift", nil); itemErrs != nil {
+		t.Errorf("expected no item errors, got %v", itemErrs)
+	}
+
+	err := errors.Join(
+		NewItemError("docker", "drift", "c1", fs.ErrNotExist),
+		NewItemError("docker", "drift", "c2", &UnsupportedError{ContainerType: "docker", Capability: "zfs driver"}),
+	)
+	itemErrs := ItemErrors("docker", "drift", err)
+	if len(itemErrs) != 2 {
+		t.Fatalf("expected 2 item errors, got %v", itemErrs)
+	}
+	if itemErrs[0].ID != "c1" || itemErrs[0].Kind != ErrorKindNotFound {
+		t.Errorf("unexpected item error %+v", itemErrs[0])
+	}
+	if itemErrs[1].ID != "c2" || itemErrs[1].Kind != ErrorKindUnsupported {
+		t.Errorf("unexpected item error %+v", itemErrs[1])
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected item error to unwrap to fs.ErrNotExist")
+	}
+
+	itemErrs = ItemErrors("podman", "list", errors.New("reading containers.json"))
+	if len(itemErrs) != 1 || itemErrs[0].ID != "" || itemErrs[0].ContainerType != "podman" {
+		t.Errorf("unexpected explorer error %v", itemErrs)
+	}
+}
+
+func TestResult_Add(t *testing.T) {
+	var result Result[Drift]
+	result.Add("containerd", "drift", []Drift{{ContainerID: "c1"}}, nil)
+	result.Add("docker", "drift", []Drift{{ContainerID: "c2"}}, NewItemError("docker", "drift", "c3", errors.New("scan failed")))
+
+	if len(result.Items) != 2 {
+		t.Errorf("expected the items of a failed operation, got %v", result.Items)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].ID != "c3" {
+		t.Errorf("unexpected errors %v", result.Errors)
+	}
+}
@@ -20,62 +20,177 @@ package explorers
 
 import (
 	"context"
+	"io"
+
+	digest "github.com/opencontainers/go-digest"
 )
 
 // ContainerExplorer defines the methods required to explore a container.
+//
+// The methods that are not supported by every container engine are in the
+// optional interfaces NamespaceLister, DeletedContainerLister,
+// ContentLister, SnapshotLister, and ImageExporter. Use Capabilities to
+// report the optional interfaces an explorer implements. Explorers that
+// implement DriftStreamer stream the drift of the containers.
 type ContainerExplorer interface {
+	Lister
+	LayerResolver
+	Mounter
+	DriftScanner
+	Exporter
+
 	// Close releases the internal resources
 	Close() error
 
-	// ContainerDrift identifies container filesystem changes
-	ContainerDrift(ctx context.Context, filter string, skipsupportcontainers bool, containerID string) ([]Drift, error)
-
-	// ExportAllContainers exports all Docker and containerd containers.
-	ExportAllContainers(ctx context.Context, outputDir string, exportOptions map[string]bool, filter map[string]string, exportSupportContainers bool) error
-
-	// ExportContainer exports a container as an image or archive.
-	ExportContainer(ctx context.Context, containerID string, outputDir string, exportOptions map[string]bool) error
-
 	// InfoContainer returns container internal information
 	InfoContainer(ctx context.Context, containerID string, spec bool) (any, error)
 
+	// Type returns the explorer type (e.g., containerd, docker, podman)
+	Type() string
+}
+
+// Lister lists the containers, images, tasks, and orphans of a container
+// engine.
+type Lister interface {
+	// GetContainerByID returns ContainerExplorer for the ID or nil
+	GetContainerByID(ctx context.Context, containerID string) (*Container, error)
+
 	// ListContainers returns all the containers in all the namespaces.
 	//
 	// ListContainers returns the ContainerExplorer's Containers structure
 	// that holds additional information about the containers.
 	ListContainers(ctx context.Context) ([]Container, error)
 
-	// ListContent returns information about content
-	ListContent(ctx context.Context) ([]Content, error)
-
 	// ListImages returns content information
 	ListImages(ctx context.Context) ([]Image, error)
 
-	// ListNamespaces returns all the namespaces in the metadata file i.e.
-	// meta.db
-	ListNamespaces(ctx context.Context) ([]string, error)
-
-	// ListSnapshots returns the snapshot information
-	ListSnapshots(ctx context.Context) ([]SnapshotKeyInfo, error)
+	// ListOrphans returns the snapshot and layer directories left on disk
+	// without a container or image using them
+	ListOrphans(ctx context.Context) ([]Orphan, error)
 
 	// ListTasks returns the container task status
 	ListTasks(ctx context.Context) ([]Task, error)
+}
+
+// LayerResolver returns the overlay directories of containers, images, and
+// snapshots.
+type LayerResolver interface {
+	// ContainerLayers returns the overlay directories of a container
+	ContainerLayers(ctx context.Context, containerID string) (LayerStack, error)
+
+	// ImageLayers returns the overlay directories of the unpacked layers of an
+	// image. The top layer is the first lower directory.
+	ImageLayers(ctx context.Context, ref string) (LayerStack, error)
+
+	// SnapshotLayers returns the overlay directory of a snapshot or layer
+	// and, if parents is set, the directories of its parent chain. The
+	// snapshot is the first lower directory.
+	SnapshotLayers(ctx context.Context, key string, parents bool) (LayerStack, error)
+}
 
+// Mounter mounts containers.
+type Mounter interface {
 	// MountAllContainer mounts all containers to the specfied path
 	MountAllContainers(ctx context.Context, mountpoint string, filter string, skipsupportcontainers bool) error
 
 	// MountContainer mounts a container to the specified path
 	MountContainer(ctx context.Context, containerID string, mountpoint string) error
+}
+
+// DriftScanner identifies container filesystem changes.
+type DriftScanner interface {
+	// ContainerDrift identifies container filesystem changes
+	ContainerDrift(ctx context.Context, filter string, skipsupportcontainers bool, containerID string) ([]Drift, error)
+}
+
+// Exporter exports containers.
+type Exporter interface {
+	// ExportAllContainers exports all Docker and containerd containers.
+	ExportAllContainers(ctx context.Context, outputDir string, options ContainerExportOptions, filter map[string]string, exportSupportContainers bool) error
+
+	// ExportContainer exports a container as an image or archive.
+	ExportContainer(ctx context.Context, containerID string, outputDir string, options ContainerExportOptions) error
+}
+
+// NamespaceLister lists the namespaces of a container engine.
+type NamespaceLister interface {
+	// ListNamespaces returns all the namespaces in the metadata file i.e.
+	// meta.db
+	ListNamespaces(ctx context.Context) ([]string, error)
+}
+
+// DeletedContainerLister recovers deleted containers.
+type DeletedContainerLister interface {
+	// ListDeletedContainers returns the deleted containers and the previous
+	// versions of updated containers recovered from the database pages
+	ListDeletedContainers(ctx context.Context) ([]Container, error)
+}
+
+// ContentLister lists, verifies, and reads the content store.
+type ContentLister interface {
+	// ListContent returns information about content
+	ListContent(ctx context.Context) ([]Content, error)
+
+	// ReadContent returns a reader for the content blob matching the digest
+	ReadContent(ctx context.Context, dgst digest.Digest) (io.ReadCloser, error)
+
+	// VerifyContent hashes the content blobs on disk and reports missing,
+	// corrupted, and orphaned blobs
+	VerifyContent(ctx context.Context) ([]ContentVerification, error)
+}
+
+// SnapshotLister lists the snapshots of a snapshotter database.
+type SnapshotLister interface {
+	// ListSnapshots returns the snapshot information
+	ListSnapshots(ctx context.Context) ([]SnapshotKeyInfo, error)
 
 	// SnapshotRoot returns the directory containing snapshots and snapshot
 	// database i.e. metadata.db
 	//
 	// SnapshotRoot is required for the containers managed using containerd.
 	SnapshotRoot(snapshotter string) string
+}
 
-	// GetContainerByID returns ContainerExplorer for the ID or nil
-	GetContainerByID(ctx context.Context, containerID string) (*Container, error)
+// ImageExporter exports images from the content store.
+type ImageExporter interface {
+	// ExportImage exports an image from the content store as an OCI image
+	// layout directory or a docker load compatible archive.
+	ExportImage(ctx context.Context, ref string, outputPath string, options ImageExportOptions) error
+}
 
-	// Type returns the explorer type (e.g., containerd, docker, podman)
-	Type() string
+// DriftStreamer streams the drift of the containers as the drift detection
+// of each container completes.
+type DriftStreamer interface {
+	// StreamContainerDrift calls fn with the drift of each container in the
+	// order of the containers, and stops at the first error of fn
+	StreamContainerDrift(ctx context.Context, filter string, skipsupportcontainers bool, containerID string, fn func(Drift) error) error
+}
+
+// StreamDrift calls fn with the drift of each container of an explorer. The
+// drift of an explorer that does not implement DriftStreamer is passed to fn
+// when the drift detection of all its containers completes.
+func StreamDrift(ctx context.Context, xplr DriftScanner, filter string, skipsupportcontainers bool, containerID string, fn func(Drift) error) error {
+	if streamer, ok := xplr.(DriftStreamer); ok {
+		return streamer.StreamContainerDrift(ctx, filter, skipsupportcontainers, containerID, fn)
+	}
+
+	drifts, err := xplr.ContainerDrift(ctx, filter, skipsupportcontainers, containerID)
+	for _, drift := range drifts {
+		if fnErr := fn(drift); fnErr != nil {
+			return fnErr
+		}
+	}
+	return err
+}
+
+// CollectDrift returns the drift of the containers streamed by a
+// DriftStreamer. It implements ContainerDrift for the explorers that stream
+// the drift.
+func CollectDrift(ctx context.Context, streamer DriftStreamer, filter string, skipsupportcontainers bool, containerID string) ([]Drift, error) {
+	var drifts []Drift
+	err := streamer.StreamContainerDrift(ctx, filter, skipsupportcontainers, containerID, func(drift Drift) error {
+		drifts = append(drifts, drift)
+		return nil
+	})
+	return drifts, err
 }
@@ -0,0 +1,63 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ContainerExportOptions controls how a container is exported.
+type ContainerExportOptions struct {
+	Image       bool        // export as raw image
+	Archive     bool        // export as archive
+	OCI         bool        // export as OCI image archive
+	Compression Compression // archive compression, gzip if empty
+}
+
+// Compression is the compression of a container archive.
+type Compression string
+
+const (
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+	CompressionNone Compression = "none"
+)
+
+// ParseCompression returns the compression for a name. An empty name is gzip.
+func ParseCompression(name string) (Compression, error) {
+	switch c := Compression(strings.ToLower(name)); c {
+	case "":
+		return CompressionGzip, nil
+	case CompressionGzip, CompressionZstd, CompressionNone:
+		return c, nil
+	default:
+		return "", fmt.Errorf("unsupported compression %q: use gzip, zstd, or none", name)
+	}
+}
+
+// Extension returns the archive file extension.
+func (c Compression) Extension() string {
+	switch c {
+	case CompressionZstd:
+		return ".tar.zst"
+	case CompressionNone:
+		return ".tar"
+	default:
+		return ".tar.gz"
+	}
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import "testing"
+
+func TestParseCompression(t *testing.T) {
+	for name, expected := range map[string]Compression{
+		"":     CompressionGzip,
+		"gzip": CompressionGzip,
+		"ZSTD": CompressionZstd,
+		"none": CompressionNone,
+	} {
+		c, err := ParseCompression(name)
+		if err != nil {
+			t.Errorf("ParseCompression(%q) failed: %v", name, err)
+		}
+		if c != expected {
+			t.Errorf("ParseCompression(%q): expected %s, got %s", name, expected, c)
+		}
+	}
+
+	if _, err := ParseCompression("bzip2"); err == nil {
+		t.Errorf("expected error for unsupported compression")
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package explorers
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -58,8 +59,12 @@ func (f *FileInfo) AsJSON() ([]byte, error) {
 
 // FileSHA256Sum calculates SHA256 hash of the specified file.
 func FileSHA256Sum(path string) (string, error) {
-	var err error
+	return FileSHA256SumContext(context.Background(), path)
+}
 
+// FileSHA256SumContext calculates SHA256 hash of the specified file, using
+// the hash cache of the context if any.
+func FileSHA256SumContext(ctx context.Context, path string) (string, error) {
 	//nolint:gosec // G304: Path is dynamic but restricted to container filesystem
 	file, err := os.Open(path)
 	if err != nil {
@@ -67,8 +72,16 @@ func FileSHA256Sum(path string) (string, error) {
 	}
 	defer file.Close()
 
+	if cache := hashCache(ctx); cache != nil {
+		return cache.sha256Sum(file)
+	}
+	return sha256Sum(file)
+}
+
+// sha256Sum calculates SHA256 hash of the content of a file.
+func sha256Sum(file io.Reader) (string, error) {
 	hash := sha256.New()
-	if _, err = io.Copy(hash, file); err != nil {
+	if _, err := io.Copy(hash, file); err != nil {
 		return "", err
 	}
 
@@ -80,6 +93,16 @@ func FileSHA256Sum(path string) (string, error) {
 
 // GetFileInfo returns file information in drift detection.
 func GetFileInfo(info os.FileInfo, path string, diffDir string) (*FileInfo, error) {
+	diffFileInfo := getFileInfo(info, path, diffDir)
+	if hash, err := FileSHA256Sum(path); err == nil {
+		diffFileInfo.FileSHA256 = hash
+	}
+
+	return &diffFileInfo, nil
+}
+
+// getFileInfo returns file information without the SHA256 hash.
+func getFileInfo(info os.FileInfo, path string, diffDir string) FileInfo {
 	diffFileInfo := FileInfo{
 		FileName:     info.Name(),
 		FullPath:     strings.Replace(path, diffDir, "", 1),
@@ -93,22 +116,33 @@ func GetFileInfo(info os.FileInfo, path string, diffDir string) (*FileInfo, erro
 		diffFileInfo.FileBirth = time.Unix(stat.Ctim.Sec, stat.Ctim.Nsec).UTC()
 	}
 
-	if hash, err := FileSHA256Sum(path); err == nil {
-		diffFileInfo.FileSHA256 = hash
-	}
-
-	return &diffFileInfo, nil
+	return diffFileInfo
 }
 
 // ScanDiffDirectory identifies added or modified files in the diff directory
 func ScanDiffDirectory(diffDir string) (addedOrModified []FileInfo, inaccessibleFiles []FileInfo, err error) {
+	return ScanDiffDirectoryContext(context.Background(), diffDir)
+}
+
+// ScanDiffDirectoryContext identifies added or modified files in the diff
+// directory, and hashes the added or modified files on the concurrent jobs
+// and with the hash cache of the context. The files are returned in the walk
+// order.
+func ScanDiffDirectoryContext(ctx context.Context, diffDir string) (addedOrModified []FileInfo, inaccessibleFiles []FileInfo, err error) {
 	log.WithField("path", diffDir).Debug("scanning drift directory")
 
 	// Map to track canonical directory paths and prevent infinite loops/cycles from symlinks
 	visited := make(map[string]bool)
 
+	// Paths of the added or modified files to hash
+	var hashPaths []string
+
 	var walk func(string) error
 	walk = func(path string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// 1. Get the Lstat info first to check if the entry itself is a symlink
 		info, lstatErr := os.Lstat(path)
 		if lstatErr != nil {
@@ -125,9 +159,7 @@ func ScanDiffDirectory(diffDir string) (addedOrModified []FileInfo, inaccessible
 			targetInfo, err := os.Stat(path)
 			if err != nil {
 				// Broken symlink or target permission error; record as an inaccessible file
-				if fileinfo, err := GetFileInfo(info, path, diffDir); err == nil {
-					inaccessibleFiles = append(inaccessibleFiles, *fileinfo)
-				}
+				inaccessibleFiles = append(inaccessibleFiles, getFileInfo(info, path, diffDir))
 				return nil
 			}
 			// Overwrite info with the target's FileInfo so metadata matches the actual file
@@ -151,9 +183,7 @@ func ScanDiffDirectory(diffDir string) (addedOrModified []FileInfo, inaccessible
 			entries, err := os.ReadDir(path)
 			if err != nil {
 				// Directory exists but contents cannot be read
-				if fileinfo, err := GetFileInfo(info, path, diffDir); err == nil {
-					inaccessibleFiles = append(inaccessibleFiles, *fileinfo)
-				}
+				inaccessibleFiles = append(inaccessibleFiles, getFileInfo(info, path, diffDir))
 				return nil
 			}
 
@@ -166,11 +196,7 @@ func ScanDiffDirectory(diffDir string) (addedOrModified []FileInfo, inaccessible
 			}
 		} else {
 			// 4. Handle regular files, device files, or symlinks resolved to files
-			fileinfo, err := GetFileInfo(info, path, diffDir)
-			if err != nil {
-				log.WithFields(log.Fields{"path": path, "error": err}).Debug("getting file information")
-				return err
-			}
+			fileinfo := getFileInfo(info, path, diffDir)
 
 			// Ensure the reported FileName matches the logical path name in the tree,
 			// rather than the base name of the resolved target file.
@@ -186,7 +212,7 @@ func ScanDiffDirectory(diffDir string) (addedOrModified []FileInfo, inaccessible
 					minor := (rdev & 0xff) | ((rdev >> 12) & 0xfff00)
 
 					if major == 0 && minor == 0 {
-						inaccessibleFiles = append(inaccessibleFiles, *fileinfo)
+						inaccessibleFiles = append(inaccessibleFiles, fileinfo)
 						return nil
 					}
 				}
@@ -198,11 +224,20 @@ func ScanDiffDirectory(diffDir string) (addedOrModified []FileInfo, inaccessible
 				fileinfo.FileType = "executable"
 			}
 
-			addedOrModified = append(addedOrModified, *fileinfo)
+			addedOrModified = append(addedOrModified, fileinfo)
+			hashPaths = append(hashPaths, path)
 		}
 		return nil
 	}
 
-	err = walk(diffDir)
+	if err = walk(diffDir); err != nil {
+		return
+	}
+
+	err = RunFileJobs(ctx, len(hashPaths), func(i int) {
+		if hash, err := FileSHA256SumContext(ctx, hashPaths[i]); err == nil {
+			addedOrModified[i].FileSHA256 = hash
+		}
+	})
 	return
 }
@@ -17,8 +17,10 @@ limitations under the License.
 package explorers
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -235,3 +237,36 @@ func TestScanDiffDirectory_UnreadableDirectory(t *testing.T) {
 		t.Errorf("expected inaccessible full path %q, got %q", expectedPath, inaccessibleFiles[0].FullPath)
 	}
 }
+
+func TestScanDiffDirectoryContext_Jobs(t *testing.T) {
+	diffDir := t.TempDir()
+	for _, name := range []string{"a", "b/c", "b/d", "e/f/g", "h"} {
+		path := filepath.Join(diffDir, name)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		_ = os.WriteFile(path, []byte(name), 0644)
+	}
+
+	expected, _, err := ScanDiffDirectory(diffDir)
+	if err != nil {
+		t.Fatalf("ScanDiffDirectory failed: %v", err)
+	}
+	files, _, err := ScanDiffDirectoryContext(WithJobs(context.Background(), 4), diffDir)
+	if err != nil {
+		t.Fatalf("ScanDiffDirectoryContext failed: %v", err)
+	}
+
+	if len(files) != len(expected) {
+		t.Fatalf("expected %d files, got %d", len(expected), len(files))
+	}
+	for i := range files {
+		if files[i].FullPath != expected[i].FullPath || files[i].FileSHA256 != expected[i].FileSHA256 || files[i].FileSHA256 == "" {
+			t.Errorf("file %d: expected %s %s, got %s %s", i, expected[i].FullPath, expected[i].FileSHA256, files[i].FullPath, files[i].FileSHA256)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := ScanDiffDirectoryContext(ctx, diffDir); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context canceled, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// hashCacheBucket is the bbolt bucket of the SHA256 hashes.
+var hashCacheBucket = []byte("sha256")
+
+// HashCache is an on-disk cache of file SHA256 hashes keyed by the device,
+// inode, size, modification time, and change time of the files. Files of
+// shared image layers are hashed once across containers and runs.
+type HashCache struct {
+	db     *bolt.DB
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// HashCacheStats provides the lookups of a hash cache.
+type HashCacheStats struct {
+	Hits   int64 // hashes read from the cache
+	Misses int64 // hashes computed and added to the cache
+}
+
+// HitRate returns the percentage of the lookups read from the cache.
+func (s HashCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) * 100 / float64(s.Hits+s.Misses)
+}
+
+// OpenHashCache opens the hash cache file, and creates it if it does not
+// exist.
+func OpenHashCache(path string) (*HashCache, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening hash cache %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(hashCacheBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initializing hash cache %s: %w", path, err)
+	}
+	return &HashCache{db: db}, nil
+}
+
+// Close closes the hash cache file.
+func (c *HashCache) Close() error {
+	return c.db.Close()
+}
+
+// Stats returns the lookups of the hash cache since it was opened.
+func (c *HashCache) Stats() HashCacheStats {
+	return HashCacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// sha256Sum returns the SHA256 hash of an open file from the cache, or
+// hashes the file and adds the hash to the cache.
+func (c *HashCache) sha256Sum(file *os.File) (string, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return sha256Sum(file)
+	}
+
+	key := make([]byte, 40)
+	binary.BigEndian.PutUint64(key[0:], uint64(stat.Dev))
+	binary.BigEndian.PutUint64(key[8:], stat.Ino)
+	binary.BigEndian.PutUint64(key[16:], uint64(stat.Size))
+	binary.BigEndian.PutUint64(key[24:], uint64(time.Unix(stat.Mtim.Sec, stat.Mtim.Nsec).UnixNano()))
+	binary.BigEndian.PutUint64(key[32:], uint64(time.Unix(stat.Ctim.Sec, stat.Ctim.Nsec).UnixNano()))
+
+	var hash string
+	err = c.db.View(func(tx *bolt.Tx) error {
+		hash = string(tx.Bucket(hashCacheBucket).Get(key))
+		return nil
+	})
+	if err == nil && hash != "" {
+		c.hits.Add(1)
+		return hash, nil
+	}
+
+	hash, err = sha256Sum(file)
+	if err != nil {
+		return "", err
+	}
+	c.misses.Add(1)
+
+	// Concurrent hashes are written in a single transaction.
+	err = c.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(hashCacheBucket).Put(key, []byte(hash))
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"path": file.Name(), "error": err}).Warn("adding hash to hash cache")
+	}
+	return hash, nil
+}
+
+type hashCacheKey struct{}
+
+// WithHashCache returns a context with the hash cache used to hash files.
+func WithHashCache(ctx context.Context, cache *HashCache) context.Context {
+	return context.WithValue(ctx, hashCacheKey{}, cache)
+}
+
+// hashCache returns the hash cache of a context, or nil if not set.
+func hashCache(ctx context.Context) *HashCache {
+	cache, _ := ctx.Value(hashCacheKey{}).(*HashCache)
+	return cache
+}
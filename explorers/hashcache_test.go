@@ -0,0 +1,93 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHashCache(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "hashes.db")
+	diffDir := filepath.Join(dir, "diff")
+	if err := os.Mkdir(diffDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(diffDir, "file.txt")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := FileSHA256Sum(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := OpenHashCache(cachePath)
+	if err != nil {
+		t.Fatalf("OpenHashCache failed: %v", err)
+	}
+	ctx := WithHashCache(context.Background(), cache)
+	for range 2 {
+		hash, err := FileSHA256SumContext(ctx, path)
+		if err != nil {
+			t.Fatalf("FileSHA256SumContext failed: %v", err)
+		}
+		if hash != expected {
+			t.Errorf("expected hash %s, got %s", expected, hash)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.HitRate() != 50 {
+		t.Errorf("expected 1 hit and 1 miss, got %+v", stats)
+	}
+
+	// A modified file is hashed again.
+	if err := os.WriteFile(path, []byte("modified"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	expected, _ = FileSHA256Sum(path)
+	if hash, _ := FileSHA256SumContext(ctx, path); hash != expected {
+		t.Errorf("expected hash %s of the modified file, got %s", expected, hash)
+	}
+	if stats := cache.Stats(); stats.Misses != 2 {
+		t.Errorf("expected 2 misses, got %+v", stats)
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The hashes persist across runs.
+	cache, err = OpenHashCache(cachePath)
+	if err != nil {
+		t.Fatalf("OpenHashCache failed: %v", err)
+	}
+	defer cache.Close()
+	ctx = WithHashCache(context.Background(), cache)
+	if _, _, err := ScanDiffDirectoryContext(ctx, diffDir); err != nil {
+		t.Fatalf("ScanDiffDirectoryContext failed: %v", err)
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 0 {
+		t.Errorf("expected 1 hit, got %+v", stats)
+	}
+}
@@ -17,7 +17,11 @@ limitations under the License.
 package explorers
 
 import (
+	"strings"
+
 	"github.com/containerd/containerd/images"
+	"github.com/distribution/reference"
+	digest "github.com/opencontainers/go-digest"
 )
 
 // Image provides information about a container image.
@@ -27,3 +31,42 @@ type Image struct {
 	SupportContainerImage bool
 	images.Image
 }
+
+// ImageExportOptions controls how an image is exported.
+type ImageExportOptions struct {
+	Layout   bool   // write an OCI image layout directory instead of a tar archive
+	Platform string // export only the manifests matching the platform e.g. linux/amd64
+}
+
+// MatchImageReference returns true if ref refers to the image name or the
+// image target digest.
+//
+// A short reference, e.g. ubuntu, is normalized to docker.io/library/ubuntu:latest
+// and also matches the familiar name ubuntu:latest used by Docker. A digest may
+// be specified in full or as a prefix of at least 12 characters.
+func MatchImageReference(name string, target digest.Digest, ref string) bool {
+	if ref == "" {
+		return false
+	}
+
+	if name == ref {
+		return true
+	}
+
+	if target.Validate() == nil {
+		if target.String() == ref {
+			return true
+		}
+		encoded := strings.TrimPrefix(ref, target.Algorithm().String()+":")
+		if len(encoded) >= 12 && strings.HasPrefix(target.Encoded(), encoded) {
+			return true
+		}
+	}
+
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return false
+	}
+	named = reference.TagNameOnly(named)
+	return name == named.String() || name == reference.FamiliarString(named)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"testing"
+
+	digest "github.com/opencontainers/go-digest"
+)
+
+func TestMatchImageReference(t *testing.T) {
+	t.Parallel()
+
+	target := digest.FromString("manifest")
+
+	tests := []struct {
+		name   string
+		image  string
+		target digest.Digest
+		ref    string
+		want   bool
+	}{
+		{name: "Exact name", image: "docker.io/library/ubuntu:22.04", target: target, ref: "docker.io/library/ubuntu:22.04", want: true},
+		{name: "Short name", image: "docker.io/library/ubuntu:22.04", target: target, ref: "ubuntu:22.04", want: true},
+		{name: "Short name default tag", image: "docker.io/library/ubuntu:latest", target: target, ref: "ubuntu", want: true},
+		{name: "Familiar name", image: "nginx:latest", target: target, ref: "nginx", want: true},
+		{name: "Familiar name different tag", image: "nginx:1.25", target: target, ref: "nginx", want: false},
+		{name: "Different tag", image: "docker.io/library/ubuntu:22.04", target: target, ref: "ubuntu", want: false},
+		{name: "Full digest", image: "registry.k8s.io/pause:3.9", target: target, ref: target.String(), want: true},
+		{name: "Digest prefix", image: "registry.k8s.io/pause:3.9", target: target, ref: target.Encoded()[:12], want: true},
+		{name: "Digest prefix too short", image: "registry.k8s.io/pause:3.9", target: target, ref: target.Encoded()[:6], want: false},
+		{name: "Empty reference", image: "registry.k8s.io/pause:3.9", target: target, ref: "", want: false},
+		{name: "Invalid target", image: "registry.k8s.io/pause:3.9", target: "", ref: "abcdef123456", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := MatchImageReference(tt.image, tt.target, tt.ref); got != tt.want {
+				t.Errorf("MatchImageReference(%q, %q, %q) = %v, want %v", tt.image, tt.target, tt.ref, got, tt.want)
+			}
+		})
+	}
+}
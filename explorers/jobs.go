@@ -0,0 +1,196 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Progress provides the progress of an operation on all containers.
+type Progress struct {
+	ContainerType string // container type: containerd, docker, podman, etc.
+	Operation     string // operation, e.g. drift, mount, or export
+	ID            string // container ID of the completed container
+	Done          int    // number of completed containers
+	Total         int    // number of containers of the operation
+	Err           error  // error of the completed container, if any
+}
+
+type (
+	jobsKey     struct{}
+	fileJobsKey struct{}
+	progressKey struct{}
+)
+
+// WithJobs returns a context with the number of containers processed
+// concurrently by an operation on all containers, and of files hashed
+// concurrently across these containers.
+func WithJobs(ctx context.Context, jobs int) context.Context {
+	ctx = context.WithValue(ctx, jobsKey{}, jobs)
+	return context.WithValue(ctx, fileJobsKey{}, make(chan struct{}, max(jobs, 1)))
+}
+
+// Jobs returns the number of concurrent jobs of a context, 1 if not set.
+func Jobs(ctx context.Context) int {
+	if jobs, ok := ctx.Value(jobsKey{}).(int); ok && jobs > 0 {
+		return jobs
+	}
+	return 1
+}
+
+// WithProgress returns a context with a function called as the containers
+// of an operation complete. The calls of an operation are serialized.
+func WithProgress(ctx context.Context, progress func(Progress)) context.Context {
+	return context.WithValue(ctx, progressKey{}, progress)
+}
+
+// RunJobs runs fn for the indexes 0 to n-1 on the concurrent jobs of the
+// context. The indexes that are not started when the context is canceled
+// are skipped and the context error is returned.
+func RunJobs(ctx context.Context, n int, fn func(i int)) error {
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for range min(Jobs(ctx), n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	var err error
+loop:
+	for i := range n {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break loop
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return err
+}
+
+// RunFileJobs runs fn for the indexes 0 to n-1 like RunJobs. The calls of
+// fn of all the RunFileJobs sharing the jobs of the context are limited to
+// the concurrent jobs, so that the files of the containers processed
+// concurrently are not read by jobs² concurrent jobs.
+func RunFileJobs(ctx context.Context, n int, fn func(i int)) error {
+	slots, ok := ctx.Value(fileJobsKey{}).(chan struct{})
+	if !ok {
+		return RunJobs(ctx, n, fn)
+	}
+
+	err := RunJobs(ctx, n, func(i int) {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		defer func() { <-slots }()
+		fn(i)
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// RunContainerJobs runs an operation for each container ID on the
+// concurrent jobs of the context and reports its progress. The errors of
+// the containers are returned as item errors joined in the order of the
+// container IDs, so that the result does not depend on the scheduling.
+func RunContainerJobs(ctx context.Context, containerType string, operation string, ids []string, fn func(i int) error) error {
+	progress, _ := ctx.Value(progressKey{}).(func(Progress))
+
+	var mu sync.Mutex
+	done := 0
+	errs := make([]error, len(ids)+1)
+	errs[len(ids)] = RunJobs(ctx, len(ids), func(i int) {
+		err := fn(i)
+		if err != nil {
+			var itemErr *ItemError
+			if !errors.As(err, &itemErr) {
+				err = NewItemError(containerType, operation, ids[i], err)
+			}
+			errs[i] = err
+		}
+
+		if progress != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			progress(Progress{
+				ContainerType: containerType,
+				Operation:     operation,
+				ID:            ids[i],
+				Done:          done,
+				Total:         len(ids),
+				Err:           err,
+			})
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// StreamContainerJobs runs an operation for each container ID like
+// RunContainerJobs, and calls emit with the values of the succeeded
+// containers in the order of the container IDs as they complete. A value is
+// held only until the values of the previous containers are emitted. The
+// operation stops at the first error of emit, which is returned.
+func StreamContainerJobs[T any](ctx context.Context, containerType string, operation string, ids []string, fn func(i int) (T, error), emit func(T) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var emitErr error
+	values := make([]T, len(ids))
+	completed := make([]bool, len(ids))
+	succeeded := make([]bool, len(ids))
+	next := 0
+
+	err := RunContainerJobs(ctx, containerType, operation, ids, func(i int) error {
+		value, err := fn(i)
+
+		mu.Lock()
+		defer mu.Unlock()
+		values[i], completed[i], succeeded[i] = value, true, err == nil
+		for ; next < len(ids) && completed[next]; next++ {
+			if succeeded[next] && emitErr == nil {
+				if emitErr = emit(values[next]); emitErr != nil {
+					cancel()
+				}
+			}
+			var zero T
+			values[next] = zero
+		}
+		return err
+	})
+	if emitErr != nil {
+		return emitErr
+	}
+	return err
+}
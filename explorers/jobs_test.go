@@ -0,0 +1,187 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJobs(t *testing.T) {
+	ctx := context.Background()
+	if Jobs(ctx) != 1 {
+		t.Errorf("expected 1 job by default, got %d", Jobs(ctx))
+	}
+	if Jobs(WithJobs(ctx, 8)) != 8 {
+		t.Errorf("expected 8 jobs, got %d", Jobs(WithJobs(ctx, 8)))
+	}
+	if Jobs(WithJobs(ctx, 0)) != 1 {
+		t.Errorf("expected 1 job for an invalid value")
+	}
+}
+
+func TestRunJobs(t *testing.T) {
+	ctx := WithJobs(context.Background(), 4)
+
+	done := make([]bool, 100)
+	if err := RunJobs(ctx, len(done), func(i int) { done[i] = true }); err != nil {
+		t.Fatalf("RunJobs failed: %v", err)
+	}
+	for i, ok := range done {
+		if !ok {
+			t.Errorf("index %d not run", i)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var started atomic.Int32
+	err := RunJobs(ctx, 100, func(int) {
+		if started.Add(1) == 1 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context canceled, got %v", err)
+	}
+	if started.Load() == 100 {
+		t.Errorf("expected the indexes after cancel to be skipped")
+	}
+}
+
+func TestRunJobs_CanceledLastIndex(t *testing.T) {
+	ctx, cancel := context.WithCancel(WithJobs(context.Background(), 1))
+	defer cancel()
+
+	// The context is canceled while the last index waits for the job.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int32
+	result := make(chan error)
+	go func() {
+		result <- RunJobs(ctx, 2, func(i int) {
+			calls.Add(1)
+			if i == 0 {
+				close(started)
+				<-release
+			}
+		})
+	}()
+
+	<-started
+	time.Sleep(10 * time.Millisecond) // RunJobs waits to hand out the last index
+	cancel()
+	close(release)
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context canceled, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected the last index to be skipped, got %d calls", calls.Load())
+	}
+}
+
+func TestRunFileJobs(t *testing.T) {
+	ctx := WithJobs(context.Background(), 3)
+
+	// The file jobs of the containers processed concurrently share the jobs.
+	var running, peak atomic.Int32
+	err := RunJobs(ctx, 3, func(int) {
+		_ = RunFileJobs(ctx, 10, func(int) {
+			n := running.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+		})
+	})
+	if err != nil {
+		t.Fatalf("RunJobs failed: %v", err)
+	}
+	if peak.Load() > 3 {
+		t.Errorf("expected at most 3 concurrent file jobs, got %d", peak.Load())
+	}
+
+	var calls atomic.Int32
+	if err := RunFileJobs(context.Background(), 5, func(int) { calls.Add(1) }); err != nil || calls.Load() != 5 {
+		t.Errorf("expected 5 calls without jobs, got %d, %v", calls.Load(), err)
+	}
+}
+
+func TestRunContainerJobs(t *testing.T) {
+	var progress []Progress
+	ctx := WithJobs(context.Background(), 3)
+	ctx = WithProgress(ctx, func(p Progress) { progress = append(progress, p) })
+
+	ids := []string{"c1", "c2", "c3", "c4", "c5"}
+	err := RunContainerJobs(ctx, "docker", "drift", ids, func(i int) error {
+		if i%2 == 1 {
+			return errors.New("scan failed")
+		}
+		return nil
+	})
+
+	itemErrs := ItemErrors("docker", "drift", err)
+	if len(itemErrs) != 2 || itemErrs[0].ID != "c2" || itemErrs[1].ID != "c4" {
+		t.Errorf("expected errors of c2 and c4 in order, got %v", itemErrs)
+	}
+	if len(progress) != len(ids) || progress[len(ids)-1].Done != len(ids) || progress[0].Total != len(ids) {
+		t.Errorf("unexpected progress %+v", progress)
+	}
+}
+
+func TestStreamContainerJobs(t *testing.T) {
+	ctx := WithJobs(context.Background(), 4)
+
+	ids := []string{"c1", "c2", "c3", "c4", "c5", "c6"}
+	var emitted []string
+	err := StreamContainerJobs(ctx, "docker", "drift", ids, func(i int) (string, error) {
+		// The first containers complete last.
+		time.Sleep(time.Duration(len(ids)-i) * time.Millisecond)
+		if i == 2 {
+			return "", errors.New("scan failed")
+		}
+		return ids[i], nil
+	}, func(id string) error {
+		emitted = append(emitted, id)
+		return nil
+	})
+
+	if expected := []string{"c1", "c2", "c4", "c5", "c6"}; !reflect.DeepEqual(emitted, expected) {
+		t.Errorf("expected values %v in order, got %v", expected, emitted)
+	}
+	if itemErrs := ItemErrors("docker", "drift", err); len(itemErrs) != 1 || itemErrs[0].ID != "c3" {
+		t.Errorf("expected the error of c3, got %v", itemErrs)
+	}
+
+	errWrite := errors.New("write failed")
+	var calls atomic.Int32
+	err = StreamContainerJobs(WithJobs(context.Background(), 1), "docker", "drift", ids, func(i int) (string, error) {
+		calls.Add(1)
+		return ids[i], nil
+	}, func(string) error {
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) {
+		t.Errorf("expected the emit error, got %v", err)
+	}
+	if calls.Load() == int32(len(ids)) {
+		t.Errorf("expected the containers after the emit error to be skipped")
+	}
+}
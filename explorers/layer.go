@@ -0,0 +1,36 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+// LayerStack provides the overlay directories of a container.
+//
+// The directories are in overlay format i.e. whiteouts are character devices
+// and opaque directories are marked using the overlay opaque xattr.
+type LayerStack struct {
+	UpperDir  string   // container writable layer
+	LowerDirs []string // image layers, the top layer first
+}
+
+// Dirs returns the directories of the layer stack, the upper directory, if
+// any, first.
+func (l LayerStack) Dirs() []string {
+	var dirs []string
+	if l.UpperDir != "" {
+		dirs = append(dirs, l.UpperDir)
+	}
+	return append(dirs, l.LowerDirs...)
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLayerStackDirs(t *testing.T) {
+	tests := []struct {
+		layers   LayerStack
+		expected string
+	}{
+		{LayerStack{}, ""},
+		{LayerStack{UpperDir: "/upper"}, "/upper"},
+		{LayerStack{LowerDirs: []string{"/top", "/base"}}, "/top:/base"},
+		{LayerStack{UpperDir: "/upper", LowerDirs: []string{"/top", "/base"}}, "/upper:/top:/base"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(tt.layers.Dirs(), ":"); got != tt.expected {
+			t.Errorf("%+v.Dirs() = %s, expected %s", tt.layers, got, tt.expected)
+		}
+	}
+}
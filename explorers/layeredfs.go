@@ -0,0 +1,357 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxSymlinks is the number of symbolic links followed when resolving a
+// path, as in Linux path resolution.
+const maxSymlinks = 40
+
+// overlayOpaqueXattrs mark an overlay directory hiding the content of the
+// lower layers.
+var overlayOpaqueXattrs = []string{"trusted.overlay.opaque", "user.overlay.opaque"}
+
+// LayeredFS is a read-only view of the merged content of a layer stack,
+// read without mounting the layers.
+//
+// Paths are resolved through the layers, the upper directory first, as
+// overlayfs does. Whiteouts hide the files of the lower layers, opaque
+// directories hide the directory content of the lower layers, and symbolic
+// links are resolved within the layered filesystem instead of the host.
+type LayeredFS struct {
+	dirs []string
+}
+
+// LayeredFile is a file of a layered filesystem.
+type LayeredFile struct {
+	Path  string      // absolute path in the layered filesystem
+	Layer string      // layer directory containing the file
+	Info  fs.FileInfo // file information, not following symbolic links
+}
+
+// HostPath returns the path of the file in its layer directory.
+func (f LayeredFile) HostPath() string {
+	return filepath.Join(f.Layer, filepath.FromSlash(f.Path))
+}
+
+// NewLayeredFS returns the layered filesystem of a layer stack.
+func NewLayeredFS(layers LayerStack) *LayeredFS {
+	return &LayeredFS{dirs: layers.Dirs()}
+}
+
+// Lstat returns a file without following a final symbolic link.
+func (l *LayeredFS) Lstat(name string) (LayeredFile, error) {
+	p, err := l.resolve(name, false)
+	if err != nil {
+		return LayeredFile{}, err
+	}
+	return l.lookup(p)
+}
+
+// Stat returns a file, following symbolic links.
+func (l *LayeredFS) Stat(name string) (LayeredFile, error) {
+	p, err := l.resolve(name, true)
+	if err != nil {
+		return LayeredFile{}, err
+	}
+	return l.lookup(p)
+}
+
+// Open opens a file for reading, following symbolic links.
+func (l *LayeredFS) Open(name string) (*os.File, error) {
+	f, err := l.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:gosec // G304: Path is resolved within the layer directories
+	return os.Open(f.HostPath())
+}
+
+// Readlink returns the target of a symbolic link.
+func (l *LayeredFS) Readlink(name string) (string, error) {
+	f, err := l.Lstat(name)
+	if err != nil {
+		return "", err
+	}
+	if f.Info.Mode()&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: syscall.EINVAL}
+	}
+	return os.Readlink(f.HostPath())
+}
+
+// ReadDir returns the merged entries of a directory sorted by name,
+// following symbolic links.
+func (l *LayeredFS) ReadDir(name string) ([]LayeredFile, error) {
+	p, err := l.resolve(name, true)
+	if err != nil {
+		return nil, err
+	}
+	files, err := l.layerFiles(p)
+	if err != nil {
+		return nil, err
+	}
+	if !files[0].Info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	return readLayerDirs(files)
+}
+
+// Walk walks the file tree rooted at name in lexical order, calling fn for
+// each file, a directory before its entries. Symbolic links are not
+// followed, except the root.
+//
+// If a directory cannot be read, fn is called again with the directory and
+// the error. Returning fs.SkipDir skips a directory, or the remaining
+// entries of the directory of a file, and fs.SkipAll stops the walk.
+func (l *LayeredFS) Walk(name string, fn func(LayeredFile, error) error) error {
+	root, err := l.Stat(name)
+	if err != nil {
+		return fn(LayeredFile{Path: name}, err)
+	}
+
+	err = l.walk(root, fn)
+	if errors.Is(err, fs.SkipDir) || errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+func (l *LayeredFS) walk(f LayeredFile, fn func(LayeredFile, error) error) error {
+	if err := fn(f, nil); err != nil || !f.Info.IsDir() {
+		if errors.Is(err, fs.SkipDir) && f.Info.IsDir() {
+			return nil
+		}
+		return err
+	}
+
+	entries, err := l.ReadDir(f.Path)
+	if err != nil {
+		if err := fn(f, err); err != nil && !errors.Is(err, fs.SkipDir) {
+			return err
+		}
+		return nil
+	}
+
+	for _, entry := range entries {
+		if err := l.walk(entry, fn); err != nil {
+			if errors.Is(err, fs.SkipDir) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve returns the absolute path of a file with the symbolic links of
+// its parent directories resolved, and of the file itself if follow is set.
+func (l *LayeredFS) resolve(name string, follow bool) (string, error) {
+	if len(l.dirs) == 0 {
+		return "", fmt.Errorf("no layers")
+	}
+
+	links := 0
+	resolved := "/"
+	rest := strings.Split(name, "/")
+	for len(rest) > 0 {
+		elem := rest[0]
+		rest = rest[1:]
+
+		switch elem {
+		case "", ".":
+			continue
+		case "..":
+			resolved = path.Dir(resolved)
+			continue
+		}
+
+		next := path.Join(resolved, elem)
+		if len(rest) == 0 && !follow {
+			resolved = next
+			break
+		}
+
+		f, err := l.lookup(next)
+		if err != nil {
+			return "", &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+		}
+		if f.Info.Mode()&fs.ModeSymlink == 0 {
+			if len(rest) > 0 && !f.Info.IsDir() {
+				return "", &fs.PathError{Op: "stat", Path: name, Err: syscall.ENOTDIR}
+			}
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", &fs.PathError{Op: "stat", Path: name, Err: syscall.ELOOP}
+		}
+		target, err := os.Readlink(f.HostPath())
+		if err != nil {
+			return "", err
+		}
+		if path.IsAbs(target) {
+			resolved = "/"
+		}
+		rest = append(strings.Split(target, "/"), rest...)
+	}
+	return resolved, nil
+}
+
+// lookup returns the top file of a path with resolved parent directories.
+func (l *LayeredFS) lookup(p string) (LayeredFile, error) {
+	files, err := l.layerFiles(p)
+	if err != nil {
+		return LayeredFile{}, err
+	}
+	return files[0], nil
+}
+
+// layerFiles returns the files of a path with resolved parent directories
+// in the layers it is visible in, the top layer first. Only the directories
+// of several layers are merged.
+func (l *LayeredFS) layerFiles(p string) ([]LayeredFile, error) {
+	var elems []string
+	if p != "/" {
+		elems = strings.Split(strings.TrimPrefix(p, "/"), "/")
+	}
+
+	var files []LayeredFile
+	for _, dir := range l.dirs {
+		info, hides := lookupLayer(dir, elems)
+		if info != nil {
+			if len(files) > 0 && !info.IsDir() {
+				break
+			}
+			files = append(files, LayeredFile{Path: p, Layer: dir, Info: info})
+		}
+		if hides {
+			break
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, &fs.PathError{Op: "lstat", Path: p, Err: fs.ErrNotExist}
+	}
+	return files, nil
+}
+
+// lookupLayer returns the file of a path in a layer directory, if any, and
+// whether the layer hides the path in the lower layers.
+func lookupLayer(dir string, elems []string) (fs.FileInfo, bool) {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return nil, false
+	}
+
+	opaque := false
+	hostPath := dir
+	for i, elem := range elems {
+		hostPath = filepath.Join(hostPath, elem)
+		if info, err = os.Lstat(hostPath); err != nil {
+			return nil, opaque
+		}
+		if isWhiteout(info) {
+			return nil, true
+		}
+		if i < len(elems)-1 {
+			if !info.IsDir() {
+				return nil, true
+			}
+			opaque = opaque || isOpaque(hostPath)
+		}
+	}
+
+	if len(elems) == 0 {
+		return info, false
+	}
+	return info, opaque || !info.IsDir() || isOpaque(hostPath)
+}
+
+// readLayerDirs returns the merged entries of the directories of a path in
+// several layers. An entry of an upper layer hides the entries of the same
+// name in the lower layers.
+func readLayerDirs(dirs []LayeredFile) ([]LayeredFile, error) {
+	seen := make(map[string]bool)
+
+	var files []LayeredFile
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir.HostPath())
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if seen[entry.Name()] {
+				continue
+			}
+			seen[entry.Name()] = true
+
+			info, err := entry.Info()
+			if err != nil {
+				return nil, err
+			}
+			if isWhiteout(info) {
+				continue
+			}
+			files = append(files, LayeredFile{
+				Path:  path.Join(dir.Path, entry.Name()),
+				Layer: dir.Layer,
+				Info:  info,
+			})
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// isWhiteout returns true for an overlay whiteout, i.e. a character device
+// 0/0.
+func isWhiteout(info fs.FileInfo) bool {
+	if info.Mode()&fs.ModeCharDevice == 0 {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && stat.Rdev == 0
+}
+
+// isOpaque returns true for an overlay opaque directory.
+func isOpaque(p string) bool {
+	value := make([]byte, 1)
+	for _, attr := range overlayOpaqueXattrs {
+		if n, err := unix.Lgetxattr(p, attr, value); err == nil && n == 1 && value[0] == 'y' {
+			return true
+		}
+	}
+	return false
+}
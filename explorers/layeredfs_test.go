@@ -0,0 +1,276 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// writeLayer creates the files of a layer directory. A file content
+// starting with "->" creates a symbolic link, and a path ending with "/" a
+// directory.
+func writeLayer(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if strings.HasSuffix(name, "/") {
+			_ = os.MkdirAll(p, 0755)
+			continue
+		}
+		_ = os.MkdirAll(filepath.Dir(p), 0755)
+		if target, ok := strings.CutPrefix(content, "->"); ok {
+			_ = os.Symlink(target, p)
+			continue
+		}
+		_ = os.WriteFile(p, []byte(content), 0644)
+	}
+}
+
+func readLayeredFile(t *testing.T, l *LayeredFS, name string) string {
+	t.Helper()
+
+	f, err := l.Open(name)
+	if err != nil {
+		t.Fatalf("Open(%s) failed: %v", name, err)
+	}
+	defer f.Close()
+
+	data, _ := io.ReadAll(f)
+	return string(data)
+}
+
+func layeredPaths(files []LayeredFile) string {
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	return strings.Join(paths, ",")
+}
+
+func TestLayeredFS(t *testing.T) {
+	tmpDir := t.TempDir()
+	upper := filepath.Join(tmpDir, "upper")
+	top := filepath.Join(tmpDir, "top")
+	base := filepath.Join(tmpDir, "base")
+
+	writeLayer(t, base, map[string]string{
+		"etc/passwd":  "base",
+		"etc/shadow":  "secret",
+		"usr/lib/a":   "a",
+		"data/x":      "x",
+		"bin/sh":      "sh",
+		"link-to-bin": "->bin",
+	})
+	writeLayer(t, top, map[string]string{
+		"etc/passwd": "top",
+		"usr/lib/b":  "b",
+		"link":       "->/etc/passwd",
+		"usr/rel":    "->../etc/passwd",
+		"escape":     "->../../../../etc/passwd",
+		"loop":       "->loop",
+	})
+	writeLayer(t, upper, map[string]string{
+		"data":    "replaced directory",
+		"tmp/new": "new",
+	})
+
+	l := NewLayeredFS(LayerStack{UpperDir: upper, LowerDirs: []string{top, base}})
+
+	// The top layer containing a file is used
+	f, err := l.Stat("/etc/passwd")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if f.Layer != top || f.Path != "/etc/passwd" || f.HostPath() != filepath.Join(top, "etc", "passwd") {
+		t.Errorf("unexpected file %+v", f)
+	}
+	if content := readLayeredFile(t, l, "etc/passwd"); content != "top" {
+		t.Errorf("expected content of the top layer, got %q", content)
+	}
+
+	// Directories are merged
+	tests := map[string]string{
+		"/":                "/bin,/data,/escape,/etc,/link,/link-to-bin,/loop,/tmp,/usr",
+		"/etc":             "/etc/passwd,/etc/shadow",
+		"/usr/lib":         "/usr/lib/a,/usr/lib/b",
+		"/usr/../usr/lib/": "/usr/lib/a,/usr/lib/b",
+		"link-to-bin":      "/bin/sh",
+	}
+	for name, expected := range tests {
+		entries, err := l.ReadDir(name)
+		if err != nil {
+			t.Fatalf("ReadDir(%s) failed: %v", name, err)
+		}
+		if got := layeredPaths(entries); got != expected {
+			t.Errorf("ReadDir(%s) = %s, expected %s", name, got, expected)
+		}
+	}
+	entries, _ := l.ReadDir("/etc")
+	if len(entries) != 2 || entries[0].Layer != top || entries[1].Layer != base {
+		t.Errorf("expected passwd from the top layer and shadow from the base layer, got %+v", entries)
+	}
+
+	// Symbolic links are resolved within the layered filesystem
+	for _, name := range []string{"/link", "/usr/rel", "/escape"} {
+		if content := readLayeredFile(t, l, name); content != "top" {
+			t.Errorf("expected %s to resolve to /etc/passwd, got %q", name, content)
+		}
+	}
+	if f, err := l.Lstat("/link"); err != nil || f.Info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("expected Lstat to return the link, got %+v, %v", f, err)
+	}
+	if target, err := l.Readlink("/usr/rel"); err != nil || target != "../etc/passwd" {
+		t.Errorf("expected link target ../etc/passwd, got %q, %v", target, err)
+	}
+	if _, err := l.Stat("/loop"); !errors.Is(err, syscall.ELOOP) {
+		t.Errorf("expected ELOOP for a symbolic link loop, got %v", err)
+	}
+
+	// A file of an upper layer hides a directory of a lower layer
+	if f, err := l.Stat("/data"); err != nil || f.Layer != upper || f.Info.IsDir() {
+		t.Errorf("expected /data file of the upper layer, got %+v, %v", f, err)
+	}
+	if _, err := l.Stat("/data/x"); err == nil {
+		t.Errorf("expected /data/x to be hidden")
+	}
+	if _, err := l.ReadDir("/data"); !errors.Is(err, syscall.ENOTDIR) {
+		t.Errorf("expected ENOTDIR for a file, got %v", err)
+	}
+	if _, err := l.Stat("/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected ErrNotExist, got %v", err)
+	}
+
+	// The walk does not follow symbolic links and can skip directories
+	var walked []string
+	err = l.Walk("/", func(f LayeredFile, err error) error {
+		if err != nil {
+			return err
+		}
+		if f.Path == "/usr" {
+			return fs.SkipDir
+		}
+		walked = append(walked, f.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	expected := "/,/bin,/bin/sh,/data,/escape,/etc,/etc/passwd,/etc/shadow,/link,/link-to-bin,/loop,/tmp,/tmp/new"
+	if got := strings.Join(walked, ","); got != expected {
+		t.Errorf("Walk = %s, expected %s", got, expected)
+	}
+
+	if err := NewLayeredFS(LayerStack{}).Walk("/", func(LayeredFile, error) error { return nil }); err != nil {
+		t.Errorf("expected the walk error to be returned by fn, got %v", err)
+	}
+	if _, err := NewLayeredFS(LayerStack{}).Stat("/"); err == nil {
+		t.Errorf("expected error for an empty layer stack")
+	}
+}
+
+func TestLayeredFS_Whiteouts(t *testing.T) {
+	tmpDir := t.TempDir()
+	upper := filepath.Join(tmpDir, "upper")
+	base := filepath.Join(tmpDir, "base")
+
+	writeLayer(t, base, map[string]string{
+		"etc/passwd":   "root",
+		"etc/shadow":   "secret",
+		"opaque/old":   "old",
+		"deleted/file": "file",
+	})
+	writeLayer(t, upper, map[string]string{
+		"etc/":       "",
+		"opaque/new": "new",
+	})
+
+	if err := unix.Mknod(filepath.Join(upper, "etc", "shadow"), unix.S_IFCHR|0000, 0); err != nil {
+		t.Skipf("creating whiteout requires privileges: %v", err)
+	}
+	_ = unix.Mknod(filepath.Join(upper, "deleted"), unix.S_IFCHR|0000, 0)
+
+	l := NewLayeredFS(LayerStack{UpperDir: upper, LowerDirs: []string{base}})
+
+	for _, name := range []string{"/etc/shadow", "/deleted", "/deleted/file"} {
+		if _, err := l.Lstat(name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected whiteout %s not to exist, got %v", name, err)
+		}
+	}
+	entries, _ := l.ReadDir("/etc")
+	if got := layeredPaths(entries); got != "/etc/passwd" {
+		t.Errorf("expected whiteout to be hidden, got %s", got)
+	}
+	entries, _ = l.ReadDir("/")
+	if got := layeredPaths(entries); got != "/etc,/opaque" {
+		t.Errorf("expected whiteout directory to be hidden, got %s", got)
+	}
+
+	if unix.Setxattr(filepath.Join(upper, "opaque"), "trusted.overlay.opaque", []byte("y"), 0) != nil {
+		t.Skip("setting the overlay opaque xattr requires privileges")
+	}
+	entries, _ = l.ReadDir("/opaque")
+	if got := layeredPaths(entries); got != "/opaque/new" {
+		t.Errorf("expected opaque directory to hide the lower layers, got %s", got)
+	}
+	if _, err := l.Stat("/opaque/old"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected /opaque/old to be hidden, got %v", err)
+	}
+}
+
+func TestLayeredFS_NestedOpaque(t *testing.T) {
+	tmpDir := t.TempDir()
+	upper := filepath.Join(tmpDir, "upper")
+	base := filepath.Join(tmpDir, "base")
+
+	writeLayer(t, base, map[string]string{
+		"a/b/deleted": "deleted",
+		"a/old":       "old",
+	})
+	writeLayer(t, upper, map[string]string{
+		"a/b/new": "new",
+	})
+
+	if unix.Setxattr(filepath.Join(upper, "a"), "trusted.overlay.opaque", []byte("y"), 0) != nil {
+		t.Skip("setting the overlay opaque xattr requires privileges")
+	}
+
+	l := NewLayeredFS(LayerStack{UpperDir: upper, LowerDirs: []string{base}})
+
+	// The opaque /a hides the lower layers below all of its subdirectories
+	for _, name := range []string{"/a/old", "/a/b/deleted"} {
+		if _, err := l.Stat(name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected %s to be hidden, got %v", name, err)
+		}
+	}
+	entries, err := l.ReadDir("/a/b")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if got := layeredPaths(entries); got != "/a/b/new" {
+		t.Errorf("expected nested directory of an opaque directory to hide the lower layers, got %s", got)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Orphan reason values.
+const (
+	OrphanUnreferenced = "UNREFERENCED" // directory is not in the snapshot or layer database
+	OrphanNoMetadata   = "NO_METADATA"  // snapshot is in the snapshotter database but not in meta.db
+	OrphanNoContainer  = "NO_CONTAINER" // container snapshot or layer without a container
+)
+
+// Orphan provides information about a snapshot or layer directory left on
+// disk after the container or image using it was removed.
+type Orphan struct {
+	ContainerType string    // container type: containerd, docker, podman, etc.
+	Key           string    // key of the directory used by mount snapshot and export snapshot
+	Path          string    // directory containing the snapshot or layer files
+	Reason        string    // one of the Orphan reason values
+	Parent        string    // key of the parent snapshot or layer
+	ParentGuessed bool      // parent is a best guess instead of being recorded on disk
+	Size          int64     // total size of the regular files
+	Files         int       // number of files and directories
+	ModifiedAt    time.Time // latest modification time of the files
+	ChangedAt     time.Time // change time of the directory
+}
+
+// NewOrphan returns an orphan with the size and timestamps of the files of
+// its directory.
+func NewOrphan(containerType string, key string, dir string, reason string) Orphan {
+	orphan := Orphan{
+		ContainerType: containerType,
+		Key:           key,
+		Path:          dir,
+		Reason:        reason,
+	}
+
+	if info, err := os.Stat(dir); err == nil {
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			orphan.ChangedAt = time.Unix(stat.Ctim.Sec, stat.Ctim.Nsec).UTC()
+		}
+	}
+
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log.WithFields(log.Fields{"path": path, "error": err}).Debug("walking orphan directory")
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		if path != dir {
+			orphan.Files++
+		}
+		if info.Mode().IsRegular() {
+			orphan.Size += info.Size()
+		}
+		if info.ModTime().After(orphan.ModifiedAt) {
+			orphan.ModifiedAt = info.ModTime().UTC()
+		}
+		return nil
+	})
+	return orphan
+}
+
+// ReadOverlayLower returns the layer IDs listed in the lower file of an
+// overlay graph driver layer, the top layer first. The lower file contains
+// the short links of the layers i.e. l/<link>, each a symbolic link to the
+// diff directory ../<id>/diff.
+//
+// An empty list is returned for a base layer without a lower file.
+func ReadOverlayLower(overlayDir string, id string) ([]string, error) {
+	//nolint:gosec // G304: Path is constructed from trusted storage root
+	data, err := os.ReadFile(filepath.Join(overlayDir, id, "lower"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, lower := range strings.Split(strings.TrimSpace(string(data)), ":") {
+		if lower == "" {
+			continue
+		}
+		target, err := os.Readlink(filepath.Join(overlayDir, lower))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, filepath.Base(filepath.Dir(target)))
+	}
+	return ids, nil
+}
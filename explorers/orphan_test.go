@@ -0,0 +1,82 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewOrphan(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(dir, "tmp", ".x"), 0755)
+	_ = os.WriteFile(filepath.Join(dir, "tmp", ".x", "kworker"), []byte("payload"), 0755)
+	_ = os.WriteFile(filepath.Join(dir, "tmp", "log"), []byte("log"), 0644)
+
+	modified := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	_ = os.Chtimes(filepath.Join(dir, "tmp", ".x", "kworker"), modified, modified)
+
+	orphan := NewOrphan("docker", "key", dir, OrphanUnreferenced)
+	if orphan.ContainerType != "docker" || orphan.Key != "key" || orphan.Path != dir || orphan.Reason != OrphanUnreferenced {
+		t.Errorf("unexpected orphan %+v", orphan)
+	}
+	if orphan.Size != 10 || orphan.Files != 4 {
+		t.Errorf("expected 10 bytes in 4 files, got %d bytes in %d files", orphan.Size, orphan.Files)
+	}
+	if !orphan.ModifiedAt.Equal(modified) {
+		t.Errorf("expected modified time %v, got %v", modified, orphan.ModifiedAt)
+	}
+	if orphan.ChangedAt.IsZero() {
+		t.Errorf("expected change time")
+	}
+
+	missing := NewOrphan("docker", "missing", filepath.Join(dir, "missing"), OrphanUnreferenced)
+	if missing.Size != 0 || missing.Files != 0 || !missing.ChangedAt.IsZero() {
+		t.Errorf("expected empty orphan, got %+v", missing)
+	}
+}
+
+func TestReadOverlayLower(t *testing.T) {
+	overlayDir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(overlayDir, "l"), 0755)
+	for id, link := range map[string]string{"top": "TOP", "base": "BASE"} {
+		_ = os.MkdirAll(filepath.Join(overlayDir, id, "diff"), 0755)
+		_ = os.Symlink("../"+id+"/diff", filepath.Join(overlayDir, "l", link))
+	}
+	_ = os.MkdirAll(filepath.Join(overlayDir, "container"), 0755)
+	_ = os.WriteFile(filepath.Join(overlayDir, "container", "lower"), []byte("l/TOP:l/BASE\n"), 0644)
+	_ = os.MkdirAll(filepath.Join(overlayDir, "broken"), 0755)
+	_ = os.WriteFile(filepath.Join(overlayDir, "broken", "lower"), []byte("l/MISSING"), 0644)
+
+	ids, err := ReadOverlayLower(overlayDir, "container")
+	if err != nil {
+		t.Fatalf("ReadOverlayLower failed: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []string{"top", "base"}) {
+		t.Errorf("expected [top base], got %v", ids)
+	}
+
+	if ids, err := ReadOverlayLower(overlayDir, "base"); err != nil || len(ids) != 0 {
+		t.Errorf("expected no lower layers of a base layer, got %v, %v", ids, err)
+	}
+	if _, err := ReadOverlayLower(overlayDir, "broken"); err == nil {
+		t.Errorf("expected error for a missing short link")
+	}
+}
@@ -16,6 +16,8 @@ limitations under the License.
 
 package podman
 
+import "time"
+
 type containerMetadata struct {
 	ImageName string `json:"image-name"`
 	ImageID   string `json:"image-id"`
@@ -50,3 +52,20 @@ type containerImage struct {
 	BigDataDigests []string       `json:"big-data-digests"`
 	Created        string         `json:"created"`
 }
+
+type containerLayer struct {
+	ID     string `json:"id"`
+	Parent string `json:"parent"`
+}
+
+// libpodContainerConfig is the JSON column of a ContainerConfig row of the
+// podman database.
+type libpodContainerConfig struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Pod             string            `json:"pod,omitempty"`
+	RootfsImageID   string            `json:"rootfsImageID,omitempty"`
+	RootfsImageName string            `json:"rootfsImageName,omitempty"`
+	CreatedTime     time.Time         `json:"createdTime"`
+	Labels          map[string]string `json:"labels,omitempty"`
+}
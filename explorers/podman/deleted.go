@@ -0,0 +1,118 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podman
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/utils"
+
+	"github.com/containerd/containerd/containers"
+	log "github.com/sirupsen/logrus"
+	"go.podman.io/podman/v6/libpod"
+)
+
+// ListDeletedContainers returns the deleted containers and the previous
+// versions of updated containers recovered from the pages of the podman
+// database db.sql and its WAL file.
+//
+// A recovered ContainerConfig record is returned with the container
+// configuration, and a recovered ContainerState record with the container
+// status and PID.
+func (e *explorer) ListDeletedContainers(_ context.Context) ([]explorers.Container, error) {
+	var recovered []explorers.Container
+
+	for _, podmanRootDir := range e.podmanRootDirs {
+		dbfile := filepath.Join(podmanRootDir, "storage", "db.sql")
+		if ok := utils.PathExistsV2(dbfile); !ok {
+			log.WithField("dbfile", dbfile).Debug("podman sqlite database file not found, skipping root directory")
+			continue
+		}
+
+		records, err := carvePodmanDB(dbfile)
+		if err != nil {
+			log.WithFields(log.Fields{"dbfile": dbfile, "error": err}).Warn("carving podman database")
+			continue
+		}
+
+		// Container names of the live and recovered configurations
+		live := make(map[string]bool)
+		names := make(map[string]string)
+		for _, record := range records {
+			if record.kind != podmanRecordConfig {
+				continue
+			}
+			if record.live {
+				live[record.id] = true
+			}
+			var config libpodContainerConfig
+			if err := json.Unmarshal(record.json, &config); err == nil && (record.live || names[record.id] == "") {
+				names[record.id] = config.Name
+			}
+		}
+
+		for _, record := range records {
+			if record.live {
+				continue
+			}
+
+			c := explorers.Container{
+				Name:          names[record.id],
+				Hostname:      names[record.id],
+				ContainerType: "podman",
+				Container: containers.Container{
+					ID: record.id,
+				},
+				Recovered:     explorers.RecoveredDeleted,
+				RecoveredFrom: fmt.Sprintf("%s page %d offset %d", record.source, record.page, record.offset),
+			}
+			if live[record.id] {
+				c.Recovered = explorers.RecoveredPreviousVersion
+			}
+
+			switch record.kind {
+			case podmanRecordConfig:
+				var config libpodContainerConfig
+				if err := json.Unmarshal(record.json, &config); err != nil {
+					log.WithFields(log.Fields{"containerID": record.id, "error": err}).Debug("unmarshalling recovered container config")
+					continue
+				}
+				c.Name = config.Name
+				c.Hostname = config.Name
+				c.ImageBase = config.RootfsImageName
+				c.Image = config.RootfsImageName
+				c.CreatedAt = config.CreatedTime
+				c.Labels = config.Labels
+			case podmanRecordState:
+				var state libpod.ContainerState
+				if err := json.Unmarshal(record.json, &state); err != nil {
+					log.WithFields(log.Fields{"containerID": record.id, "error": err}).Debug("unmarshalling recovered container state")
+					continue
+				}
+				c.ProcessID = state.PID
+				c.Status = state.State.String()
+			}
+			recovered = append(recovered, c)
+		}
+	}
+
+	return recovered, nil
+}
@@ -20,13 +20,15 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/google/container-explorer/explorers"
 	"github.com/google/container-explorer/utils"
 	log "github.com/sirupsen/logrus"
 )
 
 // ExportContainer exports a podman container as a raw or as an archive.
-func (e *explorer) ExportContainer(ctx context.Context, containerID string, outputDir string, exportOptions map[string]bool) error {
+func (e *explorer) ExportContainer(ctx context.Context, containerID string, outputDir string, options explorers.ContainerExportOptions) error {
 	targetContainer, err := e.GetContainerByID(ctx, containerID)
 	if err != nil {
 		return fmt.Errorf("finding container %s: %w", containerID, err)
@@ -40,6 +42,28 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 		"containerType": targetContainer.ContainerType,
 	}).Info("container found")
 
+	e.recordCustody(ctx, targetContainer.ID)
+
+	if options.OCI {
+		log.Infof("exporting container %s as an OCI image to %s", targetContainer.ID, outputDir)
+		layers, err := e.ContainerLayers(ctx, targetContainer.ID)
+		if err != nil {
+			return fmt.Errorf("getting container %s layers: %w", targetContainer.ID, err)
+		}
+
+		// The image layers are created from the lower directories.
+		if err := utils.ExportContainerOCI(ctx, targetContainer.ID, layers, nil, outputDir); err != nil {
+			return fmt.Errorf("failed to export container %s as OCI image: %w", targetContainer.ID, err)
+		}
+		log.Infof("successfully exported container %s as an OCI image", targetContainer.ID)
+
+		// The OCI image is created from the overlay directories and does
+		// not require mounting the container.
+		if !options.Image && !options.Archive {
+			return nil
+		}
+	}
+
 	// Ensure outputDir exists
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
@@ -74,11 +98,10 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 	// Defer unmount and cleanup of the mountpoint
 	defer func() {
 		log.Infof("cleaning up mountpoint %s for container %s", mountpoint, targetContainer.ID)
-		unmountCmdOutput, unmountErr := utils.Runner.RunWithoutContext("umount", mountpoint)
-		if unmountErr != nil {
-			log.Warnf("failed to unmount %s: %v; output: %s", mountpoint, unmountErr, string(unmountCmdOutput))
+		if unmountErr := utils.Unmount(ctx, mountpoint); unmountErr != nil {
+			log.Warnf("failed to unmount %s: %v", mountpoint, unmountErr)
 		} else {
-			log.Infof("successfully unmounted %s; output: %s", mountpoint, string(unmountCmdOutput))
+			log.Infof("successfully unmounted %s", mountpoint)
 		}
 
 		if rmErr := os.Remove(mountpoint); rmErr != nil {
@@ -88,7 +111,7 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 		}
 	}()
 
-	if exportOptions["image"] {
+	if options.Image {
 		log.Infof("exporting container %s as a raw image to %s", targetContainer.ID, outputDir)
 		if err := utils.ExportContainerImage(ctx, targetContainer.ID, mountpoint, outputDir); err != nil {
 			return fmt.Errorf("failed to export container %s as raw image: %w", targetContainer.ID, err)
@@ -96,9 +119,9 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 		log.Infof("successfully exported container %s as a raw image", targetContainer.ID)
 	}
 
-	if exportOptions["archive"] {
+	if options.Archive {
 		log.Infof("exporting container %s as an archive to %s", targetContainer.ID, outputDir)
-		if err := utils.ExportContainerArchive(ctx, targetContainer.ID, mountpoint, outputDir); err != nil {
+		if err := utils.ExportContainerArchive(ctx, targetContainer.ID, mountpoint, outputDir, options.Compression); err != nil {
 			return fmt.Errorf("failed to export container %s as archive: %w", targetContainer.ID, err)
 		}
 		log.Infof("successfully exported container %s as an archive", targetContainer.ID)
@@ -107,8 +130,50 @@ func (e *explorer) ExportContainer(ctx context.Context, containerID string, outp
 	return nil
 }
 
+// recordCustody records the container and the configuration files it is read
+// from to the custody record of the context.
+func (e *explorer) recordCustody(ctx context.Context, containerID string) {
+	custody := utils.CustodyFromContext(ctx)
+	if custody == nil {
+		return
+	}
+
+	podmanRootDir, config, err := e.findContainerConfig(containerID)
+	if err != nil {
+		log.WithFields(log.Fields{"containerID": containerID, "error": err}).Warn("recording container custody")
+		return
+	}
+
+	custody.AddSource("podman containers", filepath.Join(podmanRootDir, "storage", "overlay-containers", "containers.json"))
+
+	// The container state is in the sqlite database or, for older podman
+	// versions, in the bolt database.
+	for _, source := range []struct{ kind, name string }{
+		{"podman state database", "db.sql"},
+		{"podman state database wal", "db.sql-wal"},
+		{"podman bolt state", filepath.Join("libpod", "bolt_state.db")},
+	} {
+		if p := filepath.Join(podmanRootDir, "storage", source.name); utils.PathExistsV2(p) {
+			custody.AddSource(source.kind, p)
+		}
+	}
+
+	record := utils.CustodyContainer{
+		ContainerID: config.ID,
+		Runtime:     e.Type(),
+		Image:       config.Image,
+		Snapshotter: "overlay",
+		SnapshotKey: config.Layer,
+	}
+	if layers, err := e.ContainerLayers(ctx, config.ID); err == nil {
+		record.Snapshots = append([]string{layers.UpperDir}, layers.LowerDirs...)
+	}
+
+	custody.AddContainer(record)
+}
+
 // ExportAllContainers exports all podman container to specific output directory.
-func (e *explorer) ExportAllContainers(ctx context.Context, outputDir string, exportOptions map[string]bool, filter map[string]string, exportSupportContainers bool) error {
+func (e *explorer) ExportAllContainers(ctx context.Context, outputDir string, options explorers.ContainerExportOptions, filter map[string]string, exportSupportContainers bool) error {
 	containers, err := e.ListContainers(ctx)
 	if err != nil {
 		return fmt.Errorf("listing containers: %w", err)
@@ -118,6 +183,8 @@ func (e *explorer) ExportAllContainers(ctx context.Context, outputDir string, ex
 		"container_count": len(containers),
 	}).Debug("podman containers")
 
+	var targets []explorers.Container
+	var ids []string
 	for _, container := range containers {
 		log.WithFields(log.Fields{
 			"containerID":   container.ID,
@@ -137,26 +204,30 @@ func (e *explorer) ExportAllContainers(ctx context.Context, outputDir string, ex
 		}
 
 		if utils.IncludeContainer(container, filter) {
+			targets = append(targets, container)
+			ids = append(ids, container.ID)
+		}
+	}
+
+	return explorers.RunContainerJobs(ctx, e.Type(), "export", ids, func(i int) error {
+		container := targets[i]
+		log.WithFields(log.Fields{
+			"containerID":   container.ID,
+			"name":          container.Name,
+			"namespace":     container.Namespace,
+			"containerType": container.ContainerType,
+		}).Debug("processing podman container for export")
+
+		err := e.ExportContainer(ctx, container.ID, outputDir, options)
+		if err != nil {
 			log.WithFields(log.Fields{
 				"containerID":   container.ID,
-				"name":          container.Name,
+				"name":          container.Runtime.Name,
 				"namespace":     container.Namespace,
 				"containerType": container.ContainerType,
-			}).Debug("processing podman container for export")
-
-			err := e.ExportContainer(ctx, container.ID, outputDir, exportOptions)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"containerID":   container.ID,
-					"name":          container.Runtime.Name,
-					"namespace":     container.Namespace,
-					"containerType": container.ContainerType,
-					"error":         err,
-				}).Error("error exporting podman container")
-			}
+				"error":         err,
+			}).Error("error exporting podman container")
 		}
-	}
-
-	// Return no error
-	return nil
+		return err
+	})
 }
@@ -0,0 +1,135 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podman
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/container-explorer/explorers"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListOrphans returns the overlay layer directories that are not used by an
+// image or a container.
+//
+// A layer directory is an orphan if it is missing from layers.json, or if
+// it is neither in the layer chain of an image nor the layer of a
+// container.
+func (e *explorer) ListOrphans(_ context.Context) ([]explorers.Orphan, error) {
+	var orphans []explorers.Orphan
+	for _, podmanRootDir := range e.podmanRootDirs {
+		overlayDir := filepath.Join(podmanRootDir, "storage", "overlay")
+		entries, err := os.ReadDir(overlayDir)
+		if err != nil {
+			log.WithFields(log.Fields{"podmanRootDir": podmanRootDir, "error": err}).Debug("reading overlay directory")
+			continue
+		}
+
+		// Without layers.json every layer directory would be reported.
+		layers, err := readLayers(podmanRootDir)
+		if err != nil {
+			log.WithFields(log.Fields{"podmanRootDir": podmanRootDir, "error": err}).Warn("reading layers.json")
+			continue
+		}
+		used := e.usedLayers(podmanRootDir, layers)
+
+		for _, entry := range entries {
+			id := entry.Name()
+			if !entry.IsDir() || id == "l" || used[id] {
+				continue
+			}
+
+			dir := filepath.Join(overlayDir, id, "diff")
+			layer, ok := layers[id]
+			if ok {
+				orphan := explorers.NewOrphan("podman", id, dir, explorers.OrphanNoContainer)
+				orphan.Parent = layer.Parent
+				orphans = append(orphans, orphan)
+				continue
+			}
+
+			orphan := explorers.NewOrphan("podman", id, dir, explorers.OrphanUnreferenced)
+			lowerIDs, err := explorers.ReadOverlayLower(overlayDir, id)
+			if err != nil {
+				log.WithFields(log.Fields{"id": id, "error": err}).Debug("reading overlay lower file")
+			}
+			if len(lowerIDs) > 0 {
+				orphan.Parent = lowerIDs[0]
+			}
+			orphans = append(orphans, orphan)
+		}
+	}
+	return orphans, nil
+}
+
+// usedLayers returns the layers of the containers and the layer chains of
+// the images.
+func (e *explorer) usedLayers(podmanRootDir string, layers map[string]containerLayer) map[string]bool {
+	var tops []string
+
+	configs, err := e.readContainerConfig(podmanRootDir)
+	if err != nil {
+		log.WithFields(log.Fields{"podmanRootDir": podmanRootDir, "error": err}).Debug("reading containers.json")
+	}
+	for _, config := range configs {
+		tops = append(tops, config.Layer)
+	}
+
+	var pmImages []containerImage
+	//nolint:gosec // G304: Path is constructed from trusted podman root
+	if data, err := os.ReadFile(filepath.Join(podmanRootDir, "storage", "overlay-images", "images.json")); err == nil {
+		if err := json.Unmarshal(data, &pmImages); err != nil {
+			log.WithFields(log.Fields{"podmanRootDir": podmanRootDir, "error": err}).Debug("unmarshalling images.json")
+		}
+	}
+	for _, pmImage := range pmImages {
+		tops = append(tops, pmImage.Layer)
+	}
+
+	used := make(map[string]bool)
+	for _, id := range tops {
+		for id != "" && !used[id] {
+			used[id] = true
+			id = layers[id].Parent
+		}
+	}
+	return used
+}
+
+// readLayers returns the layers of layers.json by ID.
+func readLayers(podmanRootDir string) (map[string]containerLayer, error) {
+	//nolint:gosec // G304: Path is constructed from trusted podman root
+	data, err := os.ReadFile(filepath.Join(podmanRootDir, "storage", "overlay-layers", "layers.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pmLayers []containerLayer
+	if err := json.Unmarshal(data, &pmLayers); err != nil {
+		return nil, fmt.Errorf("unmarshalling layers.json: %w", err)
+	}
+
+	layers := make(map[string]containerLayer)
+	for _, layer := range pmLayers {
+		layers[layer.ID] = layer
+	}
+	return layers, nil
+}
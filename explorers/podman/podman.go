@@ -21,6 +21,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -48,6 +49,21 @@ type explorer struct {
 	podmanRootDirs []string
 }
 
+func init() {
+	explorers.Register(explorers.Registration{
+		Name:     "podman",
+		Priority: 20,
+		New: func(config explorers.Config) (explorers.ContainerExplorer, error) {
+			return NewExplorer(config.ImageRoot)
+		},
+		Detect: func(config explorers.Config) bool {
+			e := &explorer{imageroot: config.ImageRoot}
+			rootDirs, err := e.getPodmanRootDirs()
+			return err == nil && len(rootDirs) > 0
+		},
+	})
+}
+
 // NewExplorer returns ContainerExplorer interface to explore podman containers.
 func NewExplorer(imageroot string) (explorers.ContainerExplorer, error) {
 	e := &explorer{
@@ -83,30 +99,6 @@ func (e *explorer) Type() string {
 	return "podman"
 }
 
-// ListNamespaces returns podman namespaces if exist.
-func (e *explorer) ListNamespaces(_ context.Context) ([]string, error) {
-	// No namespaces in podman returning nil, nil
-	log.Info("listing namespaces is not supported in podman")
-
-	return nil, nil
-}
-
-// ListSnapshots returns podman containers snapshots.
-func (e *explorer) ListSnapshots(_ context.Context) ([]explorers.SnapshotKeyInfo, error) {
-	// No snapshots for podman
-	log.Info("listing snapshots is not implemented in podman")
-
-	return nil, nil
-}
-
-// SnapshotRoot returns snapshot root directory.
-func (e *explorer) SnapshotRoot(_ string) string {
-	// No snapshot root for podman
-	log.Info("snapshot root concept is not applicable in podman")
-
-	return ""
-}
-
 // ListContainers returns all podman containers.
 func (e *explorer) ListContainers(_ context.Context) ([]explorers.Container, error) {
 	var podmanContainers []explorers.Container
@@ -118,6 +110,16 @@ func (e *explorer) ListContainers(_ context.Context) ([]explorers.Container, err
 			continue
 		}
 
+		// The start and exit times are in the podman database, if any.
+		states := make(map[string]libpod.ContainerState)
+		containerStates, err := readContainerStates(podmanRootDir)
+		if err != nil {
+			log.WithFields(log.Fields{"podmanRootDir": podmanRootDir, "error": err}).Debug("reading container states")
+		}
+		for _, state := range containerStates {
+			states[state.id] = state.ContainerState
+		}
+
 		var metadata containerMetadata
 
 		for _, config := range configs {
@@ -142,6 +144,9 @@ func (e *explorer) ListContainers(_ context.Context) ([]explorers.Container, err
 					Image:     metadata.ImageName,
 					CreatedAt: parsedTime,
 				},
+				StartedAt:  states[config.ID].StartedTime,
+				FinishedAt: states[config.ID].FinishedTime,
+				OOMKilled:  states[config.ID].OOMKilled,
 			}
 			podmanContainers = append(podmanContainers, podmanContainer)
 		}
@@ -219,66 +224,72 @@ func (e *explorer) ListImages(_ context.Context) ([]explorers.Image, error) {
 	return ceImages, nil
 }
 
-// ListContent returns container contents.
-func (e *explorer) ListContent(_ context.Context) ([]explorers.Content, error) {
-	log.Info("listing content is not implemented for podman")
-
-	return nil, nil
-}
-
 // ListTasks returns running tasks.
 func (e *explorer) ListTasks(_ context.Context) ([]explorers.Task, error) {
 	var containerTasks []explorers.Task
 
 	for _, podmanRootDir := range e.podmanRootDirs {
-		dbfile := filepath.Join(podmanRootDir, "storage", "db.sql")
-		if ok := utils.PathExistsV2(dbfile); !ok {
-			log.WithField("dbfile", dbfile).Debug("podman sqlite database file not found, skipping root directory")
-			continue
+		states, err := readContainerStates(podmanRootDir)
+		if err != nil {
+			return nil, err
 		}
 
-		conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", dbfile))
-		if err != nil {
-			return nil, fmt.Errorf("opening sqlite database: %w", err)
+		for _, state := range states {
+			containerTask := explorers.Task{
+				ContainerType: "podman",
+				Name:          state.id,
+				PID:           state.PID,
+				Status:        state.State.String(),
+			}
+
+			containerTasks = append(containerTasks, containerTask)
 		}
+	}
 
-		err = func() error {
-			defer conn.Close()
+	return containerTasks, nil
+}
 
-			rows, err := conn.Query("SELECT ID, JSON FROM ContainerState;")
-			if err != nil {
-				return fmt.Errorf("query podman container state: %w", err)
-			}
-			defer rows.Close()
+// containerState is the state of a container in the podman database.
+type containerState struct {
+	id string
+	libpod.ContainerState
+}
 
-			for rows.Next() {
-				var id, stateJSON string
-				if err := rows.Scan(&id, &stateJSON); err != nil {
-					return fmt.Errorf("reading container state row: %w", err)
-				}
+// readContainerStates returns the container states of the podman database of
+// a root directory, or none if there is no database.
+func readContainerStates(podmanRootDir string) ([]containerState, error) {
+	dbfile := filepath.Join(podmanRootDir, "storage", "db.sql")
+	if ok := utils.PathExistsV2(dbfile); !ok {
+		log.WithField("dbfile", dbfile).Debug("podman sqlite database file not found, skipping root directory")
+		return nil, nil
+	}
 
-				var containerstate libpod.ContainerState
-				if err := json.Unmarshal([]byte(stateJSON), &containerstate); err != nil {
-					return fmt.Errorf("unmarshalling podman container state json: %w", err)
-				}
+	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", dbfile))
+	if err != nil {
+		return nil, fmt.Errorf("opening sqlite database: %w", err)
+	}
+	defer conn.Close()
 
-				containerTask := explorers.Task{
-					ContainerType: "podman",
-					Name:          id,
-					PID:           containerstate.PID,
-					Status:        containerstate.State.String(),
-				}
+	rows, err := conn.Query("SELECT ID, JSON FROM ContainerState;")
+	if err != nil {
+		return nil, fmt.Errorf("query podman container state: %w", err)
+	}
+	defer rows.Close()
 
-				containerTasks = append(containerTasks, containerTask)
-			}
-			return nil
-		}()
-		if err != nil {
-			return nil, err
+	var states []containerState
+	for rows.Next() {
+		var id, stateJSON string
+		if err := rows.Scan(&id, &stateJSON); err != nil {
+			return nil, fmt.Errorf("reading container state row: %w", err)
 		}
-	}
 
-	return containerTasks, nil
+		state := containerState{id: id}
+		if err := json.Unmarshal([]byte(stateJSON), &state.ContainerState); err != nil {
+			return nil, fmt.Errorf("unmarshalling podman container state json: %w", err)
+		}
+		states = append(states, state)
+	}
+	return states, rows.Err()
 }
 
 // InfoContainer returns container internal information.
@@ -376,6 +387,143 @@ func (e *explorer) MountContainer(ctx context.Context, containerID string, mount
 	return fmt.Errorf("no matching container")
 }
 
+// ContainerLayers returns the overlay directories of a podman container.
+func (e *explorer) ContainerLayers(_ context.Context, containerID string) (explorers.LayerStack, error) {
+	podmanRootDir, config, err := e.findContainerConfig(containerID)
+	if err != nil {
+		return explorers.LayerStack{}, err
+	}
+	return e.layerStack(podmanRootDir, config.ID, config.Layer)
+}
+
+// ImageLayers returns the overlay directories of the layers of a podman
+// image.
+func (e *explorer) ImageLayers(_ context.Context, ref string) (explorers.LayerStack, error) {
+	for _, podmanRootDir := range e.podmanRootDirs {
+		imageConfigFile := filepath.Join(podmanRootDir, "storage", "overlay-images", "images.json")
+		data, err := os.ReadFile(imageConfigFile)
+		if err != nil {
+			log.WithFields(log.Fields{"podmanRootDir": podmanRootDir, "error": err}).Debug("reading images.json")
+			continue
+		}
+
+		var pmImages []containerImage
+		if err := json.Unmarshal(data, &pmImages); err != nil {
+			log.WithFields(log.Fields{"imageConfigFile": imageConfigFile, "error": err}).Debug("unmarshalling images.json")
+			continue
+		}
+
+		for _, pmImage := range pmImages {
+			if matchPodmanImage(pmImage, ref) {
+				return e.imageLayerStack(podmanRootDir, pmImage.Layer)
+			}
+		}
+	}
+
+	return explorers.LayerStack{}, fmt.Errorf("image %s not found", ref)
+}
+
+// matchPodmanImage returns true if the reference matches a name, the
+// manifest digest, or the ID of a podman image.
+func matchPodmanImage(pmImage containerImage, ref string) bool {
+	names := pmImage.Names
+	if len(names) == 0 {
+		names = []string{""}
+	}
+	for _, name := range names {
+		if explorers.MatchImageReference(name, digest.Digest(pmImage.Digest), ref) {
+			return true
+		}
+	}
+	return explorers.MatchImageReference("", digest.NewDigestFromEncoded(digest.SHA256, pmImage.ID), ref)
+}
+
+// imageLayerStack returns the overlay directories of a layer and its lower
+// layers. The base layer of an image has no lower file.
+func (e *explorer) imageLayerStack(podmanRootDir string, layer string) (explorers.LayerStack, error) {
+	overlayDir := filepath.Join(podmanRootDir, "storage", "overlay")
+	layerDir := filepath.Join(overlayDir, layer)
+
+	topDir := filepath.Join(layerDir, "diff")
+	if linkData, err := os.ReadFile(filepath.Join(layerDir, "link")); err == nil {
+		topDir = filepath.Join(overlayDir, "l", strings.TrimSpace(string(linkData)))
+	}
+	if _, err := os.Stat(topDir); err != nil {
+		return explorers.LayerStack{}, fmt.Errorf("image layer %s: %w", layer, err)
+	}
+
+	lowerDirs := []string{topDir}
+	lowerData, err := os.ReadFile(filepath.Join(layerDir, "lower"))
+	if err != nil && !os.IsNotExist(err) {
+		return explorers.LayerStack{}, fmt.Errorf("reading lower file: %w", err)
+	}
+	for _, lowerDir := range strings.Split(strings.TrimSpace(string(lowerData)), ":") {
+		if lowerDir != "" {
+			lowerDirs = append(lowerDirs, filepath.Join(overlayDir, lowerDir))
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"podmanRootDir": podmanRootDir,
+		"layer":         layer,
+		"lowerdirs":     len(lowerDirs),
+	}).Debug("image layers")
+
+	return explorers.LayerStack{LowerDirs: lowerDirs}, nil
+}
+
+// SnapshotLayers returns the overlay directories of a layer found by its ID
+// or ID prefix. The parent chain is read from the lower file of the layer.
+func (e *explorer) SnapshotLayers(_ context.Context, key string, parents bool) (explorers.LayerStack, error) {
+	for _, podmanRootDir := range e.podmanRootDirs {
+		overlayDir := filepath.Join(podmanRootDir, "storage", "overlay")
+
+		layer := ""
+		if _, err := os.Stat(filepath.Join(overlayDir, key, "diff")); err == nil {
+			layer = key
+		} else if len(key) >= 12 {
+			matches, _ := filepath.Glob(filepath.Join(overlayDir, key+"*", "diff"))
+			if len(matches) == 1 {
+				layer = filepath.Base(filepath.Dir(matches[0]))
+			}
+		}
+		if layer == "" {
+			continue
+		}
+
+		layers, err := e.imageLayerStack(podmanRootDir, layer)
+		if err != nil {
+			return explorers.LayerStack{}, err
+		}
+		if !parents {
+			layers.LowerDirs = layers.LowerDirs[:1]
+		}
+		return layers, nil
+	}
+
+	return explorers.LayerStack{}, fmt.Errorf("layer %s not found", key)
+}
+
+// findContainerConfig returns the podman root directory and the
+// containers.json entry of a container.
+func (e *explorer) findContainerConfig(containerID string) (string, containerConfig, error) {
+	for _, podmanRootDir := range e.podmanRootDirs {
+		configs, err := e.readContainerConfig(podmanRootDir)
+		if err != nil {
+			log.WithFields(log.Fields{"podmanRootDir": podmanRootDir, "error": err}).Debug("reading containers.json")
+			continue
+		}
+
+		for _, config := range configs {
+			if config.ID == containerID || (len(config.Names) > 0 && config.Names[0] == containerID) {
+				return podmanRootDir, config, nil
+			}
+		}
+	}
+
+	return "", containerConfig{}, fmt.Errorf("no matching container")
+}
+
 // MountAllContainers mounts all podman containers.
 func (e *explorer) MountAllContainers(ctx context.Context, mountpoint string, _ string, _ bool) error {
 	containers, err := e.ListContainers(ctx)
@@ -383,14 +531,20 @@ func (e *explorer) MountAllContainers(ctx context.Context, mountpoint string, _
 		return fmt.Errorf("listing container: %w", err)
 	}
 
-	for _, container := range containers {
+	ids := make([]string, len(containers))
+	for i, container := range containers {
+		ids[i] = container.ID
+	}
+
+	return explorers.RunContainerJobs(ctx, e.Type(), "mount", ids, func(i int) error {
+		container := containers[i]
 		containerMountPoint := filepath.Join(mountpoint, container.ID)
 		if err := os.MkdirAll(containerMountPoint, 0755); err != nil {
 			log.WithFields(log.Fields{
 				"containerID": container.ID,
 				"error":       err,
 			}).Error("creating container mountpoint failed; skipping container mount")
-			continue
+			return err
 		}
 
 		containerName := container.Name
@@ -399,22 +553,35 @@ func (e *explorer) MountAllContainers(ctx context.Context, mountpoint string, _
 				"containerID": container.ID,
 				"error":       err,
 			}).Error("mounting container failed; skipping container mount")
+			return err
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
 
 // ContainerDrift finds the drifted files from containers.
 //   - skipsupportcontainers are only applicable for containerd containers used in GKE. It is not used in Docker and podman.
 //   - filter uses labels to filter the containers. `filter` is not used in podman containers.
-func (e *explorer) ContainerDrift(_ context.Context, _ string, _ bool, containerID string) ([]explorers.Drift, error) {
-	var drifts []explorers.Drift
+func (e *explorer) ContainerDrift(ctx context.Context, filter string, skipsupportcontainers bool, containerID string) ([]explorers.Drift, error) {
+	return explorers.CollectDrift(ctx, e, filter, skipsupportcontainers, containerID)
+}
 
+// StreamContainerDrift finds the drifted files from containers, and calls fn
+// with the drift of each container as it completes.
+func (e *explorer) StreamContainerDrift(ctx context.Context, _ string, _ bool, containerID string, fn func(explorers.Drift) error) error {
+	type driftTarget struct {
+		podmanRootDir string
+		config        containerConfig
+	}
+
+	var errs []error
+	var targets []driftTarget
+	var ids []string
 	for _, podmanRootDir := range e.podmanRootDirs {
 		configs, err := e.readContainerConfig(podmanRootDir)
 		if err != nil {
 			log.WithFields(log.Fields{"podmanRootDir": podmanRootDir, "error": err}).Error("reading container config")
+			errs = append(errs, explorers.NewItemError(e.Type(), "drift", "", err))
 			continue
 		}
 
@@ -423,48 +590,59 @@ func (e *explorer) ContainerDrift(_ context.Context, _ string, _ bool, container
 			if containerID != "" && config.ID != containerID && (len(config.Names) == 0 || config.Names[0] != containerID) {
 				continue
 			}
+			targets = append(targets, driftTarget{podmanRootDir: podmanRootDir, config: config})
+			ids = append(ids, config.ID)
+		}
+	}
 
-			log.WithFields(log.Fields{"containerType": "podman", "containerID": config.ID}).Debug("checking container drift")
-
-			// Get upperdir for Podman container
-			overlayDir := filepath.Join(podmanRootDir, "storage", "overlay")
-			layerDir := filepath.Join(overlayDir, config.Layer)
+	err := explorers.StreamContainerJobs(ctx, e.Type(), "drift", ids, func(i int) (*explorers.Drift, error) {
+		return e.containerDrift(ctx, targets[i].podmanRootDir, targets[i].config)
+	}, func(drift *explorers.Drift) error {
+		return fn(*drift)
+	})
+	return errors.Join(append(errs, err)...)
+}
 
-			linkFile := filepath.Join(layerDir, "link")
-			linkData, err := os.ReadFile(linkFile)
-			if err != nil {
-				log.WithFields(log.Fields{"container": config.ID, "error": err}).Error("reading link file")
-				continue
-			}
-			upperDir := filepath.Join(overlayDir, "l", strings.TrimSpace(string(linkData)))
-			log.WithFields(log.Fields{"containerType": "podman", "containerID": config.ID, "upperdir": upperDir}).Debug("checking upper layer for drift")
+// containerDrift finds the drifted files of a container in a podman root
+// directory.
+func (e *explorer) containerDrift(ctx context.Context, podmanRootDir string, config containerConfig) (*explorers.Drift, error) {
+	log.WithFields(log.Fields{"containerType": "podman", "containerID": config.ID}).Debug("checking container drift")
 
-			// Scan upperdir
-			addedOrModified, inaccessibleFiles, err := explorers.ScanDiffDirectory(upperDir)
-			if err != nil {
-				log.WithFields(log.Fields{"container": config.ID, "error": err}).Error("failed to scan diff directory")
-				continue
-			}
+	// Get upperdir for Podman container
+	overlayDir := filepath.Join(podmanRootDir, "storage", "overlay")
+	layerDir := filepath.Join(overlayDir, config.Layer)
 
-			drift := explorers.Drift{
-				ContainerID:       config.ID,
-				ContainerType:     "podman",
-				AddedOrModified:   addedOrModified,
-				InaccessibleFiles: inaccessibleFiles,
-			}
+	linkFile := filepath.Join(layerDir, "link")
+	linkData, err := os.ReadFile(linkFile)
+	if err != nil {
+		log.WithFields(log.Fields{"container": config.ID, "error": err}).Error("reading link file")
+		return nil, err
+	}
+	upperDir := filepath.Join(overlayDir, "l", strings.TrimSpace(string(linkData)))
+	log.WithFields(log.Fields{"containerType": "podman", "containerID": config.ID, "upperdir": upperDir}).Debug("checking upper layer for drift")
 
-			log.WithFields(log.Fields{
-				"containerType":        drift.ContainerType,
-				"containerID":          drift.ContainerID,
-				"numAddedOrModified":   len(drift.AddedOrModified),
-				"numInaccessibleFiles": len(drift.InaccessibleFiles),
-			}).Debug("container drift detail")
+	// Scan upperdir
+	addedOrModified, inaccessibleFiles, err := explorers.ScanDiffDirectoryContext(ctx, upperDir)
+	if err != nil {
+		log.WithFields(log.Fields{"container": config.ID, "error": err}).Error("failed to scan diff directory")
+		return nil, err
+	}
 
-			drifts = append(drifts, drift)
-		}
+	drift := explorers.Drift{
+		ContainerID:       config.ID,
+		ContainerType:     "podman",
+		AddedOrModified:   addedOrModified,
+		InaccessibleFiles: inaccessibleFiles,
 	}
 
-	return drifts, nil
+	log.WithFields(log.Fields{
+		"containerType":        drift.ContainerType,
+		"containerID":          drift.ContainerID,
+		"numAddedOrModified":   len(drift.AddedOrModified),
+		"numInaccessibleFiles": len(drift.InaccessibleFiles),
+	}).Debug("container drift detail")
+
+	return &drift, nil
 }
 
 // Close closes explorer.
@@ -613,7 +791,36 @@ func (e *explorer) readContainerConfig(podmanRootDir string) ([]containerConfig,
 	return configs, nil
 }
 
-func (e *explorer) mountContainer(_ context.Context, podmanRootDir string, containerID string, layer string, mountpoint string) error {
+func (e *explorer) mountContainer(ctx context.Context, podmanRootDir string, containerID string, layer string, mountpoint string) error {
+	layers, err := e.layerStack(podmanRootDir, containerID, layer)
+	if err != nil {
+		return err
+	}
+	upperDir := layers.UpperDir
+	lowerDir := strings.Join(layers.LowerDirs, ":")
+
+	// Linux mount options
+	mountOpt := fmt.Sprintf("ro,lowerdir=%s:%s", upperDir, lowerDir)
+	mountArgs := []string{"-t", "overlay", "overlay", "-o", mountOpt, mountpoint}
+
+	record := utils.MountRecord{Mountpoint: mountpoint, Type: utils.MountTypeOverlay, Source: upperDir, ContainerID: containerID}
+	out, err := utils.Mount(ctx, record, mountArgs...)
+	if err != nil {
+		log.Infof("mount command: mount %s", strings.Join(mountArgs, " "))
+		if string(out) != "" {
+			return fmt.Errorf("running mount command: %w, output: %s", err, strings.TrimSpace(string(out)))
+		}
+		return fmt.Errorf("running mount command: %w", err)
+	}
+	if string(out) != "" {
+		log.Infof("mount command output: %s", string(out))
+	}
+
+	return nil
+}
+
+// layerStack returns the overlay directories of a container layer.
+func (e *explorer) layerStack(podmanRootDir string, containerID string, layer string) (explorers.LayerStack, error) {
 	overlayDir := filepath.Join(podmanRootDir, "storage", "overlay")
 	layerDir := filepath.Join(overlayDir, layer)
 
@@ -621,7 +828,7 @@ func (e *explorer) mountContainer(_ context.Context, podmanRootDir string, conta
 	linkFile := filepath.Join(layerDir, "link")
 	linkData, err := os.ReadFile(linkFile)
 	if err != nil {
-		return fmt.Errorf("reading link file: %w", err)
+		return explorers.LayerStack{}, fmt.Errorf("reading link file: %w", err)
 	}
 	upperDir := filepath.Join(overlayDir, "l", strings.TrimSpace(string(linkData)))
 
@@ -629,7 +836,7 @@ func (e *explorer) mountContainer(_ context.Context, podmanRootDir string, conta
 	lowerFile := filepath.Join(layerDir, "lower")
 	lowerData, err := os.ReadFile(lowerFile)
 	if err != nil {
-		return fmt.Errorf("reading lower file: %w", err)
+		return explorers.LayerStack{}, fmt.Errorf("reading lower file: %w", err)
 	}
 
 	log.WithFields(log.Fields{
@@ -644,23 +851,9 @@ func (e *explorer) mountContainer(_ context.Context, podmanRootDir string, conta
 	for _, lowerDir := range strings.Split(strings.TrimSpace(string(lowerData)), ":") {
 		lowerDirs = append(lowerDirs, filepath.Join(overlayDir, lowerDir))
 	}
-	lowerDir := strings.Join(lowerDirs, ":")
-
-	// Linux mount options
-	mountOpt := fmt.Sprintf("ro,lowerdir=%s:%s", upperDir, lowerDir)
-	mountArgs := []string{"-t", "overlay", "overlay", "-o", mountOpt, mountpoint}
-
-	out, err := utils.Runner.RunWithoutContext("mount", mountArgs...)
-	if err != nil {
-		log.Infof("mount command: mount %s", strings.Join(mountArgs, " "))
-		if string(out) != "" {
-			return fmt.Errorf("running mount command: %w, output: %s", err, strings.TrimSpace(string(out)))
-		}
-		return fmt.Errorf("running mount command: %w", err)
-	}
-	if string(out) != "" {
-		log.Infof("mount command output: %s", string(out))
-	}
 
-	return nil
+	return explorers.LayerStack{
+		UpperDir:  upperDir,
+		LowerDirs: lowerDirs,
+	}, nil
 }
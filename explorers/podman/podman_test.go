@@ -20,10 +20,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -239,8 +241,8 @@ func TestNewExplorer_SuccessRootless_WithStorageConfMalformed(t *testing.T) {
 	}
 }
 
-func TestListNamespacesAndSnapshots(t *testing.T) {
-	// These are not implemented/supported in Podman, verify they return nil, nil
+func TestCapabilities(t *testing.T) {
+	// Namespaces, snapshots, and content are not supported in Podman
 	tmpDir := t.TempDir()
 	createMockPasswd(t, tmpDir, []string{"mockuser:x:1000:1000:Mock User:/home/mockuser:/bin/bash"})
 	graphRoot := filepath.Join(tmpDir, "home", "mockuser", ".local", "share", "containers", "storage")
@@ -251,18 +253,19 @@ func TestListNamespacesAndSnapshots(t *testing.T) {
 		t.Fatalf("NewExplorer failed: %v", err)
 	}
 
-	nss, err := exp.ListNamespaces(context.Background())
-	if err != nil || nss != nil {
-		t.Errorf("ListNamespaces expected nil, nil; got %v, %v", nss, err)
+	supported := make(map[string]bool)
+	for _, c := range explorers.Capabilities(exp) {
+		supported[c.Name] = c.Supported
 	}
-
-	snaps, err := exp.ListSnapshots(context.Background())
-	if err != nil || snaps != nil {
-		t.Errorf("ListSnapshots expected nil, nil; got %v, %v", snaps, err)
+	expected := map[string]bool{
+		explorers.CapabilityNamespaces:        false,
+		explorers.CapabilityDeletedContainers: true,
+		explorers.CapabilityContent:           false,
+		explorers.CapabilitySnapshots:         false,
+		explorers.CapabilityImageExport:       false,
 	}
-
-	if exp.SnapshotRoot("overlayfs") != "" {
-		t.Errorf("SnapshotRoot expected empty string, got '%s'", exp.SnapshotRoot("overlayfs"))
+	if !reflect.DeepEqual(supported, expected) {
+		t.Errorf("expected capabilities %v, got %v", expected, supported)
 	}
 }
 
@@ -338,6 +341,23 @@ func TestListContainers(t *testing.T) {
 	if !ctr.CreatedAt.Equal(now) {
 		t.Errorf("expected CreatedAt %v, got %v", now, ctr.CreatedAt)
 	}
+	if !ctr.StartedAt.IsZero() {
+		t.Errorf("expected no StartedAt without a database, got %v", ctr.StartedAt)
+	}
+
+	// Case 3: Start and exit times from the podman database
+	started := now.Add(time.Minute)
+	finished := now.Add(time.Hour)
+	createMockSQLiteDB(t, filepath.Join(storageDir, "db.sql"), map[string]string{
+		containerID: fmt.Sprintf(`{"state":6,"startedTime":%q,"finishedTime":%q,"oomKilled":true}`, started.Format(time.RFC3339Nano), finished.Format(time.RFC3339Nano)),
+	})
+	ctrs, err = exp.ListContainers(context.Background())
+	if err != nil || len(ctrs) != 1 {
+		t.Fatalf("ListContainers failed: %v", err)
+	}
+	if !ctrs[0].StartedAt.Equal(started) || !ctrs[0].FinishedAt.Equal(finished) || !ctrs[0].OOMKilled {
+		t.Errorf("expected StartedAt %v, FinishedAt %v, and OOMKilled, got %v %v %t", started, finished, ctrs[0].StartedAt, ctrs[0].FinishedAt, ctrs[0].OOMKilled)
+	}
 }
 
 func TestGetContainerByID(t *testing.T) {
@@ -689,6 +709,149 @@ func TestContainerDrift(t *testing.T) {
 	}
 }
 
+func TestContainerLayers(t *testing.T) {
+	tmpDir := t.TempDir()
+	createMockPasswd(t, tmpDir, []string{"mockuser:x:1000:1000:Mock User:/home/mockuser:/bin/bash"})
+	storageDir := filepath.Join(tmpDir, "home", "mockuser", ".local", "share", "containers", "storage")
+	_ = os.MkdirAll(storageDir, 0755)
+
+	exp, err := NewExplorer(tmpDir)
+	if err != nil {
+		t.Fatalf("NewExplorer failed: %v", err)
+	}
+
+	containerID := "c1234abcd1234abcd1234abcd1234abcd1234abcd1234abcd1234abcd1234"
+	configsBytes, _ := json.Marshal([]containerConfig{
+		{ID: containerID, Names: []string{"web"}, Layer: "layer-123"},
+	})
+	overlayContainersDir := filepath.Join(storageDir, "overlay-containers")
+	_ = os.MkdirAll(overlayContainersDir, 0755)
+	_ = os.WriteFile(filepath.Join(overlayContainersDir, "containers.json"), configsBytes, 0600)
+
+	overlayDir := filepath.Join(storageDir, "overlay")
+	layerDir := filepath.Join(overlayDir, "layer-123")
+	_ = os.MkdirAll(layerDir, 0755)
+	_ = os.WriteFile(filepath.Join(layerDir, "link"), []byte("link-name"), 0600)
+	_ = os.WriteFile(filepath.Join(layerDir, "lower"), []byte("l/lower-name"), 0600)
+
+	for _, id := range []string{containerID, "web"} {
+		layers, err := exp.ContainerLayers(context.Background(), id)
+		if err != nil {
+			t.Fatalf("ContainerLayers(%s) failed: %v", id, err)
+		}
+		if expected := filepath.Join(overlayDir, "l", "link-name"); layers.UpperDir != expected {
+			t.Errorf("expected upper dir %s, got %s", expected, layers.UpperDir)
+		}
+		if expected := filepath.Join(overlayDir, "l", "lower-name"); len(layers.LowerDirs) != 1 || layers.LowerDirs[0] != expected {
+			t.Errorf("expected lower dirs [%s], got %v", expected, layers.LowerDirs)
+		}
+	}
+
+	if _, err := exp.ContainerLayers(context.Background(), "missing"); err == nil {
+		t.Errorf("expected error for unknown container")
+	}
+}
+
+func TestImageLayers(t *testing.T) {
+	tmpDir := t.TempDir()
+	createMockPasswd(t, tmpDir, []string{"mockuser:x:1000:1000:Mock User:/home/mockuser:/bin/bash"})
+	storageDir := filepath.Join(tmpDir, "home", "mockuser", ".local", "share", "containers", "storage")
+	_ = os.MkdirAll(storageDir, 0755)
+
+	exp, err := NewExplorer(tmpDir)
+	if err != nil {
+		t.Fatalf("NewExplorer failed: %v", err)
+	}
+
+	imageID := "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2"
+	imagesBytes, _ := json.Marshal([]containerImage{
+		{ID: imageID, Names: []string{"docker.io/library/alpine:3.19"}, Layer: "top-layer"},
+		{ID: "b" + imageID[1:], Names: []string{"docker.io/library/busybox:latest"}, Layer: "base-layer"},
+	})
+	overlayImagesDir := filepath.Join(storageDir, "overlay-images")
+	_ = os.MkdirAll(overlayImagesDir, 0755)
+	_ = os.WriteFile(filepath.Join(overlayImagesDir, "images.json"), imagesBytes, 0600)
+
+	// The top layer has a link and lower file, the base layer only a diff
+	overlayDir := filepath.Join(storageDir, "overlay")
+	_ = os.MkdirAll(filepath.Join(overlayDir, "top-layer", "diff"), 0755)
+	_ = os.WriteFile(filepath.Join(overlayDir, "top-layer", "link"), []byte("TOPLINK"), 0600)
+	_ = os.WriteFile(filepath.Join(overlayDir, "top-layer", "lower"), []byte("l/BASELINK"), 0600)
+	_ = os.MkdirAll(filepath.Join(overlayDir, "l"), 0755)
+	_ = os.Symlink("../top-layer/diff", filepath.Join(overlayDir, "l", "TOPLINK"))
+	_ = os.MkdirAll(filepath.Join(overlayDir, "base-layer", "diff"), 0755)
+
+	for _, ref := range []string{"alpine:3.19", imageID[:12]} {
+		layers, err := exp.ImageLayers(context.Background(), ref)
+		if err != nil {
+			t.Fatalf("ImageLayers(%s) failed: %v", ref, err)
+		}
+		expected := []string{filepath.Join(overlayDir, "l", "TOPLINK"), filepath.Join(overlayDir, "l", "BASELINK")}
+		if !reflect.DeepEqual(layers.LowerDirs, expected) || layers.UpperDir != "" {
+			t.Errorf("ImageLayers(%s): expected lower dirs %v, got %+v", ref, expected, layers)
+		}
+	}
+
+	layers, err := exp.ImageLayers(context.Background(), "busybox")
+	if err != nil {
+		t.Fatalf("ImageLayers(busybox) failed: %v", err)
+	}
+	if expected := []string{filepath.Join(overlayDir, "base-layer", "diff")}; !reflect.DeepEqual(layers.LowerDirs, expected) {
+		t.Errorf("expected base layer %v, got %v", expected, layers.LowerDirs)
+	}
+
+	if _, err := exp.ImageLayers(context.Background(), "missing"); err == nil {
+		t.Errorf("expected error for unknown image")
+	}
+}
+
+func TestSnapshotLayers(t *testing.T) {
+	tmpDir := t.TempDir()
+	createMockPasswd(t, tmpDir, []string{"mockuser:x:1000:1000:Mock User:/home/mockuser:/bin/bash"})
+	storageDir := filepath.Join(tmpDir, "home", "mockuser", ".local", "share", "containers", "storage")
+
+	// A container layer on top of a base image layer
+	overlayDir := filepath.Join(storageDir, "overlay")
+	containerLayer := "c0ffee0c0ffee0c0ffee0c0ffee"
+	_ = os.MkdirAll(filepath.Join(overlayDir, containerLayer, "diff"), 0755)
+	_ = os.WriteFile(filepath.Join(overlayDir, containerLayer, "lower"), []byte("l/BASELINK"), 0600)
+	_ = os.MkdirAll(filepath.Join(overlayDir, "base-layer", "diff"), 0755)
+	_ = os.WriteFile(filepath.Join(overlayDir, "base-layer", "link"), []byte("BASELINK"), 0600)
+	_ = os.MkdirAll(filepath.Join(overlayDir, "l"), 0755)
+	_ = os.Symlink("../base-layer/diff", filepath.Join(overlayDir, "l", "BASELINK"))
+
+	exp, err := NewExplorer(tmpDir)
+	if err != nil {
+		t.Fatalf("NewExplorer failed: %v", err)
+	}
+
+	top := filepath.Join(overlayDir, containerLayer, "diff")
+	base := filepath.Join(overlayDir, "l", "BASELINK")
+
+	tests := []struct {
+		key      string
+		parents  bool
+		expected []string
+	}{
+		{containerLayer, false, []string{top}},
+		{containerLayer[:12], true, []string{top, base}},
+		{"base-layer", true, []string{base}},
+	}
+	for _, tt := range tests {
+		layers, err := exp.SnapshotLayers(context.Background(), tt.key, tt.parents)
+		if err != nil {
+			t.Fatalf("SnapshotLayers(%s, %t) failed: %v", tt.key, tt.parents, err)
+		}
+		if !reflect.DeepEqual(layers.LowerDirs, tt.expected) || layers.UpperDir != "" {
+			t.Errorf("SnapshotLayers(%s, %t): expected lower dirs %v, got %+v", tt.key, tt.parents, tt.expected, layers)
+		}
+	}
+
+	if _, err := exp.SnapshotLayers(context.Background(), "missing", false); err == nil {
+		t.Errorf("expected error for unknown layer")
+	}
+}
+
 type mockCommandCall struct {
 	Name string
 	Args []string
@@ -785,16 +948,30 @@ func TestExportContainer(t *testing.T) {
 	defer func() { utils.Runner = origRunner }()
 
 	outputDir := filepath.Join(tmpDir, "output")
-	exportOptions := map[string]bool{
-		"archive": true,
-		"image":   true,
+	exportOptions := explorers.ContainerExportOptions{
+		Archive: true,
+		Image:   true,
 	}
 
-	err = exp.ExportContainer(context.Background(), containerID, outputDir, exportOptions)
+	// State databases recorded as custody sources
+	_ = os.WriteFile(filepath.Join(storageDir, "db.sql-wal"), []byte("wal"), 0600)
+	_ = os.MkdirAll(filepath.Join(storageDir, "libpod"), 0755)
+	_ = os.WriteFile(filepath.Join(storageDir, "libpod", "bolt_state.db"), []byte("bolt"), 0600)
+	custody := utils.NewCustody("examiner", "test", nil)
+
+	err = exp.ExportContainer(utils.WithCustody(context.Background(), custody), containerID, outputDir, exportOptions)
 	if err != nil {
 		t.Fatalf("ExportContainer failed: %v", err)
 	}
 
+	var sources []string
+	for _, source := range custody.Sources {
+		sources = append(sources, filepath.Base(source.Path))
+	}
+	if got := strings.Join(sources, ","); got != "containers.json,db.sql-wal,bolt_state.db" {
+		t.Errorf("unexpected custody sources %s", got)
+	}
+
 	// Verify command calls
 	callNames := make([]string, len(mockRunner.Calls))
 	for i, c := range mockRunner.Calls {
@@ -804,7 +981,6 @@ func TestExportContainer(t *testing.T) {
 
 	hasMount := false
 	hasUmount := false
-	hasTar := false
 	for _, name := range callNames {
 		if name == "mount" {
 			hasMount = true
@@ -812,9 +988,6 @@ func TestExportContainer(t *testing.T) {
 		if name == "umount" {
 			hasUmount = true
 		}
-		if name == "tar" {
-			hasTar = true
-		}
 	}
 
 	if !hasMount {
@@ -823,8 +996,8 @@ func TestExportContainer(t *testing.T) {
 	if !hasUmount {
 		t.Errorf("expected 'umount' to be executed")
 	}
-	if !hasTar {
-		t.Errorf("expected 'tar' to be executed")
+	if _, err := os.Stat(filepath.Join(outputDir, containerID+".tar.gz")); err != nil {
+		t.Errorf("expected container archive: %v", err)
 	}
 }
 
@@ -877,8 +1050,8 @@ func TestExportAllContainers(t *testing.T) {
 	defer func() { utils.Runner = origRunner }()
 
 	outputDir := filepath.Join(tmpDir, "output")
-	exportOptions := map[string]bool{
-		"archive": true,
+	exportOptions := explorers.ContainerExportOptions{
+		Archive: true,
 	}
 
 	err = exp.ExportAllContainers(context.Background(), outputDir, exportOptions, nil, false)
@@ -888,23 +1061,11 @@ func TestExportAllContainers(t *testing.T) {
 
 	// Verify we attempted to export the container
 	exportedArchive := filepath.Join(outputDir, containerID+".tar.gz")
-	hasTar := false
-	for _, c := range mockRunner.Calls {
-		if c.Name == "tar" {
-			hasTar = true
-			foundOut := false
-			for _, arg := range c.Args {
-				if arg == exportedArchive {
-					foundOut = true
-				}
-			}
-			if !foundOut {
-				t.Errorf("expected tar command to export to '%s', args were: %v", exportedArchive, c.Args)
-			}
-		}
+	if _, err := os.Stat(exportedArchive); err != nil {
+		t.Errorf("expected archive %s: %v", exportedArchive, err)
 	}
-	if !hasTar {
-		t.Errorf("expected 'tar' to be executed")
+	if _, err := os.Stat(exportedArchive + ".manifest.json"); err != nil {
+		t.Errorf("expected archive manifest: %v", err)
 	}
 }
 
@@ -1142,12 +1303,315 @@ func TestContainerDrift_MissingLink(t *testing.T) {
 	_ = os.MkdirAll(layerDir, 0755)
 
 	drifts, err := exp.ContainerDrift(context.Background(), "", false, containerID)
-	if err != nil {
-		t.Fatalf("ContainerDrift failed: %v", err)
-	}
 
-	// ContainerDrift should log warning and continue, returning empty list of drifts
+	// ContainerDrift should continue and return the container error
 	if len(drifts) != 0 {
 		t.Errorf("expected 0 drifts, got %d", len(drifts))
 	}
+	itemErrs := explorers.ItemErrors("podman", "drift", err)
+	if len(itemErrs) != 1 {
+		t.Fatalf("expected 1 item error, got %v", err)
+	}
+	if itemErrs[0].ID != containerID || itemErrs[0].Kind != explorers.ErrorKindNotFound {
+		t.Errorf("unexpected item error: %+v", itemErrs[0])
+	}
+}
+
+func TestListOrphans(t *testing.T) {
+	tmpDir := t.TempDir()
+	createMockPasswd(t, tmpDir, []string{"mockuser:x:1000:1000:Mock User:/home/mockuser:/bin/bash"})
+	storageDir := filepath.Join(tmpDir, "home", "mockuser", ".local", "share", "containers", "storage")
+
+	// An image layer chain, a container layer, a layer of a removed
+	// container still in layers.json, and a layer only on disk
+	overlayDir := filepath.Join(storageDir, "overlay")
+	_ = os.MkdirAll(filepath.Join(overlayDir, "l"), 0755)
+	for _, id := range []string{"base", "top", "container", "removed", "deleted"} {
+		_ = os.MkdirAll(filepath.Join(overlayDir, id, "diff"), 0755)
+		_ = os.WriteFile(filepath.Join(overlayDir, id, "link"), []byte("L-"+id), 0600)
+		_ = os.Symlink("../"+id+"/diff", filepath.Join(overlayDir, "l", "L-"+id))
+		if id != "base" {
+			_ = os.WriteFile(filepath.Join(overlayDir, id, "lower"), []byte("l/L-top:l/L-base"), 0600)
+		}
+	}
+	_ = os.WriteFile(filepath.Join(overlayDir, "top", "lower"), []byte("l/L-base"), 0600)
+
+	writeJSON := func(name string, v any) {
+		data, _ := json.Marshal(v)
+		_ = os.MkdirAll(filepath.Dir(filepath.Join(storageDir, name)), 0755)
+		_ = os.WriteFile(filepath.Join(storageDir, name), data, 0600)
+	}
+	writeJSON("overlay-layers/layers.json", []containerLayer{
+		{ID: "base"},
+		{ID: "top", Parent: "base"},
+		{ID: "container", Parent: "top"},
+		{ID: "removed", Parent: "top"},
+	})
+	writeJSON("overlay-images/images.json", []containerImage{{ID: "image", Layer: "top"}})
+	writeJSON("overlay-containers/containers.json", []containerConfig{{ID: "container-1", Layer: "container"}})
+
+	exp, err := NewExplorer(tmpDir)
+	if err != nil {
+		t.Fatalf("NewExplorer failed: %v", err)
+	}
+
+	orphans, err := exp.ListOrphans(context.Background())
+	if err != nil {
+		t.Fatalf("ListOrphans failed: %v", err)
+	}
+	if len(orphans) != 2 {
+		t.Fatalf("expected 2 orphans, got %+v", orphans)
+	}
+	if orphans[0].Key != "deleted" || orphans[0].Reason != explorers.OrphanUnreferenced || orphans[0].Parent != "top" {
+		t.Errorf("expected the unreferenced layer, got %+v", orphans[0])
+	}
+	if orphans[1].Key != "removed" || orphans[1].Reason != explorers.OrphanNoContainer || orphans[1].Parent != "top" {
+		t.Errorf("expected the layer of the removed container, got %+v", orphans[1])
+	}
+	if orphans[1].ContainerType != "podman" || orphans[1].Path != filepath.Join(overlayDir, "removed", "diff") {
+		t.Errorf("unexpected orphan %+v", orphans[1])
+	}
+
+	// The orphans are mounted using their layer IDs
+	layers, err := exp.SnapshotLayers(context.Background(), "deleted", true)
+	if err != nil {
+		t.Fatalf("SnapshotLayers failed: %v", err)
+	}
+	if len(layers.LowerDirs) != 3 || layers.LowerDirs[0] != filepath.Join(overlayDir, "l", "L-deleted") {
+		t.Errorf("expected the deleted layer and its parents, got %v", layers.LowerDirs)
+	}
+}
+
+// helper to create a podman SQLite state database with the ContainerConfig
+// and ContainerState tables in WAL mode. The returned function copies the
+// database and WAL files while the database is open.
+func createMockPodmanStateDB(t *testing.T, dbfile string) (*sql.DB, func(dir string)) {
+	if err := os.MkdirAll(filepath.Dir(dbfile), 0755); err != nil {
+		t.Fatalf("failed to create db directory: %v", err)
+	}
+	db, err := sql.Open("sqlite3", dbfile)
+	if err != nil {
+		t.Fatalf("failed to open sqlite db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range []string{
+		"PRAGMA journal_mode=WAL",
+		"CREATE TABLE ContainerConfig (ID TEXT PRIMARY KEY NOT NULL, Name TEXT UNIQUE NOT NULL, PodID TEXT, JSON TEXT NOT NULL)",
+		"CREATE TABLE ContainerState (ID TEXT PRIMARY KEY NOT NULL, JSON TEXT NOT NULL)",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to execute %q: %v", stmt, err)
+		}
+	}
+
+	snapshot := func(dir string) {
+		_ = os.MkdirAll(dir, 0755)
+		for _, name := range []string{"db.sql", "db.sql-wal"} {
+			data, err := os.ReadFile(filepath.Join(filepath.Dir(dbfile), name))
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", name, err)
+			}
+			_ = os.WriteFile(filepath.Join(dir, name), data, 0644)
+		}
+	}
+	return db, snapshot
+}
+
+func podmanConfigJSON(id string, name string, image string, env int) string {
+	config := map[string]any{
+		"id":              id,
+		"name":            name,
+		"rootfsImageID":   "img-" + id,
+		"rootfsImageName": image,
+		"createdTime":     "2026-01-02T15:04:05Z",
+		"labels":          map[string]string{"app": name},
+		"spec": map[string]any{
+			"ociVersion": "1.0.0",
+			"process": map[string]any{
+				"args": []string{"/bin/sh", "-c", "sleep infinity"},
+				"env":  []string{"PADDING=" + string(make([]byte, env))},
+			},
+		},
+	}
+	data, _ := json.Marshal(config)
+	return string(data)
+}
+
+func TestCarvePodmanDB(t *testing.T) {
+	tmpDir := t.TempDir()
+	id1 := "1111111111111111111111111111111111111111111111111111111111111111"
+	id2 := "2222222222222222222222222222222222222222222222222222222222222222"
+	id3 := "3333333333333333333333333333333333333333333333333333333333333333"
+
+	dbFile := filepath.Join(tmpDir, "state", "db.sql")
+	db, snapshot := createMockPodmanStateDB(t, dbFile)
+	for _, stmt := range []struct {
+		query string
+		args  []any
+	}{
+		{"INSERT INTO ContainerConfig VALUES (?, ?, NULL, ?)", []any{id1, "web", podmanConfigJSON(id1, "web", "docker.io/library/nginx:latest", 10)}},
+		{"INSERT INTO ContainerState VALUES (?, ?)", []any{id1, `{"state":2,"pid":0,"mountPoint":""}`}},
+		{"INSERT INTO ContainerConfig VALUES (?, ?, NULL, ?)", []any{id2, "miner", podmanConfigJSON(id2, "miner", "docker.io/evil/xmrig:latest", 10)}},
+		{"INSERT INTO ContainerState VALUES (?, ?)", []any{id2, `{"state":3,"pid":4242,"mountPoint":"/merged"}`}},
+		{"INSERT INTO ContainerConfig VALUES (?, ?, NULL, ?)", []any{id3, "big", podmanConfigJSON(id3, "big", "docker.io/library/big:latest", 6000)}},
+		{"UPDATE ContainerState SET JSON = ? WHERE ID = ?", []any{`{"state":3,"pid":1234,"mountPoint":"/merged"}`, id1}},
+		{"DELETE FROM ContainerConfig WHERE ID = ?", []any{id2}},
+		{"DELETE FROM ContainerState WHERE ID = ?", []any{id2}},
+	} {
+		if _, err := db.Exec(stmt.query, stmt.args...); err != nil {
+			db.Close()
+			t.Fatalf("failed to execute %q: %v", stmt.query, err)
+		}
+	}
+
+	walDir := filepath.Join(tmpDir, "wal")
+	snapshot(walDir)
+	db.Close()
+
+	find := func(records []podmanStateRecord, kind string, id string, live bool, contains string) *podmanStateRecord {
+		for i, record := range records {
+			if record.kind == kind && record.id == id && record.live == live && strings.Contains(string(record.json), contains) {
+				return &records[i]
+			}
+		}
+		return nil
+	}
+
+	// The rows of the database and the WAL file
+	records, err := carvePodmanDB(filepath.Join(walDir, "db.sql"))
+	if err != nil {
+		t.Fatalf("carvePodmanDB failed: %v", err)
+	}
+	if find(records, podmanRecordConfig, id1, true, `"name":"web"`) == nil ||
+		find(records, podmanRecordState, id1, true, `"pid":1234`) == nil ||
+		find(records, podmanRecordConfig, id3, true, `"name":"big"`) == nil {
+		t.Errorf("expected live records, got %+v", records)
+	}
+	if find(records, podmanRecordConfig, id2, false, `"name":"miner"`) == nil || find(records, podmanRecordState, id2, false, `"pid":4242`) == nil {
+		t.Errorf("expected deleted records, got %+v", records)
+	}
+	if record := find(records, podmanRecordState, id1, false, `"state":2`); record == nil || record.source != "db.sql-wal" {
+		t.Errorf("expected previous state in the WAL file, got %+v", records)
+	}
+	for _, record := range records {
+		if record.id == id2 && record.live {
+			t.Errorf("unexpected live record of a deleted container %+v", record)
+		}
+	}
+
+	// The checkpointed database without WAL file
+	records, err = carvePodmanDB(dbFile)
+	if err != nil {
+		t.Fatalf("carvePodmanDB failed: %v", err)
+	}
+	if find(records, podmanRecordConfig, id2, false, `"name":"miner"`) == nil || find(records, podmanRecordState, id2, false, `"pid":4242`) == nil {
+		t.Errorf("expected deleted records in the database pages, got %+v", records)
+	}
+
+	// Invalid database files
+	if _, err := carvePodmanDB(filepath.Join(tmpDir, "missing.sql")); err == nil {
+		t.Errorf("expected error for a missing file")
+	}
+	_ = os.WriteFile(filepath.Join(tmpDir, "invalid.sql"), []byte("not a database"), 0644)
+	if _, err := carvePodmanDB(filepath.Join(tmpDir, "invalid.sql")); err == nil {
+		t.Errorf("expected error for an invalid file")
+	}
+}
+
+func TestSqliteRecordValues_Corrupt(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{"Empty payload", nil},
+		{"Header larger than payload", []byte{0x10, 0x0d}},
+		{"Truncated serial type", []byte{0x03, 0x81, 0x81}},
+		{"Value larger than payload", []byte{0x02, 0x21, 'a'}},
+		{"Serial type overflowing int", []byte{0x0a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00, 0x00}},
+		{"Serial type of a negative size", []byte{0x0a, 0xc0, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x0c, 0x00, 0x00}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if values, ok := sqliteRecordValues(tt.payload); ok {
+				t.Errorf("expected corrupt record to be rejected, got %q", values)
+			}
+		})
+	}
+
+	values, ok := sqliteRecordValues([]byte{0x03, 0x01, 0x13, 0x2a, 'a', 'b', 'c'})
+	if !ok || len(values) != 2 || values[0] != nil || string(values[1]) != "abc" {
+		t.Errorf("unexpected values %q of a valid record", values)
+	}
+}
+
+func TestListDeletedContainers(t *testing.T) {
+	tmpDir := t.TempDir()
+	createMockPasswd(t, tmpDir, []string{"mockuser:x:1000:1000:Mock User:/home/mockuser:/bin/bash"})
+	storageDir := filepath.Join(tmpDir, "home", "mockuser", ".local", "share", "containers", "storage")
+	_ = os.MkdirAll(storageDir, 0755)
+
+	exp, err := NewExplorer(tmpDir)
+	if err != nil {
+		t.Fatalf("NewExplorer failed: %v", err)
+	}
+
+	// No database
+	deleted, err := exp.(explorers.DeletedContainerLister).ListDeletedContainers(context.Background())
+	if err != nil || len(deleted) != 0 {
+		t.Fatalf("expected no deleted containers, got %v, %v", deleted, err)
+	}
+
+	id1 := "1111111111111111111111111111111111111111111111111111111111111111"
+	id2 := "2222222222222222222222222222222222222222222222222222222222222222"
+	db, snapshot := createMockPodmanStateDB(t, filepath.Join(tmpDir, "state", "db.sql"))
+	for _, stmt := range []struct {
+		query string
+		args  []any
+	}{
+		{"INSERT INTO ContainerConfig VALUES (?, ?, NULL, ?)", []any{id1, "web", podmanConfigJSON(id1, "web", "docker.io/library/nginx:latest", 10)}},
+		{"INSERT INTO ContainerState VALUES (?, ?)", []any{id1, `{"state":2,"pid":0,"mountPoint":""}`}},
+		{"INSERT INTO ContainerConfig VALUES (?, ?, NULL, ?)", []any{id2, "miner", podmanConfigJSON(id2, "miner", "docker.io/evil/xmrig:latest", 10)}},
+		{"INSERT INTO ContainerState VALUES (?, ?)", []any{id2, `{"state":3,"pid":4242,"mountPoint":"/merged"}`}},
+		{"UPDATE ContainerState SET JSON = ? WHERE ID = ?", []any{`{"state":3,"pid":1234,"mountPoint":"/merged"}`, id1}},
+		{"DELETE FROM ContainerConfig WHERE ID = ?", []any{id2}},
+		{"DELETE FROM ContainerState WHERE ID = ?", []any{id2}},
+	} {
+		if _, err := db.Exec(stmt.query, stmt.args...); err != nil {
+			db.Close()
+			t.Fatalf("failed to execute %q: %v", stmt.query, err)
+		}
+	}
+	snapshot(storageDir)
+	db.Close()
+
+	deleted, err = exp.(explorers.DeletedContainerLister).ListDeletedContainers(context.Background())
+	if err != nil {
+		t.Fatalf("ListDeletedContainers failed: %v", err)
+	}
+
+	var config, state, previous *explorers.Container
+	for i, c := range deleted {
+		switch {
+		case c.ID == id2 && c.Image != "":
+			config = &deleted[i]
+		case c.ID == id2 && c.Status != "":
+			state = &deleted[i]
+		case c.ID == id1 && c.Status == "created":
+			previous = &deleted[i]
+		case c.ID == id1 && c.Recovered != explorers.RecoveredPreviousVersion:
+			t.Errorf("expected previous version of a live container, got %+v", c)
+		}
+	}
+
+	if config == nil || config.Name != "miner" || config.Image != "docker.io/evil/xmrig:latest" || config.Labels["app"] != "miner" ||
+		config.Recovered != explorers.RecoveredDeleted || config.CreatedAt.IsZero() || !strings.HasPrefix(config.RecoveredFrom, "db.sql") {
+		t.Errorf("unexpected deleted container config %+v", config)
+	}
+	if state == nil || state.Name != "miner" || state.Status != "running" || state.ProcessID != 4242 || state.Recovered != explorers.RecoveredDeleted {
+		t.Errorf("unexpected deleted container state %+v", state)
+	}
+	if previous == nil || previous.Name != "web" || previous.Recovered != explorers.RecoveredPreviousVersion {
+		t.Errorf("unexpected previous container state %+v", previous)
+	}
 }
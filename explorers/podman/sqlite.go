@@ -0,0 +1,500 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podman
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	sqliteMagic     = "SQLite format 3\x00"
+	sqliteWALMagic  = 0x377f0682 // WAL magic; the last bit selects the checksum byte order
+	sqliteWALHeader = 32
+	sqliteWALFrame  = 24
+
+	// B-tree page types
+	sqliteTableInterior = 0x05
+	sqliteTableLeaf     = 0x0d
+
+	// Podman state record kinds
+	podmanRecordConfig = "config" // ContainerConfig row
+	podmanRecordState  = "state"  // ContainerState row
+)
+
+// containerIDPattern matches the container ID stored before the JSON column
+// of a ContainerConfig or ContainerState row.
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}$`)
+
+// sqlitePage is a version of a database page from the database file or
+// from a WAL frame.
+type sqlitePage struct {
+	number uint32
+	data   []byte
+	source string // database or WAL file name
+	offset int64  // file offset of the page
+}
+
+// sqliteCarver reads the pages of a SQLite database and its WAL file
+// without SQLite, so that the pages of deleted rows and of replaced page
+// versions are read too.
+type sqliteCarver struct {
+	pageSize int
+	usable   int                    // page size without the reserved bytes
+	current  map[uint32]*sqlitePage // latest committed version of the pages
+	previous []*sqlitePage          // replaced and uncommitted page versions
+	free     map[uint32]bool        // freelist pages
+	seen     map[string]bool        // records returned by kind, ID, and JSON
+	records  []podmanStateRecord
+}
+
+// podmanStateRecord is a ContainerConfig or ContainerState row found in the
+// pages of the podman database.
+type podmanStateRecord struct {
+	kind   string // one of the podman record kinds
+	id     string // container ID
+	json   []byte // JSON column
+	source string // database or WAL file name
+	page   uint32
+	offset int64 // file offset of the record
+	live   bool  // record is a row of the live database
+}
+
+// carvePodmanDB returns the ContainerConfig and ContainerState records of
+// the pages of a podman db.sql file and of its db.sql-wal file, including
+// the records of deleted rows and the previous versions of updated rows.
+//
+// Cells of the live table pages are live records. Deleted records are
+// found in the cells of freelist pages and of page versions replaced by a
+// later WAL frame, and in the unallocated space and freeblocks of pages.
+func carvePodmanDB(dbFile string) ([]podmanStateRecord, error) {
+	//nolint:gosec // G304: Path is constructed from trusted podman root
+	data, err := os.ReadFile(dbFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading database file: %w", err)
+	}
+	if len(data) < 100 || string(data[:len(sqliteMagic)]) != sqliteMagic {
+		return nil, fmt.Errorf("%s is not a SQLite database", dbFile)
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid page size %d", pageSize)
+	}
+
+	c := &sqliteCarver{
+		pageSize: pageSize,
+		usable:   pageSize - int(data[20]),
+		current:  make(map[uint32]*sqlitePage),
+		free:     make(map[uint32]bool),
+		seen:     make(map[string]bool),
+	}
+	name := filepath.Base(dbFile)
+	for i := 0; (i+1)*pageSize <= len(data); i++ {
+		c.current[uint32(i+1)] = &sqlitePage{
+			number: uint32(i + 1),
+			data:   data[i*pageSize : (i+1)*pageSize],
+			source: name,
+			offset: int64(i * pageSize),
+		}
+	}
+
+	if err := c.readWAL(dbFile + "-wal"); err != nil {
+		log.WithFields(log.Fields{"dbfile": dbFile, "error": err}).Warn("reading WAL file")
+	}
+	c.readFreelist()
+
+	numbers := make([]uint32, 0, len(c.current))
+	for number := range c.current {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	// Live records first, so that the recovered copies of live rows are
+	// skipped.
+	for _, number := range numbers {
+		if !c.free[number] {
+			c.carveCells(c.current[number], true)
+		}
+	}
+	for _, number := range numbers {
+		if c.free[number] {
+			c.carveCells(c.current[number], false)
+		}
+		c.carveUnallocated(c.current[number])
+	}
+	for _, p := range c.previous {
+		c.carveCells(p, false)
+		c.carveUnallocated(p)
+	}
+
+	log.WithFields(log.Fields{
+		"dbfile":   dbFile,
+		"pages":    len(c.current),
+		"previous": len(c.previous),
+		"free":     len(c.free),
+		"records":  len(c.records),
+	}).Debug("carved podman database")
+
+	return c.records, nil
+}
+
+// readWAL applies the committed frames of a WAL file to the current pages.
+// The page versions replaced by a later frame and the frames that are not
+// committed, or left by a previous WAL generation, are previous versions.
+func (c *sqliteCarver) readWAL(walFile string) error {
+	//nolint:gosec // G304: Path is constructed from trusted podman root
+	data, err := os.ReadFile(walFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) < sqliteWALHeader {
+		return nil
+	}
+	if binary.BigEndian.Uint32(data[0:4])&^1 != sqliteWALMagic {
+		return fmt.Errorf("invalid WAL magic")
+	}
+	if int(binary.BigEndian.Uint32(data[8:12])) != c.pageSize {
+		return fmt.Errorf("WAL page size does not match the database page size")
+	}
+	salt := data[16:24]
+	name := filepath.Base(walFile)
+
+	var pending []*sqlitePage
+	valid := true
+	for offset := sqliteWALHeader; offset+sqliteWALFrame+c.pageSize <= len(data); offset += sqliteWALFrame + c.pageSize {
+		header := data[offset : offset+sqliteWALFrame]
+		p := &sqlitePage{
+			number: binary.BigEndian.Uint32(header[0:4]),
+			data:   data[offset+sqliteWALFrame : offset+sqliteWALFrame+c.pageSize],
+			source: name,
+			offset: int64(offset + sqliteWALFrame),
+		}
+		// Frames after the first frame of another WAL generation are not
+		// read by SQLite. The frame checksums are not verified.
+		if !valid || !bytes.Equal(header[8:16], salt) || p.number == 0 {
+			valid = false
+			c.previous = append(c.previous, p)
+			continue
+		}
+
+		pending = append(pending, p)
+		dbSize := binary.BigEndian.Uint32(header[4:8])
+		if dbSize == 0 {
+			continue
+		}
+
+		// commit frame
+		for _, committed := range pending {
+			if replaced, ok := c.current[committed.number]; ok {
+				c.previous = append(c.previous, replaced)
+			}
+			c.current[committed.number] = committed
+		}
+		pending = nil
+		for number, p := range c.current {
+			if number > dbSize {
+				c.previous = append(c.previous, p)
+				delete(c.current, number)
+			}
+		}
+	}
+	c.previous = append(c.previous, pending...)
+	return nil
+}
+
+// readFreelist marks the freelist trunk and leaf pages.
+func (c *sqliteCarver) readFreelist() {
+	first, ok := c.current[1]
+	if !ok {
+		return
+	}
+	trunk := binary.BigEndian.Uint32(first.data[32:36])
+	for i := 0; trunk != 0 && i < len(c.current); i++ {
+		p, ok := c.current[trunk]
+		if !ok || c.free[trunk] {
+			return
+		}
+		c.free[trunk] = true
+
+		count := int(binary.BigEndian.Uint32(p.data[4:8]))
+		for j := 0; j < count && 8+4*j+4 <= c.usable; j++ {
+			c.free[binary.BigEndian.Uint32(p.data[8+4*j:])] = true
+		}
+		trunk = binary.BigEndian.Uint32(p.data[0:4])
+	}
+}
+
+// btreeHeader returns the type, cell count, and cell pointer array offset
+// of a table leaf page.
+func (c *sqliteCarver) btreeHeader(p *sqlitePage) (int, int, bool) {
+	header := 0
+	if p.number == 1 {
+		header = 100
+	}
+	if p.data[header] != sqliteTableLeaf {
+		return header, 0, false
+	}
+	count := int(binary.BigEndian.Uint16(p.data[header+3:]))
+	if header+8+2*count > c.usable {
+		return header, 0, false
+	}
+	return header, count, true
+}
+
+// carveCells adds the records of the cells of a table leaf page.
+func (c *sqliteCarver) carveCells(p *sqlitePage, live bool) {
+	header, count, ok := c.btreeHeader(p)
+	if !ok {
+		return
+	}
+	for i := 0; i < count; i++ {
+		cell := int(binary.BigEndian.Uint16(p.data[header+8+2*i:]))
+		payload, ok := c.cellPayload(p, cell)
+		if !ok {
+			continue
+		}
+		values, ok := sqliteRecordValues(payload)
+		if !ok || len(values) < 2 {
+			continue
+		}
+		// ContainerConfig is ID, Name, PodID, JSON and ContainerState is
+		// ID, JSON.
+		c.addRecord(string(values[0]), values[len(values)-1], p, int64(cell), live)
+	}
+}
+
+// carveUnallocated adds the records of the JSON objects found in the
+// unallocated space and freeblocks of a table leaf page, or anywhere in a
+// page that is not a table leaf page e.g. a reused or overflow page.
+//
+// The cell header of a deleted cell is overwritten by the freeblock header,
+// so the container ID is taken from the column before the JSON.
+func (c *sqliteCarver) carveUnallocated(p *sqlitePage) {
+	header, count, ok := c.btreeHeader(p)
+	if !ok {
+		if p.data[0] != sqliteTableInterior {
+			c.carveJSON(p, 0, c.usable)
+		}
+		return
+	}
+
+	contentStart := int(binary.BigEndian.Uint16(p.data[header+5:]))
+	if contentStart == 0 {
+		contentStart = 65536
+	}
+	c.carveJSON(p, header+8+2*count, min(contentStart, c.usable))
+
+	freeblock := int(binary.BigEndian.Uint16(p.data[header+1:]))
+	for i := 0; freeblock != 0 && freeblock+4 <= c.usable && i < c.usable/4; i++ {
+		size := int(binary.BigEndian.Uint16(p.data[freeblock+2:]))
+		c.carveJSON(p, freeblock+4, min(freeblock+size, c.usable))
+		freeblock = int(binary.BigEndian.Uint16(p.data[freeblock:]))
+	}
+}
+
+// carveJSON adds the records of the JSON objects of a page region.
+func (c *sqliteCarver) carveJSON(p *sqlitePage, start int, end int) {
+	for i := start; i >= 0 && i < end-1; i++ {
+		if p.data[i] != '{' || p.data[i+1] != '"' {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(p.data[i:end]))
+		var object json.RawMessage
+		if err := dec.Decode(&object); err != nil {
+			continue
+		}
+		id := containerIDPattern.Find(p.data[max(start, i-64):i])
+		if c.addRecord(string(id), object, p, int64(i), false) {
+			i += int(dec.InputOffset()) - 1
+		}
+	}
+}
+
+// addRecord adds a ContainerConfig or ContainerState record. The record is
+// skipped if it is not a podman container record or if it was already
+// added.
+func (c *sqliteCarver) addRecord(id string, value []byte, p *sqlitePage, offset int64, live bool) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return false
+	}
+
+	kind := ""
+	switch {
+	case fields["spec"] != nil && fields["id"] != nil:
+		kind = podmanRecordConfig
+		var configID string
+		if err := json.Unmarshal(fields["id"], &configID); err == nil && configID != "" {
+			id = configID
+		}
+	case fields["state"] != nil && (fields["startedTime"] != nil || fields["mountPoint"] != nil || fields["pid"] != nil):
+		kind = podmanRecordState
+	default:
+		return false
+	}
+	if id == "" {
+		return false
+	}
+
+	key := kind + "/" + id + "/" + string(value)
+	if c.seen[key] {
+		return true
+	}
+	c.seen[key] = true
+
+	c.records = append(c.records, podmanStateRecord{
+		kind:   kind,
+		id:     id,
+		json:   value,
+		source: p.source,
+		page:   p.number,
+		offset: p.offset + offset,
+		live:   live,
+	})
+	return true
+}
+
+// cellPayload returns the payload of a table leaf cell, including the
+// payload stored in overflow pages.
+func (c *sqliteCarver) cellPayload(p *sqlitePage, cell int) ([]byte, bool) {
+	if cell <= 0 || cell >= c.usable {
+		return nil, false
+	}
+	size, n := sqliteVarint(p.data[cell:c.usable])
+	if n == 0 || size > uint64(c.pageSize)*uint64(len(c.current)+len(c.previous)+1) {
+		return nil, false
+	}
+	cell += n
+	_, n = sqliteVarint(p.data[cell:c.usable]) // rowid
+	if n == 0 {
+		return nil, false
+	}
+	cell += n
+
+	local := c.localPayload(int(size))
+	if cell+local > c.usable {
+		return nil, false
+	}
+	payload := append([]byte{}, p.data[cell:cell+local]...)
+	if local == int(size) {
+		return payload, true
+	}
+
+	if cell+local+4 > c.usable {
+		return nil, false
+	}
+	overflow := binary.BigEndian.Uint32(p.data[cell+local:])
+	for len(payload) < int(size) {
+		op, ok := c.current[overflow]
+		if !ok {
+			return nil, false
+		}
+		chunk := min(c.usable-4, int(size)-len(payload))
+		payload = append(payload, op.data[4:4+chunk]...)
+		overflow = binary.BigEndian.Uint32(op.data[0:4])
+	}
+	return payload, true
+}
+
+// localPayload returns the size of the payload stored in a table leaf cell.
+func (c *sqliteCarver) localPayload(size int) int {
+	maxLocal := c.usable - 35
+	if size <= maxLocal {
+		return size
+	}
+	minLocal := (c.usable-12)*32/255 - 23
+	local := minLocal + (size-minLocal)%(c.usable-4)
+	if local > maxLocal {
+		return minLocal
+	}
+	return local
+}
+
+// sqliteRecordValues returns the columns of a record. Text and blob values
+// are returned as is, and other values as nil.
+func sqliteRecordValues(payload []byte) ([][]byte, bool) {
+	headerSize, n := sqliteVarint(payload)
+	if n == 0 || headerSize > uint64(len(payload)) {
+		return nil, false
+	}
+
+	var values [][]byte
+	body := int(headerSize)
+	for pos := n; pos < int(headerSize); {
+		serialType, n := sqliteVarint(payload[pos:headerSize])
+		if n == 0 {
+			return nil, false
+		}
+		pos += n
+
+		// The size is checked as uint64, as the serial type of a corrupt
+		// record may not fit in an int.
+		var size uint64
+		switch {
+		case serialType >= 12:
+			size = (serialType - 12) / 2
+		case serialType >= 1 && serialType <= 4:
+			size = serialType
+		case serialType == 5:
+			size = 6
+		case serialType == 6 || serialType == 7:
+			size = 8
+		}
+		if size > uint64(len(payload)-body) {
+			return nil, false
+		}
+		if serialType >= 12 {
+			values = append(values, payload[body:body+int(size)])
+		} else {
+			values = append(values, nil)
+		}
+		body += int(size)
+	}
+	return values, true
+}
+
+// sqliteVarint returns a SQLite variable length integer and its length, or
+// a length of 0 if the data is too short.
+func sqliteVarint(data []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(data); i++ {
+		if i == 8 {
+			return v<<8 | uint64(data[i]), 9
+		}
+		v = v<<7 | uint64(data[i]&0x7f)
+		if data[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
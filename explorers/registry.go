@@ -0,0 +1,126 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Config provides the root directories and options passed to the factory
+// and detection probe of a registered explorer.
+type Config struct {
+	ImageRoot         string            // mounted image root directory
+	ContainerdRoot    string            // containerd root directory
+	DockerRoot        string            // docker root directory
+	SupportContainers *SupportContainer // Kubernetes supporting containers, if any
+	Options           map[string]string // explorer specific options by option name
+}
+
+// Option describes an explorer specific option of a registered explorer.
+type Option struct {
+	Name    string // option name, e.g. layer-cache
+	Usage   string // option description
+	Default string // value used if the option is not set
+}
+
+// Registration provides the factory of a container explorer.
+type Registration struct {
+	Name     string // container engine name returned by Type
+	Priority int    // explorers with a lower priority are tried first
+	New      func(config Config) (ContainerExplorer, error)
+	Detect   func(config Config) bool // reports if the roots contain the container engine
+	Options  []Option                 // explorer specific options
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Registration)
+)
+
+// Register registers the factory of a container explorer. Register is
+// called from the init function of the explorer package, and panics if the
+// name is empty or already registered.
+func Register(r Registration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if r.Name == "" || r.New == nil {
+		panic("explorers: Register requires a name and a factory")
+	}
+	if _, ok := registry[r.Name]; ok {
+		panic("explorers: Register called twice for " + r.Name)
+	}
+	registry[r.Name] = r
+}
+
+// Registrations returns the registered explorers sorted by priority and
+// name.
+func Registrations() []Registration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	registrations := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		registrations = append(registrations, r)
+	}
+	sort.Slice(registrations, func(i, j int) bool {
+		if registrations[i].Priority != registrations[j].Priority {
+			return registrations[i].Priority < registrations[j].Priority
+		}
+		return registrations[i].Name < registrations[j].Name
+	})
+	return registrations
+}
+
+// Lookup returns the registered explorer of a container engine name.
+func Lookup(name string) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	r, ok := registry[strings.ToLower(name)]
+	return r, ok
+}
+
+// RegisteredNames returns the names of the registered explorers sorted by
+// priority.
+func RegisteredNames() []string {
+	var names []string
+	for _, r := range Registrations() {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// ResolveOptions returns the explorer specific options with the default
+// values of the options that are not set. An error is returned if an option
+// is not in the options of the registered explorer.
+func (r Registration) ResolveOptions(options map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string)
+	for _, o := range r.Options {
+		resolved[o.Name] = o.Default
+	}
+	for name, value := range options {
+		if _, ok := resolved[name]; !ok {
+			return nil, fmt.Errorf("unsupported %s option %s", r.Name, name)
+		}
+		resolved[name] = value
+	}
+	return resolved, nil
+}
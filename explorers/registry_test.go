@@ -0,0 +1,69 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explorers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	newExplorer := func(Config) (ContainerExplorer, error) { return nil, nil }
+	Register(Registration{Name: "test-late", Priority: 90, New: newExplorer})
+	Register(Registration{Name: "test-early", Priority: -90, New: newExplorer})
+
+	names := RegisteredNames()
+	if names[0] != "test-early" || names[len(names)-1] != "test-late" {
+		t.Errorf("unexpected registration order %v", names)
+	}
+	if r, ok := Lookup("TEST-EARLY"); !ok || r.Name != "test-early" {
+		t.Errorf("expected lookup of test-early, got %+v, %v", r, ok)
+	}
+	if _, ok := Lookup("missing"); ok {
+		t.Errorf("unexpected lookup of a missing engine")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for a duplicate registration")
+		}
+	}()
+	Register(Registration{Name: "test-late", New: newExplorer})
+}
+
+func TestRegistration_ResolveOptions(t *testing.T) {
+	r := Registration{
+		Name: "test",
+		Options: []Option{
+			{Name: "layer-cache"},
+			{Name: "mode", Default: "fast"},
+		},
+	}
+
+	options, err := r.ResolveOptions(map[string]string{"layer-cache": "layers"})
+	if err != nil {
+		t.Fatalf("ResolveOptions failed: %v", err)
+	}
+	expected := map[string]string{"layer-cache": "layers", "mode": "fast"}
+	if !reflect.DeepEqual(options, expected) {
+		t.Errorf("expected %v, got %v", expected, options)
+	}
+
+	if _, err := r.ResolveOptions(map[string]string{"unknown": "x"}); err == nil {
+		t.Errorf("expected error for an unknown option")
+	}
+}
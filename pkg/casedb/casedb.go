@@ -0,0 +1,343 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package casedb writes the records of container-explorer to a SQLite case
+// database.
+//
+// The containers, images, snapshots, tasks, content, drift files, and item
+// errors are written to a table each, keyed by the source root and the
+// container ID, so that the records of many image roots and runs can be
+// joined with SQL. Each run adds a row to the runs table, and the records of
+// the run reference it by run_id. A database is created if it does not
+// exist, and the records of a run are added to the existing records.
+package casedb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/container-explorer/explorers"
+	_ "github.com/mattn/go-sqlite3" // Required for sqlite3 driver
+)
+
+// schema creates the tables and indexes of a case database.
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	started_at TEXT NOT NULL,
+	command TEXT
+);
+CREATE TABLE IF NOT EXISTS containers (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	source_root TEXT NOT NULL,
+	container_type TEXT,
+	namespace TEXT,
+	container_id TEXT NOT NULL,
+	name TEXT,
+	hostname TEXT,
+	image TEXT,
+	snapshotter TEXT,
+	snapshot_key TEXT,
+	created_at TEXT,
+	updated_at TEXT,
+	pid INTEGER,
+	status TEXT,
+	running INTEGER,
+	support_container INTEGER,
+	recovered TEXT,
+	recovered_from TEXT,
+	labels TEXT
+);
+CREATE INDEX IF NOT EXISTS containers_source ON containers (source_root, container_id);
+CREATE TABLE IF NOT EXISTS images (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	source_root TEXT NOT NULL,
+	container_type TEXT,
+	namespace TEXT,
+	name TEXT,
+	digest TEXT,
+	media_type TEXT,
+	size INTEGER,
+	created_at TEXT,
+	updated_at TEXT,
+	support_container_image INTEGER,
+	labels TEXT
+);
+CREATE INDEX IF NOT EXISTS images_source ON images (source_root, name);
+CREATE TABLE IF NOT EXISTS snapshots (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	source_root TEXT NOT NULL,
+	container_type TEXT,
+	namespace TEXT,
+	snapshotter TEXT,
+	key TEXT,
+	name TEXT,
+	parent TEXT,
+	kind TEXT,
+	size INTEGER,
+	overlay_path TEXT,
+	created_at TEXT,
+	updated_at TEXT,
+	labels TEXT
+);
+CREATE INDEX IF NOT EXISTS snapshots_source ON snapshots (source_root, key);
+CREATE TABLE IF NOT EXISTS tasks (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	source_root TEXT NOT NULL,
+	container_type TEXT,
+	namespace TEXT,
+	container_id TEXT NOT NULL,
+	pid INTEGER,
+	status TEXT
+);
+CREATE INDEX IF NOT EXISTS tasks_source ON tasks (source_root, container_id);
+CREATE TABLE IF NOT EXISTS contents (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	source_root TEXT NOT NULL,
+	container_type TEXT,
+	namespace TEXT,
+	digest TEXT,
+	size INTEGER,
+	created_at TEXT,
+	updated_at TEXT,
+	labels TEXT
+);
+CREATE INDEX IF NOT EXISTS contents_source ON contents (source_root, digest);
+CREATE TABLE IF NOT EXISTS drift_files (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	source_root TEXT NOT NULL,
+	container_type TEXT,
+	container_id TEXT NOT NULL,
+	change TEXT NOT NULL,
+	path TEXT,
+	file_name TEXT,
+	file_type TEXT,
+	size INTEGER,
+	modified_at TEXT,
+	accessed_at TEXT,
+	changed_at TEXT,
+	birth_at TEXT,
+	uid TEXT,
+	owner TEXT,
+	gid TEXT,
+	sha256 TEXT
+);
+CREATE INDEX IF NOT EXISTS drift_files_source ON drift_files (source_root, container_id);
+CREATE INDEX IF NOT EXISTS drift_files_sha256 ON drift_files (sha256);
+CREATE TABLE IF NOT EXISTS errors (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	source_root TEXT NOT NULL,
+	container_type TEXT,
+	operation TEXT,
+	item_id TEXT,
+	kind TEXT,
+	message TEXT
+);
+`
+
+// Change values of the drift_files table.
+const (
+	ChangeAddedOrModified = "added_or_modified" // file added or modified in the container layer
+	ChangeInaccessible    = "inaccessible"      // file deleted or inaccessible in the container layer
+)
+
+// DB is a case database open for the records of a run. The records of a run
+// are written in a single transaction, committed when the database is
+// closed. DBs are safe for concurrent use.
+type DB struct {
+	db    *sql.DB
+	mu    sync.Mutex
+	tx    *sql.Tx
+	runID int64
+}
+
+// Open opens the case database file, creates it if it does not exist, and
+// adds a run of the command.
+func Open(path string, command string) (*DB, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening case database %s: %w", path, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating case database %s: %w", path, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("starting case database transaction: %w", err)
+	}
+	res, err := tx.Exec("INSERT INTO runs (started_at, command) VALUES (?, ?)", timeValue(time.Now()), command)
+	if err == nil {
+		var runID int64
+		if runID, err = res.LastInsertId(); err == nil {
+			return &DB{db: db, tx: tx, runID: runID}, nil
+		}
+	}
+	tx.Rollback()
+	db.Close()
+	return nil, fmt.Errorf("adding case database run: %w", err)
+}
+
+// RunID returns the ID of the run in the runs table.
+func (d *DB) RunID() int64 {
+	return d.runID
+}
+
+// Supported reports whether records of the type of record can be added to
+// a case database.
+func Supported(record any) bool {
+	switch record.(type) {
+	case explorers.Container, explorers.Image, explorers.SnapshotKeyInfo, explorers.Task,
+		explorers.Content, explorers.Drift, explorers.ItemError:
+		return true
+	}
+	return false
+}
+
+// Add adds a record of an image root to the table of its type. A drift adds
+// a row per file.
+func (d *DB) Add(sourceRoot string, record any) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.tx == nil {
+		return fmt.Errorf("adding to closed case database")
+	}
+
+	var err error
+	switch r := record.(type) {
+	case explorers.Container:
+		_, err = d.tx.Exec(`INSERT INTO containers (run_id, source_root, container_type, namespace,
+			container_id, name, hostname, image, snapshotter, snapshot_key, created_at, updated_at, pid,
+			status, running, support_container, recovered, recovered_from, labels)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			d.runID, sourceRoot, r.ContainerType, r.Namespace, r.ID, r.Name, r.Hostname, r.Image,
+			r.Snapshotter, r.SnapshotKey, timeValue(r.CreatedAt), timeValue(r.UpdatedAt), r.ProcessID,
+			r.Status, r.Running, r.SupportContainer, r.Recovered, r.RecoveredFrom, labelsValue(r.Labels))
+	case explorers.Image:
+		_, err = d.tx.Exec(`INSERT INTO images (run_id, source_root, container_type, namespace, name,
+			digest, media_type, size, created_at, updated_at, support_container_image, labels)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			d.runID, sourceRoot, r.ContainerType, r.Namespace, r.Name, r.Target.Digest.String(),
+			r.Target.MediaType, r.Target.Size, timeValue(r.CreatedAt), timeValue(r.UpdatedAt),
+			r.SupportContainerImage, labelsValue(r.Labels))
+	case explorers.SnapshotKeyInfo:
+		_, err = d.tx.Exec(`INSERT INTO snapshots (run_id, source_root, container_type, namespace,
+			snapshotter, key, name, parent, kind, size, overlay_path, created_at, updated_at, labels)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			d.runID, sourceRoot, r.ContainerType, r.Namespace, r.Snapshotter, r.Key, r.Name, r.Parent,
+			r.Kind.String(), int64(r.Size), r.OverlayPath, timeValue(r.CreatedAt), timeValue(r.UpdatedAt),
+			labelsValue(r.Labels))
+	case explorers.Task:
+		_, err = d.tx.Exec(`INSERT INTO tasks (run_id, source_root, container_type, namespace,
+			container_id, pid, status) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			d.runID, sourceRoot, r.ContainerType, r.Namespace, r.Name, r.PID, r.Status)
+	case explorers.Content:
+		_, err = d.tx.Exec(`INSERT INTO contents (run_id, source_root, container_type, namespace,
+			digest, size, created_at, updated_at, labels) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			d.runID, sourceRoot, r.ContainerType, r.Namespace, r.Digest.String(), r.Size,
+			timeValue(r.CreatedAt), timeValue(r.UpdatedAt), labelsValue(r.Labels))
+	case explorers.Drift:
+		err = d.addDriftFiles(sourceRoot, r, ChangeAddedOrModified, r.AddedOrModified)
+		if err == nil {
+			err = d.addDriftFiles(sourceRoot, r, ChangeInaccessible, r.InaccessibleFiles)
+		}
+	case explorers.ItemError:
+		err = d.addError(sourceRoot, r)
+	default:
+		return fmt.Errorf("unsupported case database record %T", record)
+	}
+	if err != nil {
+		return fmt.Errorf("adding %T to case database: %w", record, err)
+	}
+	return nil
+}
+
+// AddErrors adds the item errors of an image root to the errors table.
+func (d *DB) AddErrors(sourceRoot string, itemErrs ...explorers.ItemError) error {
+	for _, itemErr := range itemErrs {
+		if err := d.Add(sourceRoot, itemErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addDriftFiles adds the files of a container drift with a change.
+func (d *DB) addDriftFiles(sourceRoot string, drift explorers.Drift, change string, files []explorers.FileInfo) error {
+	for _, f := range files {
+		_, err := d.tx.Exec(`INSERT INTO drift_files (run_id, source_root, container_type,
+			container_id, change, path, file_name, file_type, size, modified_at, accessed_at, changed_at,
+			birth_at, uid, owner, gid, sha256) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			d.runID, sourceRoot, drift.ContainerType, drift.ContainerID, change, f.FullPath, f.FileName,
+			f.FileType, f.FileSize, timeValue(f.FileModified), timeValue(f.FileAccessed),
+			timeValue(f.FileChanged), timeValue(f.FileBirth), f.FileUID, f.FileOwner, f.FileGID, f.FileSHA256)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addError adds an item error.
+func (d *DB) addError(sourceRoot string, itemErr explorers.ItemError) error {
+	_, err := d.tx.Exec(`INSERT INTO errors (run_id, source_root, container_type, operation, item_id,
+		kind, message) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		d.runID, sourceRoot, itemErr.ContainerType, itemErr.Operation, itemErr.ID, itemErr.Kind, itemErr.Message)
+	return err
+}
+
+// Close commits the records of the run and closes the database. Close can
+// be called more than once.
+func (d *DB) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.tx == nil {
+		return nil
+	}
+
+	err := d.tx.Commit()
+	d.tx = nil
+	if err != nil {
+		d.db.Close()
+		return fmt.Errorf("committing case database: %w", err)
+	}
+	return d.db.Close()
+}
+
+// timeValue returns the RFC 3339 text of a time, or NULL for the zero time.
+func timeValue(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// labelsValue returns the JSON object of labels, or NULL if there are none.
+func labelsValue(labels map[string]string) any {
+	if len(labels) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
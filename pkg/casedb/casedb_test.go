@@ -0,0 +1,108 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package casedb
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/images"
+	"github.com/google/container-explorer/explorers"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "case.db")
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []any{
+		explorers.Container{ContainerType: "docker", Container: containers.Container{ID: "c1", Image: "nginx", CreatedAt: created, Labels: map[string]string{"app": "web"}}},
+		explorers.Image{ContainerType: "docker", Image: images.Image{Name: "nginx", Target: ocispec.Descriptor{Digest: digest.FromString("nginx"), Size: 10}}},
+		explorers.SnapshotKeyInfo{ContainerType: "containerd", Key: "k1", OverlayPath: "/snapshots/1"},
+		explorers.Task{ContainerType: "docker", Name: "c1", PID: 42, Status: "running"},
+		explorers.Content{ContainerType: "containerd", Info: content.Info{Digest: digest.FromString("blob"), Size: 4}},
+		explorers.Drift{ContainerType: "docker", ContainerID: "c1",
+			AddedOrModified:   []explorers.FileInfo{{FullPath: "/tmp/a.sh", FileType: "executable", FileSHA256: "abc"}},
+			InaccessibleFiles: []explorers.FileInfo{{FullPath: "/etc/passwd"}},
+		},
+	}
+
+	for run := 1; run <= 2; run++ {
+		db, err := Open(path, "ce list containers")
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		if db.RunID() != int64(run) {
+			t.Errorf("expected run %d, got %d", run, db.RunID())
+		}
+		for _, record := range records {
+			if !Supported(record) {
+				t.Errorf("expected %T to be supported", record)
+			}
+			if err := db.Add("/mnt/node-a", record); err != nil {
+				t.Fatalf("Add failed: %v", err)
+			}
+		}
+		if err := db.AddErrors("/mnt/node-a", explorers.ItemError{ContainerType: "docker", Operation: "drift", ID: "c2", Kind: explorers.ErrorKindNotFound}); err != nil {
+			t.Fatalf("AddErrors failed: %v", err)
+		}
+		if err := db.Add("/mnt/node-a", "unsupported"); err == nil || Supported("unsupported") {
+			t.Errorf("expected error for an unsupported record")
+		}
+		if err := db.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("expected a second Close to succeed, got %v", err)
+		}
+		if err := db.Add("/mnt/node-a", records[0]); err == nil {
+			t.Errorf("expected error adding to a closed database")
+		}
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for table, expected := range map[string]int{"runs": 2, "containers": 2, "images": 2, "snapshots": 2, "tasks": 2, "contents": 2, "drift_files": 4, "errors": 2} {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil || count != expected {
+			t.Errorf("expected %d rows in %s, got %d (%v)", expected, table, count, err)
+		}
+	}
+
+	var createdAt, labels, change string
+	err = db.QueryRow(`SELECT c.created_at, c.labels, d.change FROM containers c JOIN drift_files d
+		ON c.source_root = d.source_root AND c.container_id = d.container_id AND c.run_id = d.run_id
+		WHERE c.run_id = 2 AND d.sha256 = 'abc'`).Scan(&createdAt, &labels, &change)
+	if err != nil {
+		t.Fatalf("joining containers and drift files failed: %v", err)
+	}
+	if createdAt != "2026-01-02T03:04:05Z" || labels != `{"app":"web"}` || change != ChangeAddedOrModified {
+		t.Errorf("unexpected container %s %s %s", createdAt, labels, change)
+	}
+
+	var updatedAt sql.NullString
+	if err := db.QueryRow("SELECT updated_at FROM containers LIMIT 1").Scan(&updatedAt); err != nil || updatedAt.Valid {
+		t.Errorf("expected a NULL zero time, got %v (%v)", updatedAt, err)
+	}
+}
@@ -0,0 +1,362 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ce provides the container-explorer library API. A Session explores
+// the containerd, docker, and podman containers of one set of root
+// directories and returns the results instead of printing them.
+//
+// A Session holds no package-level state, and sessions of different image
+// roots can be used concurrently from one process. Mounting and exporting
+// containers run external commands through utils.Runner. The commands run
+// with a context carrying a custody record are recorded to the custody
+// record; a runner replacing utils.Runner is wrapped with
+// utils.NewRecordingRunner to keep recording them.
+package ce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"runtime"
+	"strings"
+
+	"github.com/google/container-explorer/explorers"
+
+	// Registered container explorers
+	_ "github.com/google/container-explorer/explorers/containerd"
+	_ "github.com/google/container-explorer/explorers/docker"
+	_ "github.com/google/container-explorer/explorers/podman"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Options provides the root directories and settings of a session.
+type Options struct {
+	ImageRoot         string                       // mounted image root directory
+	ContainerdRoot    string                       // containerd root directory, resolved from the image root if empty
+	DockerRoot        string                       // docker root directory, resolved from the image root if empty
+	SupportContainers *explorers.SupportContainer  // Kubernetes supporting containers, if any
+	Engines           []string                     // registered container engines, or the detected engines if empty
+	EngineOptions     map[string]map[string]string // explorer specific options by container engine
+
+	// Deprecated: LayerCache is the containerd layer cache directory. Use
+	// the layer-cache option of EngineOptions["containerd"] instead, which
+	// takes precedence.
+	LayerCache string
+}
+
+// DriftOptions provides the containers of a drift.
+type DriftOptions struct {
+	ContainerID       string // container ID, or all containers if empty
+	Filter            string // comma separated label filter using key=value
+	SupportContainers bool   // include Kubernetes supporting containers
+}
+
+// ExportOptions provides the formats and containers of an export.
+type ExportOptions struct {
+	Image             bool                  // export as raw image
+	Archive           bool                  // export as archive
+	OCI               bool                  // export as OCI image archive
+	Compression       explorers.Compression // archive compression, gzip if empty
+	Filter            map[string]string     // label filter of ExportAll
+	SupportContainers bool                  // include Kubernetes supporting containers in ExportAll
+}
+
+// Session provides the explorers of a set of root directories.
+type Session struct {
+	options   Options
+	explorers []explorers.ContainerExplorer
+}
+
+// New returns a session of the root directories of the options. The
+// explorers of the selected engines are created, or of the registered
+// engines detected in the root directories if no engine is selected. The
+// session must be closed to release the explorer databases.
+func New(options Options) (*Session, error) {
+	options = ResolveRoots(options)
+	options.EngineOptions = engineOptions(options)
+	s := &Session{options: options}
+
+	for name := range options.EngineOptions {
+		if _, ok := explorers.Lookup(name); !ok {
+			return nil, fmt.Errorf("unsupported container engine %s", name)
+		}
+	}
+
+	registrations := explorers.Registrations()
+	if len(options.Engines) > 0 {
+		registrations = nil
+		for _, name := range options.Engines {
+			r, ok := explorers.Lookup(name)
+			if !ok {
+				return nil, fmt.Errorf("unsupported container engine %s: use %s", name, strings.Join(explorers.RegisteredNames(), ", "))
+			}
+			registrations = append(registrations, r)
+		}
+	}
+
+	for _, r := range registrations {
+		engineOptions, err := r.ResolveOptions(options.EngineOptions[r.Name])
+		if err != nil {
+			return nil, err
+		}
+		config := explorers.Config{
+			ImageRoot:         options.ImageRoot,
+			ContainerdRoot:    options.ContainerdRoot,
+			DockerRoot:        options.DockerRoot,
+			SupportContainers: options.SupportContainers,
+			Options:           engineOptions,
+		}
+
+		if len(options.Engines) == 0 && r.Detect != nil && !r.Detect(config) {
+			log.Debugf("%s not detected", r.Name)
+			continue
+		}
+		xplr, err := r.New(config)
+		if err != nil {
+			log.Debugf("unable to get %s explorer: %v", r.Name, err)
+			continue
+		}
+		s.explorers = append(s.explorers, xplr)
+	}
+
+	if len(s.explorers) == 0 {
+		return nil, fmt.Errorf("no container explorers for image root %q, containerd root %q, and docker root %q", options.ImageRoot, options.ContainerdRoot, options.DockerRoot)
+	}
+	return s, nil
+}
+
+// Close releases the explorers of the session.
+func (s *Session) Close() error {
+	var errs []error
+	for _, xplr := range s.explorers {
+		if err := xplr.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing %s explorer: %w", xplr.Type(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Options returns the options of the session with the resolved root
+// directories.
+func (s *Session) Options() Options {
+	return s.options
+}
+
+// Explorers returns the explorers of the session.
+func (s *Session) Explorers() []explorers.ContainerExplorer {
+	return s.explorers
+}
+
+// ListContainers returns the containers of all explorers. The containers of
+// the other explorers are returned if an explorer fails, and the returned
+// error joins an explorers.ItemError for each failure.
+func (s *Session) ListContainers(ctx context.Context) ([]explorers.Container, error) {
+	return collect(s, "list", func(xplr explorers.ContainerExplorer) ([]explorers.Container, error) {
+		return xplr.ListContainers(ctx)
+	})
+}
+
+// ListImages returns the images of all explorers.
+func (s *Session) ListImages(ctx context.Context) ([]explorers.Image, error) {
+	return collect(s, "list", func(xplr explorers.ContainerExplorer) ([]explorers.Image, error) {
+		return xplr.ListImages(ctx)
+	})
+}
+
+// ListSnapshots returns the snapshots of the explorers that support
+// listing snapshots.
+func (s *Session) ListSnapshots(ctx context.Context) ([]explorers.SnapshotKeyInfo, error) {
+	return collect(s, "list", func(xplr explorers.ContainerExplorer) ([]explorers.SnapshotKeyInfo, error) {
+		snapshotLister, ok := xplr.(explorers.SnapshotLister)
+		if !ok {
+			return nil, nil
+		}
+		return snapshotLister.ListSnapshots(ctx)
+	})
+}
+
+// ListTasks returns the tasks of all explorers.
+func (s *Session) ListTasks(ctx context.Context) ([]explorers.Task, error) {
+	return collect(s, "list", func(xplr explorers.ContainerExplorer) ([]explorers.Task, error) {
+		return xplr.ListTasks(ctx)
+	})
+}
+
+// Info returns the internal information of a container, and its runtime
+// spec if spec is set.
+func (s *Session) Info(ctx context.Context, containerID string, spec bool) (any, error) {
+	xplr, err := s.containerExplorer(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	return xplr.InfoContainer(ctx, containerID, spec)
+}
+
+// Drift returns the filesystem changes of a container, or of all containers
+// if the container ID is empty. Drift is only supported on Linux.
+func (s *Session) Drift(ctx context.Context, options DriftOptions) ([]explorers.Drift, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("drift is only supported on Linux")
+	}
+	if options.ContainerID != "" {
+		xplr, err := s.containerExplorer(ctx, options.ContainerID)
+		if err != nil {
+			return nil, err
+		}
+		return xplr.ContainerDrift(ctx, options.Filter, !options.SupportContainers, options.ContainerID)
+	}
+	return collect(s, "drift", func(xplr explorers.ContainerExplorer) ([]explorers.Drift, error) {
+		return xplr.ContainerDrift(ctx, options.Filter, !options.SupportContainers, options.ContainerID)
+	})
+}
+
+// StreamDrift calls fn with the filesystem changes of each container, or of
+// a container if the container ID is set, as the drift detection of the
+// container completes. The drift is not held in memory, and the drift
+// detection stops at the first error of fn, which is returned. Otherwise the
+// returned error joins an explorers.ItemError for each failure. StreamDrift
+// is only supported on Linux.
+func (s *Session) StreamDrift(ctx context.Context, options DriftOptions, fn func(explorers.Drift) error) error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("drift is only supported on Linux")
+	}
+	xplrs := s.explorers
+	if options.ContainerID != "" {
+		xplr, err := s.containerExplorer(ctx, options.ContainerID)
+		if err != nil {
+			return err
+		}
+		xplrs = []explorers.ContainerExplorer{xplr}
+	}
+
+	var fnErr error
+	emit := func(drift explorers.Drift) error {
+		fnErr = fn(drift)
+		return fnErr
+	}
+
+	var errs []error
+	for _, xplr := range xplrs {
+		err := explorers.StreamDrift(ctx, xplr, options.Filter, !options.SupportContainers, options.ContainerID, emit)
+		if fnErr != nil {
+			return fnErr
+		}
+		for _, itemErr := range explorers.ItemErrors(xplr.Type(), "drift", err) {
+			errs = append(errs, &itemErr)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Export exports a container to the output directory. The exported
+// artifacts and the commands run are recorded to the custody record of the
+// context, if any.
+// Export is only supported on Linux.
+func (s *Session) Export(ctx context.Context, containerID string, outputDir string, options ExportOptions) error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("exporting a container is only supported on Linux")
+	}
+	xplr, err := s.containerExplorer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	return xplr.ExportContainer(ctx, containerID, outputDir, options.exportOptions())
+}
+
+// ExportAll exports all containers matching the label filter of the options
+// to the output directory. The other containers are exported if a container
+// fails, and the returned error joins an explorers.ItemError for each
+// failure. Export is only supported on Linux.
+func (s *Session) ExportAll(ctx context.Context, outputDir string, options ExportOptions) error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("exporting a container is only supported on Linux")
+	}
+
+	var errs []error
+	for _, xplr := range s.explorers {
+		err := xplr.ExportAllContainers(ctx, outputDir, options.exportOptions(), options.Filter, options.SupportContainers)
+		for _, itemErr := range explorers.ItemErrors(xplr.Type(), "export", err) {
+			errs = append(errs, &itemErr)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// containerExplorer returns the explorer of a container.
+func (s *Session) containerExplorer(ctx context.Context, containerID string) (explorers.ContainerExplorer, error) {
+	for _, xplr := range s.explorers {
+		container, err := xplr.GetContainerByID(ctx, containerID)
+		if err != nil {
+			log.Debugf("error checking container ID %s in %s explorer: %v", containerID, xplr.Type(), err)
+			continue
+		}
+		if container != nil {
+			return xplr, nil
+		}
+	}
+	return nil, fmt.Errorf("container %s %w", containerID, explorers.ErrNotFound)
+}
+
+// engineOptions returns the engine options of the options, with the
+// deprecated layer cache as the containerd layer-cache option.
+func engineOptions(options Options) map[string]map[string]string {
+	if options.LayerCache == "" {
+		return options.EngineOptions
+	}
+	if _, ok := options.EngineOptions["containerd"]["layer-cache"]; ok {
+		return options.EngineOptions
+	}
+
+	resolved := make(map[string]map[string]string, len(options.EngineOptions)+1)
+	for engine, values := range options.EngineOptions {
+		resolved[engine] = maps.Clone(values)
+	}
+	if resolved["containerd"] == nil {
+		resolved["containerd"] = make(map[string]string)
+	}
+	resolved["containerd"]["layer-cache"] = options.LayerCache
+	return resolved
+}
+
+// exportOptions returns the container export options of the explorers. A
+// raw image is exported if no format is set.
+func (o ExportOptions) exportOptions() explorers.ContainerExportOptions {
+	return explorers.ContainerExportOptions{
+		Image:       o.Image || (!o.Archive && !o.OCI),
+		Archive:     o.Archive,
+		OCI:         o.OCI,
+		Compression: o.Compression,
+	}
+}
+
+// collect returns the values of all explorers of a session and the joined
+// item errors of the containers and explorers that failed. The values of an
+// explorer are returned even if it failed for some containers.
+func collect[T any](s *Session, operation string, list func(explorers.ContainerExplorer) ([]T, error)) ([]T, error) {
+	var result explorers.Result[T]
+	for _, xplr := range s.explorers {
+		v, err := list(xplr)
+		result.Add(xplr.Type(), operation, v, err)
+	}
+
+	var errs []error
+	for i := range result.Errors {
+		errs = append(errs, &result.Errors[i])
+	}
+	return result.Items, errors.Join(errs...)
+}
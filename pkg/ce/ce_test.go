@@ -0,0 +1,296 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/google/container-explorer/explorers"
+)
+
+// setupMockOverlay2Container creates a Docker overlay2 container with the
+// files of its writable layer on top of a base image layer.
+func setupMockOverlay2Container(t *testing.T, dockerRoot string, containerID string, files map[string]string) {
+	t.Helper()
+
+	containerDir := filepath.Join(dockerRoot, "containers", containerID)
+	_ = os.MkdirAll(containerDir, 0755)
+	config := fmt.Sprintf(`{
+		"ID": "%s",
+		"Driver": "overlay2",
+		"Created": "2026-06-12T00:30:43Z",
+		"State": {"Running": true, "Pid": 1234},
+		"Config": {"Image": "ubuntu:latest", "Labels": {"app": "test-docker"}}
+	}`, containerID)
+	_ = os.WriteFile(filepath.Join(containerDir, "config.v2.json"), []byte(config), 0600)
+	_ = os.WriteFile(filepath.Join(containerDir, "hostconfig.json"), []byte(`{}`), 0600)
+
+	mountID := "mount-" + containerID
+	mountDir := filepath.Join(dockerRoot, "image", "overlay2", "layerdb", "mounts", containerID)
+	_ = os.MkdirAll(mountDir, 0755)
+	_ = os.WriteFile(filepath.Join(mountDir, "mount-id"), []byte(mountID), 0600)
+
+	overlayDir := filepath.Join(dockerRoot, "overlay2")
+	layerFiles := map[string]string{
+		"base/diff/etc/hosts": "localhost",
+		mountID + "/link":     "UPPER-" + containerID,
+		mountID + "/lower":    "l/BASE",
+	}
+	for name, content := range files {
+		layerFiles[filepath.Join(mountID, "diff", name)] = content
+	}
+	for name, content := range layerFiles {
+		_ = os.MkdirAll(filepath.Dir(filepath.Join(overlayDir, name)), 0755)
+		_ = os.WriteFile(filepath.Join(overlayDir, name), []byte(content), 0644)
+	}
+	_ = os.MkdirAll(filepath.Join(overlayDir, "l"), 0755)
+	_ = os.Symlink("../"+mountID+"/diff", filepath.Join(overlayDir, "l", "UPPER-"+containerID))
+	_ = os.Symlink("../base/diff", filepath.Join(overlayDir, "l", "BASE"))
+}
+
+func TestResolveRoots(t *testing.T) {
+	options := ResolveRoots(Options{ImageRoot: "/mnt/image"})
+	if options.DockerRoot != "/mnt/image/var/lib/docker" || options.ContainerdRoot != "/mnt/image/var/lib/containerd" {
+		t.Errorf("unexpected roots of image root %+v", options)
+	}
+
+	options = ResolveRoots(Options{ContainerdRoot: "/data/containerd/"})
+	if options.DockerRoot != "/data/docker" {
+		t.Errorf("unexpected docker root of containerd root %+v", options)
+	}
+}
+
+func TestSession(t *testing.T) {
+	imageRoot := t.TempDir()
+	setupMockOverlay2Container(t, filepath.Join(imageRoot, "var", "lib", "docker"), "container-lib", map[string]string{"tmp/dropper": "payload"})
+
+	session, err := New(Options{ImageRoot: imageRoot})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer session.Close()
+	ctx := context.Background()
+
+	if session.Options().DockerRoot != filepath.Join(imageRoot, "var", "lib", "docker") {
+		t.Errorf("unexpected resolved docker root %s", session.Options().DockerRoot)
+	}
+
+	containers, err := session.ListContainers(ctx)
+	if err != nil {
+		t.Fatalf("ListContainers failed: %v", err)
+	}
+	if len(containers) != 1 || containers[0].ID != "container-lib" {
+		t.Fatalf("unexpected containers %+v", containers)
+	}
+
+	if _, err := session.Info(ctx, "container-lib", false); err != nil {
+		t.Errorf("Info failed: %v", err)
+	}
+	if _, err := session.Info(ctx, "missing", false); err == nil {
+		t.Errorf("expected error for a missing container")
+	}
+
+	if runtime.GOOS == "linux" {
+		drifts, err := session.Drift(ctx, DriftOptions{ContainerID: "container-lib"})
+		if err != nil {
+			t.Fatalf("Drift failed: %v", err)
+		}
+		if len(drifts) != 1 || len(drifts[0].AddedOrModified) != 1 || drifts[0].AddedOrModified[0].FullPath != "/tmp/dropper" {
+			t.Errorf("unexpected drift %+v", drifts)
+		}
+
+		var streamed []explorers.Drift
+		err = session.StreamDrift(ctx, DriftOptions{}, func(drift explorers.Drift) error {
+			streamed = append(streamed, drift)
+			return nil
+		})
+		if err != nil || len(streamed) != 1 || streamed[0].ContainerID != "container-lib" {
+			t.Errorf("unexpected streamed drift %+v: %v", streamed, err)
+		}
+
+		errStop := errors.New("stop")
+		if err := session.StreamDrift(ctx, DriftOptions{}, func(explorers.Drift) error { return errStop }); !errors.Is(err, errStop) {
+			t.Errorf("expected the error of fn, got %v", err)
+		}
+	}
+}
+
+func TestSession_Concurrent(t *testing.T) {
+	var roots []string
+	for i := range 4 {
+		imageRoot := t.TempDir()
+		setupMockOverlay2Container(t, filepath.Join(imageRoot, "var", "lib", "docker"), fmt.Sprintf("container-%d", i), nil)
+		roots = append(roots, imageRoot)
+	}
+
+	var wg sync.WaitGroup
+	ids := make([]string, len(roots))
+	for i, root := range roots {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session, err := New(Options{ImageRoot: root})
+			if err != nil {
+				t.Errorf("New failed: %v", err)
+				return
+			}
+			defer session.Close()
+			containers, err := session.ListContainers(context.Background())
+			if err != nil || len(containers) != 1 {
+				t.Errorf("unexpected containers %+v, %v", containers, err)
+				return
+			}
+			ids[i] = containers[0].ID
+		}()
+	}
+	wg.Wait()
+
+	for i, id := range ids {
+		if id != fmt.Sprintf("container-%d", i) {
+			t.Errorf("session %d returned container %s", i, id)
+		}
+	}
+}
+
+func TestDockerDataRoot(t *testing.T) {
+	// Case 1: Config does not exist -> default
+	tmpDir := t.TempDir()
+	path := DockerDataRoot(tmpDir)
+	if path != DefaultDockerRootDir {
+		t.Errorf("expected default docker data root %q, got %q", DefaultDockerRootDir, path)
+	}
+
+	// Case 2: Config exists but is invalid JSON
+	dockerConfigDir := filepath.Join(tmpDir, "etc", "docker")
+	_ = os.MkdirAll(dockerConfigDir, 0755)
+	_ = os.WriteFile(filepath.Join(dockerConfigDir, "daemon.json"), []byte("{invalid-json}"), 0600)
+	path = DockerDataRoot(tmpDir)
+	if path != DefaultDockerRootDir {
+		t.Errorf("expected default docker data root on invalid JSON, got %q", path)
+	}
+
+	// Case 3: Config exists, valid JSON, but missing data-root
+	_ = os.WriteFile(filepath.Join(dockerConfigDir, "daemon.json"), []byte(`{"debug": true}`), 0600)
+	path = DockerDataRoot(tmpDir)
+	if path != DefaultDockerRootDir {
+		t.Errorf("expected default docker data root on missing data-root, got %q", path)
+	}
+
+	// Case 4: Config exists, valid JSON, custom data-root
+	_ = os.WriteFile(filepath.Join(dockerConfigDir, "daemon.json"), []byte(`{"data-root": "/custom/docker/root"}`), 0600)
+	path = DockerDataRoot(tmpDir)
+	if path != "/custom/docker/root" {
+		t.Errorf("expected custom docker data root '/custom/docker/root', got %q", path)
+	}
+}
+
+func TestContainerdDataDir(t *testing.T) {
+	// Case 1: Config does not exist -> default
+	tmpDir := t.TempDir()
+	path := ContainerdDataDir(tmpDir)
+	if path != DefaultContainerdRootDir {
+		t.Errorf("expected default containerd root %q, got %q", DefaultContainerdRootDir, path)
+	}
+
+	// Case 2: Config exists but parsing fails (invalid TOML)
+	containerdConfigDir := filepath.Join(tmpDir, "etc", "containerd")
+	_ = os.MkdirAll(containerdConfigDir, 0755)
+	_ = os.WriteFile(filepath.Join(containerdConfigDir, "config.toml"), []byte("invalid-toml"), 0600)
+	path = ContainerdDataDir(tmpDir)
+	if path != DefaultContainerdRootDir {
+		t.Errorf("expected default containerd root on invalid TOML, got %q", path)
+	}
+
+	// Case 3: Config exists, valid TOML, but missing root
+	_ = os.WriteFile(filepath.Join(containerdConfigDir, "config.toml"), []byte(`version = 2`), 0600)
+	path = ContainerdDataDir(tmpDir)
+	if path != DefaultContainerdRootDir {
+		t.Errorf("expected default containerd root on missing root key, got %q", path)
+	}
+
+	// Case 4: Config exists, valid TOML, custom root
+	_ = os.WriteFile(filepath.Join(containerdConfigDir, "config.toml"), []byte(`root = "/custom/containerd/root"`), 0600)
+	path = ContainerdDataDir(tmpDir)
+	if path != "/custom/containerd/root" {
+		t.Errorf("expected custom containerd root '/custom/containerd/root', got %q", path)
+	}
+}
+
+func TestEngineOptions_LayerCache(t *testing.T) {
+	// The deprecated layer cache is the containerd layer-cache option
+	options := map[string]map[string]string{"docker": {"opt": "value"}}
+	got := engineOptions(Options{LayerCache: "layers", EngineOptions: options})
+	if got["containerd"]["layer-cache"] != "layers" || got["docker"]["opt"] != "value" {
+		t.Errorf("unexpected engine options %v", got)
+	}
+	if _, ok := options["containerd"]; ok {
+		t.Errorf("expected the engine options of the caller to be unchanged, got %v", options)
+	}
+
+	// The layer-cache option takes precedence
+	got = engineOptions(Options{LayerCache: "layers", EngineOptions: map[string]map[string]string{"containerd": {"layer-cache": "cache"}}})
+	if got["containerd"]["layer-cache"] != "cache" {
+		t.Errorf("expected the layer-cache option, got %v", got)
+	}
+
+	if got := engineOptions(Options{}); got != nil {
+		t.Errorf("expected no engine options, got %v", got)
+	}
+}
+
+func TestNew_Engines(t *testing.T) {
+	imageRoot := t.TempDir()
+	setupMockOverlay2Container(t, filepath.Join(imageRoot, "var", "lib", "docker"), "container-lib", nil)
+
+	// Only the detected engines are explored
+	session, err := New(Options{ImageRoot: imageRoot})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	var types []string
+	for _, xplr := range session.Explorers() {
+		types = append(types, xplr.Type())
+	}
+	session.Close()
+	if len(types) != 1 || types[0] != "docker" {
+		t.Errorf("expected the docker explorer only, got %v", types)
+	}
+
+	// A selected engine is explored without detection
+	session, err = New(Options{ImageRoot: imageRoot, Engines: []string{"podman"}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if len(session.Explorers()) != 1 || session.Explorers()[0].Type() != "podman" {
+		t.Errorf("expected the podman explorer only")
+	}
+	session.Close()
+
+	if _, err := New(Options{ImageRoot: imageRoot, Engines: []string{"cri-o"}}); err == nil {
+		t.Errorf("expected error for an unregistered engine")
+	}
+	if _, err := New(Options{ImageRoot: imageRoot, EngineOptions: map[string]map[string]string{"docker": {"layer-cache": "layers"}}}); err == nil {
+		t.Errorf("expected error for an unsupported engine option")
+	}
+}
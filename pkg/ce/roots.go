@@ -0,0 +1,115 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ce
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	containerdConfig "github.com/containerd/containerd/services/server/config"
+	dockerConfig "github.com/docker/docker/daemon/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultContainerdRootDir = "/var/lib/containerd"
+	DefaultDockerRootDir     = "/var/lib/docker"
+)
+
+// ResolveRoots returns the options with the docker and containerd root
+// directories that are not specified set using the image root or the other
+// root directory.
+func ResolveRoots(options Options) Options {
+	// Handle docker managed containers root.
+	if options.DockerRoot == "" {
+		if options.ImageRoot != "" {
+			dockerDataDir := DockerDataRoot(options.ImageRoot)
+			options.DockerRoot = filepath.Join(options.ImageRoot, strings.Replace(dockerDataDir, "/", "", 1))
+		} else if options.ContainerdRoot != "" {
+			parentDir := filepath.Dir(strings.TrimSuffix(options.ContainerdRoot, "/"))
+			options.DockerRoot = filepath.Join(parentDir, "docker")
+		}
+	}
+
+	// Handle containerd managed containers root.
+	if options.ContainerdRoot == "" {
+		if options.ImageRoot != "" {
+			containerdDataDir := ContainerdDataDir(options.ImageRoot)
+			options.ContainerdRoot = filepath.Join(options.ImageRoot, strings.Replace(containerdDataDir, "/", "", 1))
+		} else if options.DockerRoot != "" {
+			parentDir := filepath.Dir(strings.TrimSuffix(options.DockerRoot, "/"))
+			options.ContainerdRoot = filepath.Join(parentDir, "containerd")
+		}
+	}
+	return options
+}
+
+// DockerDataRoot returns Docker data-root directory of an image root.
+// Returns custom path if configured, otherwise returns the default path.
+func DockerDataRoot(imageRootDir string) string {
+	configPath := filepath.Join(imageRootDir, "etc", "docker", "daemon.json")
+
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		log.WithFields(log.Fields{"configPath": configPath, "error": err}).Debug("reading docker config")
+		return DefaultDockerRootDir
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		log.WithFields(log.Fields{"configPath": configPath, "error": err}).Debug("reading docker config file")
+		return DefaultDockerRootDir
+	}
+
+	var cfg dockerConfig.Config
+
+	err = json.Unmarshal(data, &cfg)
+	if err != nil {
+		log.WithFields(log.Fields{"configPath": configPath, "error": err}).Debug("unmarshalling docker config")
+		return DefaultDockerRootDir
+	}
+
+	if cfg.Root == "" {
+		return DefaultDockerRootDir
+	}
+
+	return cfg.Root
+}
+
+// ContainerdDataDir returns containerd root directory of an image root.
+// Returns custom path if configured, otherwise returns the default path.
+func ContainerdDataDir(imageRootDir string) string {
+	configPath := filepath.Join(imageRootDir, "etc", "containerd", "config.toml")
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		log.WithFields(log.Fields{"configPath": configPath, "error": err}).Debug("reading containerd config")
+		return DefaultContainerdRootDir
+	}
+
+	var cfg containerdConfig.Config
+
+	if err := containerdConfig.LoadConfig(configPath, &cfg); err != nil {
+		log.WithFields(log.Fields{"configPath": configPath, "error": err}).Debug("parsing containerd config")
+		return DefaultContainerdRootDir
+	}
+
+	if cfg.Root == "" {
+		return DefaultContainerdRootDir
+	}
+
+	return cfg.Root
+}
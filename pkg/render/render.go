@@ -0,0 +1,667 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package render writes the records of container-explorer in the table,
+// JSON, JSON lines, CSV, YAML, and Go template output formats.
+//
+// The records are written as they are produced, to stdout or to an output
+// file, so that they are not held in memory. Records sorted by a column are
+// written when the renderer is closed. The sqlite format adds the records to
+// a case database, see package casedb, and the timesketch format writes the
+// timeline events of the records, see package timesketch.
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/google/container-explorer/explorers"
+	"github.com/google/container-explorer/pkg/casedb"
+	"github.com/google/container-explorer/pkg/timesketch"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats.
+const (
+	FormatTable      = "table"      // a row per record with the selected columns
+	FormatJSON       = "json"       // a JSON array of the records
+	FormatJSONLine   = "json_line"  // a JSON object per line
+	FormatCSV        = "csv"        // a CSV header and a CSV row per record
+	FormatYAML       = "yaml"       // a YAML sequence of the records
+	FormatTemplate   = "template"   // a Go template executed for each record
+	FormatSQLite     = "sqlite"     // the tables of a case database in the output file
+	FormatTimesketch = "timesketch" // a Timesketch JSON object per line for each event of a record
+)
+
+// Formats are the output formats of the Format option.
+var Formats = []string{FormatTable, FormatJSON, FormatJSONLine, FormatCSV, FormatYAML, FormatSQLite, FormatTimesketch}
+
+// TimeLayout is the layout of the time values of the table and CSV formats.
+const TimeLayout = "2006-01-02T15:04:05Z"
+
+// tableFlushRows is the number of table rows aligned and written together
+// to an output file or a pipe.
+const tableFlushRows = 100
+
+// Column is a column of the table and CSV formats, also used to sort the
+// records.
+type Column[T any] struct {
+	Name   string      // header of the column, e.g. CONTAINER ID
+	Value  func(T) any // value of the column for a record
+	Hidden bool        // the column is shown only if selected by Options.Columns
+}
+
+// Options provides the output of a renderer.
+type Options struct {
+	Format     string   // output format, table if empty
+	Template   string   // Go template of each record, e.g. {{.ID}} {{.Image}}, that sets the template format
+	Columns    []string // columns of the table and CSV formats, all but the hidden columns if empty
+	SortBy     string   // column sorting the records, in descending order if prefixed with -
+	OutputFile string   // output file, or stdout if empty
+	SourceRoot string   // image root of the records in the sqlite and timesketch formats
+	Command    string   // command line of the run in the sqlite format
+}
+
+// layout is the structure of the records in the JSON and YAML formats.
+type layout int
+
+const (
+	layoutArray  layout = iota // an array of the records
+	layoutResult               // an explorers.Result of the records and the item errors
+	layoutRecord               // the records one after the other
+)
+
+// Renderer writes records in an output format. Renderers are safe for
+// concurrent use.
+//
+// The JSON format is an array of the records, or for NewResult an object
+// with the records in Items and the item errors in Errors, and for
+// NewRecord the record. The table format of NewRecord is a line per column.
+// The table rows written to a terminal or sorted are aligned together. The
+// rows written to an output file or a pipe are streamed and aligned in
+// blocks of 100 rows, so the column widths may change between blocks.
+// Records without columns are written as JSON in the table and CSV formats.
+type Renderer[T any] struct {
+	format     string
+	layout     layout
+	columns    []Column[T] // selected columns
+	sortBy     *Column[T]
+	descending bool
+	template   *template.Template
+	sourceRoot string
+
+	mu      sync.Mutex
+	w       io.Writer
+	file    *os.File // output file, or nil for stdout
+	tw      *tabwriter.Writer
+	blocks  bool // table rows are aligned and written in blocks
+	csv     *csv.Writer
+	db      *casedb.DB
+	indent  string // indentation of the JSON format, none in output files
+	count   int
+	records []T // records held until the renderer is closed to sort them
+	errors  []explorers.ItemError
+	err     error // first write error
+	closed  bool
+}
+
+// New returns a renderer of an array of records.
+func New[T any](options Options, columns []Column[T]) (*Renderer[T], error) {
+	return newRenderer(options, columns, layoutArray)
+}
+
+// NewResult returns a renderer of an explorers.Result of the records and of
+// the item errors added to the renderer.
+func NewResult[T any](options Options, columns []Column[T]) (*Renderer[T], error) {
+	return newRenderer(options, columns, layoutResult)
+}
+
+// NewRecord returns a renderer of the record of a command that outputs a
+// single record.
+func NewRecord[T any](options Options, columns []Column[T]) (*Renderer[T], error) {
+	return newRenderer(options, columns, layoutRecord)
+}
+
+// newRenderer returns a renderer of the layout, and writes the header of the
+// table and CSV formats.
+func newRenderer[T any](options Options, columns []Column[T], layout layout) (*Renderer[T], error) {
+	r := &Renderer[T]{
+		format:     strings.ToLower(options.Format),
+		layout:     layout,
+		sourceRoot: options.SourceRoot,
+		w:          os.Stdout,
+		indent:     " ",
+	}
+
+	switch {
+	case options.Template != "":
+		tmpl, err := template.New("format").Funcs(template.FuncMap{"json": toJSON}).Parse(options.Template)
+		if err != nil {
+			return nil, fmt.Errorf("parsing format template: %w", err)
+		}
+		r.format, r.template = FormatTemplate, tmpl
+	case r.format == "":
+		r.format = FormatTable
+	case r.format == "jsonl":
+		r.format = FormatJSONLine
+	case !slices.Contains(Formats, r.format):
+		return nil, fmt.Errorf("unsupported output format %s: use %s", options.Format, strings.Join(Formats, ", "))
+	}
+	if len(columns) == 0 && (r.format == FormatTable || r.format == FormatCSV) {
+		r.format = FormatJSON
+	}
+
+	var err error
+	if r.columns, err = selectColumns(columns, options.Columns); err != nil {
+		return nil, err
+	}
+	if options.SortBy != "" {
+		name, descending := strings.CutPrefix(options.SortBy, "-")
+		i := slices.IndexFunc(columns, func(c Column[T]) bool { return columnKey(c.Name) == columnKey(name) })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown sort column %s: use %s", name, columnNames(columns))
+		}
+		r.sortBy, r.descending = &columns[i], descending
+	}
+
+	if r.format == FormatSQLite {
+		var zero T
+		switch {
+		case options.OutputFile == "":
+			return nil, fmt.Errorf("the sqlite output format requires an output file")
+		case !casedb.Supported(zero):
+			return nil, fmt.Errorf("the sqlite output format is not supported for the records of this command")
+		}
+		if r.db, err = casedb.Open(options.OutputFile, options.Command); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+
+	if r.format == FormatTimesketch {
+		var zero T
+		if !timesketch.Supported(zero) {
+			return nil, fmt.Errorf("the timesketch output format is not supported for the records of this command")
+		}
+	}
+
+	if options.OutputFile != "" {
+		file, err := os.OpenFile(options.OutputFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("creating output file %s: %w", options.OutputFile, err)
+		}
+		r.w, r.file, r.indent = file, file, ""
+	}
+
+	switch r.format {
+	case FormatTable:
+		r.tw = tabwriter.NewWriter(r.w, 1, 8, 1, '\t', 0)
+		r.blocks = r.sortBy == nil && !isTerminal(r.w)
+		if layout != layoutRecord {
+			var header []string
+			for _, c := range r.columns {
+				header = append(header, c.Name)
+			}
+			r.write(r.tw, strings.Join(header, "\t")+"\n")
+		}
+	case FormatCSV:
+		r.csv = csv.NewWriter(r.w)
+		var header []string
+		for _, c := range r.columns {
+			header = append(header, c.Name)
+		}
+		r.writeCSV(header)
+	}
+	return r, nil
+}
+
+// selectColumns returns the columns selected by name, or the columns that
+// are not hidden if no names are selected.
+func selectColumns[T any](columns []Column[T], names []string) ([]Column[T], error) {
+	var selected []Column[T]
+	if len(names) == 0 {
+		for _, c := range columns {
+			if !c.Hidden {
+				selected = append(selected, c)
+			}
+		}
+		return selected, nil
+	}
+
+	for _, name := range names {
+		i := slices.IndexFunc(columns, func(c Column[T]) bool { return columnKey(c.Name) == columnKey(name) })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown column %s: use %s", name, columnNames(columns))
+		}
+		selected = append(selected, columns[i])
+	}
+	return selected, nil
+}
+
+// columnKey returns the name of a column matched by the Columns and SortBy
+// options, e.g. container-id for CONTAINER ID.
+func columnKey(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "-", "_", "-", "/", "-").Replace(name)
+}
+
+// columnNames returns the names of columns matched by the options.
+func columnNames[T any](columns []Column[T]) string {
+	var names []string
+	for _, c := range columns {
+		names = append(names, columnKey(c.Name))
+	}
+	return strings.Join(names, ", ")
+}
+
+// Write writes a record, or holds it until the renderer is closed if the
+// records are sorted. It returns the first write error of the renderer.
+func (r *Renderer[T]) Write(record T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return fmt.Errorf("writing to closed output")
+	}
+
+	if r.sortBy != nil {
+		r.records = append(r.records, record)
+		return nil
+	}
+	return r.writeRecord(record)
+}
+
+// writeRecord writes a record in the output format.
+func (r *Renderer[T]) writeRecord(record T) error {
+	switch r.format {
+	case FormatJSON:
+		data, err := r.marshal(record, r.itemIndent())
+		if err != nil {
+			return fmt.Errorf("marshaling to JSON: %w", err)
+		}
+		switch {
+		case r.layout == layoutRecord:
+			r.write(r.w, string(data)+"\n")
+		case r.count == 0:
+			r.write(r.w, r.openJSON()+r.itemIndent()+string(data))
+		default:
+			r.write(r.w, ","+r.newline()+r.itemIndent()+string(data))
+		}
+	case FormatJSONLine:
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("marshaling to json_line: %w", err)
+		}
+		r.write(r.w, string(data)+"\n")
+	case FormatYAML:
+		data, err := toYAML(record, r.layout != layoutRecord)
+		if err != nil {
+			return fmt.Errorf("marshaling to YAML: %w", err)
+		}
+		switch {
+		case r.layout == layoutRecord && r.count > 0:
+			r.write(r.w, "---\n"+data)
+		case r.layout == layoutResult && r.count == 0:
+			r.write(r.w, "Items:\n"+data)
+		default:
+			r.write(r.w, data)
+		}
+	case FormatCSV:
+		var values []string
+		for _, c := range r.columns {
+			values = append(values, formatValue(c.Value(record)))
+		}
+		r.writeCSV(values)
+	case FormatSQLite:
+		if err := r.db.Add(r.sourceRoot, record); err != nil && r.err == nil {
+			r.err = err
+		}
+	case FormatTimesketch:
+		for _, event := range timesketch.Events(r.sourceRoot, record) {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("marshaling to timesketch: %w", err)
+			}
+			r.write(r.w, string(data)+"\n")
+		}
+	case FormatTemplate:
+		var buf bytes.Buffer
+		if err := r.template.Execute(&buf, record); err != nil {
+			return fmt.Errorf("executing format template: %w", err)
+		}
+		r.write(r.w, buf.String()+"\n")
+	default:
+		if r.layout == layoutRecord {
+			for _, c := range r.columns {
+				if value := formatValue(c.Value(record)); value != "" {
+					r.write(r.tw, c.Name+":\t"+value+"\n")
+				}
+			}
+			break
+		}
+
+		var values []string
+		for _, c := range r.columns {
+			values = append(values, formatValue(c.Value(record)))
+		}
+		r.write(r.tw, strings.Join(values, "\t")+"\n")
+		if r.blocks && (r.count+1)%tableFlushRows == 0 {
+			r.flushTable()
+		}
+	}
+	r.count++
+	return r.err
+}
+
+// AddErrors adds item errors to the Errors of a result renderer.
+func (r *Renderer[T]) AddErrors(itemErrs ...explorers.ItemError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, itemErrs...)
+}
+
+// Errors returns the item errors added to the renderer.
+func (r *Renderer[T]) Errors() []explorers.ItemError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.errors
+}
+
+// Close writes the sorted records, completes the output, and closes the
+// output file. It returns the first write error of the renderer. Close can
+// be called more than once.
+func (r *Renderer[T]) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return r.err
+	}
+	r.closed = true
+
+	if r.sortBy != nil {
+		slices.SortStableFunc(r.records, func(a, b T) int {
+			if r.descending {
+				a, b = b, a
+			}
+			return compareValues(r.sortBy.Value(a), r.sortBy.Value(b))
+		})
+		for _, record := range r.records {
+			if err := r.writeRecord(record); err != nil {
+				break
+			}
+		}
+		r.records = nil
+	}
+
+	switch r.format {
+	case FormatJSON:
+		if r.layout != layoutRecord {
+			r.write(r.w, r.closeJSON())
+		}
+	case FormatYAML:
+		r.write(r.w, r.closeYAML())
+	case FormatCSV:
+		r.csv.Flush()
+		if err := r.csv.Error(); err != nil && r.err == nil {
+			r.err = fmt.Errorf("writing output: %w", err)
+		}
+	case FormatTable:
+		r.flushTable()
+	case FormatSQLite:
+		if err := r.db.AddErrors(r.sourceRoot, r.errors...); err != nil && r.err == nil {
+			r.err = err
+		}
+		if err := r.db.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+
+	if r.file != nil {
+		if err := r.file.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+	return r.err
+}
+
+// openJSON returns the start of the JSON format up to the first record.
+func (r *Renderer[T]) openJSON() string {
+	if r.layout == layoutResult {
+		return "{" + r.newline() + r.indent + `"Items":` + r.space() + "[" + r.newline()
+	}
+	return "[" + r.newline()
+}
+
+// closeJSON returns the end of the JSON format after the last record.
+func (r *Renderer[T]) closeJSON() string {
+	var start, end string
+	if r.count == 0 {
+		start = r.openJSON()
+	} else {
+		end = r.newline()
+	}
+	if r.layout != layoutResult {
+		return start + end + "]\n"
+	}
+
+	errs, err := r.marshal(r.errors, r.indent)
+	if err != nil {
+		errs = []byte("null")
+	}
+	return start + end + r.indent + "]," + r.newline() + r.indent + `"Errors":` + r.space() + string(errs) + r.newline() + "}\n"
+}
+
+// closeYAML returns the end of the YAML format after the last record.
+func (r *Renderer[T]) closeYAML() string {
+	switch r.layout {
+	case layoutArray:
+		if r.count == 0 {
+			return "[]\n"
+		}
+	case layoutResult:
+		var start string
+		if r.count == 0 {
+			start = "Items: []\n"
+		}
+		errs, err := toYAML(map[string][]explorers.ItemError{"Errors": r.errors}, false)
+		if err != nil {
+			errs = "Errors: null\n"
+		}
+		return start + errs
+	}
+	return ""
+}
+
+// itemIndent returns the indentation of the records in the JSON format.
+func (r *Renderer[T]) itemIndent() string {
+	switch r.layout {
+	case layoutRecord:
+		return ""
+	case layoutResult:
+		return r.indent + r.indent
+	}
+	return r.indent
+}
+
+// newline returns the line separator of the JSON format.
+func (r *Renderer[T]) newline() string {
+	if r.indent == "" {
+		return ""
+	}
+	return "\n"
+}
+
+// space returns the space after the keys of the JSON format.
+func (r *Renderer[T]) space() string {
+	if r.indent == "" {
+		return ""
+	}
+	return " "
+}
+
+// marshal returns the JSON of a value, indented with the prefix unless the
+// renderer writes to an output file.
+func (r *Renderer[T]) marshal(v any, prefix string) ([]byte, error) {
+	if r.indent == "" {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, prefix, r.indent)
+}
+
+// write writes a string and records the first write error.
+func (r *Renderer[T]) write(w io.Writer, text string) {
+	if r.err != nil {
+		return
+	}
+	if _, err := io.WriteString(w, text); err != nil {
+		r.err = fmt.Errorf("writing output: %w", err)
+	}
+}
+
+// writeCSV writes a CSV row and records the first write error.
+func (r *Renderer[T]) writeCSV(values []string) {
+	if r.err != nil {
+		return
+	}
+	if err := r.csv.Write(values); err != nil {
+		r.err = fmt.Errorf("writing output: %w", err)
+		return
+	}
+	r.csv.Flush()
+	if err := r.csv.Error(); err != nil {
+		r.err = fmt.Errorf("writing output: %w", err)
+	}
+}
+
+// flushTable writes the buffered table rows.
+func (r *Renderer[T]) flushTable() {
+	if err := r.tw.Flush(); err != nil && r.err == nil {
+		r.err = fmt.Errorf("writing output: %w", err)
+	}
+}
+
+// isTerminal returns true if the output is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// formatValue returns the text of a column value.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(TimeLayout)
+	}
+	return fmt.Sprint(v)
+}
+
+// compareValues compares column values: numbers by value, times in
+// chronological order, and other values by their text.
+func compareValues(a, b any) int {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Compare(tb)
+		}
+	}
+	if na, ok := number(a); ok {
+		if nb, ok := number(b); ok {
+			switch {
+			case na < nb:
+				return -1
+			case na > nb:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(formatValue(a), formatValue(b))
+}
+
+// number returns the value of a number.
+func number(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// toJSON returns the JSON of a value, for the json function of templates.
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// toYAML returns the YAML of a value with the keys and the order of its
+// JSON, as an item of a sequence if item is set.
+func toYAML(v any, item bool) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", err
+	}
+	node := doc.Content[0]
+	blockStyle(node)
+	if item {
+		node = &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{node}}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// blockStyle sets the default style of the nodes parsed from JSON, so that
+// they are written in the YAML block style.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/container-explorer/explorers"
+	"gopkg.in/yaml.v3"
+)
+
+type record struct {
+	Name    string    `yaml:"Name"`
+	Size    int       `yaml:"Size"`
+	Created time.Time `yaml:"Created"`
+}
+
+var columns = []Column[record]{
+	{Name: "NAME", Value: func(r record) any { return r.Name }},
+	{Name: "SIZE", Value: func(r record) any { return r.Size }},
+	{Name: "CREATED AT", Value: func(r record) any { return r.Created }, Hidden: true},
+}
+
+var records = []record{
+	{Name: "c1", Size: 10, Created: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+	{Name: "c2", Size: 9, Created: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+}
+
+// render writes records with a renderer and returns the output file.
+func render(t *testing.T, open func(Options, []Column[record]) (*Renderer[record], error), options Options, records ...record) string {
+	t.Helper()
+	options.OutputFile = filepath.Join(t.TempDir(), "output")
+	r, err := open(options, columns)
+	if err != nil {
+		t.Fatalf("opening renderer failed: %v", err)
+	}
+	for _, record := range records {
+		if err := r.Write(record); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	r.AddErrors(explorers.ItemError{ContainerType: "docker", Operation: "list", ID: "c3", Kind: explorers.ErrorKindNotFound})
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	data, err := os.ReadFile(options.OutputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRenderer_JSON(t *testing.T) {
+	var array []record
+	if err := json.Unmarshal([]byte(render(t, New, Options{Format: "json"}, records...)), &array); err != nil || !reflect.DeepEqual(array, records) {
+		t.Errorf("expected JSON array %v, got %v (%v)", records, array, err)
+	}
+	if output := render(t, New, Options{Format: "json"}); strings.TrimSpace(output) != "[]" {
+		t.Errorf("expected an empty JSON array, got %q", output)
+	}
+
+	var result explorers.Result[record]
+	if err := json.Unmarshal([]byte(render(t, NewResult, Options{Format: "json"}, records...)), &result); err != nil {
+		t.Fatalf("invalid JSON result: %v", err)
+	}
+	if !reflect.DeepEqual(result.Items, records) || len(result.Errors) != 1 || result.Errors[0].ID != "c3" {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	var single record
+	if err := json.Unmarshal([]byte(render(t, NewRecord, Options{Format: "json"}, records[0])), &single); err != nil || single != records[0] {
+		t.Errorf("expected JSON record %v, got %v (%v)", records[0], single, err)
+	}
+
+	for _, format := range []string{"json_line", "jsonl"} {
+		output := render(t, New, Options{Format: format}, records...)
+		if lines := strings.Split(strings.TrimSpace(output), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], `{"Name":"c1"`) {
+			t.Errorf("unexpected %s output %q", format, output)
+		}
+	}
+}
+
+func TestRenderer_Table(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(render(t, New, Options{}, records...)), "\n")
+	if len(lines) != 3 || lines[0] != "NAME\tSIZE" || lines[2] != "c2\t9" {
+		t.Errorf("unexpected table %q", lines)
+	}
+
+	// The rows of an output file are written in blocks as they are
+	// produced, and the sorted rows are aligned together.
+	var many []record
+	for i := range tableFlushRows + 1 {
+		many = append(many, record{Name: fmt.Sprintf("c%d", i)})
+	}
+	many[tableFlushRows].Name = "container-with-a-long-name"
+	lines = strings.Split(strings.TrimSpace(render(t, New, Options{}, many...)), "\n")
+	if len(lines) != len(many)+1 {
+		t.Errorf("expected %d table lines, got %d", len(many)+1, len(lines))
+	}
+	if lines[1] != "c0\t0" {
+		t.Errorf("expected the first block to be aligned apart, got %q", lines[1])
+	}
+	lines = strings.Split(strings.TrimSpace(render(t, New, Options{SortBy: "size"}, many...)), "\n")
+	if lines[1] != "c0\t\t\t\t0" {
+		t.Errorf("expected the sorted rows to be aligned together, got %q", lines[1])
+	}
+
+	// A record is a line per column with a value.
+	output := render(t, NewRecord, Options{Columns: []string{"name", "created-at"}}, record{Name: "c1"})
+	if expected := "NAME:\t\tc1\nCREATED AT:\t0001-01-01T00:00:00Z\n"; output != expected {
+		t.Errorf("unexpected record table %q", output)
+	}
+}
+
+func TestRenderer_CSV(t *testing.T) {
+	output := render(t, New, Options{Format: "csv", Columns: []string{"Created_At", "name"}}, records...)
+	rows, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV %q: %v", output, err)
+	}
+	expected := [][]string{{"CREATED AT", "NAME"}, {"2026-01-02T00:00:00Z", "c1"}, {"2026-01-01T00:00:00Z", "c2"}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected CSV %v, got %v", expected, rows)
+	}
+}
+
+func TestRenderer_YAML(t *testing.T) {
+	var array []record
+	output := render(t, New, Options{Format: "yaml"}, records...)
+	if err := yaml.Unmarshal([]byte(output), &array); err != nil || !reflect.DeepEqual(array, records) {
+		t.Errorf("expected YAML sequence %v, got %v from %q (%v)", records, array, output, err)
+	}
+	if !strings.HasPrefix(output, "- Name: c1\n  Size: 10\n") {
+		t.Errorf("expected the keys in the JSON order, got %q", output)
+	}
+	if output := render(t, New, Options{Format: "yaml"}); output != "[]\n" {
+		t.Errorf("expected an empty YAML sequence, got %q", output)
+	}
+
+	var result struct {
+		Items  []record         `yaml:"Items"`
+		Errors []map[string]any `yaml:"Errors"`
+	}
+	output = render(t, NewResult, Options{Format: "yaml"}, records...)
+	if err := yaml.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("invalid YAML result %q: %v", output, err)
+	}
+	if !reflect.DeepEqual(result.Items, records) || len(result.Errors) != 1 || result.Errors[0]["ID"] != "c3" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	output = render(t, NewResult, Options{Format: "yaml"})
+	if err := yaml.Unmarshal([]byte(output), &result); err != nil || len(result.Items) != 0 {
+		t.Errorf("expected an empty YAML result, got %q (%v)", output, err)
+	}
+
+	// Strings that look like other types stay strings.
+	var quoted []record
+	output = render(t, New, Options{Format: "yaml"}, record{Name: "true"}, record{Name: "10"})
+	if err := yaml.Unmarshal([]byte(output), &quoted); err != nil || quoted[0].Name != "true" || quoted[1].Name != "10" {
+		t.Errorf("expected quoted strings, got %q (%v)", output, err)
+	}
+}
+
+func TestRenderer_Template(t *testing.T) {
+	output := render(t, New, Options{Format: "table", Template: "{{.Name}} {{.Size}} {{json .Name}}"}, records...)
+	if output != "c1 10 \"c1\"\nc2 9 \"c2\"\n" {
+		t.Errorf("unexpected template output %q", output)
+	}
+	if _, err := New(Options{Template: "{{.Name"}, columns); err == nil {
+		t.Errorf("expected error for an invalid template")
+	}
+}
+
+func TestRenderer_SortBy(t *testing.T) {
+	tests := []struct {
+		sortBy   string
+		expected string
+	}{
+		{sortBy: "size", expected: "c2 c1 "},
+		{sortBy: "-SIZE", expected: "c1 c2 "},
+		{sortBy: "created_at", expected: "c2 c1 "},
+		{sortBy: "-name", expected: "c2 c1 "},
+	}
+	for _, tt := range tests {
+		output := render(t, New, Options{Template: "{{.Name}}", SortBy: tt.sortBy}, records...)
+		if got := strings.ReplaceAll(output, "\n", " "); got != tt.expected {
+			t.Errorf("sort by %s: expected %q, got %q", tt.sortBy, tt.expected, got)
+		}
+	}
+
+	// Numbers are compared by value.
+	many := []record{{Name: "a", Size: 100}, {Name: "b", Size: 20}, {Name: "c", Size: 3}}
+	if output := render(t, New, Options{Template: "{{.Name}}", SortBy: "size"}, many...); output != "c\nb\na\n" {
+		t.Errorf("expected numeric order, got %q", output)
+	}
+}
+
+func TestRenderer_Errors(t *testing.T) {
+	if _, err := New(Options{Format: "xml"}, columns); err == nil {
+		t.Errorf("expected error for an unsupported format")
+	}
+	if _, err := New(Options{Columns: []string{"missing"}}, columns); err == nil {
+		t.Errorf("expected error for an unknown column")
+	}
+	if _, err := New(Options{SortBy: "missing"}, columns); err == nil {
+		t.Errorf("expected error for an unknown sort column")
+	}
+	if _, err := New(Options{OutputFile: filepath.Join(t.TempDir(), "missing", "output")}, columns); err == nil {
+		t.Errorf("expected error for an output file in a missing directory")
+	}
+
+	// Records without columns are written as JSON.
+	r, err := New[record](Options{Format: "csv", OutputFile: filepath.Join(t.TempDir(), "output")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.format != FormatJSON {
+		t.Errorf("expected the json format without columns, got %s", r.format)
+	}
+	r.Close()
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package timesketch converts the records of container-explorer to timeline
+// events that Timesketch imports from JSONL files.
+//
+// A record has an event for each of its times that is set: the created,
+// updated, started, and finished times of containers, the pull time of images
+// and content, the creation time of snapshots, and the MACB times of the
+// drifted files. The timestamp descriptions follow the Plaso ones.
+package timesketch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/container-explorer/explorers"
+)
+
+// Timestamp descriptions of the events.
+const (
+	DescCreation     = "Creation Time"
+	DescModification = "Modification Time"
+	DescStart        = "Start Time"
+	DescEnd          = "End Time"
+	DescOOMKill      = "OOM Kill Time"
+	DescPull         = "Pull Time"
+
+	DescContentModification  = "Content Modification Time"
+	DescLastAccess           = "Last Access Time"
+	DescMetadataModification = "Metadata Modification Time"
+)
+
+// Data types of the events.
+const (
+	DataTypeContainer = "container:lifecycle"
+	DataTypeImage     = "container:image"
+	DataTypeContent   = "container:content"
+	DataTypeSnapshot  = "container:snapshot"
+	DataTypeDrift     = "container:drift:file"
+)
+
+// Event is a timeline event in the Timesketch JSONL format. The message,
+// datetime, and timestamp_desc fields are required by Timesketch; the other
+// fields are attributes of the event.
+type Event struct {
+	Message       string `json:"message"`
+	Datetime      string `json:"datetime"`
+	Timestamp     int64  `json:"timestamp"` // microseconds since the Unix epoch
+	TimestampDesc string `json:"timestamp_desc"`
+	DataType      string `json:"data_type"`
+
+	SourceRoot    string `json:"source_root,omitempty"`
+	ContainerType string `json:"container_type,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	ContainerID   string `json:"container_id,omitempty"`
+	ContainerName string `json:"container_name,omitempty"`
+	Image         string `json:"image,omitempty"`
+	Digest        string `json:"digest,omitempty"`
+	SnapshotKey   string `json:"snapshot_key,omitempty"`
+	Snapshotter   string `json:"snapshotter,omitempty"`
+	Path          string `json:"path,omitempty"`
+	FileType      string `json:"file_type,omitempty"`
+	Size          int64  `json:"size,omitempty"`
+	SHA256        string `json:"sha256,omitempty"`
+	Change        string `json:"change,omitempty"`
+	Status        string `json:"status,omitempty"`
+	OOMKilled     bool   `json:"oom_killed,omitempty"`
+}
+
+// Supported reports whether records of the type of record have events.
+func Supported(record any) bool {
+	switch record.(type) {
+	case explorers.Container, explorers.Image, explorers.Content, explorers.SnapshotKeyInfo, explorers.Drift:
+		return true
+	}
+	return false
+}
+
+// Events returns the events of a record of an image root, or none if the
+// record is not supported.
+func Events(sourceRoot string, record any) []Event {
+	tl := timeline{sourceRoot: sourceRoot}
+	switch r := record.(type) {
+	case explorers.Container:
+		attributes := Event{
+			ContainerType: r.ContainerType,
+			Namespace:     r.Namespace,
+			ContainerID:   r.ID,
+			ContainerName: r.Name,
+			Image:         r.Image,
+			Status:        r.Status,
+			OOMKilled:     r.OOMKilled,
+		}
+		name := containerName(r)
+		tl.add(r.CreatedAt, DescCreation, DataTypeContainer, fmt.Sprintf("%s created from image %s", name, r.Image), attributes)
+		tl.add(r.UpdatedAt, DescModification, DataTypeContainer, fmt.Sprintf("%s updated", name), attributes)
+		tl.add(r.StartedAt, DescStart, DataTypeContainer, fmt.Sprintf("%s started", name), attributes)
+		if r.OOMKilled {
+			tl.add(r.FinishedAt, DescOOMKill, DataTypeContainer, fmt.Sprintf("%s killed out of memory", name), attributes)
+		} else {
+			tl.add(r.FinishedAt, DescEnd, DataTypeContainer, fmt.Sprintf("%s finished", name), attributes)
+		}
+	case explorers.Image:
+		attributes := Event{
+			ContainerType: r.ContainerType,
+			Namespace:     r.Namespace,
+			Image:         r.Name,
+			Digest:        r.Target.Digest.String(),
+			Size:          r.Target.Size,
+		}
+		tl.add(r.CreatedAt, DescPull, DataTypeImage, fmt.Sprintf("%s image %s pulled", r.ContainerType, r.Name), attributes)
+		tl.add(r.UpdatedAt, DescModification, DataTypeImage, fmt.Sprintf("%s image %s updated", r.ContainerType, r.Name), attributes)
+	case explorers.Content:
+		attributes := Event{
+			ContainerType: r.ContainerType,
+			Namespace:     r.Namespace,
+			Digest:        r.Digest.String(),
+			Size:          r.Size,
+		}
+		tl.add(r.CreatedAt, DescPull, DataTypeContent, fmt.Sprintf("%s content %s pulled", r.ContainerType, r.Digest), attributes)
+	case explorers.SnapshotKeyInfo:
+		attributes := Event{
+			ContainerType: r.ContainerType,
+			Namespace:     r.Namespace,
+			SnapshotKey:   r.Key,
+			Snapshotter:   r.Snapshotter,
+			Path:          r.OverlayPath,
+		}
+		tl.add(r.CreatedAt, DescCreation, DataTypeSnapshot, fmt.Sprintf("%s snapshot %s created", r.ContainerType, r.Key), attributes)
+	case explorers.Drift:
+		for _, f := range r.AddedOrModified {
+			tl.addFile(r, f, "added_or_modified")
+		}
+		for _, f := range r.InaccessibleFiles {
+			tl.addFile(r, f, "inaccessible")
+		}
+	}
+	return tl.events
+}
+
+// timeline collects the events of a record.
+type timeline struct {
+	sourceRoot string
+	events     []Event
+}
+
+// add adds an event with the attributes at a time, unless the time is zero.
+func (tl *timeline) add(t time.Time, desc string, dataType string, message string, attributes Event) {
+	if t.IsZero() {
+		return
+	}
+	attributes.Message = message
+	attributes.Datetime = t.UTC().Format(time.RFC3339Nano)
+	attributes.Timestamp = t.UnixMicro()
+	attributes.TimestampDesc = desc
+	attributes.DataType = dataType
+	attributes.SourceRoot = tl.sourceRoot
+	tl.events = append(tl.events, attributes)
+}
+
+// addFile adds the MACB events of a drifted file.
+func (tl *timeline) addFile(drift explorers.Drift, f explorers.FileInfo, change string) {
+	attributes := Event{
+		ContainerType: drift.ContainerType,
+		ContainerID:   drift.ContainerID,
+		Path:          f.FullPath,
+		FileType:      f.FileType,
+		Size:          f.FileSize,
+		SHA256:        f.FileSHA256,
+		Change:        change,
+	}
+	message := fmt.Sprintf("%s container %s file %s %s", drift.ContainerType, drift.ContainerID, f.FullPath, change)
+	tl.add(f.FileModified, DescContentModification, DataTypeDrift, message, attributes)
+	tl.add(f.FileAccessed, DescLastAccess, DataTypeDrift, message, attributes)
+	tl.add(f.FileChanged, DescMetadataModification, DataTypeDrift, message, attributes)
+	tl.add(f.FileBirth, DescCreation, DataTypeDrift, message, attributes)
+}
+
+// containerName returns the name of a container in event messages.
+func containerName(c explorers.Container) string {
+	name := fmt.Sprintf("%s container %s", c.ContainerType, c.ID)
+	if c.Name != "" {
+		name = fmt.Sprintf("%s (%s)", name, c.Name)
+	}
+	return name
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timesketch
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/images"
+	"github.com/google/container-explorer/explorers"
+	digest "github.com/opencontainers/go-digest"
+)
+
+func TestEvents(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+	started := created.Add(time.Minute)
+	finished := created.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		record any
+		descs  []string
+	}{
+		{
+			name: "docker container",
+			record: explorers.Container{
+				ContainerType: "docker",
+				Name:          "web",
+				Container:     containers.Container{ID: "c1", Image: "nginx", CreatedAt: created},
+				StartedAt:     started,
+				FinishedAt:    finished,
+			},
+			descs: []string{DescCreation, DescStart, DescEnd},
+		},
+		{
+			name: "OOM-killed container",
+			record: explorers.Container{
+				ContainerType: "podman",
+				Container:     containers.Container{ID: "c2", CreatedAt: created},
+				FinishedAt:    finished,
+				OOMKilled:     true,
+			},
+			descs: []string{DescCreation, DescOOMKill},
+		},
+		{
+			name:   "containerd container",
+			record: explorers.Container{ContainerType: "containerd", Container: containers.Container{ID: "c3", CreatedAt: created, UpdatedAt: finished}},
+			descs:  []string{DescCreation, DescModification},
+		},
+		{
+			name:   "image",
+			record: explorers.Image{ContainerType: "containerd", Image: images.Image{Name: "nginx", CreatedAt: created}},
+			descs:  []string{DescPull},
+		},
+		{
+			name:   "content",
+			record: explorers.Content{ContainerType: "containerd", Info: content.Info{Digest: digest.FromString("blob"), CreatedAt: created}},
+			descs:  []string{DescPull},
+		},
+		{
+			name:   "snapshot",
+			record: explorers.SnapshotKeyInfo{ContainerType: "containerd", Key: "k1", CreatedAt: created},
+			descs:  []string{DescCreation},
+		},
+		{
+			name: "drift",
+			record: explorers.Drift{ContainerType: "docker", ContainerID: "c1", AddedOrModified: []explorers.FileInfo{
+				{FullPath: "/tmp/a.sh", FileModified: created, FileAccessed: started, FileChanged: finished, FileBirth: created},
+			}},
+			descs: []string{DescContentModification, DescLastAccess, DescMetadataModification, DescCreation},
+		},
+		{
+			name:   "unsupported record",
+			record: explorers.Task{Name: "c1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if Supported(tt.record) != (tt.descs != nil) {
+				t.Errorf("expected Supported %t", tt.descs != nil)
+			}
+			events := Events("/mnt/node-a", tt.record)
+			if len(events) != len(tt.descs) {
+				t.Fatalf("expected %d events, got %+v", len(tt.descs), events)
+			}
+			for i, event := range events {
+				if event.TimestampDesc != tt.descs[i] {
+					t.Errorf("expected event %d %s, got %s", i, tt.descs[i], event.TimestampDesc)
+				}
+				if event.Message == "" || event.DataType == "" || event.SourceRoot != "/mnt/node-a" {
+					t.Errorf("expected message, data type, and source root, got %+v", event)
+				}
+			}
+		})
+	}
+
+	// The required Timesketch fields are set.
+	events := Events("/", tests[0].record)
+	data, err := json.Marshal(events[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["datetime"] != "2026-01-02T03:04:05.000006Z" || fields["timestamp"] != float64(created.UnixMicro()) ||
+		fields["timestamp_desc"] != DescCreation || fields["message"] != "docker container c1 (web) created from image nginx" ||
+		fields["container_id"] != "c1" {
+		t.Errorf("unexpected event fields %v", fields)
+	}
+}
@@ -0,0 +1,397 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+	paxXattrPrefix = "SCHILY.xattr."
+)
+
+// overlayXattrPrefixes are the xattrs used internally by overlayfs.
+var overlayXattrPrefixes = []string{"trusted.overlay.", "user.overlay."}
+
+// ArchiveEntry describes a file written to an archive.
+type ArchiveEntry struct {
+	Path     string            `json:"path"`
+	Type     string            `json:"type"`
+	Mode     string            `json:"mode"`
+	UID      int               `json:"uid"`
+	GID      int               `json:"gid"`
+	Size     int64             `json:"size"`
+	ModTime  time.Time         `json:"mtime"`
+	SHA256   string            `json:"sha256,omitempty"`
+	Linkname string            `json:"linkname,omitempty"`
+	Devmajor int64             `json:"devmajor,omitempty"`
+	Devminor int64             `json:"devminor,omitempty"`
+	Sparse   bool              `json:"sparse,omitempty"`
+	Xattrs   map[string][]byte `json:"xattrs,omitempty"`
+}
+
+// WriteLayer writes the content of an overlay layer directory as an
+// uncompressed tar layer.
+//
+// Overlay whiteouts, i.e. character devices 0/0, are written as .wh.<name>
+// files and opaque directories are marked using .wh..wh..opq as described in
+// the OCI image layer specification. Entries are written in lexical order.
+func WriteLayer(w io.Writer, dir string) error {
+	tw := newTreeWriter(w, false)
+	if err := tw.writeTree(dir); err != nil {
+		return err
+	}
+	return tw.tw.Close()
+}
+
+// WriteArchive writes the content of a directory as an uncompressed PAX tar
+// archive and returns the written entries.
+//
+// Ownership, extended attributes, device nodes, and hard links are kept, and
+// sparse files are written in the PAX 1.0 sparse format. Entries are written
+// in lexical order and the SHA256 of each regular file is recorded.
+func WriteArchive(w io.Writer, dir string) ([]ArchiveEntry, error) {
+	tw := newTreeWriter(w, true)
+	if err := tw.writeTree(dir); err != nil {
+		return nil, err
+	}
+	if err := tw.tw.Close(); err != nil {
+		return nil, err
+	}
+	return tw.entries, nil
+}
+
+// treeWriter writes directory entries to a tar stream.
+//
+// In layer mode, overlay whiteouts are converted to OCI whiteouts. In archive
+// mode, character devices are kept, sparse files keep their holes, and the
+// written entries are recorded.
+type treeWriter struct {
+	w         io.Writer
+	tw        *tar.Writer
+	archive   bool
+	hardlinks map[[2]uint64]string // device and inode to the first entry name
+	entries   []ArchiveEntry
+}
+
+func newTreeWriter(w io.Writer, archive bool) *treeWriter {
+	return &treeWriter{
+		w:         w,
+		tw:        tar.NewWriter(w),
+		archive:   archive,
+		hardlinks: make(map[[2]uint64]string),
+	}
+}
+
+// writeTree walks the directory in lexical order and writes every entry.
+func (t *treeWriter) writeTree(dir string) error {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return fmt.Errorf("resolving directory %s: %w", dir, err)
+	}
+
+	if err := filepath.WalkDir(root, func(p string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		return t.addEntry(p, filepath.ToSlash(rel))
+	}); err != nil {
+		return fmt.Errorf("writing %s: %w", dir, err)
+	}
+	return nil
+}
+
+// addEntry writes a file, directory, link, or device to the tar stream.
+func (t *treeWriter) addEntry(p string, name string) error {
+	info, err := os.Lstat(p)
+	if err != nil {
+		return err
+	}
+	stat, _ := info.Sys().(*syscall.Stat_t)
+
+	if info.Mode()&fs.ModeSocket != 0 {
+		log.WithField("path", p).Debug("skipping socket")
+		return nil
+	}
+
+	// Overlay whiteout
+	if !t.archive && info.Mode()&fs.ModeCharDevice != 0 && stat != nil && stat.Rdev == 0 {
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     path.Join(path.Dir(name), whiteoutPrefix+path.Base(name)),
+			Uid:      int(stat.Uid),
+			Gid:      int(stat.Gid),
+			ModTime:  info.ModTime(),
+			Format:   tar.FormatPAX,
+		}
+		return t.tw.WriteHeader(hdr)
+	}
+
+	var linkname string
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if linkname, err = os.Readlink(p); err != nil {
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, linkname)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	hdr.Format = tar.FormatPAX
+
+	// Host user and group names do not apply to container files. Access and
+	// change times vary between reads and are not kept.
+	hdr.Uname = ""
+	hdr.Gname = ""
+	hdr.AccessTime = time.Time{}
+	hdr.ChangeTime = time.Time{}
+
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	if hdr.Typeflag == tar.TypeReg && stat != nil && stat.Nlink > 1 {
+		key := [2]uint64{uint64(stat.Dev), stat.Ino}
+		if first, ok := t.hardlinks[key]; ok {
+			hdr.Typeflag = tar.TypeLink
+			hdr.Linkname = first
+			hdr.Size = 0
+		} else {
+			t.hardlinks[key] = name
+		}
+	}
+
+	xattrs, err := readXattrs(p)
+	if err != nil {
+		log.WithFields(log.Fields{"path": p, "error": err}).Warn("reading extended attributes")
+	}
+
+	opaque := false
+	for attr, value := range xattrs {
+		if isOverlayXattr(attr) {
+			if strings.HasSuffix(attr, ".opaque") && value == "y" {
+				opaque = true
+			}
+			continue
+		}
+		if hdr.PAXRecords == nil {
+			hdr.PAXRecords = make(map[string]string)
+		}
+		hdr.PAXRecords[paxXattrPrefix+attr] = value
+	}
+
+	var (
+		digest string
+		sparse bool
+	)
+	if hdr.Typeflag == tar.TypeReg && hdr.Size > 0 {
+		if digest, sparse, err = t.writeFile(p, hdr, stat); err != nil {
+			return err
+		}
+	} else {
+		if err := t.tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			digest = hex.EncodeToString(sha256.New().Sum(nil))
+		}
+	}
+	t.record(hdr, digest, sparse)
+
+	if !t.archive && info.IsDir() && opaque {
+		return t.tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     path.Join(name, whiteoutOpaque),
+			Uid:      hdr.Uid,
+			Gid:      hdr.Gid,
+			ModTime:  hdr.ModTime,
+			Format:   tar.FormatPAX,
+		})
+	}
+	return nil
+}
+
+// writeFile writes a regular file and returns the SHA256 of its content and
+// whether it was written as a sparse file.
+func (t *treeWriter) writeFile(p string, hdr *tar.Header, stat *syscall.Stat_t) (string, bool, error) {
+	//nolint:gosec // G304: Path is within the exported directory
+	f, err := os.Open(p)
+	if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+
+	// A file using fewer blocks than its size has holes.
+	if t.archive && stat != nil && stat.Blocks*512 < hdr.Size {
+		regions, err := dataRegions(f, hdr.Size)
+		if err != nil {
+			log.WithFields(log.Fields{"path": p, "error": err}).Debug("reading sparse file regions")
+		}
+		if err == nil && regions != nil {
+			if err := t.writeSparse(f, hdr, regions, h); err != nil {
+				return "", false, fmt.Errorf("copying sparse file %s: %w", p, err)
+			}
+			return hex.EncodeToString(h.Sum(nil)), true, nil
+		}
+	}
+
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return "", false, err
+	}
+	if _, err := io.CopyN(io.MultiWriter(t.tw, h), f, hdr.Size); err != nil {
+		return "", false, fmt.Errorf("copying %s: %w", p, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), false, nil
+}
+
+// record adds a written entry to the archive entries.
+func (t *treeWriter) record(hdr *tar.Header, digest string, sparse bool) {
+	if !t.archive {
+		return
+	}
+
+	entry := ArchiveEntry{
+		Path:     hdr.Name,
+		Type:     entryType(hdr.Typeflag),
+		Mode:     fmt.Sprintf("%04o", hdr.Mode&0o7777),
+		UID:      hdr.Uid,
+		GID:      hdr.Gid,
+		Size:     hdr.Size,
+		ModTime:  hdr.ModTime.UTC(),
+		SHA256:   digest,
+		Linkname: hdr.Linkname,
+		Devmajor: hdr.Devmajor,
+		Devminor: hdr.Devminor,
+		Sparse:   sparse,
+	}
+	for key, value := range hdr.PAXRecords {
+		if entry.Xattrs == nil {
+			entry.Xattrs = make(map[string][]byte)
+		}
+		entry.Xattrs[strings.TrimPrefix(key, paxXattrPrefix)] = []byte(value)
+	}
+	t.entries = append(t.entries, entry)
+}
+
+// entryType returns the manifest type of a tar entry.
+func entryType(typeflag byte) string {
+	switch typeflag {
+	case tar.TypeReg:
+		return "file"
+	case tar.TypeDir:
+		return "dir"
+	case tar.TypeSymlink:
+		return "symlink"
+	case tar.TypeLink:
+		return "hardlink"
+	case tar.TypeChar:
+		return "char"
+	case tar.TypeBlock:
+		return "block"
+	case tar.TypeFifo:
+		return "fifo"
+	default:
+		return string(typeflag)
+	}
+}
+
+// readXattrs returns the extended attributes of a file without following
+// symbolic links.
+func readXattrs(p string) (map[string]string, error) {
+	size, err := unix.Llistxattr(p, nil)
+	if err != nil || size == 0 {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	buf := make([]byte, size)
+	size, err = unix.Llistxattr(p, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	xattrs := make(map[string]string)
+	for _, attr := range strings.Split(strings.TrimRight(string(buf[:size]), "\x00"), "\x00") {
+		if attr == "" {
+			continue
+		}
+
+		vsize, err := unix.Lgetxattr(p, attr, nil)
+		if err != nil {
+			return xattrs, fmt.Errorf("reading xattr %s: %w", attr, err)
+		}
+		value := make([]byte, vsize)
+		vsize, err = unix.Lgetxattr(p, attr, value)
+		if err != nil {
+			return xattrs, fmt.Errorf("reading xattr %s: %w", attr, err)
+		}
+		xattrs[attr] = string(value[:vsize])
+	}
+	return xattrs, nil
+}
+
+// isOverlayXattr returns true for the xattrs used internally by overlayfs.
+func isOverlayXattr(attr string) bool {
+	for _, prefix := range overlayXattrPrefixes {
+		if strings.HasPrefix(attr, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashZeros writes n zero bytes to the hash.
+func hashZeros(h hash.Hash, n int64) {
+	var zeros [32 * 1024]byte
+	for n > 0 {
+		chunk := min(n, int64(len(zeros)))
+		h.Write(zeros[:chunk])
+		n -= chunk
+	}
+}
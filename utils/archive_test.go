@@ -0,0 +1,278 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// readLayer returns the tar headers of a layer in archive order.
+func readLayer(t *testing.T, data []byte) []*tar.Header {
+	t.Helper()
+
+	var headers []*tar.Header
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read layer: %v", err)
+		}
+		headers = append(headers, hdr)
+	}
+	return headers
+}
+
+func TestWriteLayer(t *testing.T) {
+	dir := t.TempDir()
+
+	_ = os.MkdirAll(filepath.Join(dir, "etc"), 0755)
+	_ = os.WriteFile(filepath.Join(dir, "etc", "passwd"), []byte("root:x:0:0"), 0644)
+	_ = os.Link(filepath.Join(dir, "etc", "passwd"), filepath.Join(dir, "etc", "passwd.link"))
+	_ = os.Symlink("etc/passwd", filepath.Join(dir, "passwd"))
+
+	var buf bytes.Buffer
+	if err := WriteLayer(&buf, dir); err != nil {
+		t.Fatalf("WriteLayer failed: %v", err)
+	}
+
+	headers := readLayer(t, buf.Bytes())
+
+	expected := []struct {
+		name     string
+		typeflag byte
+		linkname string
+	}{
+		{"etc/", tar.TypeDir, ""},
+		{"etc/passwd", tar.TypeReg, ""},
+		{"etc/passwd.link", tar.TypeLink, "etc/passwd"},
+		{"passwd", tar.TypeSymlink, "etc/passwd"},
+	}
+	if len(headers) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(headers))
+	}
+	for i, e := range expected {
+		hdr := headers[i]
+		if hdr.Name != e.name || hdr.Typeflag != e.typeflag || hdr.Linkname != e.linkname {
+			t.Errorf("entry %d: expected %s (%c) -> %q, got %s (%c) -> %q", i, e.name, e.typeflag, e.linkname, hdr.Name, hdr.Typeflag, hdr.Linkname)
+		}
+		if hdr.Uname != "" || hdr.Gname != "" {
+			t.Errorf("entry %s: expected no user and group names, got %q and %q", hdr.Name, hdr.Uname, hdr.Gname)
+		}
+	}
+	if headers[1].Size != int64(len("root:x:0:0")) {
+		t.Errorf("expected etc/passwd size %d, got %d", len("root:x:0:0"), headers[1].Size)
+	}
+}
+
+func TestWriteLayer_Whiteouts(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := unix.Mknod(filepath.Join(dir, "deleted"), unix.S_IFCHR|0000, 0); err != nil {
+		t.Skipf("creating whiteout requires privileges: %v", err)
+	}
+
+	opaqueDir := filepath.Join(dir, "opaque")
+	_ = os.MkdirAll(opaqueDir, 0755)
+	opaque := unix.Setxattr(opaqueDir, "trusted.overlay.opaque", []byte("y"), 0) == nil
+
+	var buf bytes.Buffer
+	if err := WriteLayer(&buf, dir); err != nil {
+		t.Fatalf("WriteLayer failed: %v", err)
+	}
+
+	names := make(map[string]*tar.Header)
+	for _, hdr := range readLayer(t, buf.Bytes()) {
+		names[hdr.Name] = hdr
+	}
+
+	hdr, ok := names[".wh.deleted"]
+	if !ok {
+		t.Fatalf("expected whiteout .wh.deleted, got %v", names)
+	}
+	if hdr.Typeflag != tar.TypeReg || hdr.Size != 0 {
+		t.Errorf("expected empty regular whiteout file, got type %c and size %d", hdr.Typeflag, hdr.Size)
+	}
+	if _, ok := names["deleted"]; ok {
+		t.Errorf("expected character device not to be written")
+	}
+
+	if !opaque {
+		t.Log("skipping opaque directory check: trusted xattrs not supported")
+		return
+	}
+	if _, ok := names["opaque/.wh..wh..opq"]; !ok {
+		t.Errorf("expected opaque marker opaque/.wh..wh..opq, got %v", names)
+	}
+	for key := range names["opaque/"].PAXRecords {
+		if strings.HasPrefix(key, paxXattrPrefix) && isOverlayXattr(strings.TrimPrefix(key, paxXattrPrefix)) {
+			t.Errorf("expected overlay xattr %s not to be written", key)
+		}
+	}
+}
+
+func TestWriteLayer_MissingDirectory(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLayer(&buf, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("expected error for missing directory")
+	}
+}
+
+func TestWriteArchive(t *testing.T) {
+	dir := t.TempDir()
+
+	_ = os.WriteFile(filepath.Join(dir, "b"), []byte("b"), 0600)
+	_ = os.WriteFile(filepath.Join(dir, "a"), []byte("a"), 0644)
+	_ = os.Link(filepath.Join(dir, "a"), filepath.Join(dir, "c"))
+	xattr := unix.Setxattr(filepath.Join(dir, "a"), "user.test", []byte("value"), 0) == nil
+	device := unix.Mknod(filepath.Join(dir, "null"), unix.S_IFCHR|0666, int(unix.Mkdev(1, 3))) == nil
+
+	// A 1 MiB file with data in the middle
+	sparsePath := filepath.Join(dir, "sparse")
+	f, _ := os.Create(sparsePath)
+	_ = f.Truncate(1 << 20)
+	_, _ = f.WriteAt([]byte("data"), 512*1024)
+	f.Close()
+
+	var buf bytes.Buffer
+	entries, err := WriteArchive(&buf, dir)
+	if err != nil {
+		t.Fatalf("WriteArchive failed: %v", err)
+	}
+
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read archive: %v", err)
+		}
+		names = append(names, hdr.Name)
+
+		switch hdr.Name {
+		case "a":
+			if xattr && hdr.PAXRecords[paxXattrPrefix+"user.test"] != "value" {
+				t.Errorf("expected user.test xattr, got %v", hdr.PAXRecords)
+			}
+		case "c":
+			if hdr.Typeflag != tar.TypeLink || hdr.Linkname != "a" {
+				t.Errorf("expected hardlink to a, got %c -> %s", hdr.Typeflag, hdr.Linkname)
+			}
+		case "null":
+			if hdr.Typeflag != tar.TypeChar || hdr.Devmajor != 1 || hdr.Devminor != 3 {
+				t.Errorf("expected character device 1/3, got %c %d/%d", hdr.Typeflag, hdr.Devmajor, hdr.Devminor)
+			}
+		case "sparse":
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				t.Fatalf("failed to read sparse file: %v", err)
+			}
+			expected := make([]byte, 1<<20)
+			copy(expected[512*1024:], "data")
+			if !bytes.Equal(data, expected) {
+				t.Errorf("sparse file content mismatch")
+			}
+		}
+	}
+
+	expectedNames := []string{"a", "b", "c", "null", "sparse"}
+	if !device {
+		expectedNames = []string{"a", "b", "c", "sparse"}
+	}
+	if strings.Join(names, ",") != strings.Join(expectedNames, ",") {
+		t.Errorf("expected entries %v, got %v", expectedNames, names)
+	}
+
+	if len(entries) != len(expectedNames) {
+		t.Fatalf("expected %d manifest entries, got %d", len(expectedNames), len(entries))
+	}
+	for _, entry := range entries {
+		switch entry.Path {
+		case "a":
+			if entry.Type != "file" || entry.Mode != "0644" || entry.SHA256 != fmt.Sprintf("%x", sha256.Sum256([]byte("a"))) {
+				t.Errorf("unexpected entry %+v", entry)
+			}
+			if xattr && string(entry.Xattrs["user.test"]) != "value" {
+				t.Errorf("expected user.test xattr in manifest, got %v", entry.Xattrs)
+			}
+		case "c":
+			if entry.Type != "hardlink" || entry.Linkname != "a" {
+				t.Errorf("unexpected entry %+v", entry)
+			}
+		case "sparse":
+			expected := make([]byte, 1<<20)
+			copy(expected[512*1024:], "data")
+			if entry.Size != 1<<20 || entry.SHA256 != fmt.Sprintf("%x", sha256.Sum256(expected)) {
+				t.Errorf("unexpected entry %+v", entry)
+			}
+			var stat unix.Stat_t
+			if err := unix.Stat(sparsePath, &stat); err == nil && stat.Blocks*512 < 1<<20 && !entry.Sparse {
+				t.Errorf("expected sparse file to be written as sparse")
+			}
+		}
+	}
+
+	// The sparse file is smaller in the archive
+	var stat unix.Stat_t
+	if err := unix.Stat(sparsePath, &stat); err == nil && stat.Blocks*512 < 1<<20 && buf.Len() >= 1<<20 {
+		t.Errorf("expected archive smaller than the sparse file, got %d bytes", buf.Len())
+	}
+}
+
+func TestDataRegions(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	defer f.Close()
+
+	// Not sparse
+	_, _ = f.Write(bytes.Repeat([]byte("x"), 8192))
+	if regions, err := dataRegions(f, 8192); err != nil || regions != nil {
+		t.Errorf("expected no regions for a file without holes, got %v, %v", regions, err)
+	}
+
+	// Ends with a hole
+	_ = f.Truncate(1 << 20)
+	regions, err := dataRegions(f, 1<<20)
+	if err != nil {
+		t.Fatalf("dataRegions failed: %v", err)
+	}
+	if regions == nil {
+		t.Skip("file system does not report holes")
+	}
+	last := regions[len(regions)-1]
+	if last.Offset != 1<<20 || last.Length != 0 {
+		t.Errorf("expected empty region at the end of the file, got %+v", last)
+	}
+}
@@ -52,5 +52,6 @@ func (r *osRunner) RunWithoutContext(name string, args ...string) ([]byte, error
 	return cmd.CombinedOutput()
 }
 
-// Runner is the active command runner instance.
-var Runner CommandRunner = &osRunner{}
+// Runner is the active command runner instance. The commands run with a
+// context carrying a custody record are recorded to the custody record.
+var Runner CommandRunner = NewRecordingRunner(&osRunner{})
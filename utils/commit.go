@@ -0,0 +1,296 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/google/container-explorer/explorers"
+	digest "github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	log "github.com/sirupsen/logrus"
+)
+
+// commitRepository is the repository name of the exported container images.
+const commitRepository = "container-explorer-export"
+
+// BaseImage provides the image a container was created from.
+type BaseImage struct {
+	Name     string                                          // image name
+	Config   *ocispec.Image                                  // image config, nil if not available
+	Layers   []ocispec.Descriptor                            // layer blobs, the bottom layer first
+	ReadBlob func(dgst digest.Digest) (io.ReadCloser, error) // reads a layer blob
+}
+
+// ExportContainerOCI exports a container as an OCI image archive.
+//
+// The image layers are copied from the base image layer blobs if all of them
+// are available. Otherwise, the image layers are created from the lower
+// directories. The upper directory, i.e. the container changes, is added as
+// the top layer.
+//
+// The archive <containerID>.oci.tar contains an OCI image layout and can be
+// loaded using docker load.
+func ExportContainerOCI(ctx context.Context, containerID string, layers explorers.LayerStack, base *BaseImage, outputDir string) error {
+	var success bool
+	archiveFilePath := filepath.Join(outputDir, fmt.Sprintf("%s.oci.tar", containerID))
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
+	}
+
+	w, err := NewImageWriter(archiveFilePath, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if !success {
+			w.Abort()
+		}
+	}()
+
+	config := ocispec.Image{
+		Platform: ocispec.Platform{
+			OS:           "linux",
+			Architecture: runtime.GOARCH,
+		},
+	}
+	if base != nil && base.Config != nil {
+		config = *base.Config
+		config.History = append([]ocispec.History(nil), base.Config.History...)
+	}
+
+	var (
+		layerDescs []ocispec.Descriptor
+		diffIDs    []digest.Digest
+	)
+
+	if baseLayersAvailable(base) {
+		log.WithField("image", base.Name).Info("copying image layers from the content store")
+
+		for _, desc := range base.Layers {
+			rc, err := base.ReadBlob(desc.Digest)
+			if err != nil {
+				return fmt.Errorf("reading layer %s: %w", desc.Digest, err)
+			}
+			err = w.WriteBlob(desc, rc)
+			rc.Close()
+			if err != nil {
+				return fmt.Errorf("copying layer %s: %w", desc.Digest, err)
+			}
+		}
+		layerDescs = base.Layers
+		diffIDs = base.Config.RootFS.DiffIDs
+	} else {
+		log.WithField("containerID", containerID).Info("creating image layers from the lower directories")
+
+		// Lower directories are ordered from the top layer.
+		for i := len(layers.LowerDirs) - 1; i >= 0; i-- {
+			desc, diffID, err := writeDirectoryLayer(w, layers.LowerDirs[i], outputDir)
+			if err != nil {
+				return err
+			}
+			layerDescs = append(layerDescs, desc)
+			diffIDs = append(diffIDs, diffID)
+		}
+	}
+
+	desc, diffID, err := writeDirectoryLayer(w, layers.UpperDir, outputDir)
+	if err != nil {
+		return err
+	}
+	layerDescs = append(layerDescs, desc)
+	diffIDs = append(diffIDs, diffID)
+
+	now := time.Now().UTC()
+	config.Created = &now
+	config.RootFS = ocispec.RootFS{
+		Type:    "layers",
+		DiffIDs: diffIDs,
+	}
+	config.History = append(config.History, ocispec.History{
+		Created:   &now,
+		CreatedBy: "container-explorer export --oci",
+		Comment:   fmt.Sprintf("container %s", containerID),
+	})
+
+	configDesc, err := writeJSONBlob(w, ocispec.MediaTypeImageConfig, config)
+	if err != nil {
+		return fmt.Errorf("writing image config: %w", err)
+	}
+
+	manifestDesc, err := writeJSONBlob(w, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    layerDescs,
+	})
+	if err != nil {
+		return fmt.Errorf("writing image manifest: %w", err)
+	}
+
+	dockerManifest := DockerManifest{
+		Config: BlobPath(configDesc.Digest),
+	}
+	for _, layer := range layerDescs {
+		dockerManifest.Layers = append(dockerManifest.Layers, BlobPath(layer.Digest))
+	}
+
+	tag := commitTag(containerID)
+	if tag != "" {
+		dockerManifest.RepoTags = []string{fmt.Sprintf("%s:%s", commitRepository, tag)}
+		manifestDesc.Annotations = map[string]string{
+			ocispec.AnnotationRefName: tag,
+		}
+	}
+
+	index := ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{manifestDesc},
+	}
+
+	if err := w.Finish(index, []DockerManifest{dockerManifest}); err != nil {
+		return err
+	}
+	if err := CustodyFromContext(ctx).AddArtifact(ArtifactOCIImage, containerID, archiveFilePath); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"containerID":     containerID,
+		"archiveFilePath": archiveFilePath,
+		"layers":          len(layerDescs),
+	}).Info("successfully exported container as OCI image")
+
+	success = true
+	return nil
+}
+
+// baseLayersAvailable returns true if all the base image layer blobs can be
+// read and match the image config.
+func baseLayersAvailable(base *BaseImage) bool {
+	if base == nil || base.Config == nil || base.ReadBlob == nil || len(base.Layers) == 0 {
+		return false
+	}
+	if len(base.Layers) != len(base.Config.RootFS.DiffIDs) {
+		return false
+	}
+
+	for _, desc := range base.Layers {
+		rc, err := base.ReadBlob(desc.Digest)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"image":  base.Name,
+				"digest": desc.Digest,
+				"error":  err,
+			}).Info("image layer is not available")
+			return false
+		}
+		rc.Close()
+	}
+	return true
+}
+
+// writeDirectoryLayer writes a layer directory as a gzip compressed layer blob.
+//
+// The layer is written to a temporary file in tmpDir because the blob size
+// and digest are required before it is added to the image.
+func writeDirectoryLayer(w *ImageWriter, dir string, tmpDir string) (ocispec.Descriptor, digest.Digest, error) {
+	tmp, err := os.CreateTemp(tmpDir, ".layer-*.tar.gz")
+	if err != nil {
+		return ocispec.Descriptor{}, "", fmt.Errorf("creating temporary layer file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	diffIDDigester := digest.Canonical.Digester()
+	blobDigester := digest.Canonical.Digester()
+
+	gz := gzip.NewWriter(io.MultiWriter(tmp, blobDigester.Hash()))
+	if err := WriteLayer(io.MultiWriter(gz, diffIDDigester.Hash()), dir); err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	if err := gz.Close(); err != nil {
+		return ocispec.Descriptor{}, "", fmt.Errorf("compressing layer %s: %w", dir, err)
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayerGzip,
+		Digest:    blobDigester.Digest(),
+		Size:      size,
+	}
+	if err := w.WriteBlob(desc, tmp); err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+
+	log.WithFields(log.Fields{
+		"dir":    dir,
+		"digest": desc.Digest,
+		"diffID": diffIDDigester.Digest(),
+	}).Debug("created layer from directory")
+
+	return desc, diffIDDigester.Digest(), nil
+}
+
+// writeJSONBlob writes a JSON document as a blob.
+func writeJSONBlob(w *ImageWriter, mediaType string, v any) (ocispec.Descriptor, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	return desc, w.WriteBlob(desc, bytes.NewReader(data))
+}
+
+// commitTag returns the image tag for an exported container or an empty
+// string if the container ID is not a valid tag.
+func commitTag(containerID string) string {
+	tag := containerID
+	if len(tag) > 12 {
+		tag = tag[:12]
+	}
+
+	if _, err := reference.ParseNormalizedNamed(fmt.Sprintf("%s:%s", commitRepository, tag)); err != nil {
+		return ""
+	}
+	return tag
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/container-explorer/explorers"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// readImageArchive returns the files of an image archive.
+func readImageArchive(t *testing.T, archivePath string) map[string][]byte {
+	t.Helper()
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer f.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read archive: %v", err)
+		}
+		data, _ := io.ReadAll(tr)
+		files[hdr.Name] = data
+	}
+	return files
+}
+
+// readArchiveImage returns the docker manifest and image config of an
+// exported container.
+func readArchiveImage(t *testing.T, files map[string][]byte) (DockerManifest, ocispec.Image) {
+	t.Helper()
+
+	var manifests []DockerManifest
+	if err := json.Unmarshal(files["manifest.json"], &manifests); err != nil {
+		t.Fatalf("failed to decode manifest.json: %v", err)
+	}
+	if len(manifests) != 1 {
+		t.Fatalf("expected 1 manifest, got %d", len(manifests))
+	}
+
+	var config ocispec.Image
+	if err := json.Unmarshal(files[manifests[0].Config], &config); err != nil {
+		t.Fatalf("failed to decode image config: %v", err)
+	}
+	return manifests[0], config
+}
+
+func testLayerStack(t *testing.T) explorers.LayerStack {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	layers := explorers.LayerStack{
+		UpperDir: filepath.Join(tmpDir, "upper"),
+		LowerDirs: []string{
+			filepath.Join(tmpDir, "lower2"),
+			filepath.Join(tmpDir, "lower1"),
+		},
+	}
+	for _, dir := range append([]string{layers.UpperDir}, layers.LowerDirs...) {
+		_ = os.MkdirAll(dir, 0755)
+		_ = os.WriteFile(filepath.Join(dir, filepath.Base(dir)), []byte(dir), 0644)
+	}
+	return layers
+}
+
+func TestExportContainerOCI(t *testing.T) {
+	layers := testLayerStack(t)
+	outputDir := filepath.Join(t.TempDir(), "output")
+
+	if err := ExportContainerOCI(context.Background(), "0123456789abcdef", layers, nil, outputDir); err != nil {
+		t.Fatalf("ExportContainerOCI failed: %v", err)
+	}
+
+	files := readImageArchive(t, filepath.Join(outputDir, "0123456789abcdef.oci.tar"))
+	for _, name := range []string{ocispec.ImageLayoutFile, ocispec.ImageIndexFile} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("expected %s in archive", name)
+		}
+	}
+
+	manifest, config := readArchiveImage(t, files)
+	if len(manifest.Layers) != 3 {
+		t.Fatalf("expected 3 layers, got %d", len(manifest.Layers))
+	}
+	if len(manifest.RepoTags) != 1 || manifest.RepoTags[0] != "container-explorer-export:0123456789ab" {
+		t.Errorf("unexpected repo tags %v", manifest.RepoTags)
+	}
+	if len(config.RootFS.DiffIDs) != 3 {
+		t.Errorf("expected 3 diff IDs, got %d", len(config.RootFS.DiffIDs))
+	}
+	if len(config.History) != 1 || !strings.Contains(config.History[0].Comment, "0123456789abcdef") {
+		t.Errorf("unexpected history %v", config.History)
+	}
+
+	// The bottom layer is written first and the upper directory last
+	for i, name := range []string{"lower1", "lower2", "upper"} {
+		if _, ok := files[manifest.Layers[i]]; !ok {
+			t.Fatalf("layer %s not found in archive", manifest.Layers[i])
+		}
+		if got := layerFiles(t, files[manifest.Layers[i]]); len(got) != 1 || got[0] != name {
+			t.Errorf("layer %d: expected [%s], got %v", i, name, got)
+		}
+	}
+
+	// The archive is not overwritten
+	if err := ExportContainerOCI(context.Background(), "0123456789abcdef", layers, nil, outputDir); err == nil {
+		t.Errorf("expected error for existing archive")
+	}
+
+	// No temporary layer files are left behind
+	entries, _ := os.ReadDir(outputDir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the archive in the output directory, got %d entries", len(entries))
+	}
+}
+
+func TestExportContainerOCI_BaseImage(t *testing.T) {
+	layers := testLayerStack(t)
+
+	blobs := map[digest.Digest]string{}
+	base := &BaseImage{
+		Name: "docker.io/library/alpine:latest",
+		Config: &ocispec.Image{
+			Platform: ocispec.Platform{OS: "linux", Architecture: "arm64"},
+			Config:   ocispec.ImageConfig{Cmd: []string{"/bin/sh"}},
+			History:  []ocispec.History{{CreatedBy: "base"}},
+		},
+		ReadBlob: func(dgst digest.Digest) (io.ReadCloser, error) {
+			data, ok := blobs[dgst]
+			if !ok {
+				return nil, fmt.Errorf("blob %s not found", dgst)
+			}
+			return io.NopCloser(strings.NewReader(data)), nil
+		},
+	}
+	for _, data := range []string{"base layer 1", "base layer 2"} {
+		desc, _ := testBlob(data)
+		blobs[desc.Digest] = data
+		base.Layers = append(base.Layers, desc)
+		base.Config.RootFS.DiffIDs = append(base.Config.RootFS.DiffIDs, digest.FromString("diff "+data))
+	}
+
+	t.Run("base layers", func(t *testing.T) {
+		outputDir := t.TempDir()
+		if err := ExportContainerOCI(context.Background(), "c1", layers, base, outputDir); err != nil {
+			t.Fatalf("ExportContainerOCI failed: %v", err)
+		}
+
+		files := readImageArchive(t, filepath.Join(outputDir, "c1.oci.tar"))
+		manifest, config := readArchiveImage(t, files)
+
+		if len(manifest.Layers) != 3 {
+			t.Fatalf("expected 3 layers, got %d", len(manifest.Layers))
+		}
+		for i, desc := range base.Layers {
+			if manifest.Layers[i] != BlobPath(desc.Digest) {
+				t.Errorf("layer %d: expected %s, got %s", i, BlobPath(desc.Digest), manifest.Layers[i])
+			}
+		}
+		if config.RootFS.DiffIDs[0] != base.Config.RootFS.DiffIDs[0] {
+			t.Errorf("expected base diff IDs to be kept")
+		}
+		if config.Architecture != "arm64" || len(config.Config.Cmd) != 1 {
+			t.Errorf("expected base image config to be kept, got %+v", config)
+		}
+		if len(config.History) != 2 || len(base.Config.History) != 1 {
+			t.Errorf("expected history to be appended without modifying the base image")
+		}
+	})
+
+	t.Run("missing base layer", func(t *testing.T) {
+		missing := *base
+		missing.Layers = append([]ocispec.Descriptor{}, base.Layers...)
+		missing.Layers[1].Digest = digest.FromString("missing")
+
+		outputDir := t.TempDir()
+		if err := ExportContainerOCI(context.Background(), "c2", layers, &missing, outputDir); err != nil {
+			t.Fatalf("ExportContainerOCI failed: %v", err)
+		}
+
+		manifest, config := readArchiveImage(t, readImageArchive(t, filepath.Join(outputDir, "c2.oci.tar")))
+		if len(manifest.Layers) != 3 {
+			t.Fatalf("expected 3 layers, got %d", len(manifest.Layers))
+		}
+		if manifest.Layers[0] == BlobPath(base.Layers[0].Digest) {
+			t.Errorf("expected layers to be created from the lower directories")
+		}
+		if config.Architecture != "arm64" {
+			t.Errorf("expected base image config to be kept")
+		}
+	})
+}
+
+// layerFiles returns the names of the files in a gzip compressed layer.
+func layerFiles(t *testing.T, data []byte) []string {
+	t.Helper()
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decompress layer: %v", err)
+	}
+	defer gz.Close()
+
+	layer, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("failed to decompress layer: %v", err)
+	}
+
+	var names []string
+	for _, hdr := range readLayer(t, layer) {
+		names = append(names, hdr.Name)
+	}
+	return names
+}
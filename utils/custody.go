@@ -0,0 +1,427 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // G501: MD5 is recorded for evidence tools that require it
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Artifact kinds recorded in the custody manifest.
+const (
+	ArtifactRawImage        = "raw-image"
+	ArtifactArchive         = "archive"
+	ArtifactArchiveManifest = "archive-manifest"
+	ArtifactOCIImage        = "oci-image"
+)
+
+// Custody records the chain of custody of an export.
+//
+// The custody record is carried in the context so that export functions can
+// add the artifacts they create. All methods are safe to call on a nil
+// Custody.
+type Custody struct {
+	mu sync.Mutex
+
+	ExportID    string             `json:"export_id"`
+	Operator    string             `json:"operator"`
+	Hostname    string             `json:"hostname"`
+	ToolVersion string             `json:"tool_version"`
+	CommandLine []string           `json:"command_line"`
+	StartedAt   time.Time          `json:"started_at"`
+	CompletedAt time.Time          `json:"completed_at"`
+	Error       string             `json:"error,omitempty"`
+	Containers  []CustodyContainer `json:"containers"`
+	Sources     []CustodyFile      `json:"sources"`
+	Artifacts   []CustodyFile      `json:"artifacts"`
+	Commands    []CustodyCommand   `json:"commands"`
+}
+
+// CustodyContainer describes an exported container.
+type CustodyContainer struct {
+	ContainerID string   `json:"container_id"`
+	Runtime     string   `json:"runtime"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Image       string   `json:"image,omitempty"`
+	Snapshotter string   `json:"snapshotter,omitempty"`
+	SnapshotKey string   `json:"snapshot_key,omitempty"`
+	Snapshots   []string `json:"snapshots,omitempty"` // layer directories, the upper directory first
+}
+
+// CustodyFile is a hashed source or artifact file.
+type CustodyFile struct {
+	Path        string    `json:"path"`
+	Kind        string    `json:"kind"`
+	ContainerID string    `json:"container_id,omitempty"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"mtime"`
+	SHA256      string    `json:"sha256"`
+	MD5         string    `json:"md5"`
+	RecordedAt  time.Time `json:"recorded_at"`
+}
+
+// CustodyCommand is an external command run during the export.
+type CustodyCommand struct {
+	Name        string    `json:"name"`
+	Args        []string  `json:"args"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type custodyKey struct{}
+
+// NewCustody returns a custody record for an export started now.
+func NewCustody(operator string, toolVersion string, commandLine []string) *Custody {
+	now := time.Now().UTC()
+	hostname, _ := os.Hostname()
+
+	if operator == "" {
+		operator = DefaultOperator()
+	}
+
+	return &Custody{
+		ExportID:    now.Format("20060102T150405.000000000Z"),
+		Operator:    operator,
+		Hostname:    hostname,
+		ToolVersion: toolVersion,
+		CommandLine: commandLine,
+		StartedAt:   now,
+		Containers:  []CustodyContainer{},
+		Sources:     []CustodyFile{},
+		Artifacts:   []CustodyFile{},
+		Commands:    []CustodyCommand{},
+	}
+}
+
+// DefaultOperator returns the user running the export. The user invoking
+// sudo is preferred over root.
+func DefaultOperator() string {
+	if name := os.Getenv("SUDO_USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// WithCustody returns a context carrying the custody record.
+func WithCustody(ctx context.Context, c *Custody) context.Context {
+	return context.WithValue(ctx, custodyKey{}, c)
+}
+
+// CustodyFromContext returns the custody record of the context or nil.
+func CustodyFromContext(ctx context.Context) *Custody {
+	c, _ := ctx.Value(custodyKey{}).(*Custody)
+	return c
+}
+
+// AddContainer records an exported container.
+func (c *Custody) AddContainer(container CustodyContainer) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Containers = append(c.Containers, container)
+}
+
+// AddSource hashes and records a source file read during the export. A
+// source already recorded is skipped.
+func (c *Custody) AddSource(kind string, path string) {
+	if c == nil || path == "" {
+		return
+	}
+
+	c.mu.Lock()
+	for _, source := range c.Sources {
+		if source.Path == path {
+			c.mu.Unlock()
+			return
+		}
+	}
+	c.mu.Unlock()
+
+	source, err := hashCustodyFile(kind, "", path)
+	if err != nil {
+		log.WithFields(log.Fields{"path": path, "error": err}).Warn("hashing custody source")
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// The source may be recorded by a concurrent export while hashing.
+	for _, s := range c.Sources {
+		if s.Path == path {
+			return
+		}
+	}
+	c.Sources = append(c.Sources, source)
+}
+
+// AddArtifact hashes and records a file created by the export.
+func (c *Custody) AddArtifact(kind string, containerID string, path string) error {
+	if c == nil {
+		return nil
+	}
+
+	artifact, err := hashCustodyFile(kind, containerID, path)
+	if err != nil {
+		return fmt.Errorf("hashing artifact %s: %w", path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Artifacts = append(c.Artifacts, artifact)
+	return nil
+}
+
+// addCommand records an external command.
+func (c *Custody) addCommand(command CustodyCommand) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Commands = append(c.Commands, command)
+}
+
+// Complete sets the completion time and the export error, if any.
+func (c *Custody) Complete(err error) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.CompletedAt = time.Now().UTC()
+	if err != nil {
+		c.Error = err.Error()
+	}
+}
+
+// Write writes the custody manifest to the output directory as
+// custody-<export ID>.json and custody-<export ID>.csv and returns the
+// file paths.
+func (c *Custody) Write(outputDir string) (string, string, error) {
+	if c == nil {
+		return "", "", nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Containers exported concurrently are recorded in completion order,
+	// so the records are sorted for a manifest that does not depend on the
+	// scheduling.
+	sort.SliceStable(c.Containers, func(i, j int) bool { return c.Containers[i].ContainerID < c.Containers[j].ContainerID })
+	sort.SliceStable(c.Sources, func(i, j int) bool { return c.Sources[i].Path < c.Sources[j].Path })
+	sort.SliceStable(c.Artifacts, func(i, j int) bool { return c.Artifacts[i].ContainerID < c.Artifacts[j].ContainerID })
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
+	}
+
+	name := fmt.Sprintf("custody-%s", c.ExportID)
+	jsonPath := filepath.Join(outputDir, name+".json")
+	csvPath := filepath.Join(outputDir, name+".csv")
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return "", "", fmt.Errorf("marshaling custody manifest: %w", err)
+	}
+	if err := os.WriteFile(jsonPath, data, 0644); err != nil {
+		return "", "", fmt.Errorf("writing custody manifest %s: %w", jsonPath, err)
+	}
+
+	//nolint:gosec // G304: Output path is provided by the user
+	f, err := os.Create(csvPath)
+	if err != nil {
+		return "", "", fmt.Errorf("creating custody manifest %s: %w", csvPath, err)
+	}
+	defer f.Close()
+
+	if err := c.writeCSV(f); err != nil {
+		return "", "", fmt.Errorf("writing custody manifest %s: %w", csvPath, err)
+	}
+	if err := f.Close(); err != nil {
+		return "", "", fmt.Errorf("closing custody manifest %s: %w", csvPath, err)
+	}
+	return jsonPath, csvPath, nil
+}
+
+// writeCSV writes one row for the export and for each container, source,
+// artifact, and command.
+func (c *Custody) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{
+		{"record", "container_id", "kind", "path", "size", "sha256", "md5", "time", "detail"},
+		{
+			"export", "", c.ToolVersion, strings.Join(c.CommandLine, " "), "", "", "", formatCustodyTime(c.StartedAt),
+			fmt.Sprintf("operator=%s hostname=%s completed=%s error=%s", c.Operator, c.Hostname, formatCustodyTime(c.CompletedAt), c.Error),
+		},
+	}
+
+	for _, container := range c.Containers {
+		rows = append(rows, []string{
+			"container", container.ContainerID, container.Runtime, container.Image, "", "", "", "",
+			fmt.Sprintf("namespace=%s snapshotter=%s snapshot_key=%s snapshots=%s", container.Namespace, container.Snapshotter, container.SnapshotKey, strings.Join(container.Snapshots, ":")),
+		})
+	}
+	for _, record := range []struct {
+		name  string
+		files []CustodyFile
+	}{{"source", c.Sources}, {"artifact", c.Artifacts}} {
+		for _, file := range record.files {
+			rows = append(rows, []string{
+				record.name, file.ContainerID, file.Kind, file.Path, strconv.FormatInt(file.Size, 10), file.SHA256, file.MD5,
+				formatCustodyTime(file.RecordedAt), "mtime=" + formatCustodyTime(file.ModTime),
+			})
+		}
+	}
+	for _, command := range c.Commands {
+		rows = append(rows, []string{
+			"command", "", command.Name, strings.Join(command.Args, " "), "", "", "", formatCustodyTime(command.StartedAt),
+			fmt.Sprintf("completed=%s error=%s", formatCustodyTime(command.CompletedAt), command.Error),
+		})
+	}
+
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// hashCustodyFile returns the SHA256 and MD5 of a file.
+func hashCustodyFile(kind string, containerID string, path string) (CustodyFile, error) {
+	//nolint:gosec // G304: Path is a source or artifact of the export
+	f, err := os.Open(path)
+	if err != nil {
+		return CustodyFile{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return CustodyFile{}, err
+	}
+
+	sha := sha256.New()
+	//nolint:gosec // G401: MD5 is recorded for evidence tools that require it
+	md := md5.New()
+	if _, err := io.Copy(io.MultiWriter(sha, md), f); err != nil {
+		return CustodyFile{}, err
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+
+	return CustodyFile{
+		Path:        absPath,
+		Kind:        kind,
+		ContainerID: containerID,
+		Size:        info.Size(),
+		ModTime:     info.ModTime().UTC(),
+		SHA256:      hex.EncodeToString(sha.Sum(nil)),
+		MD5:         hex.EncodeToString(md.Sum(nil)),
+		RecordedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// formatCustodyTime formats a timestamp as RFC 3339 in UTC.
+func formatCustodyTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// recordingRunner records the commands run by a command runner.
+type recordingRunner struct {
+	runner CommandRunner
+}
+
+// NewRecordingRunner returns a command runner recording the commands run
+// with a context carrying a custody record to the custody record of the
+// context, so that commands run in parallel for different custody records
+// are recorded apart.
+//
+// A recording runner wraps the runner of a recording runner, so that the
+// commands are recorded once.
+func NewRecordingRunner(runner CommandRunner) CommandRunner {
+	if r, ok := runner.(*recordingRunner); ok {
+		runner = r.runner
+	}
+	return &recordingRunner{runner: runner}
+}
+
+// Run executes and records a command with context.
+func (r *recordingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	started := time.Now().UTC()
+	out, err := r.runner.Run(ctx, name, args...)
+	recordCommand(CustodyFromContext(ctx), name, args, started, err)
+	return out, err
+}
+
+// RunSeparate executes and records a command with context.
+func (r *recordingRunner) RunSeparate(ctx context.Context, name string, args []string, stdout, stderr io.Writer) error {
+	started := time.Now().UTC()
+	err := r.runner.RunSeparate(ctx, name, args, stdout, stderr)
+	recordCommand(CustodyFromContext(ctx), name, args, started, err)
+	return err
+}
+
+// RunWithoutContext executes a command without context. The command is not
+// recorded, as there is no custody record.
+func (r *recordingRunner) RunWithoutContext(name string, args ...string) ([]byte, error) {
+	return r.runner.RunWithoutContext(name, args...)
+}
+
+// recordCommand records a command to a custody record, if any.
+func recordCommand(custody *Custody, name string, args []string, started time.Time, err error) {
+	command := CustodyCommand{
+		Name:        name,
+		Args:        append([]string(nil), args...),
+		StartedAt:   started,
+		CompletedAt: time.Now().UTC(),
+	}
+	if err != nil {
+		command.Error = err.Error()
+	}
+	custody.addCommand(command)
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/container-explorer/explorers"
+)
+
+func TestCustody(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	custody := NewCustody("examiner", "0.6.0", []string{"ce", "export", "ctr1", tmpDir})
+	if custody.Operator != "examiner" || custody.ToolVersion != "0.6.0" || custody.StartedAt.Location().String() != "UTC" {
+		t.Errorf("unexpected custody %+v", custody)
+	}
+
+	ctx := WithCustody(context.Background(), custody)
+	if CustodyFromContext(ctx) != custody {
+		t.Fatalf("expected custody from context")
+	}
+	if CustodyFromContext(context.Background()) != nil {
+		t.Errorf("expected no custody in background context")
+	}
+
+	source := filepath.Join(tmpDir, "meta.db")
+	_ = os.WriteFile(source, []byte("hello"), 0644)
+	custody.AddSource("containerd metadata", source)
+	custody.AddSource("containerd metadata", source)
+	custody.AddSource("missing", filepath.Join(tmpDir, "missing"))
+	if len(custody.Sources) != 1 {
+		t.Fatalf("expected 1 source, got %d", len(custody.Sources))
+	}
+	if custody.Sources[0].SHA256 != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("unexpected source sha256 %s", custody.Sources[0].SHA256)
+	}
+	if custody.Sources[0].MD5 != "5d41402abc4b2a76b9719d911017c592" {
+		t.Errorf("unexpected source md5 %s", custody.Sources[0].MD5)
+	}
+
+	artifact := filepath.Join(tmpDir, "ctr1.tar.gz")
+	_ = os.WriteFile(artifact, []byte("archive"), 0644)
+	if err := custody.AddArtifact(ArtifactArchive, "ctr1", artifact); err != nil {
+		t.Fatalf("AddArtifact failed: %v", err)
+	}
+	if err := custody.AddArtifact(ArtifactArchive, "ctr1", filepath.Join(tmpDir, "missing")); err == nil {
+		t.Errorf("expected error for missing artifact")
+	}
+	custody.AddContainer(CustodyContainer{ContainerID: "ctr1", Runtime: "containerd", Snapshots: []string{"/upper", "/lower"}})
+
+	runner := NewRecordingRunner(&MockCommandRunner{
+		Responses: map[string]MockResponse{
+			"umount": {Err: fmt.Errorf("target is busy")},
+		},
+	})
+	_, _ = runner.Run(ctx, "mount", "-t", "overlay")
+	_, _ = runner.Run(ctx, "umount", "/mnt")
+	if len(custody.Commands) != 2 {
+		t.Fatalf("expected 2 commands, got %d", len(custody.Commands))
+	}
+	if custody.Commands[0].Name != "mount" || custody.Commands[0].Error != "" || custody.Commands[1].Error != "target is busy" {
+		t.Errorf("unexpected commands %+v", custody.Commands)
+	}
+
+	custody.Complete(nil)
+	jsonPath, csvPath, err := custody.Write(tmpDir)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("failed to read custody json: %v", err)
+	}
+	var decoded Custody
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to decode custody json: %v", err)
+	}
+	if len(decoded.Artifacts) != 1 || decoded.Artifacts[0].ContainerID != "ctr1" || decoded.CompletedAt.IsZero() {
+		t.Errorf("unexpected custody json artifacts %+v", decoded.Artifacts)
+	}
+
+	f, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatalf("failed to open custody csv: %v", err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read custody csv: %v", err)
+	}
+
+	// header, export, container, source, artifact, and 2 commands
+	if len(rows) != 7 {
+		t.Fatalf("expected 7 csv rows, got %d", len(rows))
+	}
+	records := make(map[string]int)
+	for _, row := range rows[1:] {
+		records[row[0]]++
+	}
+	if records["export"] != 1 || records["container"] != 1 || records["source"] != 1 || records["artifact"] != 1 || records["command"] != 2 {
+		t.Errorf("unexpected csv records %v", records)
+	}
+}
+
+func TestCustody_Nil(t *testing.T) {
+	var custody *Custody
+
+	custody.AddSource("source", "/etc/hostname")
+	custody.AddContainer(CustodyContainer{ContainerID: "ctr1"})
+	custody.Complete(nil)
+	if err := custody.AddArtifact(ArtifactArchive, "ctr1", "/missing"); err != nil {
+		t.Errorf("expected no error for nil custody, got %v", err)
+	}
+	if jsonPath, _, err := custody.Write(t.TempDir()); err != nil || jsonPath != "" {
+		t.Errorf("expected no custody files for nil custody, got %s, %v", jsonPath, err)
+	}
+}
+
+func TestRecordingRunner_ContextCustody(t *testing.T) {
+	first := NewCustody("analyst", "test", nil)
+	second := NewCustody("analyst", "test", nil)
+
+	// Commands are recorded to the custody record of the context.
+	runner := NewRecordingRunner(&MockCommandRunner{})
+	_, _ = runner.Run(WithCustody(context.Background(), first), "mount", "/a")
+	_ = runner.RunSeparate(WithCustody(context.Background(), second), "losetup", []string{"-f"}, nil, nil)
+	_, _ = runner.Run(context.Background(), "sync")
+	_, _ = runner.RunWithoutContext("umount", "/a")
+
+	if len(first.Commands) != 1 || first.Commands[0].Name != "mount" {
+		t.Errorf("unexpected first custody commands %+v", first.Commands)
+	}
+	if len(second.Commands) != 1 || second.Commands[0].Name != "losetup" {
+		t.Errorf("unexpected second custody commands %+v", second.Commands)
+	}
+}
+
+func TestRecordingRunner_Nested(t *testing.T) {
+	custody := NewCustody("analyst", "test", nil)
+	ctx := WithCustody(context.Background(), custody)
+
+	// A recording runner wrapping a recording runner records a command once.
+	runner := NewRecordingRunner(NewRecordingRunner(&MockCommandRunner{}))
+	_, _ = runner.Run(ctx, "mount", "/a")
+	_ = runner.RunSeparate(ctx, "losetup", []string{"-f"}, nil, nil)
+
+	if len(custody.Commands) != 2 {
+		t.Errorf("expected 2 commands, got %+v", custody.Commands)
+	}
+}
+
+func TestExportContainerArchive_Custody(t *testing.T) {
+	tmpDir := t.TempDir()
+	mountpoint := filepath.Join(tmpDir, "container_mount")
+	_ = os.MkdirAll(mountpoint, 0755)
+	_ = os.WriteFile(filepath.Join(mountpoint, "file"), []byte("data"), 0644)
+
+	custody := NewCustody("examiner", "test", nil)
+	ctx := WithCustody(context.Background(), custody)
+
+	if err := ExportContainerArchive(ctx, "ctr1", mountpoint, tmpDir, explorers.CompressionGzip); err != nil {
+		t.Fatalf("ExportContainerArchive failed: %v", err)
+	}
+
+	if len(custody.Artifacts) != 2 {
+		t.Fatalf("expected archive and manifest artifacts, got %+v", custody.Artifacts)
+	}
+	if custody.Artifacts[0].Kind != ArtifactArchive || custody.Artifacts[1].Kind != ArtifactArchiveManifest {
+		t.Errorf("unexpected artifact kinds %s, %s", custody.Artifacts[0].Kind, custody.Artifacts[1].Kind)
+	}
+}
@@ -19,23 +19,43 @@ package utils
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/google/container-explorer/explorers"
+	"github.com/klauspost/compress/zstd"
 	log "github.com/sirupsen/logrus"
 )
 
 // ExportContainerImage creates a raw disk image file of a container.
 func ExportContainerImage(ctx context.Context, containerID string, mountpoint string, outputDir string) error {
+	imageFilePath, err := createContainerImage(ctx, containerID, mountpoint, outputDir)
+	if err != nil {
+		return err
+	}
+
+	// The image is hashed after it is unmounted.
+	return CustodyFromContext(ctx).AddArtifact(ArtifactRawImage, containerID, imageFilePath)
+}
+
+// createContainerImage creates, formats, and populates the raw disk image and
+// returns its path.
+func createContainerImage(ctx context.Context, containerID string, mountpoint string, outputDir string) (string, error) {
 	var success bool
 	imageFileName := fmt.Sprintf("%s.raw", containerID)
 	imageFilePath := filepath.Join(outputDir, imageFileName)
 
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
+		return "", fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
 	}
 
 	defer func() {
@@ -48,7 +68,7 @@ func ExportContainerImage(ctx context.Context, containerID string, mountpoint st
 	// 1. Calculate the required size for the image.
 	contentSize, err := CalculateDirectorySize(mountpoint)
 	if err != nil {
-		return fmt.Errorf("failed to calculate content size for %s: %w", mountpoint, err)
+		return "", fmt.Errorf("failed to calculate content size for %s: %w", mountpoint, err)
 	}
 	log.Infof("calculated the content size of %s as %d bytes", mountpoint, contentSize)
 
@@ -61,13 +81,13 @@ func ExportContainerImage(ctx context.Context, containerID string, mountpoint st
 	// 2. Create the image file
 	imgFile, err := os.Create(imageFilePath)
 	if err != nil {
-		return fmt.Errorf("failed to create image file %s: %w", imageFilePath, err)
+		return "", fmt.Errorf("failed to create image file %s: %w", imageFilePath, err)
 	}
 
 	// 3. Set the image file size
 	if err := imgFile.Truncate(imageSize); err != nil {
 		imgFile.Close()
-		return fmt.Errorf("failed to truncate image file %s to size %d: %w", imageFilePath, imageSize, err)
+		return "", fmt.Errorf("failed to truncate image file %s to size %d: %w", imageFilePath, imageSize, err)
 	}
 
 	// 4. Sync and Close the file before formatting
@@ -75,7 +95,7 @@ func ExportContainerImage(ctx context.Context, containerID string, mountpoint st
 		log.Warnf("failed to sync image file %s after truncation: %v", imageFilePath, err)
 	}
 	if err := imgFile.Close(); err != nil {
-		return fmt.Errorf("failed to close image file %s before formatting: %w", imageFilePath, err)
+		return "", fmt.Errorf("failed to close image file %s before formatting: %w", imageFilePath, err)
 	}
 	log.Infof("successfully created container %s target image file %s", containerID, imageFilePath)
 
@@ -88,7 +108,7 @@ func ExportContainerImage(ctx context.Context, containerID string, mountpoint st
 			"output":  string(mkfsOutput),
 			"error":   err,
 		}).Error("formatting target image")
-		return fmt.Errorf("mkfs.ext4 failed for %s: %w. Output: %s", imageFilePath, err, string(mkfsOutput))
+		return "", fmt.Errorf("mkfs.ext4 failed for %s: %w. Output: %s", imageFilePath, err, string(mkfsOutput))
 	}
 	log.Infof("successfully formatted image %s as ext4", imageFilePath)
 
@@ -97,19 +117,22 @@ func ExportContainerImage(ctx context.Context, containerID string, mountpoint st
 
 	imageMountDir, err := os.MkdirTemp("", fmt.Sprintf("%s-img-mount-*.d", containerID))
 	if err != nil {
-		return fmt.Errorf("failed to create temporary mount directory for image %s: %w", imageFilePath, err)
+		return "", fmt.Errorf("failed to create temporary mount directory for image %s: %w", imageFilePath, err)
 	}
 	log.Infof("created temporary image mount directory: %s", imageMountDir)
 
 	var loopDevice string
 	imageSuccessfullyMounted := false
 
-	// Defer cleanup actions in LIFO order (unmount image, detach loop, remove temp dir)
+	// Defer cleanup actions in LIFO order (unmount image, detach loop, remove temp dir).
+	// The cleanup commands are not cancelled with the context, but are
+	// recorded to its custody record.
+	cleanupCtx := context.WithoutCancel(ctx)
 	var unmounted bool
 	defer func() {
 		if imageSuccessfullyMounted {
 			log.Infof("unmounting image from %s", imageMountDir)
-			umountOutput, umountErr := Runner.RunWithoutContext("umount", imageMountDir)
+			umountOutput, umountErr := Runner.Run(cleanupCtx, "umount", imageMountDir)
 			if umountErr == nil {
 				unmounted = true
 				log.Infof("successfully unmounted image filesystem from %s", imageMountDir)
@@ -117,7 +140,7 @@ func ExportContainerImage(ctx context.Context, containerID string, mountpoint st
 				log.Warnf("failed to unmount image filesystem from %s: %v; output: %s", imageMountDir, umountErr, string(umountOutput))
 				// Try lazy unmount
 				log.Infof("attempting lazy unmount from %s", imageMountDir)
-				lazyUmountOutput, lazyErr := Runner.RunWithoutContext("umount", "-l", imageMountDir)
+				lazyUmountOutput, lazyErr := Runner.Run(cleanupCtx, "umount", "-l", imageMountDir)
 				if lazyErr == nil {
 					unmounted = true
 				} else {
@@ -126,16 +149,25 @@ func ExportContainerImage(ctx context.Context, containerID string, mountpoint st
 			}
 		}
 
+		detached := true
 		if loopDevice != "" {
 			log.Infof("detaching loop device %s for image %s", loopDevice, imageFilePath)
-			detachOutput, detachErr := Runner.RunWithoutContext("losetup", "-d", loopDevice)
+			detachOutput, detachErr := Runner.Run(cleanupCtx, "losetup", "-d", loopDevice)
 			if detachErr != nil {
+				detached = false
 				log.Warnf("failed to detach loop device %s: %v; output: %s", loopDevice, detachErr, string(detachOutput))
 			} else {
 				log.Infof("successfully detached loop device %s", loopDevice)
 			}
 		}
 
+		// Keep the registry record for `umount` if the cleanup failed.
+		if detached && (!imageSuccessfullyMounted || unmounted) {
+			if err := UnregisterMount(imageMountDir); err != nil {
+				log.Warnf("failed to remove %s from the mount registry: %v", imageMountDir, err)
+			}
+		}
+
 		if !imageSuccessfullyMounted || unmounted {
 			log.Infof("removing temporary image mount directory %s", imageMountDir)
 			if err := os.RemoveAll(imageMountDir); err != nil {
@@ -152,21 +184,26 @@ func ExportContainerImage(ctx context.Context, containerID string, mountpoint st
 	err = Runner.RunSeparate(ctx, "losetup", []string{"-f", "--show", imageFilePath}, &stdoutBuf, &stderrBuf)
 	if err != nil {
 		log.Errorf("losetup -f --show %s failed: %v; stderr: %s", imageFilePath, err, stderrBuf.String())
-		return fmt.Errorf("losetup -f --show %s failed: %w. Output: %s", imageFilePath, err, stderrBuf.String())
+		return "", fmt.Errorf("losetup -f --show %s failed: %w. Output: %s", imageFilePath, err, stderrBuf.String())
 	}
 	loopDevice = strings.TrimSpace(stdoutBuf.String())
 	if loopDevice == "" {
 		log.Errorf("losetup -f --show %s returned an empty loop device path", imageFilePath)
-		return fmt.Errorf("losetup -f --show %s returned an empty loop device path", imageFilePath)
+		return "", fmt.Errorf("losetup -f --show %s returned an empty loop device path", imageFilePath)
 	}
 	log.Infof("image %s associated with loop device %s", imageFilePath, loopDevice)
 
+	record := MountRecord{Mountpoint: imageMountDir, Type: MountTypeLoop, Source: imageFilePath, Device: loopDevice, ContainerID: containerID}
+	if err := RegisterMount(record); err != nil {
+		log.Warnf("failed to record loop device %s in the mount registry: %v", loopDevice, err)
+	}
+
 	// 6.2. Mount the loop device
 	log.Infof("mounting loop device %s to %s", loopDevice, imageMountDir)
 	mountImageOutput, err := Runner.Run(ctx, "mount", loopDevice, imageMountDir)
 	if err != nil {
 		log.Errorf("failed to mount %s to %s: %v; output: %s", loopDevice, imageMountDir, err, string(mountImageOutput))
-		return fmt.Errorf("failed to mount loop device %s to %s: %w. Output: %s", loopDevice, imageMountDir, err, string(mountImageOutput))
+		return "", fmt.Errorf("failed to mount loop device %s to %s: %w. Output: %s", loopDevice, imageMountDir, err, string(mountImageOutput))
 	}
 	imageSuccessfullyMounted = true // Set flag for deferred cleanup
 	log.Infof("successfully mounted %s to %s; output: %s", loopDevice, imageMountDir, string(mountImageOutput))
@@ -179,7 +216,7 @@ func ExportContainerImage(ctx context.Context, containerID string, mountpoint st
 	copyOutput, err := Runner.Run(ctx, "cp", "-a", filepath.Join(mountpoint, "."), imageMountDir)
 	if err != nil {
 		log.Errorf("failed to copy data from %s to %s: %v; output: %s", mountpoint, imageMountDir, err, string(copyOutput))
-		return fmt.Errorf("failed to copy data from %s to %s: %w. Output: %s", mountpoint, imageMountDir, err, string(copyOutput))
+		return "", fmt.Errorf("failed to copy data from %s to %s: %w. Output: %s", mountpoint, imageMountDir, err, string(copyOutput))
 	}
 	log.Infof("successfully copied data from %s to %s; output: %s", mountpoint, imageMountDir, string(copyOutput))
 
@@ -195,14 +232,28 @@ func ExportContainerImage(ctx context.Context, containerID string, mountpoint st
 	log.Infof("image %s successfully created, formatted, and populated", imageFilePath)
 
 	success = true
-	return nil
+	return imageFilePath, nil
 }
 
-// ExportContainerArchive creates a .tar.gz archive of the content of the mountpoint.
-func ExportContainerArchive(ctx context.Context, containerID string, mountpoint string, outputDir string) error {
+// ArchiveManifest lists the files of a container archive.
+//
+// The manifest is written next to the archive as <archive>.manifest.json.
+type ArchiveManifest struct {
+	ContainerID string                `json:"container_id"`
+	Archive     string                `json:"archive"`
+	Compression explorers.Compression `json:"compression"`
+	SHA256      string                `json:"sha256"`
+	Created     time.Time             `json:"created"`
+	Entries     []ArchiveEntry        `json:"entries"`
+}
+
+// ExportContainerArchive creates a tar archive of the content of the
+// mountpoint and a manifest with the SHA256 of every file.
+func ExportContainerArchive(ctx context.Context, containerID string, mountpoint string, outputDir string, compression explorers.Compression) error {
 	var success bool
-	archiveFileName := fmt.Sprintf("%s.tar.gz", containerID)
+	archiveFileName := containerID + compression.Extension()
 	archiveFilePath := filepath.Join(outputDir, archiveFileName)
+	manifestFilePath := archiveFilePath + ".manifest.json"
 
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
@@ -212,6 +263,7 @@ func ExportContainerArchive(ctx context.Context, containerID string, mountpoint
 		if !success {
 			log.Infof("cleaning up incomplete archive file: %s", archiveFilePath)
 			os.Remove(archiveFilePath)
+			os.Remove(manifestFilePath)
 		}
 	}()
 
@@ -219,24 +271,112 @@ func ExportContainerArchive(ctx context.Context, containerID string, mountpoint
 		"containerID":     containerID,
 		"mountpoint":      mountpoint,
 		"archiveFilePath": archiveFilePath,
+		"compression":     compression,
 	}).Debug("preparing to create container archive")
 
-	tarArgs := []string{"-czf", archiveFilePath, "-C", mountpoint, "."}
-	tarOutput, err := Runner.Run(ctx, "tar", tarArgs...)
+	//nolint:gosec // G304: Output path is provided by the user
+	f, err := os.Create(archiveFilePath)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"command": fmt.Sprintf("tar %s", strings.Join(tarArgs, " ")),
-			"output":  string(tarOutput),
-			"error":   err,
-		}).Error("tar command failed")
-		return fmt.Errorf("failed to create archive %s: %w. Output: %s", archiveFilePath, err, string(tarOutput))
+		return fmt.Errorf("failed to create archive %s: %w", archiveFilePath, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	out := io.MultiWriter(f, h)
+
+	var cw io.WriteCloser
+	switch compression {
+	case explorers.CompressionZstd:
+		if cw, err = zstd.NewWriter(out); err != nil {
+			return fmt.Errorf("creating zstd writer: %w", err)
+		}
+	case explorers.CompressionNone:
+		cw = nopWriteCloser{out}
+	default:
+		cw = gzip.NewWriter(out)
+	}
+
+	entries, err := WriteArchive(cw, mountpoint)
+	if err != nil {
+		cw.Close()
+		return fmt.Errorf("failed to create archive %s: %w", archiveFilePath, err)
+	}
+	if err := cw.Close(); err != nil {
+		return fmt.Errorf("failed to compress archive %s: %w", archiveFilePath, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close archive %s: %w", archiveFilePath, err)
+	}
+
+	manifest := ArchiveManifest{
+		ContainerID: containerID,
+		Archive:     archiveFileName,
+		Compression: compression,
+		SHA256:      hex.EncodeToString(h.Sum(nil)),
+		Created:     time.Now().UTC(),
+		Entries:     entries,
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling archive manifest: %w", err)
+	}
+	if err := os.WriteFile(manifestFilePath, data, 0644); err != nil {
+		return fmt.Errorf("writing archive manifest %s: %w", manifestFilePath, err)
 	}
 
 	log.WithFields(log.Fields{
 		"archiveFilePath": archiveFilePath,
-		"output":          string(tarOutput),
+		"entries":         len(entries),
+		"sha256":          manifest.SHA256,
 	}).Debug("successfully created container archive")
 
+	custody := CustodyFromContext(ctx)
+	if err := custody.AddArtifact(ArtifactArchive, containerID, archiveFilePath); err != nil {
+		return err
+	}
+	if err := custody.AddArtifact(ArtifactArchiveManifest, containerID, manifestFilePath); err != nil {
+		return err
+	}
+
 	success = true
 	return nil
 }
+
+// ExportLayersArchive creates a tar archive of the content of a layer stack.
+//
+// A single directory is archived as is, keeping the overlay whiteouts. The
+// directories of a layer stack are mounted read-only to a temporary mount
+// point and the merged content is archived.
+func ExportLayersArchive(ctx context.Context, name string, layers explorers.LayerStack, outputDir string, compression explorers.Compression) error {
+	dirs := layers.Dirs()
+	switch len(dirs) {
+	case 0:
+		return fmt.Errorf("no layers to export")
+	case 1:
+		return ExportContainerArchive(ctx, name, dirs[0], outputDir, compression)
+	}
+
+	mountpoint := GetMountPoint()
+	if err := os.MkdirAll(mountpoint, 0755); err != nil {
+		return fmt.Errorf("failed to create mountpoint directory %s: %w", mountpoint, err)
+	}
+	defer os.Remove(mountpoint)
+
+	if err := MountLayers(ctx, MountRecord{Mountpoint: mountpoint, Snapshot: name}, layers); err != nil {
+		return err
+	}
+	defer func() {
+		if err := Unmount(ctx, mountpoint); err != nil {
+			log.WithFields(log.Fields{"mountpoint": mountpoint, "error": err}).Error("unmounting layers")
+		}
+	}()
+
+	return ExportContainerArchive(ctx, name, mountpoint, outputDir, compression)
+}
+
+// nopWriteCloser adds a no-op Close to a writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
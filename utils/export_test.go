@@ -17,7 +17,13 @@ limitations under the License.
 package utils
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -25,6 +31,9 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/google/container-explorer/explorers"
+	"github.com/klauspost/compress/zstd"
 )
 
 type MockCommandCall struct {
@@ -246,59 +255,143 @@ func TestExportContainerImage_CalculateDirectorySizeFailure(t *testing.T) {
 func TestExportContainerArchive_Success(t *testing.T) {
 	tmpDir := t.TempDir()
 	mountpoint := filepath.Join(tmpDir, "container_mount")
-	_ = os.Mkdir(mountpoint, 0755)
+	_ = os.MkdirAll(filepath.Join(mountpoint, "etc"), 0755)
+	_ = os.WriteFile(filepath.Join(mountpoint, "etc", "hostname"), []byte("ctr1"), 0644)
+	_ = os.Symlink("etc/hostname", filepath.Join(mountpoint, "hostname"))
 
 	outputDir := filepath.Join(tmpDir, "output")
 	containerID := "ctr1"
 
-	mockRunner := &MockCommandRunner{
-		Responses: map[string]MockResponse{
-			"tar": {Err: nil},
-		},
-	}
+	mockRunner := &MockCommandRunner{}
 	oldRunner := Runner
 	Runner = mockRunner
 	defer func() { Runner = oldRunner }()
 
-	err := ExportContainerArchive(context.Background(), containerID, mountpoint, outputDir)
-	if err != nil {
-		t.Fatalf("ExportContainerArchive failed: %v", err)
+	for _, tc := range []struct {
+		compression explorers.Compression
+		archive     string
+		decompress  func(io.Reader) (io.Reader, error)
+	}{
+		{explorers.CompressionGzip, "ctr1.tar.gz", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{explorers.CompressionZstd, "ctr1.tar.zst", func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+		{explorers.CompressionNone, "ctr1.tar", func(r io.Reader) (io.Reader, error) { return r, nil }},
+	} {
+		t.Run(string(tc.compression), func(t *testing.T) {
+			err := ExportContainerArchive(context.Background(), containerID, mountpoint, outputDir, tc.compression)
+			if err != nil {
+				t.Fatalf("ExportContainerArchive failed: %v", err)
+			}
+
+			archivePath := filepath.Join(outputDir, tc.archive)
+			data, err := os.ReadFile(archivePath)
+			if err != nil {
+				t.Fatalf("failed to read archive: %v", err)
+			}
+
+			r, err := tc.decompress(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("failed to decompress archive: %v", err)
+			}
+			var names []string
+			tr := tar.NewReader(r)
+			for {
+				hdr, err := tr.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("failed to read archive: %v", err)
+				}
+				names = append(names, hdr.Name)
+			}
+			expectedNames := []string{"etc/", "etc/hostname", "hostname"}
+			if !reflect.DeepEqual(names, expectedNames) {
+				t.Errorf("expected entries %v, got %v", expectedNames, names)
+			}
+
+			manifestData, err := os.ReadFile(archivePath + ".manifest.json")
+			if err != nil {
+				t.Fatalf("failed to read archive manifest: %v", err)
+			}
+			var manifest ArchiveManifest
+			if err := json.Unmarshal(manifestData, &manifest); err != nil {
+				t.Fatalf("failed to decode archive manifest: %v", err)
+			}
+			if manifest.Archive != tc.archive || manifest.Compression != tc.compression || manifest.ContainerID != containerID {
+				t.Errorf("unexpected manifest %+v", manifest)
+			}
+			if manifest.SHA256 != fmt.Sprintf("%x", sha256.Sum256(data)) {
+				t.Errorf("expected archive sha256 %x, got %s", sha256.Sum256(data), manifest.SHA256)
+			}
+			if len(manifest.Entries) != 3 || manifest.Entries[1].SHA256 != fmt.Sprintf("%x", sha256.Sum256([]byte("ctr1"))) {
+				t.Errorf("unexpected manifest entries %+v", manifest.Entries)
+			}
+		})
 	}
 
-	expectedArchiveFile := filepath.Join(outputDir, "ctr1.tar.gz")
-	// Verify tar was called with correct arguments
-	expectedCall := MockCommandCall{
-		Name: "tar",
-		Args: []string{"-czf", expectedArchiveFile, "-C", mountpoint, "."},
+	// The archive is created without external commands
+	if len(mockRunner.Calls) != 0 {
+		t.Errorf("expected no command calls, got %v", mockRunner.Calls)
 	}
+}
+
+func TestExportLayersArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	layerDir := filepath.Join(tmpDir, "layer")
+	_ = os.MkdirAll(layerDir, 0755)
+	_ = os.WriteFile(filepath.Join(layerDir, "payload"), []byte("payload"), 0644)
+	outputDir := filepath.Join(tmpDir, "output")
 
-	if len(mockRunner.Calls) != 1 {
-		t.Fatalf("expected 1 command call, got %d", len(mockRunner.Calls))
+	mockRunner := &MockCommandRunner{}
+	setRunner(t, mockRunner)
+
+	// A single layer is archived without mounting
+	if err := ExportLayersArchive(context.Background(), "snap1", explorers.LayerStack{LowerDirs: []string{layerDir}}, outputDir, explorers.CompressionNone); err != nil {
+		t.Fatalf("ExportLayersArchive failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "snap1.tar")); err != nil {
+		t.Errorf("expected archive: %v", err)
+	}
+	if len(mockRunner.Calls) != 0 {
+		t.Errorf("expected no command calls, got %v", mockRunner.Calls)
+	}
+
+	// A layer stack is mounted, archived, and unmounted
+	layers := explorers.LayerStack{LowerDirs: []string{layerDir, filepath.Join(tmpDir, "base")}}
+	if err := ExportLayersArchive(context.Background(), "snap2", layers, outputDir, explorers.CompressionNone); err != nil {
+		t.Fatalf("ExportLayersArchive failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "snap2.tar")); err != nil {
+		t.Errorf("expected archive: %v", err)
 	}
-	if !reflect.DeepEqual(mockRunner.Calls[0], expectedCall) {
-		t.Errorf("expected call %v, got %v", expectedCall, mockRunner.Calls[0])
+	if len(mockRunner.Calls) != 2 || mockRunner.Calls[0].Name != "mount" || mockRunner.Calls[1].Name != "umount" {
+		t.Fatalf("expected mount and umount calls, got %v", mockRunner.Calls)
+	}
+	mountpoint := mockRunner.Calls[1].Args[0]
+	if _, err := os.Stat(mountpoint); !os.IsNotExist(err) {
+		t.Errorf("expected mountpoint %s to be removed", mountpoint)
+	}
+
+	// A failed mount creates no archive
+	setRunner(t, &MockCommandRunner{Responses: map[string]MockResponse{"mount": {Err: fmt.Errorf("exit status 32")}}})
+	if err := ExportLayersArchive(context.Background(), "snap3", layers, outputDir, explorers.CompressionNone); err == nil {
+		t.Errorf("expected error for failed mount")
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "snap3.tar")); !os.IsNotExist(err) {
+		t.Errorf("expected no archive for failed mount")
+	}
+
+	if err := ExportLayersArchive(context.Background(), "empty", explorers.LayerStack{}, outputDir, explorers.CompressionNone); err == nil {
+		t.Errorf("expected error for empty layer stack")
 	}
 }
 
 func TestExportContainerArchive_Failure(t *testing.T) {
 	tmpDir := t.TempDir()
-	mountpoint := filepath.Join(tmpDir, "container_mount")
-	_ = os.Mkdir(mountpoint, 0755)
-
 	outputDir := filepath.Join(tmpDir, "output")
 	containerID := "ctr1"
 
-	// Mock tar to fail
-	mockRunner := &MockCommandRunner{
-		Responses: map[string]MockResponse{
-			"tar": {Err: fmt.Errorf("tar error"), Output: []byte("tar: write error")},
-		},
-	}
-	oldRunner := Runner
-	Runner = mockRunner
-	defer func() { Runner = oldRunner }()
-
-	err := ExportContainerArchive(context.Background(), containerID, mountpoint, outputDir)
+	err := ExportContainerArchive(context.Background(), containerID, filepath.Join(tmpDir, "non_existent_mount"), outputDir, explorers.CompressionGzip)
 	if err == nil {
 		t.Fatalf("ExportContainerArchive expected failure, got nil")
 	}
@@ -308,4 +401,7 @@ func TestExportContainerArchive_Failure(t *testing.T) {
 	if _, err := os.Stat(expectedArchiveFile); !os.IsNotExist(err) {
 		t.Errorf("expected archive file %s to be cleaned up, but it exists", expectedArchiveFile)
 	}
+	if _, err := os.Stat(expectedArchiveFile + ".manifest.json"); !os.IsNotExist(err) {
+		t.Errorf("expected no archive manifest")
+	}
 }
@@ -0,0 +1,314 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	log "github.com/sirupsen/logrus"
+)
+
+// DockerManifest is an entry of manifest.json used by docker load.
+type DockerManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// ImageWriter writes image blobs as an OCI image layout.
+//
+// The image layout is written either to a directory or to a tar archive.
+// The tar archive also contains manifest.json so that it can be loaded
+// using docker load.
+type ImageWriter struct {
+	path    string
+	archive bool
+	file    *os.File
+	tw      *tar.Writer
+	dirs    map[string]bool
+	written map[digest.Digest]bool
+}
+
+// NewImageWriter returns an ImageWriter writing to outputPath.
+//
+// If archive is true, outputPath is a tar file. Otherwise, outputPath is an
+// OCI image layout directory that must not exist or must be empty.
+func NewImageWriter(outputPath string, archive bool) (*ImageWriter, error) {
+	w := &ImageWriter{
+		path:    outputPath,
+		archive: archive,
+		dirs:    make(map[string]bool),
+		written: make(map[digest.Digest]bool),
+	}
+
+	if archive {
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory %s: %w", filepath.Dir(outputPath), err)
+		}
+
+		//nolint:gosec // G304: Output path is provided by the user
+		f, err := os.OpenFile(outputPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("creating image archive: %w", err)
+		}
+		w.file = f
+		w.tw = tar.NewWriter(f)
+		return w, nil
+	}
+
+	entries, err := os.ReadDir(outputPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading output directory %s: %w", outputPath, err)
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("output directory %s is not empty", outputPath)
+	}
+	if err := os.MkdirAll(outputPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory %s: %w", outputPath, err)
+	}
+	return w, nil
+}
+
+// BlobPath returns the path of a blob relative to the image layout root.
+//
+// i.e. blobs/sha256/<encoded digest>
+func BlobPath(dgst digest.Digest) string {
+	return path.Join(ocispec.ImageBlobsDir, dgst.Algorithm().String(), dgst.Encoded())
+}
+
+// WriteBlob copies the blob from the reader to the image layout.
+//
+// The blob is verified against the digest and size of the descriptor.
+// Blobs already written are skipped.
+func (w *ImageWriter) WriteBlob(desc ocispec.Descriptor, r io.Reader) error {
+	if err := desc.Digest.Validate(); err != nil {
+		return fmt.Errorf("invalid digest %s: %w", desc.Digest, err)
+	}
+	if w.written[desc.Digest] {
+		return nil
+	}
+
+	if w.archive {
+		if err := w.writeArchiveBlob(desc, r); err != nil {
+			return err
+		}
+	} else {
+		if err := w.writeDirectoryBlob(desc, r); err != nil {
+			return err
+		}
+	}
+
+	w.written[desc.Digest] = true
+	log.WithFields(log.Fields{
+		"digest":    desc.Digest,
+		"mediaType": desc.MediaType,
+		"size":      desc.Size,
+	}).Debug("wrote image blob")
+	return nil
+}
+
+// writeArchiveBlob writes a blob to the tar archive.
+//
+// The tar header requires the size in advance. The descriptor size is used
+// and any difference in size or digest is reported as an error.
+func (w *ImageWriter) writeArchiveBlob(desc ocispec.Descriptor, r io.Reader) error {
+	if err := w.writeArchiveDirs(desc.Digest); err != nil {
+		return err
+	}
+
+	hdr := archiveHeader(BlobPath(desc.Digest), desc.Size)
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("writing tar header for %s: %w", desc.Digest, err)
+	}
+
+	digester := desc.Digest.Algorithm().Digester()
+	n, err := io.CopyN(w.tw, io.TeeReader(r, digester.Hash()), desc.Size)
+	if err != nil {
+		return fmt.Errorf("blob %s is %d bytes, expected %d bytes: %w", desc.Digest, n, desc.Size, err)
+	}
+
+	// The blob must not be larger than the descriptor size
+	if extra, _ := io.Copy(io.Discard, r); extra > 0 {
+		return fmt.Errorf("blob %s is larger than expected %d bytes", desc.Digest, desc.Size)
+	}
+
+	if digester.Digest() != desc.Digest {
+		return fmt.Errorf("blob digest mismatch: expected %s, got %s", desc.Digest, digester.Digest())
+	}
+	return nil
+}
+
+// writeArchiveDirs writes the blob directory entries to the tar archive.
+func (w *ImageWriter) writeArchiveDirs(dgst digest.Digest) error {
+	for _, dir := range []string{ocispec.ImageBlobsDir, path.Join(ocispec.ImageBlobsDir, dgst.Algorithm().String())} {
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.tw.WriteHeader(archiveDirHeader(dir)); err != nil {
+			return fmt.Errorf("writing tar header for %s: %w", dir, err)
+		}
+		w.dirs[dir] = true
+	}
+	return nil
+}
+
+// writeDirectoryBlob writes a blob to the image layout directory.
+//
+// The blob is written to a temporary file and renamed after verification.
+func (w *ImageWriter) writeDirectoryBlob(desc ocispec.Descriptor, r io.Reader) error {
+	blobPath := filepath.Join(w.path, filepath.FromSlash(BlobPath(desc.Digest)))
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0755); err != nil {
+		return fmt.Errorf("creating blob directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(blobPath), ".tmp-")
+	if err != nil {
+		return fmt.Errorf("creating temporary blob file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	digester := desc.Digest.Algorithm().Digester()
+	n, err := io.Copy(io.MultiWriter(tmp, digester.Hash()), r)
+	if err != nil {
+		return fmt.Errorf("writing blob %s: %w", desc.Digest, err)
+	}
+	if n != desc.Size {
+		return fmt.Errorf("blob %s is %d bytes, expected %d bytes", desc.Digest, n, desc.Size)
+	}
+	if digester.Digest() != desc.Digest {
+		return fmt.Errorf("blob digest mismatch: expected %s, got %s", desc.Digest, digester.Digest())
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing blob %s: %w", desc.Digest, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("setting blob %s permissions: %w", desc.Digest, err)
+	}
+	return os.Rename(tmp.Name(), blobPath)
+}
+
+// Finish writes oci-layout, index.json, and manifest.json and closes the
+// image writer.
+//
+// manifest.json is only written to a tar archive.
+func (w *ImageWriter) Finish(index ocispec.Index, manifests []DockerManifest) error {
+	layout, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		return fmt.Errorf("marshaling image layout: %w", err)
+	}
+
+	indexData, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("marshaling image index: %w", err)
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{ocispec.ImageLayoutFile, layout},
+		{ocispec.ImageIndexFile, indexData},
+	}
+
+	if w.archive {
+		manifestData, err := json.Marshal(manifests)
+		if err != nil {
+			return fmt.Errorf("marshaling docker manifest: %w", err)
+		}
+		files = append(files, struct {
+			name string
+			data []byte
+		}{"manifest.json", manifestData})
+	}
+
+	for _, file := range files {
+		if w.archive {
+			if err := w.tw.WriteHeader(archiveHeader(file.name, int64(len(file.data)))); err != nil {
+				return fmt.Errorf("writing tar header for %s: %w", file.name, err)
+			}
+			if _, err := io.Copy(w.tw, bytes.NewReader(file.data)); err != nil {
+				return fmt.Errorf("writing %s: %w", file.name, err)
+			}
+			continue
+		}
+
+		if err := os.WriteFile(filepath.Join(w.path, file.name), file.data, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", file.name, err)
+		}
+	}
+
+	if w.archive {
+		if err := w.tw.Close(); err != nil {
+			return fmt.Errorf("closing tar archive: %w", err)
+		}
+		if err := w.file.Close(); err != nil {
+			return fmt.Errorf("closing image archive: %w", err)
+		}
+	}
+	return nil
+}
+
+// Abort closes the image writer and removes the incomplete output.
+func (w *ImageWriter) Abort() {
+	log.Infof("cleaning up incomplete image export: %s", w.path)
+
+	if w.archive {
+		_ = w.file.Close()
+		_ = os.Remove(w.path)
+		return
+	}
+	_ = os.RemoveAll(w.path)
+}
+
+// archiveHeader returns a tar header for a regular file in the image archive.
+//
+// The header uses fixed ownership and timestamps so that exporting the same
+// image produces the same archive.
+func archiveHeader(name string, size int64) *tar.Header {
+	return &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  time.Unix(0, 0),
+		Format:   tar.FormatPAX,
+	}
+}
+
+// archiveDirHeader returns a tar header for a directory in the image archive.
+func archiveDirHeader(name string) *tar.Header {
+	return &tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name + "/",
+		Mode:     0755,
+		ModTime:  time.Unix(0, 0),
+		Format:   tar.FormatPAX,
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func testBlob(data string) (ocispec.Descriptor, io.Reader) {
+	return ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayerGzip,
+		Digest:    digest.FromString(data),
+		Size:      int64(len(data)),
+	}, strings.NewReader(data)
+}
+
+func TestImageWriter_Archive(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "out", "image.tar")
+
+	w, err := NewImageWriter(outputPath, true)
+	if err != nil {
+		t.Fatalf("NewImageWriter failed: %v", err)
+	}
+
+	desc, r := testBlob("layer")
+	if err := w.WriteBlob(desc, r); err != nil {
+		t.Fatalf("WriteBlob failed: %v", err)
+	}
+	// Writing the same blob again is a no-op
+	if err := w.WriteBlob(desc, strings.NewReader("layer")); err != nil {
+		t.Fatalf("WriteBlob of existing blob failed: %v", err)
+	}
+
+	index := ocispec.Index{Manifests: []ocispec.Descriptor{desc}}
+	manifests := []DockerManifest{{Config: BlobPath(desc.Digest), RepoTags: []string{"test:latest"}}}
+	if err := w.Finish(index, manifests); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+
+	f, err := os.Open(outputPath)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer f.Close()
+
+	entries := make(map[string][]byte)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read archive: %v", err)
+		}
+		data, _ := io.ReadAll(tr)
+		entries[hdr.Name] = data
+	}
+
+	expected := []string{"blobs/", "blobs/sha256/", BlobPath(desc.Digest), "oci-layout", "index.json", "manifest.json"}
+	if len(entries) != len(expected) {
+		t.Errorf("expected %d entries, got %d", len(expected), len(entries))
+	}
+	for _, name := range expected {
+		if _, ok := entries[name]; !ok {
+			t.Errorf("expected archive entry %s", name)
+		}
+	}
+
+	if string(entries[BlobPath(desc.Digest)]) != "layer" {
+		t.Errorf("expected blob content 'layer', got %q", entries[BlobPath(desc.Digest)])
+	}
+
+	var gotManifests []DockerManifest
+	if err := json.Unmarshal(entries["manifest.json"], &gotManifests); err != nil {
+		t.Fatalf("failed to unmarshal manifest.json: %v", err)
+	}
+	if len(gotManifests) != 1 || gotManifests[0].RepoTags[0] != "test:latest" {
+		t.Errorf("unexpected manifest.json: %s", entries["manifest.json"])
+	}
+}
+
+func TestImageWriter_Layout(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "layout")
+
+	w, err := NewImageWriter(outputPath, false)
+	if err != nil {
+		t.Fatalf("NewImageWriter failed: %v", err)
+	}
+
+	desc, r := testBlob("config")
+	if err := w.WriteBlob(desc, r); err != nil {
+		t.Fatalf("WriteBlob failed: %v", err)
+	}
+	if err := w.Finish(ocispec.Index{Manifests: []ocispec.Descriptor{desc}}, nil); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputPath, "blobs", "sha256", desc.Digest.Encoded()))
+	if err != nil || string(data) != "config" {
+		t.Errorf("expected blob content 'config', got %q (err: %v)", data, err)
+	}
+
+	layout, err := os.ReadFile(filepath.Join(outputPath, "oci-layout"))
+	if err != nil || !bytes.Contains(layout, []byte(ocispec.ImageLayoutVersion)) {
+		t.Errorf("expected oci-layout with version %s, got %q (err: %v)", ocispec.ImageLayoutVersion, layout, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(outputPath, "index.json")); err != nil {
+		t.Errorf("expected index.json: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputPath, "manifest.json")); err == nil {
+		t.Error("expected no manifest.json in image layout directory")
+	}
+
+	// A non-empty directory is rejected
+	if _, err := NewImageWriter(outputPath, false); err == nil {
+		t.Error("expected error for non-empty output directory, got nil")
+	}
+}
+
+func TestImageWriter_VerifyBlob(t *testing.T) {
+	tests := []struct {
+		name    string
+		archive bool
+		data    string
+	}{
+		{name: "Archive digest mismatch", archive: true, data: "tamper"},
+		{name: "Archive larger blob", archive: true, data: "layer and more"},
+		{name: "Archive smaller blob", archive: true, data: "lay"},
+		{name: "Layout digest mismatch", archive: false, data: "tamper"},
+		{name: "Layout size mismatch", archive: false, data: "layer and more"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "image")
+
+			w, err := NewImageWriter(outputPath, tt.archive)
+			if err != nil {
+				t.Fatalf("NewImageWriter failed: %v", err)
+			}
+
+			desc, _ := testBlob("layer")
+			if err := w.WriteBlob(desc, strings.NewReader(tt.data)); err == nil {
+				t.Fatal("expected WriteBlob error, got nil")
+			}
+
+			w.Abort()
+			if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+				t.Errorf("expected output %s to be removed, got %v", outputPath, err)
+			}
+		})
+	}
+}
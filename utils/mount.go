@@ -0,0 +1,401 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/container-explorer/explorers"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// MountRegistryName is the name of the mount registry file. A registry is kept
+// in the parent directory of each mountpoint, i.e. the mountpoint root.
+const MountRegistryName = ".container-explorer-mounts.json"
+
+// Mount types recorded in the mount registry.
+const (
+	MountTypeOverlay = "overlay"
+	MountTypeBind    = "bind"
+	MountTypeLoop    = "loop"
+)
+
+// mountInfoPath is the mount table of the current process.
+var mountInfoPath = "/proc/self/mountinfo"
+
+// MountRecord is a mount or loop device created by container-explorer.
+type MountRecord struct {
+	Mountpoint  string    `json:"mountpoint"`
+	Type        string    `json:"type"`
+	Source      string    `json:"source,omitempty"`
+	Device      string    `json:"device,omitempty"`
+	ContainerID string    `json:"container_id,omitempty"`
+	Image       string    `json:"image,omitempty"`
+	Snapshot    string    `json:"snapshot,omitempty"`
+	PID         int       `json:"pid"`
+	CreatedAt   time.Time `json:"created_at"`
+	Active      bool      `json:"active,omitempty"`
+}
+
+// mountRegistry is the content of a mount registry file.
+type mountRegistry struct {
+	Mounts []MountRecord `json:"mounts"`
+}
+
+// MountRegistryPath returns the mount registry file of a mountpoint root.
+func MountRegistryPath(root string) string {
+	return filepath.Join(root, MountRegistryName)
+}
+
+// Mount runs the mount command and records the mount in the registry of the
+// mountpoint root.
+func Mount(ctx context.Context, record MountRecord, args ...string) ([]byte, error) {
+	out, err := Runner.Run(ctx, "mount", args...)
+	if err != nil {
+		return out, err
+	}
+
+	if err := RegisterMount(record); err != nil {
+		log.WithFields(log.Fields{"mountpoint": record.Mountpoint, "error": err}).Warn("recording mount")
+	}
+	return out, nil
+}
+
+// MountLayers mounts the directories of a layer stack read-only. The upper
+// directory, if any, is mounted as the top lower directory.
+//
+// A single directory is bind mounted, as overlayfs requires two lower
+// directories without an upper directory.
+func MountLayers(ctx context.Context, record MountRecord, layers explorers.LayerStack) error {
+	dirs := layers.Dirs()
+
+	var args []string
+	switch len(dirs) {
+	case 0:
+		return fmt.Errorf("no layers to mount")
+	case 1:
+		record.Type = MountTypeBind
+		args = []string{"-o", "bind,ro", dirs[0], record.Mountpoint}
+	default:
+		record.Type = MountTypeOverlay
+		args = []string{"-t", "overlay", "overlay", "-o", "ro,lowerdir=" + strings.Join(dirs, ":"), record.Mountpoint}
+	}
+	record.Source = dirs[0]
+
+	log.WithFields(log.Fields{
+		"mountpoint": record.Mountpoint,
+		"type":       record.Type,
+		"layers":     len(dirs),
+	}).Debug("mounting layers")
+
+	out, err := Mount(ctx, record, args...)
+	if err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("running mount command: %w; output: %s", err, strings.TrimSpace(string(out)))
+		}
+		return fmt.Errorf("running mount command: %w", err)
+	}
+	return nil
+}
+
+// RegisterMount records a mount in the registry of the mountpoint root. An
+// existing record of the mountpoint is replaced.
+func RegisterMount(record MountRecord) error {
+	mountpoint, err := filepath.Abs(record.Mountpoint)
+	if err != nil {
+		return err
+	}
+	record.Mountpoint = mountpoint
+	record.PID = os.Getpid()
+	record.Active = false
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+
+	return updateMountRegistry(filepath.Dir(mountpoint), func(registry *mountRegistry) {
+		mounts := registry.Mounts[:0]
+		for _, m := range registry.Mounts {
+			if m.Mountpoint != mountpoint {
+				mounts = append(mounts, m)
+			}
+		}
+		registry.Mounts = append(mounts, record)
+	})
+}
+
+// UnregisterMount removes a mountpoint from the registry of the mountpoint
+// root. The registry file is removed when it has no records left.
+func UnregisterMount(mountpoint string) error {
+	mountpoint, err := filepath.Abs(mountpoint)
+	if err != nil {
+		return err
+	}
+
+	root := filepath.Dir(mountpoint)
+	if _, err := os.Stat(MountRegistryPath(root)); os.IsNotExist(err) {
+		return nil
+	}
+
+	return updateMountRegistry(root, func(registry *mountRegistry) {
+		mounts := registry.Mounts[:0]
+		for _, m := range registry.Mounts {
+			if m.Mountpoint != mountpoint {
+				mounts = append(mounts, m)
+			}
+		}
+		registry.Mounts = mounts
+	})
+}
+
+// updateMountRegistry applies an update to a registry file while holding an
+// exclusive lock on it.
+func updateMountRegistry(root string, update func(*mountRegistry)) error {
+	registryPath := MountRegistryPath(root)
+
+	//nolint:gosec // G304: Registry path is within the mountpoint root
+	f, err := os.OpenFile(registryPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("opening mount registry %s: %w", registryPath, err)
+	}
+	defer f.Close()
+
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		return fmt.Errorf("locking mount registry %s: %w", registryPath, err)
+	}
+	defer func() { _ = unix.Flock(int(f.Fd()), unix.LOCK_UN) }()
+
+	registry, err := decodeMountRegistry(f)
+	if err != nil {
+		return fmt.Errorf("reading mount registry %s: %w", registryPath, err)
+	}
+	update(&registry)
+
+	if len(registry.Mounts) == 0 {
+		return os.Remove(registryPath)
+	}
+
+	data, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("writing mount registry %s: %w", registryPath, err)
+	}
+	return nil
+}
+
+// decodeMountRegistry decodes a registry file. An empty file is an empty
+// registry.
+func decodeMountRegistry(r io.Reader) (mountRegistry, error) {
+	var registry mountRegistry
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return registry, err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return registry, nil
+	}
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return registry, err
+	}
+	return registry, nil
+}
+
+// ReadMountRegistry returns the mounts recorded in a mountpoint root.
+func ReadMountRegistry(root string) ([]MountRecord, error) {
+	//nolint:gosec // G304: Registry path is within the mountpoint root
+	f, err := os.Open(MountRegistryPath(root))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	registry, err := decodeMountRegistry(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading mount registry %s: %w", f.Name(), err)
+	}
+	return registry.Mounts, nil
+}
+
+// RegisteredMounts returns the mounts recorded in the specified mountpoint
+// roots and marks the mounts that are active.
+//
+// If no root is specified, the roots of the active mounts and the temporary
+// directories used by export are searched.
+func RegisteredMounts(roots ...string) ([]MountRecord, error) {
+	active, err := ActiveMountpoints()
+	if err != nil {
+		log.WithField("error", err).Warn("reading active mounts")
+	}
+
+	if len(roots) == 0 {
+		roots = append(roots, os.TempDir(), filepath.Join(os.TempDir(), "mnt"))
+		for mountpoint := range active {
+			roots = append(roots, filepath.Dir(mountpoint))
+		}
+	}
+
+	seen := make(map[string]bool)
+	var records []MountRecord
+	for _, root := range roots {
+		root, err := filepath.Abs(root)
+		if err != nil || seen[root] {
+			continue
+		}
+		seen[root] = true
+
+		mounts, err := ReadMountRegistry(root)
+		if err != nil {
+			log.WithFields(log.Fields{"root": root, "error": err}).Warn("reading mount registry")
+			continue
+		}
+		for _, m := range mounts {
+			m.Active = active[m.Mountpoint]
+			records = append(records, m)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Mountpoint < records[j].Mountpoint
+	})
+	return records, nil
+}
+
+// ActiveMountpoints returns the mountpoints of the current mount namespace.
+func ActiveMountpoints() (map[string]bool, error) {
+	f, err := os.Open(mountInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mountpoints := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// The fifth field is the mountpoint relative to the process root.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mountpoints[unescapeMountInfo(fields[4])] = true
+	}
+	return mountpoints, scanner.Err()
+}
+
+// unescapeMountInfo decodes the octal escapes used for spaces, tabs,
+// newlines, and backslashes in the mount table.
+func unescapeMountInfo(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// Unmount unmounts a mountpoint, falling back to a lazy unmount, detaches the
+// loop device recorded for the mountpoint, and removes the mountpoint from the
+// registry. The commands are not cancelled with the context, as Unmount
+// cleans up after a cancelled operation.
+func Unmount(ctx context.Context, mountpoint string) error {
+	ctx = context.WithoutCancel(ctx)
+	mountpoint, err := filepath.Abs(mountpoint)
+	if err != nil {
+		return err
+	}
+
+	var record MountRecord
+	mounts, err := ReadMountRegistry(filepath.Dir(mountpoint))
+	if err != nil {
+		log.WithFields(log.Fields{"mountpoint": mountpoint, "error": err}).Warn("reading mount registry")
+	}
+	for _, m := range mounts {
+		if m.Mountpoint == mountpoint {
+			record = m
+		}
+	}
+
+	var errs []error
+	if err := unmount(ctx, mountpoint); err != nil {
+		errs = append(errs, err)
+	}
+
+	if record.Device != "" {
+		out, err := Runner.Run(ctx, "losetup", "-d", record.Device)
+		if err != nil {
+			log.WithFields(log.Fields{"device": record.Device, "output": strings.TrimSpace(string(out)), "error": err}).Warn("detaching loop device")
+			errs = append(errs, fmt.Errorf("detaching loop device %s: %w", record.Device, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return UnregisterMount(mountpoint)
+}
+
+// unmount runs umount and, if the mountpoint is busy, umount -l. A mountpoint
+// that is not mounted is not an error.
+func unmount(ctx context.Context, mountpoint string) error {
+	out, err := Runner.Run(ctx, "umount", mountpoint)
+	if err == nil {
+		log.WithField("mountpoint", mountpoint).Info("unmounted")
+		return nil
+	}
+	log.WithFields(log.Fields{"mountpoint": mountpoint, "output": strings.TrimSpace(string(out)), "error": err}).Warn("unmounting, attempting lazy unmount")
+
+	lazyOut, lazyErr := Runner.Run(ctx, "umount", "-l", mountpoint)
+	if lazyErr == nil {
+		log.WithField("mountpoint", mountpoint).Info("lazily unmounted")
+		return nil
+	}
+
+	if active, activeErr := ActiveMountpoints(); activeErr == nil && !active[mountpoint] {
+		log.WithField("mountpoint", mountpoint).Debug("mountpoint is not mounted")
+		return nil
+	}
+	return fmt.Errorf("unmounting %s: %w; output: %s", mountpoint, lazyErr, strings.TrimSpace(string(lazyOut)))
+}
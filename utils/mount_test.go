@@ -0,0 +1,284 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/container-explorer/explorers"
+)
+
+// setMountInfo replaces the mount table with the specified mountpoints.
+func setMountInfo(t *testing.T, mountpoints ...string) {
+	t.Helper()
+
+	var b strings.Builder
+	for i, mountpoint := range mountpoints {
+		fmt.Fprintf(&b, "%d 1 0:%d / %s ro,relatime shared:1 - overlay overlay ro\n", 100+i, 50+i, strings.ReplaceAll(mountpoint, " ", `\040`))
+	}
+	path := filepath.Join(t.TempDir(), "mountinfo")
+	_ = os.WriteFile(path, []byte(b.String()), 0644)
+
+	oldPath := mountInfoPath
+	mountInfoPath = path
+	t.Cleanup(func() { mountInfoPath = oldPath })
+}
+
+func setRunner(t *testing.T, runner CommandRunner) {
+	t.Helper()
+
+	oldRunner := Runner
+	Runner = runner
+	t.Cleanup(func() { Runner = oldRunner })
+}
+
+func TestMount(t *testing.T) {
+	root := t.TempDir()
+	mountpoint := filepath.Join(root, "ctr1")
+	mockRunner := &MockCommandRunner{}
+	setRunner(t, mockRunner)
+
+	args := []string{"-t", "overlay", "overlay", "-o", "ro,lowerdir=/upper:/lower", mountpoint}
+	if _, err := Mount(context.Background(), MountRecord{Mountpoint: mountpoint, Type: MountTypeOverlay, Source: "/upper", ContainerID: "ctr1"}, args...); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	if len(mockRunner.Calls) != 1 || mockRunner.Calls[0].Name != "mount" {
+		t.Errorf("expected mount command, got %v", mockRunner.Calls)
+	}
+
+	records, err := ReadMountRegistry(root)
+	if err != nil {
+		t.Fatalf("ReadMountRegistry failed: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	if records[0].Mountpoint != mountpoint || records[0].ContainerID != "ctr1" || records[0].PID != os.Getpid() || records[0].CreatedAt.IsZero() {
+		t.Errorf("unexpected record %+v", records[0])
+	}
+
+	// A mountpoint is recorded once
+	_, _ = Mount(context.Background(), MountRecord{Mountpoint: mountpoint, Type: MountTypeOverlay, ContainerID: "ctr1"}, args...)
+	if records, _ := ReadMountRegistry(root); len(records) != 1 {
+		t.Errorf("expected 1 record after remount, got %d", len(records))
+	}
+
+	// A failed mount is not recorded
+	failed := filepath.Join(root, "ctr2")
+	setRunner(t, &MockCommandRunner{Responses: map[string]MockResponse{"mount": {Err: fmt.Errorf("exit status 32")}}})
+	if _, err := Mount(context.Background(), MountRecord{Mountpoint: failed, Type: MountTypeOverlay}, "-t", "overlay", failed); err == nil {
+		t.Errorf("expected mount error")
+	}
+	if records, _ := ReadMountRegistry(root); len(records) != 1 {
+		t.Errorf("expected failed mount not to be recorded, got %d records", len(records))
+	}
+}
+
+func TestMountLayers(t *testing.T) {
+	root := t.TempDir()
+
+	tests := []struct {
+		name     string
+		layers   explorers.LayerStack
+		args     string
+		wantType string
+	}{
+		{
+			name:     "Image layers",
+			layers:   explorers.LayerStack{LowerDirs: []string{"/top", "/base"}},
+			args:     "-t overlay overlay -o ro,lowerdir=/top:/base " + filepath.Join(root, "Image layers"),
+			wantType: MountTypeOverlay,
+		},
+		{
+			name:     "Container layers",
+			layers:   explorers.LayerStack{UpperDir: "/upper", LowerDirs: []string{"/base"}},
+			args:     "-t overlay overlay -o ro,lowerdir=/upper:/base " + filepath.Join(root, "Container layers"),
+			wantType: MountTypeOverlay,
+		},
+		{
+			name:     "Single layer",
+			layers:   explorers.LayerStack{LowerDirs: []string{"/base"}},
+			args:     "-o bind,ro /base " + filepath.Join(root, "Single layer"),
+			wantType: MountTypeBind,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRunner := &MockCommandRunner{}
+			setRunner(t, mockRunner)
+
+			mountpoint := filepath.Join(root, tt.name)
+			if err := MountLayers(context.Background(), MountRecord{Mountpoint: mountpoint, Image: "alpine"}, tt.layers); err != nil {
+				t.Fatalf("MountLayers failed: %v", err)
+			}
+			if len(mockRunner.Calls) != 1 || strings.Join(mockRunner.Calls[0].Args, " ") != tt.args {
+				t.Errorf("expected mount %s, got %v", tt.args, mockRunner.Calls)
+			}
+
+			records, _ := ReadMountRegistry(root)
+			found := false
+			for _, r := range records {
+				if r.Mountpoint == mountpoint {
+					found = r.Type == tt.wantType && r.Image == "alpine"
+				}
+			}
+			if !found {
+				t.Errorf("expected %s record for %s, got %+v", tt.wantType, mountpoint, records)
+			}
+		})
+	}
+
+	if err := MountLayers(context.Background(), MountRecord{Mountpoint: filepath.Join(root, "empty")}, explorers.LayerStack{}); err == nil {
+		t.Errorf("expected error for empty layer stack")
+	}
+}
+
+func TestRegisteredMounts(t *testing.T) {
+	root := t.TempDir()
+	active := filepath.Join(root, "active dir")
+	inactive := filepath.Join(root, "inactive")
+	setMountInfo(t, "/", active)
+
+	_ = RegisterMount(MountRecord{Mountpoint: inactive, Type: MountTypeLoop, Device: "/dev/loop7"})
+	_ = RegisterMount(MountRecord{Mountpoint: active, Type: MountTypeOverlay})
+
+	records, err := RegisteredMounts(root)
+	if err != nil {
+		t.Fatalf("RegisteredMounts failed: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Mountpoint != active || !records[0].Active {
+		t.Errorf("expected active record for %s, got %+v", active, records[0])
+	}
+	if records[1].Mountpoint != inactive || records[1].Active {
+		t.Errorf("expected inactive record for %s, got %+v", inactive, records[1])
+	}
+
+	// Roots of the active mounts are searched by default
+	records, _ = RegisteredMounts()
+	found := false
+	for _, r := range records {
+		if r.Mountpoint == active {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected %s in the default roots, got %+v", active, records)
+	}
+}
+
+func TestUnmount(t *testing.T) {
+	root := t.TempDir()
+	mountpoint := filepath.Join(root, "image")
+	_ = RegisterMount(MountRecord{Mountpoint: mountpoint, Type: MountTypeLoop, Device: "/dev/loop7"})
+
+	mockRunner := &MockCommandRunner{}
+	setRunner(t, mockRunner)
+
+	if err := Unmount(context.Background(), mountpoint); err != nil {
+		t.Fatalf("Unmount failed: %v", err)
+	}
+
+	expected := []string{"umount " + mountpoint, "losetup -d /dev/loop7"}
+	var calls []string
+	for _, c := range mockRunner.Calls {
+		calls = append(calls, c.Name+" "+strings.Join(c.Args, " "))
+	}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected calls %v, got %v", expected, calls)
+	}
+	if _, err := os.Stat(MountRegistryPath(root)); !os.IsNotExist(err) {
+		t.Errorf("expected empty registry to be removed")
+	}
+}
+
+func TestUnmount_Custody(t *testing.T) {
+	root := t.TempDir()
+	mountpoint := filepath.Join(root, "image")
+	_ = RegisterMount(MountRecord{Mountpoint: mountpoint, Type: MountTypeLoop, Device: "/dev/loop7"})
+
+	setRunner(t, NewRecordingRunner(&MockCommandRunner{}))
+
+	// The commands of a cancelled operation are recorded to its custody record.
+	custody := NewCustody("examiner", "test", nil)
+	ctx, cancel := context.WithCancel(WithCustody(context.Background(), custody))
+	cancel()
+	if err := Unmount(ctx, mountpoint); err != nil {
+		t.Fatalf("Unmount failed: %v", err)
+	}
+
+	var commands []string
+	for _, c := range custody.Commands {
+		commands = append(commands, c.Name+" "+strings.Join(c.Args, " "))
+	}
+	if got := strings.Join(commands, ","); got != "umount "+mountpoint+",losetup -d /dev/loop7" {
+		t.Errorf("unexpected custody commands %s", got)
+	}
+}
+
+func TestUnmount_Lazy(t *testing.T) {
+	root := t.TempDir()
+	mountpoint := filepath.Join(root, "ctr1")
+	_ = RegisterMount(MountRecord{Mountpoint: mountpoint, Type: MountTypeOverlay})
+
+	// Busy and still mounted
+	setMountInfo(t, mountpoint)
+	mockRunner := &MockCommandRunner{Responses: map[string]MockResponse{"umount": {Err: fmt.Errorf("target is busy")}}}
+	setRunner(t, mockRunner)
+
+	if err := Unmount(context.Background(), mountpoint); err == nil {
+		t.Errorf("expected error for busy mount")
+	}
+	if len(mockRunner.Calls) != 2 || strings.Join(mockRunner.Calls[1].Args, " ") != "-l "+mountpoint {
+		t.Errorf("expected lazy unmount, got %v", mockRunner.Calls)
+	}
+	if records, _ := ReadMountRegistry(root); len(records) != 1 {
+		t.Errorf("expected record to be kept after a failed unmount")
+	}
+
+	// Not mounted
+	setMountInfo(t)
+	if err := Unmount(context.Background(), mountpoint); err != nil {
+		t.Errorf("expected no error for a mountpoint that is not mounted, got %v", err)
+	}
+	if records, _ := ReadMountRegistry(root); len(records) != 0 {
+		t.Errorf("expected record to be removed, got %+v", records)
+	}
+}
+
+func TestUnescapeMountInfo(t *testing.T) {
+	tests := map[string]string{
+		"/mnt/ctr":            "/mnt/ctr",
+		`/mnt/my\040dir`:      "/mnt/my dir",
+		`/mnt/a\011b\134c`:    "/mnt/a\tb\\c",
+		`/mnt/trailing\04`:    `/mnt/trailing\04`,
+		`/mnt/not\999escaped`: `/mnt/not\999escaped`,
+	}
+	for input, expected := range tests {
+		if got := unescapeMountInfo(input); got != expected {
+			t.Errorf("unescapeMountInfo(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	blockSize = 512
+
+	paxGNUSparseMajor    = "GNU.sparse.major"
+	paxGNUSparseMinor    = "GNU.sparse.minor"
+	paxGNUSparseName     = "GNU.sparse.name"
+	paxGNUSparseRealSize = "GNU.sparse.realsize"
+
+	// maxOctal11 is the largest value of an 11 digit octal header field.
+	maxOctal11 = 1<<33 - 1
+	// maxOctal7 is the largest value of a 7 digit octal header field.
+	maxOctal7 = 1<<21 - 1
+)
+
+// sparseRegion is a data region of a sparse file.
+type sparseRegion struct {
+	Offset int64
+	Length int64
+}
+
+// dataRegions returns the data regions of a file using SEEK_DATA and
+// SEEK_HOLE. A nil slice is returned if the file system does not report holes.
+func dataRegions(f *os.File, size int64) ([]sparseRegion, error) {
+	fd := int(f.Fd())
+	regions := []sparseRegion{}
+
+	for offset := int64(0); offset < size; {
+		data, err := unix.Seek(fd, offset, unix.SEEK_DATA)
+		if err != nil {
+			// ENXIO: no data after offset, the file ends with a hole
+			if errors.Is(err, unix.ENXIO) {
+				break
+			}
+			if errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EOPNOTSUPP) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if data >= size {
+			break
+		}
+
+		hole, err := unix.Seek(fd, data, unix.SEEK_HOLE)
+		if err != nil {
+			return nil, err
+		}
+		hole = min(hole, size)
+
+		regions = append(regions, sparseRegion{Offset: data, Length: hole - data})
+		offset = hole
+	}
+
+	// A file without holes is not sparse.
+	if len(regions) == 1 && regions[0].Offset == 0 && regions[0].Length == size {
+		return nil, nil
+	}
+
+	// The sparse map must cover the file size. An empty region is added
+	// if the file ends with a hole.
+	if len(regions) == 0 || regions[len(regions)-1].Offset+regions[len(regions)-1].Length < size {
+		regions = append(regions, sparseRegion{Offset: size, Length: 0})
+	}
+	return regions, nil
+}
+
+// writeSparse writes a sparse file in the PAX 1.0 sparse format.
+//
+// archive/tar does not write sparse files, so the PAX and ustar headers are
+// encoded here and written directly to the underlying writer. The entry data
+// is the sparse map followed by the data regions.
+func (t *treeWriter) writeSparse(f *os.File, hdr *tar.Header, regions []sparseRegion, h hash.Hash) error {
+	// Pad the previous entry
+	if err := t.tw.Flush(); err != nil {
+		return err
+	}
+
+	var sparseMap strings.Builder
+	sparseMap.WriteString(strconv.Itoa(len(regions)) + "\n")
+	var dataSize int64
+	for _, r := range regions {
+		sparseMap.WriteString(strconv.FormatInt(r.Offset, 10) + "\n" + strconv.FormatInt(r.Length, 10) + "\n")
+		dataSize += r.Length
+	}
+	mapData := padBlock([]byte(sparseMap.String()))
+	entrySize := int64(len(mapData)) + dataSize
+
+	records := make(map[string]string)
+	for key, value := range hdr.PAXRecords {
+		records[key] = value
+	}
+	records[paxGNUSparseMajor] = "1"
+	records[paxGNUSparseMinor] = "0"
+	records[paxGNUSparseName] = hdr.Name
+	records[paxGNUSparseRealSize] = strconv.FormatInt(hdr.Size, 10)
+	records["mtime"] = formatPAXTime(hdr.ModTime.Unix(), int64(hdr.ModTime.Nanosecond()))
+	if entrySize > maxOctal11 {
+		records["size"] = strconv.FormatInt(entrySize, 10)
+	}
+	if hdr.Uid > maxOctal7 {
+		records["uid"] = strconv.Itoa(hdr.Uid)
+	}
+	if hdr.Gid > maxOctal7 {
+		records["gid"] = strconv.Itoa(hdr.Gid)
+	}
+
+	dir, file := path.Split(hdr.Name)
+	paxData := encodePAXRecords(records)
+	paxHeader := ustarHeader(path.Join(dir, "PaxHeaders.0", file), tar.TypeXHeader, 0o644, 0, 0, int64(len(paxData)), hdr.ModTime.Unix())
+	fileHeader := ustarHeader(path.Join(dir, "GNUSparseFile.0", file), tar.TypeReg, hdr.Mode, hdr.Uid, hdr.Gid, entrySize, hdr.ModTime.Unix())
+
+	for _, b := range [][]byte{paxHeader, padBlock(paxData), fileHeader, mapData} {
+		if _, err := t.w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	// The file hash includes the zeros of the holes.
+	var offset int64
+	for _, r := range regions {
+		hashZeros(h, r.Offset-offset)
+		if _, err := io.Copy(io.MultiWriter(t.w, h), io.NewSectionReader(f, r.Offset, r.Length)); err != nil {
+			return err
+		}
+		offset = r.Offset + r.Length
+	}
+	hashZeros(h, hdr.Size-offset)
+
+	if pad := (blockSize - dataSize%blockSize) % blockSize; pad > 0 {
+		if _, err := t.w.Write(make([]byte, pad)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodePAXRecords encodes the records of a PAX extended header sorted by key.
+func encodePAXRecords(records map[string]string) []byte {
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		// The record length includes the length field itself.
+		record := " " + key + "=" + records[key] + "\n"
+		size := len(record) + len(strconv.Itoa(len(record)))
+		if len(strconv.Itoa(size)) > len(strconv.Itoa(len(record))) {
+			size++
+		}
+		b.WriteString(strconv.Itoa(size) + record)
+	}
+	return []byte(b.String())
+}
+
+// formatPAXTime formats a timestamp as a PAX decimal time.
+func formatPAXTime(sec, nsec int64) string {
+	if nsec == 0 {
+		return strconv.FormatInt(sec, 10)
+	}
+	return strings.TrimRight(fmt.Sprintf("%d.%09d", sec, nsec), "0")
+}
+
+// ustarHeader encodes a ustar header block.
+//
+// Values that do not fit the header fields are expected in PAX records.
+func ustarHeader(name string, typeflag byte, mode int64, uid, gid int, size, mtime int64) []byte {
+	b := make([]byte, blockSize)
+
+	// The full name is stored in the PAX records.
+	if len(name) > 100 {
+		name = name[len(name)-100:]
+	}
+	copy(b[0:100], name)
+	formatOctal(b[100:108], mode&0o7777)
+	formatOctal(b[108:116], min(int64(uid), maxOctal7))
+	formatOctal(b[116:124], min(int64(gid), maxOctal7))
+	formatOctal(b[124:136], min(size, maxOctal11))
+	formatOctal(b[136:148], max(mtime, 0))
+	b[156] = typeflag
+	copy(b[257:263], "ustar\x00")
+	copy(b[263:265], "00")
+
+	// The checksum is computed with the checksum field set to spaces.
+	copy(b[148:156], "        ")
+	var sum int64
+	for _, c := range b {
+		sum += int64(c)
+	}
+	copy(b[148:156], fmt.Sprintf("%06o\x00 ", sum))
+	return b
+}
+
+// formatOctal writes a NUL terminated zero padded octal number to the field.
+func formatOctal(field []byte, v int64) {
+	s := strconv.FormatInt(v, 8)
+	if len(s) > len(field)-1 {
+		s = s[len(s)-(len(field)-1):]
+	}
+	copy(field, strings.Repeat("0", len(field)-1-len(s))+s)
+	field[len(field)-1] = 0
+}
+
+// padBlock pads data with zeros to a multiple of the block size.
+func padBlock(data []byte) []byte {
+	if pad := (blockSize - len(data)%blockSize) % blockSize; pad > 0 {
+		data = append(data, make([]byte, pad)...)
+	}
+	return data
+}